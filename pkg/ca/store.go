@@ -0,0 +1,149 @@
+package ca
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ErrNoCertificates is returned if a PEM file contains no certificates.
+var ErrNoCertificates = errors.New("no PEM encoded certificates found")
+
+// certExtensions are the extensions of the files loaded from directories.
+var certExtensions = []string{".pem", ".crt", ".cer"}
+
+// DSigRoots returns the certificates of the CA issuing the certificates used for the digital signatures
+// of the FSCR responses. Only the I.CA intermediate is built in, the self-signed I.CA root must be added
+// to the trust store, otherwise no digital signature is trusted.
+func DSigRoots() ([]*x509.Certificate, error) {
+	cert, err := parseCert(ICACertificate)
+	if err != nil {
+		return nil, fmt.Errorf("parse I.CA certificate: %w", err)
+	}
+
+	return []*x509.Certificate{cert}, nil
+}
+
+// LoadCertificates loads the PEM encoded certificates from the files at the paths. If a path is a directory,
+// all files in it with the .pem, .crt or .cer extension are loaded (subdirectories are skipped).
+func LoadCertificates(paths []string) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for _, path := range paths {
+		files, err := certFiles(path)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, f := range files {
+			data, err := ioutil.ReadFile(f)
+			if err != nil {
+				return nil, fmt.Errorf("read file %s: %w", f, err)
+			}
+
+			c, err := ParsePEMCertificates(data)
+			if err != nil {
+				return nil, fmt.Errorf("parse certificates %s: %w", f, err)
+			}
+
+			certs = append(certs, c...)
+		}
+	}
+
+	return certs, nil
+}
+
+// Merge returns the certificates without duplicates, in the order of their first appearance.
+func Merge(certs ...[]*x509.Certificate) []*x509.Certificate {
+	var merged []*x509.Certificate
+	for _, cs := range certs {
+	loop:
+		for _, c := range cs {
+			for _, m := range merged {
+				if c.Equal(m) {
+					continue loop
+				}
+			}
+
+			merged = append(merged, c)
+		}
+	}
+
+	return merged
+}
+
+// certFiles returns the certificate files at the path.
+func certFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("stat %s: %w", path, err)
+	}
+
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	entries, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("read directory %s: %w", path, err)
+	}
+
+	var files []string
+	for _, e := range entries {
+		if e.IsDir() || !hasCertExtension(e.Name()) {
+			continue
+		}
+
+		files = append(files, filepath.Join(path, e.Name()))
+	}
+
+	sort.Strings(files)
+
+	return files, nil
+}
+
+func hasCertExtension(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, e := range certExtensions {
+		if ext == e {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ParsePEMCertificates decodes all PEM encoded certificates of the data. Other PEM blocks are skipped.
+// ErrNoCertificates is returned if the data contains no certificate.
+func ParsePEMCertificates(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parse certificate: %w", err)
+		}
+
+		certs = append(certs, cert)
+	}
+
+	if len(certs) == 0 {
+		return nil, ErrNoCertificates
+	}
+
+	return certs, nil
+}
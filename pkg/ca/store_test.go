@@ -0,0 +1,77 @@
+package ca_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/chutommy/eetgateway/pkg/ca"
+	"github.com/stretchr/testify/require"
+)
+
+func generateRoot(t *testing.T, cn string) (*x509.Certificate, []byte) {
+	pk, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	raw, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &pk.PublicKey, pk)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(raw)
+	require.NoError(t, err)
+
+	return cert, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: raw})
+}
+
+func TestLoadCertificates(t *testing.T) {
+	root1, pem1 := generateRoot(t, "root 1")
+	root2, pem2 := generateRoot(t, "root 2")
+	root3, pem3 := generateRoot(t, "root 3")
+
+	dir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "bundle.pem"), append(pem1, pem2...), 0o600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "readme.txt"), []byte("not a certificate"), 0o600))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "old"), 0o700))
+
+	file := filepath.Join(t.TempDir(), "root3.crt")
+	require.NoError(t, ioutil.WriteFile(file, pem3, 0o600))
+
+	certs, err := ca.LoadCertificates([]string{dir, file})
+	require.NoError(t, err)
+	require.Len(t, certs, 3)
+	require.True(t, root1.Equal(certs[0]))
+	require.True(t, root2.Equal(certs[1]))
+	require.True(t, root3.Equal(certs[2]))
+
+	_, err = ca.LoadCertificates([]string{filepath.Join(dir, "readme.txt")})
+	require.ErrorIs(t, err, ca.ErrNoCertificates)
+
+	_, err = ca.LoadCertificates([]string{filepath.Join(dir, "missing.pem")})
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	merged := ca.Merge([]*x509.Certificate{root1, root2}, certs)
+	require.Equal(t, []*x509.Certificate{root1, root2, certs[2]}, merged)
+}
+
+func TestDSigRoots(t *testing.T) {
+	roots, err := ca.DSigRoots()
+	require.NoError(t, err)
+	require.Len(t, roots, 1)
+}
@@ -0,0 +1,447 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/chutommy/eetgateway/pkg/ca"
+	"github.com/chutommy/eetgateway/pkg/gateway"
+	"github.com/chutommy/eetgateway/pkg/keystore"
+	"github.com/chutommy/eetgateway/pkg/revocation"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// clientFlag is the flag of the client address whose lockout is lifted as well.
+const clientFlag = "client"
+
+// externalFlag is the flag of a certificate whose private key is kept by the external signer.
+const externalFlag = "external"
+
+// errPasswordMismatch is returned if the confirmation of a new password differs.
+var errPasswordMismatch = errors.New("passwords don't match")
+
+// errInvalidClient is returned if the client to unlock isn't an IP address.
+var errInvalidClient = errors.New("invalid client IP address")
+
+func initCertCmd() {
+	configDir, err := osConfigDir()
+	if err != nil {
+		panic(err)
+	}
+
+	configPath := filepath.Join(configDir, configFile)
+	certCmd.PersistentFlags().StringP(configPathFlag, "c", configPath, "path to config file")
+
+	certUnlockCmd.Flags().String(clientFlag, "", "IP address of a client to unlock as well")
+	certImportCmd.Flags().Bool(externalFlag, false, "import PEM encoded certificates whose private key is kept by the external signer")
+
+	certCmd.AddCommand(certImportCmd, certListCmd, certRenameCmd, certPasswdCmd, certUnlockCmd, certDeleteCmd, certShowCmd)
+}
+
+var certCmd = &cobra.Command{
+	Use:   "cert",
+	Short: "Manage the taxpayers' certificates in the keystore",
+	Args:  cobra.NoArgs,
+}
+
+var certImportCmd = &cobra.Command{
+	Use:   "import <cert_id> <file.p12>",
+	Short: "Verify and store a taxpayer's certificate from a PKCS#12 file",
+	Long: `Verify and store a taxpayer's certificate from a PKCS#12 file.
+
+With --external the file holds the PEM encoded taxpayer's certificate, optionally followed
+by its CA's certificate, whose private key is kept by the external signer set by signer.url.
+Only the certificate is stored.`,
+	Args: cobra.ExactArgs(2),
+	RunE: certImportCmdRunE,
+}
+
+var certListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the IDs of the stored certificates",
+	Args:  cobra.NoArgs,
+	RunE:  certListCmdRunE,
+}
+
+var certRenameCmd = &cobra.Command{
+	Use:   "rename <cert_id> <new_cert_id>",
+	Short: "Change the ID of a stored certificate",
+	Args:  cobra.ExactArgs(2),
+	RunE:  certRenameCmdRunE,
+}
+
+var certPasswdCmd = &cobra.Command{
+	Use:   "passwd <cert_id>",
+	Short: "Change the password of a stored certificate",
+	Args:  cobra.ExactArgs(1),
+	RunE:  certPasswdCmdRunE,
+}
+
+var certUnlockCmd = &cobra.Command{
+	Use:   "unlock <cert_id>",
+	Short: "Lift the lockout of a certificate after too many failed password attempts",
+	Args:  cobra.ExactArgs(1),
+	RunE:  certUnlockCmdRunE,
+}
+
+var certDeleteCmd = &cobra.Command{
+	Use:   "delete <cert_id>",
+	Short: "Delete a stored certificate",
+	Args:  cobra.ExactArgs(1),
+	RunE:  certDeleteCmdRunE,
+}
+
+var certShowCmd = &cobra.Command{
+	Use:   "show <cert_id>",
+	Short: "Show the identity and the usage policy of a stored certificate",
+	Args:  cobra.ExactArgs(1),
+	RunE:  certShowCmdRunE,
+}
+
+func certImportCmdRunE(cmd *cobra.Command, args []string) error {
+	id, path := args[0], args[1]
+
+	external, err := cmd.Flags().GetBool(externalFlag)
+	if err != nil {
+		return err
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read certificate file %s: %w", path, err)
+	}
+
+	gSvc, _, err := newCertGatewaySvc(cmd, true)
+	if err != nil {
+		return err
+	}
+
+	if external {
+		key, err := externalSigner(data)
+		if err != nil {
+			return err
+		}
+
+		password, err := readNewPassword("Certificate password: ")
+		if err != nil {
+			return err
+		}
+
+		err = gSvc.StoreCertSigner(context.Background(), id, password, data, key, nil)
+		if err != nil {
+			return fmt.Errorf("store certificate: %w", err)
+		}
+
+		fmt.Printf("The certificate was successfully stored: %s\n", id)
+
+		return nil
+	}
+
+	pkcsPassword, err := readPassword(fmt.Sprintf("Password of %s: ", filepath.Base(path)))
+	if err != nil {
+		return err
+	}
+
+	password, err := readNewPassword("Certificate password: ")
+	if err != nil {
+		return err
+	}
+
+	err = gSvc.StoreCert(context.Background(), id, password, data, string(pkcsPassword), nil)
+	if err != nil {
+		return fmt.Errorf("store certificate: %w", err)
+	}
+
+	fmt.Printf("The certificate was successfully stored: %s\n", id)
+
+	return nil
+}
+
+// externalSigner returns the signer of the taxpayer's certificate, the first of the PEM encoded certificates,
+// provided by the configured external signer.
+func externalSigner(certData []byte) (crypto.Signer, error) {
+	signers := newSignerProvider()
+	if signers == nil {
+		return nil, fmt.Errorf("%s not set: %w", signerURL, keystore.ErrSignerUnavailable)
+	}
+
+	certs, err := ca.ParsePEMCertificates(certData)
+	if err != nil {
+		return nil, fmt.Errorf("parse PEM certificates: %w", err)
+	}
+
+	key, err := signers.Signer(certs[0])
+	if err != nil {
+		return nil, fmt.Errorf("provide the signer: %w", err)
+	}
+
+	return key, nil
+}
+
+func certListCmdRunE(cmd *cobra.Command, _ []string) error {
+	gSvc, _, err := newCertGatewaySvc(cmd, false)
+	if err != nil {
+		return err
+	}
+
+	ids, err := gSvc.ListCertIDs(context.Background(), 0, -1)
+	if err != nil {
+		return fmt.Errorf("list certificate IDs: %w", err)
+	}
+
+	for _, id := range ids {
+		fmt.Println(id)
+	}
+
+	return nil
+}
+
+func certRenameCmdRunE(cmd *cobra.Command, args []string) error {
+	gSvc, _, err := newCertGatewaySvc(cmd, false)
+	if err != nil {
+		return err
+	}
+
+	if err = gSvc.UpdateCertID(context.Background(), args[0], args[1]); err != nil {
+		return fmt.Errorf("update certificate ID: %w", err)
+	}
+
+	fmt.Printf("The certificate was successfully renamed: %s -> %s\n", args[0], args[1])
+
+	return nil
+}
+
+func certPasswdCmdRunE(cmd *cobra.Command, args []string) error {
+	gSvc, _, err := newCertGatewaySvc(cmd, false)
+	if err != nil {
+		return err
+	}
+
+	oldPassword, err := readPassword("Current certificate password: ")
+	if err != nil {
+		return err
+	}
+
+	newPassword, err := readNewPassword("New certificate password: ")
+	if err != nil {
+		return err
+	}
+
+	if err = gSvc.UpdateCertPassword(context.Background(), args[0], oldPassword, newPassword); err != nil {
+		return fmt.Errorf("update certificate password: %w", err)
+	}
+
+	fmt.Printf("The password was successfully changed: %s\n", args[0])
+
+	return nil
+}
+
+func certUnlockCmdRunE(cmd *cobra.Command, args []string) error {
+	client, err := cmd.Flags().GetString(clientFlag)
+	if err != nil {
+		return fmt.Errorf("retrieve '%s' flag: %w", clientFlag, err)
+	}
+
+	if client != "" && net.ParseIP(client) == nil {
+		return fmt.Errorf("%s: %w", client, errInvalidClient)
+	}
+
+	gSvc, _, err := newCertGatewaySvc(cmd, false)
+	if err != nil {
+		return err
+	}
+
+	if err = gSvc.UnlockCert(context.Background(), args[0], client); err != nil {
+		return fmt.Errorf("unlock certificate: %w", err)
+	}
+
+	fmt.Printf("The certificate was successfully unlocked: %s\n", args[0])
+
+	return nil
+}
+
+func certDeleteCmdRunE(cmd *cobra.Command, args []string) error {
+	gSvc, _, err := newCertGatewaySvc(cmd, false)
+	if err != nil {
+		return err
+	}
+
+	if err = gSvc.DeleteID(context.Background(), args[0]); err != nil {
+		return fmt.Errorf("delete certificate: %w", err)
+	}
+
+	fmt.Printf("The certificate was successfully deleted: %s\n", args[0])
+
+	return nil
+}
+
+func certShowCmdRunE(cmd *cobra.Command, args []string) error {
+	_, ks, err := newCertGatewaySvc(cmd, false)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	id, err := ks.Identity(ctx, args[0])
+	if err != nil {
+		return fmt.Errorf("retrieve certificate identity: %w", err)
+	}
+
+	policy, err := ks.GetPolicy(ctx, args[0])
+	if err != nil {
+		return fmt.Errorf("retrieve certificate policy: %w", err)
+	}
+
+	fmt.Printf("ID:               %s\n", args[0])
+	if id.SerialNumber != nil {
+		fmt.Printf("Serial number:    %s\n", id.SerialNumber)
+		fmt.Printf("Issuer:           %s\n", issuerName(id.Issuer))
+	}
+
+	if !id.RevokedAt.IsZero() {
+		fmt.Printf("Revoked at:       %s\n", id.RevokedAt.Format(time.RFC3339))
+	}
+
+	if policy == nil {
+		fmt.Println("Policy:           unrestricted")
+		return nil
+	}
+
+	if len(policy.IDProvoz) > 0 {
+		fmt.Printf("id_provoz:        %v\n", policy.IDProvoz)
+	}
+
+	if len(policy.IDPokl) > 0 {
+		fmt.Printf("id_pokl:          %s\n", strings.Join(policy.IDPokl, ", "))
+	}
+
+	if policy.DICPoverujiciho != "" {
+		fmt.Printf("dic_poverujiciho: %s\n", policy.DICPoverujiciho)
+	}
+
+	if !policy.ValidFrom.IsZero() {
+		fmt.Printf("valid_from:       %s\n", policy.ValidFrom.Format(time.RFC3339))
+	}
+
+	if !policy.ValidTo.IsZero() {
+		fmt.Printf("valid_to:         %s\n", policy.ValidTo.Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+// loadCLIConfig loads the configuration the same way as the serve command does.
+func loadCLIConfig(cmd *cobra.Command) error {
+	configPath, err := cmd.Flags().GetString(configPathFlag)
+	if err != nil {
+		return fmt.Errorf("retrieve 'path' flag: %w", err)
+	}
+
+	setDefaultConfig()
+	loadConfigFromENV()
+	if err = loadConfigFromFile(configPath); err != nil {
+		return fmt.Errorf("load config from file: %w", err)
+	}
+
+	return nil
+}
+
+// newCertGatewaySvc loads the configuration and connects to the keystore. The FSCR isn't contacted
+// by the certificate management. The revocation data are loaded only if checkRevocation is set,
+// the commands not storing new certificates don't check their revocation status.
+func newCertGatewaySvc(cmd *cobra.Command, checkRevocation bool) (gateway.Service, keystore.Service, error) {
+	if err := loadCLIConfig(cmd); err != nil {
+		return nil, nil, err
+	}
+
+	caSvc, err := newCASvc()
+	if err != nil {
+		return nil, nil, fmt.Errorf("start CA service: %w", err)
+	}
+
+	ks, err := newKeystoreSvc()
+	if err != nil {
+		return nil, nil, fmt.Errorf("start keystore client: %w", err)
+	}
+
+	var rSvc revocation.Service
+	if checkRevocation {
+		eetRoots, dsigRoots := caSvc.Roots()
+		rSvc = newRevocationSvc(ca.Merge(eetRoots, dsigRoots), false)
+		refreshRevocations(context.Background(), rSvc)
+	}
+
+	return newGatewaySvc(nil, caSvc, ks, rSvc), ks, nil
+}
+
+// stdin is shared by the prompts, so the buffered input isn't lost between them.
+var stdin = bufio.NewReader(os.Stdin)
+
+// readPassword prompts for a password. The input isn't echoed if it's read from a terminal.
+func readPassword(prompt string) ([]byte, error) {
+	fmt.Fprint(os.Stderr, prompt)
+
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		password, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return nil, fmt.Errorf("read password: %w", err)
+		}
+
+		return password, nil
+	}
+
+	line, err := stdin.ReadBytes('\n')
+	if err != nil && (!errors.Is(err, io.EOF) || len(line) == 0) {
+		return nil, fmt.Errorf("read password: %w", err)
+	}
+
+	return bytes.TrimRight(line, "\r\n"), nil
+}
+
+// readNewPassword prompts for a new password and its confirmation.
+func readNewPassword(prompt string) ([]byte, error) {
+	password, err := readPassword(prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	confirmation, err := readPassword("Confirm password: ")
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.Equal(password, confirmation) {
+		return nil, errPasswordMismatch
+	}
+
+	return password, nil
+}
+
+// issuerName returns the string representation of the DER encoded issuer.
+func issuerName(der []byte) string {
+	var rdn pkix.RDNSequence
+	if _, err := asn1.Unmarshal(der, &rdn); err != nil {
+		return fmt.Sprintf("%X", der)
+	}
+
+	var name pkix.Name
+	name.FillFromRDNSequence(&rdn)
+
+	return name.String()
+}
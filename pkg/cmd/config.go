@@ -8,6 +8,9 @@ import (
 	"runtime"
 	"time"
 
+	"github.com/chutommy/eetgateway/pkg/gateway"
+	"github.com/chutommy/eetgateway/pkg/keystore"
+	"github.com/chutommy/eetgateway/pkg/revocation"
 	"github.com/spf13/viper"
 )
 
@@ -18,9 +21,16 @@ const (
 	// ["eetgateway.json", ".env"]
 
 	cliQuietMode = "cli.quiet_mode"
+	cliDebugMode = "cli.debug_mode"
 
 	eetProductionMode = "eet.production_mode"
 	eetRequestTimeout = "eet.request_timeout"
+	eetMaxSigningSkew = "eet.max_signing_time_skew"
+
+	caEETRoots       = "ca.eet_roots"
+	caDSigRoots      = "ca.dsig_roots"
+	caReplaceBuiltin = "ca.replace_builtin"
+	caHotReload      = "ca.hot_reload"
 
 	redisNetwork  = "redis.network"
 	redisAddr     = "redis.addr"
@@ -44,6 +54,33 @@ const (
 	redisTLSCertificate = "redis.tls.certificate"
 	redisTLSPrivateKey  = "redis.tls.private_key"
 
+	cacheEnable = "cache.enable"
+	cacheTTL    = "cache.ttl"
+	cacheSize   = "cache.size"
+
+	lockoutThreshold  = "lockout.threshold"
+	lockoutBaseDelay  = "lockout.base_delay"
+	lockoutMaxDelay   = "lockout.max_delay"
+	lockoutResetAfter = "lockout.reset_after"
+	lockoutInProgress = "lockout.max_in_progress"
+	lockoutPerClient  = "lockout.per_client"
+
+	renewalGracePeriod = "renewal.grace_period"
+
+	signerURL     = "signer.url"
+	signerTimeout = "signer.timeout"
+
+	revocationCRLURLs         = "revocation.crl_urls"
+	revocationCRLFiles        = "revocation.crl_files"
+	revocationOffline         = "revocation.offline"
+	revocationOCSP            = "revocation.ocsp"
+	revocationStrict          = "revocation.strict"
+	revocationRefreshInterval = "revocation.refresh_interval"
+	revocationRequestTimeout  = "revocation.request_timeout"
+
+	sessionTTL      = "session.ttl"
+	sessionCapacity = "session.capacity"
+
 	serverAddr = "server.addr"
 
 	serverReadTimeout       = "server.read_timeout"
@@ -54,19 +91,40 @@ const (
 
 	serverMaxHeaderBytes = "server.max_header_bytes"
 
+	serverTrustedProxies = "server.trusted_proxies"
+	serverClientIPHeader = "server.client_ip_header"
+
 	serverTLSEnable      = "server.tls.enable"
 	serverTLSCertificate = "server.tls.certificate"
 	serverTLSPrivateKey  = "server.tls.private_key"
 
 	serverMutualTLSEnable    = "server.mutual_tls.enable"
 	serverMutualTLSClientCAs = "server.mutual_tls.client_cas"
+
+	grpcEnable          = "grpc.enable"
+	grpcAddr            = "grpc.addr"
+	grpcShutdownTimeout = "grpc.shutdown_timeout"
+
+	grpcTLSEnable      = "grpc.tls.enable"
+	grpcTLSCertificate = "grpc.tls.certificate"
+	grpcTLSPrivateKey  = "grpc.tls.private_key"
+
+	grpcMutualTLSEnable    = "grpc.mutual_tls.enable"
+	grpcMutualTLSClientCAs = "grpc.mutual_tls.client_cas"
 )
 
 func setDefaultConfig() {
 	viper.SetDefault(cliQuietMode, false)
+	viper.SetDefault(cliDebugMode, false)
 
 	viper.SetDefault(eetProductionMode, false)
 	viper.SetDefault(eetRequestTimeout, (10 * time.Second).String())
+	viper.SetDefault(eetMaxSigningSkew, gateway.DefaultMaxSigningTimeSkew.String())
+
+	viper.SetDefault(caEETRoots, []string{})
+	viper.SetDefault(caDSigRoots, []string{})
+	viper.SetDefault(caReplaceBuiltin, false)
+	viper.SetDefault(caHotReload, true)
 
 	viper.SetDefault(redisNetwork, "tcp")
 	viper.SetDefault(redisAddr, "localhost:6379")
@@ -90,6 +148,33 @@ func setDefaultConfig() {
 	viper.SetDefault(redisTLSCertificate, "certs/redis/client/client.crt")
 	viper.SetDefault(redisTLSPrivateKey, "certs/redis/client/client.key")
 
+	viper.SetDefault(cacheEnable, false)
+	viper.SetDefault(cacheTTL, keystore.DefaultCachePolicy.TTL.String())
+	viper.SetDefault(cacheSize, keystore.DefaultCachePolicy.Size)
+
+	viper.SetDefault(lockoutThreshold, keystore.DefaultLockoutPolicy.Threshold)
+	viper.SetDefault(lockoutBaseDelay, keystore.DefaultLockoutPolicy.BaseDelay.String())
+	viper.SetDefault(lockoutMaxDelay, keystore.DefaultLockoutPolicy.MaxDelay.String())
+	viper.SetDefault(lockoutResetAfter, keystore.DefaultLockoutPolicy.ResetAfter.String())
+	viper.SetDefault(lockoutInProgress, keystore.DefaultLockoutPolicy.MaxInProgress)
+	viper.SetDefault(lockoutPerClient, false)
+
+	viper.SetDefault(renewalGracePeriod, keystore.DefaultGracePeriod.String())
+
+	viper.SetDefault(signerURL, "")
+	viper.SetDefault(signerTimeout, (5 * time.Second).String())
+
+	viper.SetDefault(revocationCRLURLs, revocation.DefaultPolicy.CRLURLs)
+	viper.SetDefault(revocationCRLFiles, revocation.DefaultPolicy.CRLFiles)
+	viper.SetDefault(revocationOffline, revocation.DefaultPolicy.Offline)
+	viper.SetDefault(revocationOCSP, revocation.DefaultPolicy.OCSP)
+	viper.SetDefault(revocationStrict, revocation.DefaultPolicy.Strict)
+	viper.SetDefault(revocationRefreshInterval, (1 * time.Hour).String())
+	viper.SetDefault(revocationRequestTimeout, (10 * time.Second).String())
+
+	viper.SetDefault(sessionTTL, gateway.DefaultSessionPolicy.TTL.String())
+	viper.SetDefault(sessionCapacity, gateway.DefaultSessionPolicy.Capacity)
+
 	viper.SetDefault(serverAddr, "localhost:8080")
 
 	viper.SetDefault(serverReadTimeout, (100 * time.Second).String())
@@ -99,6 +184,8 @@ func setDefaultConfig() {
 	viper.SetDefault(serverShutdownTimeout, (10 * time.Second).String())
 
 	viper.SetDefault(serverMaxHeaderBytes, http.DefaultMaxHeaderBytes)
+	viper.SetDefault(serverTrustedProxies, []string{})
+	viper.SetDefault(serverClientIPHeader, "X-Forwarded-For")
 
 	viper.SetDefault(serverTLSEnable, false)
 	viper.SetDefault(serverTLSCertificate, "certs/server/server.crt")
@@ -106,6 +193,17 @@ func setDefaultConfig() {
 
 	viper.SetDefault(serverMutualTLSEnable, false)
 	viper.SetDefault(serverMutualTLSClientCAs, []string{"certs/client/ca.crt"})
+
+	viper.SetDefault(grpcEnable, false)
+	viper.SetDefault(grpcAddr, "localhost:8081")
+	viper.SetDefault(grpcShutdownTimeout, (10 * time.Second).String())
+
+	viper.SetDefault(grpcTLSEnable, false)
+	viper.SetDefault(grpcTLSCertificate, "certs/server/server.crt")
+	viper.SetDefault(grpcTLSPrivateKey, "certs/server/server.key")
+
+	viper.SetDefault(grpcMutualTLSEnable, false)
+	viper.SetDefault(grpcMutualTLSClientCAs, []string{"certs/client/ca.crt"})
 }
 
 func osConfigDir() (string, error) {
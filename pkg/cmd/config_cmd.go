@@ -0,0 +1,346 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cast"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// errInvalidConfig is returned if the validation of the configuration finds a problem.
+var errInvalidConfig = errors.New("invalid configuration")
+
+// redacted replaces the values of the secret keys.
+const redacted = "<redacted>"
+
+// secretConfigKeys are the keys whose values aren't printed.
+var secretConfigKeys = map[string]bool{
+	redisPassword: true,
+}
+
+// configPathKeys are the keys of the files and directories loaded by the gateway mapped to the keys
+// enabling them. The paths of the keys with no enabling key are always loaded.
+var configPathKeys = map[string]string{
+	caEETRoots:               "",
+	caDSigRoots:              "",
+	revocationCRLFiles:       "",
+	redisTLSRootCAs:          redisTLSEnable,
+	redisTLSCertificate:      redisTLSEnable,
+	redisTLSPrivateKey:       redisTLSEnable,
+	serverTLSCertificate:     serverTLSEnable,
+	serverTLSPrivateKey:      serverTLSEnable,
+	serverMutualTLSClientCAs: serverMutualTLSEnable,
+	grpcTLSCertificate:       grpcTLSEnable,
+	grpcTLSPrivateKey:        grpcTLSEnable,
+	grpcMutualTLSClientCAs:   grpcMutualTLSEnable,
+}
+
+// configKind is the expected type of a configuration value.
+type configKind int
+
+const (
+	stringKind configKind = iota
+	stringsKind
+	boolKind
+	intKind
+	durationKind
+)
+
+func initConfigCmd() {
+	configDir, err := osConfigDir()
+	if err != nil {
+		panic(err)
+	}
+
+	configPath := filepath.Join(configDir, configFile)
+	configCmd.PersistentFlags().StringP(configPathFlag, "c", configPath, "path to config file")
+
+	configCmd.AddCommand(configShowCmd, configValidateCmd)
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the effective configuration",
+	Args:  cobra.NoArgs,
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the merged configuration and the source of each key",
+	Long: `Print the merged configuration and the source of each key.
+
+The defaults are overridden by the config file, which is overridden by the environment
+variables. The values of the secrets are redacted.`,
+	Args: cobra.NoArgs,
+	RunE: configShowCmdRunE,
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check the merged configuration without starting the server",
+	Long: `Check the merged configuration without starting the server.
+
+The types of the values, the unknown keys of the config file and the environment, the files
+of the enabled TLS material and the conflicting options are checked.`,
+	Args: cobra.NoArgs,
+	RunE: configValidateCmdRunE,
+}
+
+func configShowCmdRunE(cmd *cobra.Command, _ []string) error {
+	if _, err := loadConfigKinds(cmd); err != nil {
+		return err
+	}
+
+	keys := viper.AllKeys()
+	sort.Strings(keys)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, key := range keys {
+		value := redacted
+		if !secretConfigKeys[key] || viper.GetString(key) == "" {
+			raw, err := json.Marshal(viper.Get(key))
+			if err != nil {
+				return fmt.Errorf("encode value of %s: %w", key, err)
+			}
+
+			value = string(raw)
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\n", key, value, configSource(key))
+	}
+
+	return w.Flush()
+}
+
+func configValidateCmdRunE(cmd *cobra.Command, _ []string) error {
+	kinds, err := loadConfigKinds(cmd)
+	if err != nil {
+		return err
+	}
+
+	problems := validateConfig(kinds)
+	for _, p := range problems {
+		fmt.Println(p)
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%d problems found: %w", len(problems), errInvalidConfig)
+	}
+
+	fmt.Println("The configuration is valid")
+
+	return nil
+}
+
+// loadConfigKinds loads the configuration the same way as the serve command does. It returns
+// the known keys with the kinds of their default values.
+func loadConfigKinds(cmd *cobra.Command) (map[string]configKind, error) {
+	configPath, err := cmd.Flags().GetString(configPathFlag)
+	if err != nil {
+		return nil, fmt.Errorf("retrieve 'path' flag: %w", err)
+	}
+
+	// only the defaults are set at this point
+	setDefaultConfig()
+	kinds := make(map[string]configKind)
+	for _, key := range viper.AllKeys() {
+		kinds[key] = kindOf(viper.Get(key))
+	}
+
+	loadConfigFromENV()
+	if err = loadConfigFromFile(configPath); err != nil {
+		return nil, fmt.Errorf("load config from file: %w", err)
+	}
+
+	return kinds, nil
+}
+
+func kindOf(value interface{}) configKind {
+	switch v := value.(type) {
+	case bool:
+		return boolKind
+	case int, int64:
+		return intKind
+	case []string:
+		return stringsKind
+	case string:
+		if _, err := time.ParseDuration(v); err == nil {
+			return durationKind
+		}
+	}
+
+	return stringKind
+}
+
+// configSource returns where the effective value of the key comes from.
+func configSource(key string) string {
+	if env := configEnvName(key); os.Getenv(env) != "" {
+		return "env " + env
+	}
+
+	if viper.InConfig(key) {
+		return "file " + viper.ConfigFileUsed()
+	}
+
+	return "default"
+}
+
+// configEnvName returns the name of the environment variable of the key.
+func configEnvName(key string) string {
+	return envPrefix + "_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// validateConfig returns the problems of the loaded configuration.
+func validateConfig(kinds map[string]configKind) []string {
+	var problems []string
+
+	keys := viper.AllKeys()
+	sort.Strings(keys)
+
+	envNames := make(map[string]bool, len(kinds))
+	for _, key := range keys {
+		kind, ok := kinds[key]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s: unknown key (%s)", key, configSource(key)))
+			continue
+		}
+
+		envNames[configEnvName(key)] = true
+		if err := checkConfigKind(viper.Get(key), kind); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v (%s)", key, err, configSource(key)))
+		}
+	}
+
+	var unknownEnv []string
+	for _, env := range os.Environ() {
+		name := strings.SplitN(env, "=", 2)[0]
+		if strings.HasPrefix(name, envPrefix+"_") && !envNames[name] {
+			unknownEnv = append(unknownEnv, fmt.Sprintf("%s: unknown environment variable", name))
+		}
+	}
+
+	sort.Strings(unknownEnv)
+	problems = append(problems, unknownEnv...)
+
+	if len(problems) > 0 {
+		// the values of the mistyped keys can't be used by the remaining checks
+		return problems
+	}
+
+	problems = append(problems, checkConfigPaths(kinds)...)
+	problems = append(problems, checkConfigConflicts()...)
+
+	return problems
+}
+
+func checkConfigKind(value interface{}, kind configKind) error {
+	var err error
+	switch kind {
+	case stringKind:
+		_, err = cast.ToStringE(value)
+	case stringsKind:
+		_, err = cast.ToStringSliceE(value)
+	case boolKind:
+		_, err = cast.ToBoolE(value)
+	case intKind:
+		_, err = cast.ToIntE(value)
+	case durationKind:
+		// a number without a unit is most likely a mistake, it would be read as nanoseconds
+		if s, ok := value.(string); ok {
+			_, err = time.ParseDuration(s)
+		} else {
+			_, err = cast.ToDurationE(value)
+		}
+	}
+
+	if err != nil {
+		return fmt.Errorf("invalid value: %v", err)
+	}
+
+	return nil
+}
+
+// checkConfigPaths checks that the loaded files and directories exist.
+func checkConfigPaths(kinds map[string]configKind) []string {
+	keys := make([]string, 0, len(configPathKeys))
+	for key := range configPathKeys {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	var problems []string
+	for _, key := range keys {
+		if enable := configPathKeys[key]; enable != "" && !viper.GetBool(enable) {
+			continue
+		}
+
+		paths := viper.GetStringSlice(key)
+		if kinds[key] == stringKind {
+			paths = []string{viper.GetString(key)}
+		}
+
+		for _, p := range paths {
+			if _, err := os.Stat(p); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", key, err))
+			}
+		}
+	}
+
+	return problems
+}
+
+// checkConfigConflicts checks the options which can't be combined.
+func checkConfigConflicts() []string {
+	var problems []string
+	conflict := func(key, format string, a ...interface{}) {
+		problems = append(problems, fmt.Sprintf("%s: %s", key, fmt.Sprintf(format, a...)))
+	}
+
+	if viper.GetBool(serverMutualTLSEnable) && !viper.GetBool(serverTLSEnable) {
+		conflict(serverMutualTLSEnable, "mutual TLS requires %s", serverTLSEnable)
+	}
+
+	if viper.GetBool(grpcMutualTLSEnable) && !viper.GetBool(grpcTLSEnable) {
+		conflict(grpcMutualTLSEnable, "mutual TLS requires %s", grpcTLSEnable)
+	}
+
+	if viper.GetBool(grpcEnable) && viper.GetString(grpcAddr) == viper.GetString(serverAddr) {
+		conflict(grpcAddr, "same as %s", serverAddr)
+	}
+
+	if viper.GetBool(cliDebugMode) && viper.GetBool(cliQuietMode) {
+		conflict(cliDebugMode, "debug logs are discarded in %s", cliQuietMode)
+	}
+
+	if viper.GetBool(revocationOffline) && len(viper.GetStringSlice(revocationCRLURLs)) > 0 {
+		conflict(revocationCRLURLs, "CRLs aren't downloaded in %s", revocationOffline)
+	}
+
+	if viper.GetDuration(eetMaxSigningSkew) <= 0 {
+		conflict(eetMaxSigningSkew, "must be positive")
+	}
+
+	if viper.GetDuration(revocationRefreshInterval) <= 0 {
+		conflict(revocationRefreshInterval, "must be positive")
+	}
+
+	if viper.GetDuration(lockoutBaseDelay) > viper.GetDuration(lockoutMaxDelay) {
+		conflict(lockoutBaseDelay, "exceeds %s", lockoutMaxDelay)
+	}
+
+	if viper.GetInt(redisMinIdleConns) > viper.GetInt(redisPoolSize) {
+		conflict(redisMinIdleConns, "exceeds %s", redisPoolSize)
+	}
+
+	return problems
+}
@@ -0,0 +1,353 @@
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"reflect"
+	"sort"
+	"sync"
+	"syscall"
+
+	"github.com/chutommy/eetgateway/pkg/fscr"
+	"github.com/fsnotify/fsnotify"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+	"go.uber.org/multierr"
+)
+
+// reloadableKeys are the configuration keys applied without restarting the server.
+var reloadableKeys = map[string]bool{
+	cliQuietMode:             true,
+	cliDebugMode:             true,
+	eetRequestTimeout:        true,
+	redisTLSCertificate:      true,
+	redisTLSPrivateKey:       true,
+	serverTLSCertificate:     true,
+	serverTLSPrivateKey:      true,
+	serverMutualTLSClientCAs: true,
+	grpcTLSCertificate:       true,
+	grpcTLSPrivateKey:        true,
+	grpcMutualTLSClientCAs:   true,
+}
+
+// tlsMaterial holds the TLS material of the HTTP and gRPC servers and the keystore client.
+var tlsMaterial = &liveTLS{}
+
+// liveTLS is the TLS material which can be replaced while the connections are being made.
+// Only the material loaded at the start is reloaded, enabling TLS requires a restart.
+type liveTLS struct {
+	mu            sync.RWMutex
+	serverCert    *tls.Certificate
+	clientCAs     *x509.CertPool
+	grpcCert      *tls.Certificate
+	grpcClientCAs *x509.CertPool
+	redisCert     *tls.Certificate
+}
+
+func (l *liveTLS) loadServerCert() error {
+	cert, err := tls.LoadX509KeyPair(viper.GetString(serverTLSCertificate), viper.GetString(serverTLSPrivateKey))
+	if err != nil {
+		return fmt.Errorf("load SSL certificate: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.serverCert = &cert
+
+	return nil
+}
+
+func (l *liveTLS) loadClientCAs() error {
+	pool, err := loadCertPool(viper.GetStringSlice(serverMutualTLSClientCAs))
+	if err != nil {
+		return fmt.Errorf("load client CA certificates: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.clientCAs = pool
+
+	return nil
+}
+
+func (l *liveTLS) loadGRPCCert() error {
+	cert, err := tls.LoadX509KeyPair(viper.GetString(grpcTLSCertificate), viper.GetString(grpcTLSPrivateKey))
+	if err != nil {
+		return fmt.Errorf("load gRPC SSL certificate: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.grpcCert = &cert
+
+	return nil
+}
+
+func (l *liveTLS) loadGRPCClientCAs() error {
+	pool, err := loadCertPool(viper.GetStringSlice(grpcMutualTLSClientCAs))
+	if err != nil {
+		return fmt.Errorf("load gRPC client CA certificates: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.grpcClientCAs = pool
+
+	return nil
+}
+
+func (l *liveTLS) loadRedisCert() error {
+	cert, err := tls.LoadX509KeyPair(viper.GetString(redisTLSCertificate), viper.GetString(redisTLSPrivateKey))
+	if err != nil {
+		return fmt.Errorf("load redis TLS keypair: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.redisCert = &cert
+
+	return nil
+}
+
+// reload reloads the TLS material loaded at the start. The current material is kept if its
+// reload fails.
+func (l *liveTLS) reload() error {
+	l.mu.RLock()
+	server, clientCAs, redis := l.serverCert != nil, l.clientCAs != nil, l.redisCert != nil
+	grpcServer, grpcClientCAs := l.grpcCert != nil, l.grpcClientCAs != nil
+	l.mu.RUnlock()
+
+	var err error
+	if server {
+		multierr.AppendInto(&err, l.loadServerCert())
+	}
+
+	if clientCAs {
+		multierr.AppendInto(&err, l.loadClientCAs())
+	}
+
+	if grpcServer {
+		multierr.AppendInto(&err, l.loadGRPCCert())
+	}
+
+	if grpcClientCAs {
+		multierr.AppendInto(&err, l.loadGRPCClientCAs())
+	}
+
+	if redis {
+		multierr.AppendInto(&err, l.loadRedisCert())
+	}
+
+	return err
+}
+
+// getCertificate returns the current certificate of the HTTP server.
+func (l *liveTLS) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.serverCert, nil
+}
+
+// getGRPCCertificate returns the current certificate of the gRPC server.
+func (l *liveTLS) getGRPCCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.grpcCert, nil
+}
+
+// getClientCertificate returns the current client certificate of the keystore client.
+func (l *liveTLS) getClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.redisCert, nil
+}
+
+// configForClient returns a function which returns the base config verifying the client
+// certificates with the current client CAs of the HTTP server, or of the gRPC server if grpc is true.
+func (l *liveTLS) configForClient(base *tls.Config, grpc bool) func(*tls.ClientHelloInfo) (*tls.Config, error) {
+	return func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		l.mu.RLock()
+		defer l.mu.RUnlock()
+
+		cfg := base.Clone()
+		cfg.GetConfigForClient = nil
+		cfg.ClientCAs = l.clientCAs
+		if grpc {
+			cfg.ClientCAs = l.grpcClientCAs
+		}
+
+		return cfg, nil
+	}
+}
+
+// loadCertPool returns the pool of the PEM encoded certificates of the files.
+func loadCertPool(paths []string) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	for _, v := range paths {
+		data, err := ioutil.ReadFile(v)
+		if err != nil {
+			return nil, fmt.Errorf("read file %s: %w", v, err)
+		}
+
+		b, _ := pem.Decode(data)
+		if b == nil {
+			return nil, fmt.Errorf("no PEM data found in %s", v)
+		}
+
+		cert, err := x509.ParseCertificate(b.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parse CA certificate %s: %w", v, err)
+		}
+
+		pool.AddCert(cert)
+	}
+
+	return pool, nil
+}
+
+// configReloader applies the reloadable settings whenever the configuration is reloaded.
+type configReloader struct {
+	client fscr.Client
+
+	mu       sync.Mutex
+	settings map[string]interface{}
+}
+
+func newConfigReloader(client fscr.Client) *configReloader {
+	return &configReloader{
+		client:   client,
+		settings: configSettings(),
+	}
+}
+
+// configSettings returns the effective values of all configuration keys.
+func configSettings() map[string]interface{} {
+	settings := make(map[string]interface{})
+	for _, key := range viper.AllKeys() {
+		settings[key] = viper.Get(key)
+	}
+
+	return settings
+}
+
+// apply applies the reloadable settings of the reread configuration and logs the changed keys
+// which require a restart. The TLS material is reloaded even if the paths don't change,
+// so the rotated files are loaded.
+func (r *configReloader) apply(trigger string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	settings := configSettings()
+
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+
+	for key := range r.settings {
+		if _, ok := settings[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	applied, restart := []string{}, []string{}
+	for _, key := range keys {
+		if reflect.DeepEqual(r.settings[key], settings[key]) {
+			continue
+		}
+
+		if reloadableKeys[key] {
+			applied = append(applied, key)
+		} else {
+			restart = append(restart, key)
+		}
+	}
+
+	r.settings = settings
+
+	setLogLevel()
+	r.client.SetTimeout(viper.GetDuration(eetRequestTimeout))
+
+	if err := tlsMaterial.reload(); err != nil {
+		log.Warn().
+			Str("entity", "Config Service").
+			Str("action", "reloading TLS material").
+			Str("status", "keeping current TLS material").
+			Err(err).
+			Send()
+	}
+
+	log.Info().
+		Str("entity", "Config Service").
+		Str("action", "reloading configuration").
+		Str("trigger", trigger).
+		Strs("applied", applied).
+		Send()
+
+	if len(restart) > 0 {
+		log.Warn().
+			Str("entity", "Config Service").
+			Str("action", "reloading configuration").
+			Strs("requireRestart", restart).
+			Str("note", "restart server to apply the keys").
+			Send()
+	}
+}
+
+// watchConfig reloads the configuration whenever the config file changes or SIGHUP is received
+// until ctx is done.
+func watchConfig(ctx context.Context, r *configReloader) {
+	if viper.ConfigFileUsed() != "" {
+		viper.OnConfigChange(func(e fsnotify.Event) {
+			log.Info().
+				Str("entity", "Config Service").
+				Str("action", "watching config file").
+				Str("status", "config file has been modified").
+				Str("operation", e.Op.String()).
+				Str("path", e.Name).
+				Send()
+
+			r.apply("config file")
+		})
+		viper.WatchConfig()
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	go func() {
+		defer signal.Stop(hup)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+				// the current configuration is kept if the file can't be read
+				var vErr viper.ConfigFileNotFoundError
+				if err := viper.ReadInConfig(); err != nil && !errors.As(err, &vErr) {
+					log.Warn().
+						Str("entity", "Config Service").
+						Str("action", "reading config file").
+						Str("status", "keeping current configuration").
+						Err(err).
+						Send()
+				}
+
+				r.apply("SIGHUP")
+			}
+		}
+	}()
+}
@@ -3,7 +3,7 @@ package cmd
 // Execute executes the root command.
 func Execute() {
 	initCommands()
-	eetgCmd.AddCommand(versionCmd, initCmd, serveCmd)
+	eetgCmd.AddCommand(versionCmd, initCmd, serveCmd, certCmd, saleCmd, configCmd)
 	_ = eetgCmd.Execute()
 }
 
@@ -11,4 +11,7 @@ func initCommands() {
 	initEETGCmd()
 	initInitCmd()
 	initServeCmd()
+	initCertCmd()
+	initSaleCmd()
+	initConfigCmd()
 }
@@ -0,0 +1,361 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/chutommy/eetgateway/pkg/ca"
+	"github.com/chutommy/eetgateway/pkg/eet"
+	"github.com/chutommy/eetgateway/pkg/gateway"
+	"github.com/chutommy/eetgateway/pkg/keystore"
+	"github.com/chutommy/eetgateway/pkg/server/httphandler"
+	"github.com/spf13/cobra"
+)
+
+const (
+	certIDFlag = "cert-id"
+	p12Flag    = "p12"
+	formatFlag = "format"
+	dryRunFlag = "dry-run"
+)
+
+const (
+	jsonFormat = "json"
+	csvFormat  = "csv"
+)
+
+// errSaleCertificate is returned if not exactly one certificate source is given.
+var errSaleCertificate = errors.New("exactly one of --cert-id and --p12 must be given")
+
+func initSaleCmd() {
+	configDir, err := osConfigDir()
+	if err != nil {
+		panic(err)
+	}
+
+	configPath := filepath.Join(configDir, configFile)
+	saleCmd.PersistentFlags().StringP(configPathFlag, "c", configPath, "path to config file")
+
+	saleSendCmd.Flags().String(certIDFlag, "", "ID of the stored certificate to sign the sales with")
+	saleSendCmd.Flags().String(p12Flag, "", "path to a PKCS#12 file with the certificate to sign the sales with")
+	saleSendCmd.Flags().String(formatFlag, "", "format of the sales file: json or csv (by the file extension if empty)")
+	saleSendCmd.Flags().Bool(dryRunFlag, false, "print the signed SOAP envelopes instead of sending them")
+
+	saleCmd.AddCommand(saleSendCmd)
+}
+
+var saleCmd = &cobra.Command{
+	Use:   "sale",
+	Short: "Send sales to the FSCR without the API server",
+	Args:  cobra.NoArgs,
+}
+
+var saleSendCmd = &cobra.Command{
+	Use:   "send <file>",
+	Short: "Send the sales of a JSON or CSV file",
+	Long: `Send the sales of a JSON or CSV file.
+
+The JSON file holds a sale request object of the HTTP API or an array of them. The header
+of the CSV file names the fields of the sale request object, each row is a sale. The certificate
+fields of the sale requests are ignored, the sales are signed with the certificate given by the flags.
+The revocation status of the certificate is checked against the configured CRL files only.`,
+	Args: cobra.ExactArgs(1),
+	RunE: saleSendCmdRunE,
+}
+
+func saleSendCmdRunE(cmd *cobra.Command, args []string) error {
+	certID, err := cmd.Flags().GetString(certIDFlag)
+	if err != nil {
+		return fmt.Errorf("retrieve '%s' flag: %w", certIDFlag, err)
+	}
+
+	p12Path, err := cmd.Flags().GetString(p12Flag)
+	if err != nil {
+		return fmt.Errorf("retrieve '%s' flag: %w", p12Flag, err)
+	}
+
+	format, err := cmd.Flags().GetString(formatFlag)
+	if err != nil {
+		return fmt.Errorf("retrieve '%s' flag: %w", formatFlag, err)
+	}
+
+	dryRun, err := cmd.Flags().GetBool(dryRunFlag)
+	if err != nil {
+		return fmt.Errorf("retrieve '%s' flag: %w", dryRunFlag, err)
+	}
+
+	if (certID == "") == (p12Path == "") {
+		return errSaleCertificate
+	}
+
+	reqs, err := readSales(args[0], format)
+	if err != nil {
+		return err
+	}
+
+	var pkcsData []byte
+	if p12Path != "" {
+		pkcsData, err = ioutil.ReadFile(p12Path)
+		if err != nil {
+			return fmt.Errorf("read PKCS#12 file %s: %w", p12Path, err)
+		}
+	}
+
+	if err = loadCLIConfig(cmd); err != nil {
+		return err
+	}
+
+	caSvc, err := newCASvc()
+	if err != nil {
+		return fmt.Errorf("start CA service: %w", err)
+	}
+
+	var ks keystore.Service
+	if certID != "" {
+		ks, err = newKeystoreSvc()
+		if err != nil {
+			return fmt.Errorf("start keystore client: %w", err)
+		}
+	}
+
+	prompt := "Certificate password: "
+	if p12Path != "" {
+		prompt = fmt.Sprintf("Password of %s: ", filepath.Base(p12Path))
+	}
+
+	password, err := readPassword(prompt)
+	if err != nil {
+		return err
+	}
+
+	// the revocation data aren't downloaded for a single batch, only the CRL files are loaded
+	eetRoots, dsigRoots := caSvc.Roots()
+	rSvc := newRevocationSvc(ca.Merge(eetRoots, dsigRoots), true)
+	refreshRevocations(context.Background(), rSvc)
+
+	if dryRun {
+		// the FSCR isn't contacted
+		return printSaleEnvelopes(newGatewaySvc(nil, caSvc, ks, rSvc), certID, pkcsData, password, reqs)
+	}
+
+	client, err := newFSCRClient()
+	if err != nil {
+		return fmt.Errorf("start FSCR client: %w", err)
+	}
+
+	// the keystore isn't needed for the sales signed with a PKCS#12 file
+	gSvc := newGatewaySvc(client, caSvc, ks, rSvc)
+
+	var failed int
+	for _, req := range reqs {
+		var odpoved *eet.OdpovedType
+		if certID != "" {
+			odpoved, err = gSvc.SendSale(context.Background(), certID, password, req.TrzbaType())
+		} else {
+			odpoved, err = gSvc.SendSaleWithCert(context.Background(), pkcsData, string(password), req.TrzbaType())
+		}
+
+		if !printSaleResult(req, odpoved, err) {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d sales not accepted", failed, len(reqs))
+	}
+
+	return nil
+}
+
+// printSaleEnvelopes prints the signed request envelopes of the sales. The certificate is checked
+// the same way as if the sales were sent.
+func printSaleEnvelopes(gSvc gateway.Service, certID string, pkcsData, password []byte, reqs []*httphandler.SendSaleReq) error {
+	for _, req := range reqs {
+		var env []byte
+		var err error
+		if certID != "" {
+			env, err = gSvc.SignSale(context.Background(), certID, password, req.TrzbaType())
+		} else {
+			env, err = gSvc.SignSaleWithCert(context.Background(), pkcsData, string(password), req.TrzbaType())
+		}
+
+		if err != nil {
+			return fmt.Errorf("sign sale %s: %w", req.PoradCis, err)
+		}
+
+		fmt.Println(string(env))
+	}
+
+	return nil
+}
+
+// printSaleResult prints the FIK and BKP of the accepted sale, or the reason it isn't accepted.
+// It returns true if the sale is accepted.
+func printSaleResult(req *httphandler.SendSaleReq, odpoved *eet.OdpovedType, err error) bool {
+	if err != nil {
+		fmt.Printf("%s: error: %v\n", req.PoradCis, err)
+		return false
+	}
+
+	for _, v := range odpoved.Varovani {
+		fmt.Printf("%s: warning %d: %s\n", req.PoradCis, v.Kodvarov, v.Zprava)
+	}
+
+	if (odpoved.Hlavicka.Datodmit != eet.DateTime{}) {
+		fmt.Printf("%s: rejected with code %d: %s\n", req.PoradCis, odpoved.Chyba.Kod, odpoved.Chyba.Zprava)
+		return false
+	}
+
+	fmt.Printf("%s: FIK %s BKP %s\n", req.PoradCis, odpoved.Potvrzeni.Fik, odpoved.Hlavicka.Bkp)
+
+	return true
+}
+
+// readSales reads and validates the sale requests of the file. The format is given by the file
+// extension if it's empty.
+func readSales(path string, format string) ([]*httphandler.SendSaleReq, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read sales file %s: %w", path, err)
+	}
+
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+
+	var reqs []*httphandler.SendSaleReq
+	switch format {
+	case jsonFormat:
+		reqs, err = decodeSalesJSON(data)
+	case csvFormat:
+		reqs, err = decodeSalesCSV(data)
+	default:
+		return nil, fmt.Errorf("unsupported format of sales file %q", format)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("decode sales file %s: %w", path, err)
+	}
+
+	for i, req := range reqs {
+		if err = req.ValidateSale(); err != nil {
+			return nil, fmt.Errorf("sale %d: %w", i+1, err)
+		}
+
+		req.DatOdesl.Normalize()
+		req.DatTrzby.Normalize()
+	}
+
+	return reqs, nil
+}
+
+// decodeSalesJSON decodes a sale request object or an array of them.
+func decodeSalesJSON(data []byte) ([]*httphandler.SendSaleReq, error) {
+	var raws []json.RawMessage
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &raws); err != nil {
+			return nil, fmt.Errorf("unmarshal array of sales: %w", err)
+		}
+	} else {
+		raws = []json.RawMessage{data}
+	}
+
+	reqs := make([]*httphandler.SendSaleReq, 0, len(raws))
+	for i, raw := range raws {
+		req := httphandler.NewSendSaleReq()
+		if err := json.Unmarshal(raw, req); err != nil {
+			return nil, fmt.Errorf("unmarshal sale %d: %w", i+1, err)
+		}
+
+		reqs = append(reqs, req)
+	}
+
+	return reqs, nil
+}
+
+// decodeSalesCSV decodes the rows of sale requests. The header names the JSON fields
+// of the sale request object. Empty cells are left with the default values.
+func decodeSalesCSV(data []byte) ([]*httphandler.SendSaleReq, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.TrimLeadingSpace = true
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read CSV header: %w", err)
+	}
+
+	kinds := saleFieldKinds()
+	for _, name := range header {
+		if _, ok := kinds[name]; !ok {
+			return nil, fmt.Errorf("unknown CSV column %q", name)
+		}
+	}
+
+	var reqs []*httphandler.SendSaleReq
+	for row := 1; ; row++ {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("read CSV row %d: %w", row, err)
+		}
+
+		// the row is converted to the JSON object, so the values are decoded the same way
+		fields := make(map[string]json.RawMessage, len(record))
+		for i, value := range record {
+			if value == "" {
+				continue
+			}
+
+			switch kinds[header[i]] {
+			case reflect.Bool, reflect.Int, reflect.Float64:
+				fields[header[i]] = json.RawMessage(value)
+			default:
+				raw, _ := json.Marshal(value)
+				fields[header[i]] = raw
+			}
+		}
+
+		raw, err := json.Marshal(fields)
+		if err != nil {
+			return nil, fmt.Errorf("encode CSV row %d: %w", row, err)
+		}
+
+		req := httphandler.NewSendSaleReq()
+		if err = json.Unmarshal(raw, req); err != nil {
+			return nil, fmt.Errorf("decode CSV row %d: %w", row, err)
+		}
+
+		reqs = append(reqs, req)
+	}
+
+	return reqs, nil
+}
+
+// saleFieldKinds returns the kinds of the fields of the sale request by their JSON names.
+func saleFieldKinds() map[string]reflect.Kind {
+	t := reflect.TypeOf(httphandler.SendSaleReq{})
+	kinds := make(map[string]reflect.Kind, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+
+		kinds[name] = ft.Kind()
+	}
+
+	return kinds
+}
@@ -1,15 +1,18 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/chutommy/eetgateway/pkg/ca"
 	"github.com/chutommy/eetgateway/pkg/server"
-	"github.com/fsnotify/fsnotify"
+	"github.com/chutommy/eetgateway/pkg/server/httphandler"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
@@ -20,6 +23,9 @@ const (
 	configPathFlag = "config"
 )
 
+// envPrefix is the prefix of the environment variables of the configuration.
+const envPrefix = "EETG"
+
 func initServeCmd() {
 	configDir, err := osConfigDir()
 	if err != nil {
@@ -87,8 +93,44 @@ func serveCmdRunE(cmd *cobra.Command, _ []string) error {
 		return fmt.Errorf("start keystore client: %w", err)
 	}
 
-	gSvc := newGatewaySvc(client, caSvc, ks)
-	h := server.NewHTTPHandler(gSvc)
+	// the revocation data are signed off by the CAs of both the taxpayers' and the FSCR certificates
+	eetRoots, dsigRoots := caSvc.Roots()
+	rSvc := newRevocationSvc(ca.Merge(eetRoots, dsigRoots), false)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err = watchTrustStore(ctx, caSvc, rSvc); err != nil {
+		return fmt.Errorf("watch trust store: %w", err)
+	}
+
+	// the safe settings are applied without restarting the server
+	watchConfig(ctx, newConfigReloader(client))
+
+	gSvc := newGatewaySvc(client, caSvc, ks, rSvc)
+
+	// the distribution points of the stored certificates are registered by the check before the initial refresh
+	checkRevocations(ctx, gSvc)
+	refreshRevocations(ctx, rSvc)
+	go runRevocationChecks(ctx, rSvc, gSvc)
+
+	clients := httphandler.ClientPolicy{
+		Enable:         viper.GetBool(lockoutPerClient),
+		TrustedProxies: viper.GetStringSlice(serverTrustedProxies),
+		Header:         viper.GetString(serverClientIPHeader),
+	}
+
+	log.Info().
+		Str("entity", "HTTP Server").
+		Str("action", "setting client identification").
+		Bool("perClientLockout", clients.Enable).
+		Strs("trustedProxies", clients.TrustedProxies).
+		Str("clientIPHeader", clients.Header).
+		Send()
+
+	h, err := server.NewHTTPHandler(gSvc, clients)
+	if err != nil {
+		return fmt.Errorf("create http handler: %w", err)
+	}
 
 	httpServer, err := newHTTPServer(h)
 	if err != nil {
@@ -97,17 +139,34 @@ func serveCmdRunE(cmd *cobra.Command, _ []string) error {
 
 	srv := server.NewService(httpServer)
 
-	runServer(srv)
+	// both servers are shut down by the same signal
+	if viper.GetBool(grpcEnable) {
+		grpcSrv, err := newGRPCServer(server.NewGRPCHandler(gSvc, clients.Enable))
+		if err != nil {
+			return fmt.Errorf("create gRPC server: %w", err)
+		}
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runServer("gRPC Server", grpcSrv, viper.GetBool(grpcTLSEnable), viper.GetDuration(grpcShutdownTimeout))
+		}()
+		defer wg.Wait()
+	}
+
+	runServer("HTTP Server", srv, viper.GetBool(serverTLSEnable), viper.GetDuration(serverShutdownTimeout))
 
 	return nil
 }
 
 func loadConfigFromENV() {
-	viper.SetEnvPrefix("EETG")
+	viper.SetEnvPrefix(envPrefix)
 	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	viper.AutomaticEnv()
 }
 
+// loadConfigFromFile reads the config file at the path if it exists.
 func loadConfigFromFile(path string) error {
 	ext := filepath.Ext(path)
 	name := strings.TrimSuffix(filepath.Base(path), ext)
@@ -129,41 +188,25 @@ func loadConfigFromFile(path string) error {
 				Str("path", path).
 				Send()
 		} else {
-			return fmt.Errorf("read config file: %w", vErr)
+			return fmt.Errorf("read config file: %w", err)
 		}
-	} else {
-		watchConfig()
 	}
 
 	return nil
 }
 
-func watchConfig() {
-	viper.OnConfigChange(func(e fsnotify.Event) {
-		log.Info().
-			Str("entity", "Config Service").
-			Str("action", "watching config file").
-			Str("status", "config file has been modified").
-			Str("operation", e.Op.String()).
-			Str("path", e.Name).
-			Str("note", "restart server to reload configuration").
-			Send()
-	})
-	viper.WatchConfig()
-}
-
-func runServer(srv server.Service) {
+func runServer(entity string, srv server.Service, tls bool, shutdownTimeout time.Duration) {
 	log.Info().
-		Str("entity", "HTTP Server").
+		Str("entity", entity).
 		Str("action", "listening").
 		Str("status", "online").
-		Dur("shutdownTimeout", viper.GetDuration(serverShutdownTimeout)).
+		Dur("shutdownTimeout", shutdownTimeout).
 		Send()
 
-	err := srv.ListenAndServe(viper.GetBool(serverTLSEnable), viper.GetDuration(serverShutdownTimeout))
+	err := srv.ListenAndServe(tls, shutdownTimeout)
 
 	log.Info().
-		Str("entity", "HTTP Server").
+		Str("entity", entity).
 		Str("action", "shutting down").
 		Str("status", "offline").
 		Err(err).
@@ -174,9 +217,19 @@ func setupLogger() {
 	zerolog.TimeFieldFormat = zerolog.TimeFormatUnixMs
 	zerolog.DurationFieldUnit = time.Second
 
-	if viper.GetBool(cliQuietMode) {
-		log.Logger = zerolog.Nop()
-	} else {
-		log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
+	setLogLevel()
+}
+
+// setLogLevel sets the global log level by the configuration. It can be changed while logging.
+func setLogLevel() {
+	// the debug logs include the (redacted) messages exchanged with the FSCR
+	switch {
+	case viper.GetBool(cliQuietMode):
+		zerolog.SetGlobalLevel(zerolog.Disabled)
+	case viper.GetBool(cliDebugMode):
+		zerolog.SetGlobalLevel(zerolog.DebugLevel)
+	default:
+		zerolog.SetGlobalLevel(zerolog.InfoLevel)
 	}
 }
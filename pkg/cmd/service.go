@@ -4,17 +4,22 @@ import (
 	"context"
 	"crypto/tls"
 	"crypto/x509"
-	"encoding/pem"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	slog "log"
 	"net/http"
+	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/chutommy/eetgateway/pkg/ca"
 	"github.com/chutommy/eetgateway/pkg/fscr"
 	"github.com/chutommy/eetgateway/pkg/gateway"
 	"github.com/chutommy/eetgateway/pkg/keystore"
+	"github.com/chutommy/eetgateway/pkg/revocation"
 	"github.com/chutommy/eetgateway/pkg/server"
+	"github.com/fsnotify/fsnotify"
 	"github.com/go-redis/redis/v8"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/viper"
@@ -24,24 +29,179 @@ const (
 	eetServerName = "eet.cz"
 )
 
+// errMutualTLSWithoutTLS is returned if the mutual TLS is enabled without the server TLS.
+var errMutualTLSWithoutTLS = errors.New("mutual TLS requires server TLS")
+
 func newCASvc() (fscr.CAService, error) {
-	mode, roots, err := getCARoots()
+	mode, eetRoots, dsigRoots, err := loadTrustStore()
 	if err != nil {
-		return nil, fmt.Errorf("fetch CA roots and mode")
-	}
-
-	dsigPool := x509.NewCertPool()
-	if ok := dsigPool.AppendCertsFromPEM(ca.ICACertificate); !ok {
-		return nil, fmt.Errorf("append to dsig certificate pool")
+		return nil, fmt.Errorf("load trust store: %w", err)
 	}
 
 	log.Info().
 		Str("entity", "Certificate Authority Service").
 		Str("action", "starting").
 		Str("mode", mode).
+		Strs("eetRoots", viper.GetStringSlice(caEETRoots)).
+		Strs("dsigRoots", viper.GetStringSlice(caDSigRoots)).
+		Bool("replaceBuiltin", viper.GetBool(caReplaceBuiltin)).
+		Bool("hotReload", viper.GetBool(caHotReload)).
+		Send()
+
+	logTrustedRoots(eetRoots, dsigRoots)
+
+	return fscr.NewCAService(eetRoots, dsigRoots), nil
+}
+
+// loadTrustStore returns the built-in EET CA roots and DSig CA certificates extended, or replaced
+// if configured so, by the certificates loaded from the configured files and directories.
+func loadTrustStore() (mode string, eetRoots, dsigRoots []*x509.Certificate, err error) {
+	mode, eetRoots, err = getCARoots()
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("fetch CA roots and mode: %w", err)
+	}
+
+	dsigRoots, err = ca.DSigRoots()
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("fetch DSig CA certificates: %w", err)
+	}
+
+	eetRoots, err = extendRoots(eetRoots, viper.GetStringSlice(caEETRoots))
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("load EET CA roots: %w", err)
+	}
+
+	dsigRoots, err = extendRoots(dsigRoots, viper.GetStringSlice(caDSigRoots))
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("load DSig CA certificates: %w", err)
+	}
+
+	return mode, eetRoots, dsigRoots, nil
+}
+
+// extendRoots loads the certificates from the paths and adds them to the built-in ones. The built-in
+// certificates are dropped if replacing is configured and at least one path is given.
+func extendRoots(builtin []*x509.Certificate, paths []string) ([]*x509.Certificate, error) {
+	if len(paths) == 0 {
+		return builtin, nil
+	}
+
+	certs, err := ca.LoadCertificates(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	if viper.GetBool(caReplaceBuiltin) {
+		return ca.Merge(certs), nil
+	}
+
+	return ca.Merge(builtin, certs), nil
+}
+
+func logTrustedRoots(eetRoots, dsigRoots []*x509.Certificate) {
+	logRoots := func(purpose string, certs []*x509.Certificate) {
+		for _, c := range certs {
+			e := log.Info()
+			if time.Now().After(c.NotAfter) {
+				e = log.Warn().Str("status", "expired")
+			}
+
+			e.Str("entity", "Certificate Authority Service").
+				Str("action", "trusting certificate").
+				Str("purpose", purpose).
+				Str("subject", c.Subject.String()).
+				Str("serialNumber", c.SerialNumber.String()).
+				Time("notBefore", c.NotBefore).
+				Time("notAfter", c.NotAfter).
+				Send()
+		}
+	}
+
+	logRoots("eet", eetRoots)
+	logRoots("dsig", dsigRoots)
+
+	for _, c := range dsigRoots {
+		if fscr.IsSelfSigned(c) {
+			return
+		}
+	}
+
+	log.Warn().
+		Str("entity", "Certificate Authority Service").
+		Str("action", "trusting certificate").
+		Str("purpose", "dsig").
+		Str("status", "no self-signed root, responses of the FSCR are rejected").
+		Str("config", caDSigRoots).
 		Send()
+}
+
+// watchTrustStore reloads the trusted certificates whenever the configured files or directories change
+// until ctx is done. The current certificates are kept if the reload fails.
+func watchTrustStore(ctx context.Context, caSvc fscr.CAService, rSvc revocation.Service) error {
+	paths := append(viper.GetStringSlice(caEETRoots), viper.GetStringSlice(caDSigRoots)...)
+	if !viper.GetBool(caHotReload) || len(paths) == 0 {
+		return nil
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("create file watcher: %w", err)
+	}
+
+	// files are watched through their directories, so they can be replaced atomically
+	for _, p := range paths {
+		dir := p
+		if info, err := os.Stat(p); err != nil || !info.IsDir() {
+			dir = filepath.Dir(p)
+		}
 
-	return fscr.NewCAService(roots, dsigPool), nil
+		if err = watcher.Add(dir); err != nil {
+			_ = watcher.Close()
+			return fmt.Errorf("watch %s: %w", dir, err)
+		}
+	}
+
+	go func() {
+		defer watcher.Close()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case err := <-watcher.Errors:
+				log.Warn().
+					Str("entity", "Certificate Authority Service").
+					Str("action", "watching trust store").
+					Err(err).
+					Send()
+			case e := <-watcher.Events:
+				_, eetRoots, dsigRoots, err := loadTrustStore()
+				if err != nil {
+					log.Warn().
+						Str("entity", "Certificate Authority Service").
+						Str("action", "reloading trust store").
+						Str("status", "keeping current certificates").
+						Str("path", e.Name).
+						Err(err).
+						Send()
+					continue
+				}
+
+				caSvc.SetRoots(eetRoots, dsigRoots)
+				rSvc.SetIssuers(ca.Merge(eetRoots, dsigRoots))
+
+				log.Info().
+					Str("entity", "Certificate Authority Service").
+					Str("action", "reloading trust store").
+					Str("operation", e.Op.String()).
+					Str("path", e.Name).
+					Send()
+				logTrustedRoots(eetRoots, dsigRoots)
+			}
+		}
+	}()
+
+	return nil
 }
 
 func getCARoots() (string, []*x509.Certificate, error) {
@@ -75,8 +235,8 @@ func newFSCRClient() (fscr.Client, error) {
 		Str("tlsVersion", "TLS 1.3").
 		Send()
 
+	// the timeout is applied by the FSCR client, so it can be changed
 	c := fscr.NewClient(&http.Client{
-		Timeout: viper.GetDuration(eetRequestTimeout),
 		Transport: &http.Transport{
 			TLSClientConfig: &tls.Config{
 				ServerName:         eetServerName,
@@ -86,6 +246,7 @@ func newFSCRClient() (fscr.Client, error) {
 			},
 		},
 	}, url)
+	c.SetTimeout(viper.GetDuration(eetRequestTimeout))
 
 	if err := c.Ping(); err != nil {
 		return nil, fmt.Errorf("ping FSCR: %w", err)
@@ -149,46 +310,187 @@ func newKeystoreSvc() (keystore.Service, error) {
 			Str("tlsVersion", "TLS 1.2").
 			Send()
 
-		cert, err := tls.LoadX509KeyPair(viper.GetString(redisTLSCertificate), viper.GetString(redisTLSPrivateKey))
-		if err != nil {
-			return nil, fmt.Errorf("load redis TLS keypair: %w", err)
+		if err := tlsMaterial.loadRedisCert(); err != nil {
+			return nil, err
 		}
 
-		pool := x509.NewCertPool()
-		for _, v := range viper.GetStringSlice(redisTLSRootCAs) {
-			data, err := ioutil.ReadFile(v)
-			if err != nil {
-				return nil, fmt.Errorf("read file %s: %w", v, err)
-			}
-
-			b, _ := pem.Decode(data)
-			cert, err := x509.ParseCertificate(b.Bytes)
-			if err != nil {
-				return nil, fmt.Errorf("parse root CA certificate %s: %w", v, err)
-			}
-
-			pool.AddCert(cert)
+		pool, err := loadCertPool(viper.GetStringSlice(redisTLSRootCAs))
+		if err != nil {
+			return nil, fmt.Errorf("load root CA certificates: %w", err)
 		}
 
 		opt.TLSConfig = &tls.Config{
-			Certificates:       []tls.Certificate{cert},
-			ServerName:         viper.GetString(redisTLSServerName),
-			RootCAs:            pool,
-			ClientSessionCache: tls.NewLRUClientSessionCache(64),
-			MinVersion:         tls.VersionTLS12,
+			GetClientCertificate: tlsMaterial.getClientCertificate,
+			ServerName:           viper.GetString(redisTLSServerName),
+			RootCAs:              pool,
+			ClientSessionCache:   tls.NewLRUClientSessionCache(64),
+			MinVersion:           tls.VersionTLS12,
 		}
 	}
 
-	ks := keystore.NewRedisService(redis.NewClient(opt))
+	lockout := keystore.LockoutPolicy{
+		Threshold:     viper.GetInt64(lockoutThreshold),
+		BaseDelay:     viper.GetDuration(lockoutBaseDelay),
+		MaxDelay:      viper.GetDuration(lockoutMaxDelay),
+		ResetAfter:    viper.GetDuration(lockoutResetAfter),
+		MaxInProgress: viper.GetInt64(lockoutInProgress),
+	}
+
+	log.Info().
+		Str("entity", "KeyStore Client").
+		Str("action", "setting lockout policy").
+		Int64("threshold", lockout.Threshold).
+		Dur("baseDelay", lockout.BaseDelay).
+		Dur("maxDelay", lockout.MaxDelay).
+		Dur("resetAfter", lockout.ResetAfter).
+		Int64("maxInProgress", lockout.MaxInProgress).
+		Send()
+
+	log.Info().
+		Str("entity", "KeyStore Client").
+		Str("action", "setting renewal grace period").
+		Dur("gracePeriod", viper.GetDuration(renewalGracePeriod)).
+		Send()
+
+	rdb := redis.NewClient(opt)
+	ks := keystore.NewRedisService(rdb, lockout, viper.GetDuration(renewalGracePeriod), newSignerProvider())
 	if err := ks.Ping(context.Background()); err != nil {
 		return nil, fmt.Errorf("ping keystore: %w", err)
 	}
 
+	if viper.GetBool(cacheEnable) {
+		cache := keystore.CachePolicy{
+			TTL:  viper.GetDuration(cacheTTL),
+			Size: viper.GetInt(cacheSize),
+		}
+
+		log.Info().
+			Str("entity", "KeyStore Client").
+			Str("action", "enabling cache").
+			Dur("ttl", cache.TTL).
+			Int("size", cache.Size).
+			Str("invalidationChannel", keystore.InvalidationChannel).
+			Send()
+
+		var err error
+		ks, err = keystore.NewCachedService(context.Background(), ks, rdb, cache)
+		if err != nil {
+			return nil, fmt.Errorf("start keystore cache: %w", err)
+		}
+	}
+
 	return ks, nil
 }
 
-func newGatewaySvc(client fscr.Client, caSvc fscr.CAService, ks keystore.Service) gateway.Service {
-	return gateway.NewService(client, caSvc, ks)
+// newSignerProvider returns the provider of the signers of the certificates whose private keys are kept
+// outside the gateway or nil if no external signer is configured.
+func newSignerProvider() keystore.SignerProvider {
+	url := viper.GetString(signerURL)
+	if url == "" {
+		return nil
+	}
+
+	log.Info().
+		Str("entity", "Signer").
+		Str("action", "setting remote signer").
+		Str("url", url).
+		Dur("timeout", viper.GetDuration(signerTimeout)).
+		Send()
+
+	return keystore.NewRemoteSigners(url, &http.Client{
+		Timeout: viper.GetDuration(signerTimeout),
+	})
+}
+
+// newRevocationSvc returns the revocation service of the configured policy. Only the CRL files are loaded
+// if offline is set, regardless of the configuration.
+func newRevocationSvc(roots []*x509.Certificate, offline bool) revocation.Service {
+	policy := revocation.Policy{
+		CRLURLs:  viper.GetStringSlice(revocationCRLURLs),
+		CRLFiles: viper.GetStringSlice(revocationCRLFiles),
+		Offline:  offline || viper.GetBool(revocationOffline),
+		OCSP:     viper.GetBool(revocationOCSP),
+		Strict:   viper.GetBool(revocationStrict),
+	}
+
+	log.Info().
+		Str("entity", "Revocation Service").
+		Str("action", "starting").
+		Strs("crlURLs", policy.CRLURLs).
+		Strs("crlFiles", policy.CRLFiles).
+		Bool("offline", policy.Offline).
+		Bool("ocsp", policy.OCSP).
+		Bool("strict", policy.Strict).
+		Dur("refreshInterval", viper.GetDuration(revocationRefreshInterval)).
+		Dur("requestTimeout", viper.GetDuration(revocationRequestTimeout)).
+		Send()
+
+	return revocation.NewService(&http.Client{
+		Timeout: viper.GetDuration(revocationRequestTimeout),
+	}, roots, policy)
+}
+
+func refreshRevocations(ctx context.Context, svc revocation.Service) {
+	// the cached revocation data are kept if the refresh fails
+	if err := svc.Refresh(ctx); err != nil {
+		log.Warn().
+			Str("entity", "Revocation Service").
+			Str("action", "refreshing revocation data").
+			Err(err).
+			Send()
+	}
+}
+
+// runRevocationChecks refreshes the revocation data and checks the stored certificates periodically until
+// ctx is done.
+func runRevocationChecks(ctx context.Context, svc revocation.Service, gSvc gateway.Service) {
+	ticker := time.NewTicker(viper.GetDuration(revocationRefreshInterval))
+	defer ticker.Stop()
+
+	for {
+		checkRevocations(ctx, gSvc)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			refreshRevocations(ctx, svc)
+		}
+	}
+}
+
+// checkRevocations checks the stored certificates and registers their distribution points
+// for the following refreshes.
+func checkRevocations(ctx context.Context, gSvc gateway.Service) {
+	revoked, err := gSvc.CheckRevocations(ctx)
+	log.Info().
+		Str("entity", "EET Gateway").
+		Str("action", "checking revocations of stored certificates").
+		Strs("revoked", revoked).
+		Err(err).
+		Send()
+}
+
+func newGatewaySvc(client fscr.Client, caSvc fscr.CAService, ks keystore.Service, rSvc revocation.Service) gateway.Service {
+	sessions := gateway.SessionPolicy{
+		TTL:      viper.GetDuration(sessionTTL),
+		Capacity: viper.GetInt(sessionCapacity),
+	}
+
+	log.Info().
+		Str("entity", "EET Gateway").
+		Str("action", "setting session policy").
+		Dur("ttl", sessions.TTL).
+		Int("capacity", sessions.Capacity).
+		Send()
+
+	log.Info().
+		Str("entity", "EET Gateway").
+		Str("action", "setting response verification").
+		Dur("maxSigningTimeSkew", viper.GetDuration(eetMaxSigningSkew)).
+		Send()
+
+	return gateway.NewService(client, caSvc, ks, rSvc, sessions, viper.GetDuration(eetMaxSigningSkew))
 }
 
 func newHTTPServer(h server.Handler) (*http.Server, error) {
@@ -223,24 +525,12 @@ func newHTTPServer(h server.Handler) (*http.Server, error) {
 			Str("tlsVersion", "TLS 1.2").
 			Send()
 
-		cert, err := tls.LoadX509KeyPair(viper.GetString(serverTLSCertificate), viper.GetString(serverTLSPrivateKey))
-		if err != nil {
-			return nil, fmt.Errorf("load SSL certificate: %w", err)
+		if err := tlsMaterial.loadServerCert(); err != nil {
+			return nil, err
 		}
 
 		httpServer.TLSNextProto = make(map[string]func(*http.Server, *tls.Conn, http.Handler), 0)
-		httpServer.TLSConfig = &tls.Config{
-			Certificates:             []tls.Certificate{cert},
-			MinVersion:               tls.VersionTLS12,
-			CurvePreferences:         []tls.CurveID{tls.CurveP521, tls.CurveP384, tls.CurveP256},
-			PreferServerCipherSuites: true,
-			CipherSuites: []uint16{
-				tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
-				tls.TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA,
-				tls.TLS_RSA_WITH_AES_256_GCM_SHA384,
-				tls.TLS_RSA_WITH_AES_256_CBC_SHA,
-			},
-		}
+		httpServer.TLSConfig = serverTLSConfig(tlsMaterial.getCertificate)
 	}
 
 	if viper.GetBool(serverMutualTLSEnable) {
@@ -251,25 +541,83 @@ func newHTTPServer(h server.Handler) (*http.Server, error) {
 			Str("tlsVersion", "TLS 1.2").
 			Send()
 
-		pool := x509.NewCertPool()
-		for _, v := range viper.GetStringSlice(serverMutualTLSClientCAs) {
-			data, err := ioutil.ReadFile(v)
-			if err != nil {
-				return nil, fmt.Errorf("read file %s: %w", v, err)
-			}
-
-			b, _ := pem.Decode(data)
-			cert, err := x509.ParseCertificate(b.Bytes)
-			if err != nil {
-				return nil, fmt.Errorf("parse client CA certificate %s: %w", v, err)
-			}
+		if httpServer.TLSConfig == nil {
+			return nil, errMutualTLSWithoutTLS
+		}
 
-			pool.AddCert(cert)
+		if err := tlsMaterial.loadClientCAs(); err != nil {
+			return nil, err
 		}
 
+		// the client CAs are retrieved for each connection, so they can be reloaded
 		httpServer.TLSConfig.ClientAuth = tls.RequireAndVerifyClientCert
-		httpServer.TLSConfig.ClientCAs = pool
+		httpServer.TLSConfig.GetConfigForClient = tlsMaterial.configForClient(httpServer.TLSConfig, false)
 	}
 
 	return httpServer, nil
 }
+
+func newGRPCServer(h server.GRPCHandler) (server.Service, error) {
+	log.Info().
+		Str("entity", "gRPC Server").
+		Str("action", "starting").
+		Str("addr", viper.GetString(grpcAddr)).
+		Send()
+
+	var tlsConfig *tls.Config
+	if viper.GetBool(grpcTLSEnable) {
+		log.Info().
+			Str("entity", "gRPC Server").
+			Str("action", "enabling server TLS").
+			Str("certificate", viper.GetString(grpcTLSCertificate)).
+			Str("privateKey", viper.GetString(grpcTLSPrivateKey)).
+			Str("tlsVersion", "TLS 1.2").
+			Send()
+
+		if err := tlsMaterial.loadGRPCCert(); err != nil {
+			return nil, err
+		}
+
+		tlsConfig = serverTLSConfig(tlsMaterial.getGRPCCertificate)
+		tlsConfig.NextProtos = []string{"h2"}
+	}
+
+	if viper.GetBool(grpcMutualTLSEnable) {
+		log.Info().
+			Str("entity", "gRPC Server").
+			Str("action", "enabling client TLS").
+			Strs("rootCAs", viper.GetStringSlice(grpcMutualTLSClientCAs)).
+			Str("tlsVersion", "TLS 1.2").
+			Send()
+
+		if tlsConfig == nil {
+			return nil, errMutualTLSWithoutTLS
+		}
+
+		if err := tlsMaterial.loadGRPCClientCAs(); err != nil {
+			return nil, err
+		}
+
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		tlsConfig.GetConfigForClient = tlsMaterial.configForClient(tlsConfig, true)
+	}
+
+	return server.NewGRPCService(h.GRPCServer(), viper.GetString(grpcAddr), tlsConfig), nil
+}
+
+// serverTLSConfig returns the TLS config of the API servers. The certificate is retrieved
+// for each connection, so it can be reloaded.
+func serverTLSConfig(getCertificate func(*tls.ClientHelloInfo) (*tls.Certificate, error)) *tls.Config {
+	return &tls.Config{
+		GetCertificate:           getCertificate,
+		MinVersion:               tls.VersionTLS12,
+		CurvePreferences:         []tls.CurveID{tls.CurveP521, tls.CurveP384, tls.CurveP256},
+		PreferServerCipherSuites: true,
+		CipherSuites: []uint16{
+			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA,
+			tls.TLS_RSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_RSA_WITH_AES_256_CBC_SHA,
+		},
+	}
+}
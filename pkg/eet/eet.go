@@ -1,9 +1,10 @@
 package eet
 
 import (
-	"crypto/rsa"
+	"crypto"
 	"encoding/xml"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/beevik/etree"
@@ -43,8 +44,10 @@ func (t *TrzbaType) etree() (*etree.Element, error) {
 	return trzba, nil
 }
 
-func (t *TrzbaType) setSecurityCodes(pk *rsa.PrivateKey) error {
-	err := t.setPKP(pk)
+// SetSecurityCodes computes the PKP and BKP codes of the sale data and sets them to the control codes
+// of the TrzbaType. The PKP is signed by the RSA key of the taxpayer's certificate.
+func (t *TrzbaType) SetSecurityCodes(key crypto.Signer) error {
+	err := t.setPKP(key)
 	if err != nil {
 		return fmt.Errorf("set pkp: %w", err)
 	}
@@ -54,8 +57,32 @@ func (t *TrzbaType) setSecurityCodes(pk *rsa.PrivateKey) error {
 	return nil
 }
 
-func (t *TrzbaType) setPKP(pk *rsa.PrivateKey) error {
-	pkp, err := pkp(t.plaintext(), pk)
+// VerifySecurityCodes checks the PKP and BKP codes set in the control codes of the TrzbaType against
+// the sale data and the public key of the taxpayer's certificate. A TrzbaType without the codes passes.
+// The BKP code can't be checked without the PKP code it is derived from.
+func (t *TrzbaType) VerifySecurityCodes(pub crypto.PublicKey) error {
+	codes := t.KontrolniKody
+	if len(codes.Pkp.PkpType) == 0 {
+		if codes.Bkp.BkpType != "" {
+			return fmt.Errorf("bkp without pkp: %w", ErrInvalidSecurityCodes)
+		}
+
+		return nil
+	}
+
+	if err := verifyPKP(t.plaintext(), codes.Pkp.PkpType, pub); err != nil {
+		return fmt.Errorf("verify pkp: %w", err)
+	}
+
+	if codes.Bkp.BkpType != "" && !strings.EqualFold(string(codes.Bkp.BkpType), string(bkp(codes.Pkp.PkpType))) {
+		return fmt.Errorf("different bkp: %w", ErrInvalidSecurityCodes)
+	}
+
+	return nil
+}
+
+func (t *TrzbaType) setPKP(key crypto.Signer) error {
+	pkp, err := pkp(t.plaintext(), key)
 	if err != nil {
 		return fmt.Errorf("calculate PKP: %w", err)
 	}
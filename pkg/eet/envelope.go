@@ -2,20 +2,27 @@ package eet
 
 import (
 	"crypto"
-	"crypto/rsa"
 	"crypto/x509"
-	"encoding/base64"
 	"encoding/xml"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/beevik/etree"
 	"github.com/chutommy/eetgateway/pkg/wsse"
+	"go.uber.org/multierr"
 )
 
 // ErrInvalidXMLDigest is returned if the referenced computed digest differs from the digest in the XML.
 var ErrInvalidXMLDigest = errors.New("computed digest differs from the digest in the XML")
 
+// ErrInvalidSignature is returned if the signature of the response doesn't match the signing certificate.
+var ErrInvalidSignature = errors.New("invalid signature of the response")
+
+// ErrUnsignedResponse is returned if a response which must be signed by the FSCR isn't signed.
+var ErrUnsignedResponse = errors.New("response not signed")
+
 // ErrInvalidSOAPMessage is returned if a SOAP message has an unexpected structure.
 var ErrInvalidSOAPMessage = errors.New("SOAP message with an unexpected structure")
 
@@ -25,9 +32,16 @@ var ErrInvalidUUID = errors.New("invalid UUID value or format")
 // ErrInvalidBKP is returned if the response BKP code is different.
 var ErrInvalidBKP = errors.New("incorrect response BKP")
 
-// NewRequestEnvelope returns a populated and signed SOAP request envelope.
-func NewRequestEnvelope(t *TrzbaType, cert *x509.Certificate, pk *rsa.PrivateKey) ([]byte, error) {
-	if err := t.setSecurityCodes(pk); err != nil {
+// ErrInvalidSecurityCodes is returned if the PKP or BKP code of a request doesn't match the sale data.
+var ErrInvalidSecurityCodes = errors.New("security codes don't match the sale data")
+
+// ErrUnsupportedKey is returned if the key of the security codes isn't an RSA key.
+var ErrUnsupportedKey = errors.New("unsupported key of the security codes")
+
+// NewRequestEnvelope returns a populated and signed SOAP request envelope. Both the PKP code
+// and the WS-Security signature are signed by the key, which must be the RSA key of the certificate.
+func NewRequestEnvelope(t *TrzbaType, cert *x509.Certificate, key crypto.Signer) ([]byte, error) {
+	if err := t.SetSecurityCodes(key); err != nil {
 		return nil, fmt.Errorf("setting security codes: %w", err)
 	}
 
@@ -36,42 +50,19 @@ func NewRequestEnvelope(t *TrzbaType, cert *x509.Certificate, pk *rsa.PrivateKey
 		return nil, fmt.Errorf("marshal trzba to etree element: %w", err)
 	}
 
-	binCert, err := certToB64(cert)
-	if err != nil {
-		return nil, fmt.Errorf("convert certificate to base64: %w", err)
-	}
-
 	// build request message
 	body := buildBodyElem()
 	body.AddChild(trzba)
 	env := getSoapEnvelope()
-	envElem, err := findElement(env.Root(), ".")
-	if err != nil {
-		return nil, err
-	}
-
-	envElem.AddChild(body)
-
-	// set binary security token
-	tokenElem, err := findElement(envElem, "./Header/Security/BinarySecurityToken")
-	if err != nil {
-		return nil, err
-	}
+	env.Root().AddChild(body)
 
-	tokenElem.SetText(string(binCert))
-
-	// set signature
 	signature, err := findElement(env.Root(), "./Header/Security/Signature")
 	if err != nil {
 		return nil, err
 	}
 
-	if err = setDigestVal(body, signature); err != nil {
-		return nil, fmt.Errorf("set digest value: %w", err)
-	}
-
-	if err = setSignatureVal(pk, signature); err != nil {
-		return nil, fmt.Errorf("set signature value: %w", err)
+	if err = wsse.NewSigner(cert, key).Sign(signature); err != nil {
+		return nil, fmt.Errorf("sign envelope: %w", err)
 	}
 
 	signedEnv, err := env.WriteToBytes()
@@ -82,56 +73,81 @@ func NewRequestEnvelope(t *TrzbaType, cert *x509.Certificate, pk *rsa.PrivateKey
 	return signedEnv, nil
 }
 
-func certToB64(cert *x509.Certificate) ([]byte, error) {
-	b64 := make([]byte, base64.StdEncoding.EncodedLen(len(cert.Raw)))
-	base64.StdEncoding.Encode(b64, cert.Raw)
-	return b64, nil
-}
-
-func setDigestVal(body *etree.Element, sign *etree.Element) error {
-	digest, err := wsse.CalcDigest(body)
+// ParseRequest returns the TrzbaType of a request given either as a bare Trzba element or as a SOAP
+// request envelope. The security codes are kept as they are, the header of the envelope is ignored.
+func ParseRequest(req []byte) (*TrzbaType, error) {
+	doc := etree.NewDocument()
+	err := doc.ReadFromBytes(req)
 	if err != nil {
-		return fmt.Errorf("calculate digest of the body element: %w", err)
+		return nil, fmt.Errorf("parse request to etree: %w", err)
 	}
 
-	digestVal := base64.StdEncoding.EncodeToString(digest)
-	digestValElem, err := findElement(sign, "./SignedInfo/Reference/DigestValue")
-	if err != nil {
-		return err
+	root := doc.Root()
+	if root == nil {
+		return nil, fmt.Errorf("empty request: %w", ErrInvalidSOAPMessage)
 	}
 
-	digestValElem.SetText(digestVal)
-
-	return nil
-}
-
-func setSignatureVal(pk *rsa.PrivateKey, signature *etree.Element) error {
-	signedInfo, err := findElement(signature, "./SignedInfo")
-	if err != nil {
-		return err
+	trzbaElem := root
+	switch root.Tag {
+	case "Trzba":
+	case "Envelope":
+		if trzbaElem, err = findElement(root, "./Body/Trzba"); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unexpected root element %s: %w", root.FullTag(), ErrInvalidSOAPMessage)
 	}
 
-	rawSig, err := wsse.CalcSignature(pk, signedInfo.Copy())
+	doc.SetRoot(trzbaElem.Copy())
+	trzbaBytes, err := doc.WriteToBytes()
 	if err != nil {
-		return fmt.Errorf("calculate signature value: %w", err)
+		return nil, fmt.Errorf("serialize etree document to bytes: %w", err)
 	}
 
-	sigVal := base64.StdEncoding.EncodeToString(rawSig)
-	sigValElem, err := findElement(signature, "./SignatureValue")
-	if err != nil {
-		return err
+	var trzba TrzbaType
+	if err = xml.Unmarshal(trzbaBytes, &trzba); err != nil {
+		return nil, fmt.Errorf("decode trzba bytes: %w", err)
 	}
 
-	sigValElem.SetText(sigVal)
-
-	return nil
+	return &trzba, nil
 }
 
+// TemporaryErrorCode is the code of the responses to the messages the FSCR can't process temporarily.
+// Such responses aren't signed.
+const TemporaryErrorCode = -1
+
 // OdpovedBody represents a SOAP Body of the response envelope.
 type OdpovedBody struct {
 	Odpoved OdpovedType `xml:"Odpoved"`
 }
 
+// SOAPFault is an unsigned SOAP fault returned by the FSCR instead of a response, e.g. if the request
+// violates the schema or the message isn't supported.
+type SOAPFault struct {
+	Code   string
+	String string
+	// Detail is the raw XML content of the detail element, if any.
+	Detail string
+}
+
+// Error implements the error interface.
+func (f *SOAPFault) Error() string {
+	if f.Detail != "" {
+		return fmt.Sprintf("SOAP fault %s: %s (%s)", f.Code, f.String, f.Detail)
+	}
+
+	return fmt.Sprintf("SOAP fault %s: %s", f.Code, f.String)
+}
+
+// soapFault represents a SOAP 1.1 Fault element.
+type soapFault struct {
+	Code   string `xml:"faultcode"`
+	String string `xml:"faultstring"`
+	Detail struct {
+		Content string `xml:",innerxml"`
+	} `xml:"detail"`
+}
+
 // ParseResponseEnvelope returns a parsed SOAP response envelope.
 func ParseResponseEnvelope(env []byte) (*OdpovedType, error) {
 	doc := etree.NewDocument()
@@ -145,6 +161,10 @@ func ParseResponseEnvelope(env []byte) (*OdpovedType, error) {
 		return nil, err
 	}
 
+	if faultElem := bodyElem.FindElement("./Fault"); faultElem != nil {
+		return nil, parseFault(faultElem)
+	}
+
 	doc.SetRoot(bodyElem.Copy())
 	odpovedBytes, err := doc.WriteToBytes()
 	if err != nil {
@@ -160,181 +180,97 @@ func ParseResponseEnvelope(env []byte) (*OdpovedType, error) {
 	return &odpoved.Odpoved, nil
 }
 
-// VerifyResponse checks whether the response envelope is valid and is signed by a trusted certificate.
-func VerifyResponse(trzba *TrzbaType, respEnv []byte, odpoved *OdpovedType, verifyCert func(*x509.Certificate) error) error {
-	envelope := etree.NewDocument()
-	err := envelope.ReadFromBytes(respEnv)
-	if err != nil {
-		return fmt.Errorf("parse envelope to etree: %w", err)
-	}
-
-	// verify only if successful
-	if !trzba.Hlavicka.Overeni && odpoved.Chyba.Kod == 0 {
-		if trzba.Hlavicka.Uuidzpravy != odpoved.Hlavicka.Uuidzpravy {
-			return fmt.Errorf("different uuid: %w", ErrInvalidUUID)
-		}
-
-		if trzba.KontrolniKody.Bkp.BkpType != odpoved.Hlavicka.Bkp {
-			return fmt.Errorf("different bkp: %w", ErrInvalidBKP)
-		}
-
-		if err := checkDigSig(envelope); err != nil {
-			return fmt.Errorf("check digital signature: %w", err)
-		}
-
-		if err := verifyCertificate(envelope, verifyCert); err != nil {
-			return fmt.Errorf("check certificate: %w", err)
-		}
-	}
-
-	return nil
-}
-
-func verifyCertificate(envelope *etree.Document, verifyCert func(*x509.Certificate) error) error {
-	tokenElem, err := findElement(envelope.Root(), "./Header/Security/BinarySecurityToken")
-	if err != nil {
-		return err
-	}
-
-	tokenB64 := tokenElem.Text()
-	rawCert, err := base64.StdEncoding.DecodeString(tokenB64)
+// parseFault returns the SOAP fault of the Fault element.
+func parseFault(faultElem *etree.Element) error {
+	doc := etree.NewDocument()
+	doc.SetRoot(faultElem.Copy())
+	faultBytes, err := doc.WriteToBytes()
 	if err != nil {
-		return fmt.Errorf("decode binary security token from base64 encoding: %w", err)
+		return fmt.Errorf("serialize fault element to bytes: %w", err)
 	}
 
-	cert, err := x509.ParseCertificate(rawCert)
-	if err != nil {
-		return fmt.Errorf("parse raw certificate: %w", err)
+	var fault soapFault
+	if err = xml.Unmarshal(faultBytes, &fault); err != nil {
+		return fmt.Errorf("decode fault bytes: %w", err)
 	}
 
-	if err = verifyCert(cert); err != nil {
-		return fmt.Errorf("verify security token: %w", err)
+	return &SOAPFault{
+		Code:   strings.TrimSpace(fault.Code),
+		String: strings.TrimSpace(fault.String),
+		Detail: strings.TrimSpace(fault.Detail.Content),
 	}
-
-	return nil
 }
 
-func checkDigSig(envelope *etree.Document) error {
-	if err := validateDigestValue(envelope); err != nil {
-		return fmt.Errorf("invalid digest value: %w", err)
-	}
-
-	if err := verifySignature(envelope); err != nil {
-		return fmt.Errorf("verify signature: %w", err)
-	}
-
-	return nil
-}
-
-func verifySignature(envelope *etree.Document) error {
-	cert, err := getCertFromToken(envelope)
-	if err != nil {
-		return fmt.Errorf("retrieve certificate from the binary security token: %w", err)
-	}
-
-	sigVal, digest, err := getSignatureDate(envelope)
-	if err != nil {
-		return fmt.Errorf("retrieve signature value and digest: %w", err)
-	}
-
-	err = rsa.VerifyPKCS1v15(cert.PublicKey.(*rsa.PublicKey), crypto.SHA256, digest, sigVal)
+// VerifyResponse checks whether the response envelope matches the request and is signed by a trusted
+// certificate. All responses are verified, including rejections and responses to the messages sent
+// in the verification mode. Only the temporary error responses may be unsigned.
+// The certificate is verified by verifyCert at the time the response was received or rejected by the FSCR.
+func VerifyResponse(trzba *TrzbaType, respEnv []byte, odpoved *OdpovedType, verifyCert func(cert *x509.Certificate, signedAt time.Time) error) error {
+	envelope := etree.NewDocument()
+	err := envelope.ReadFromBytes(respEnv)
 	if err != nil {
-		return fmt.Errorf("verify PKCS1v15 signature: %w", err)
+		return fmt.Errorf("parse envelope to etree: %w", err)
 	}
 
-	return nil
-}
-
-func getSignatureDate(envelope *etree.Document) (sigVal []byte, digest []byte, err error) {
-	sigElem, err := findElement(envelope.Root(), "./Header/Security/Signature")
-	if err != nil {
-		return nil, nil, err
+	if trzba.Hlavicka.Uuidzpravy != odpoved.Hlavicka.Uuidzpravy {
+		return fmt.Errorf("different uuid: %w", ErrInvalidUUID)
 	}
 
-	// signature value
-	{
-		sigValElem, err := findElement(sigElem, "./SignatureValue")
-		if err != nil {
-			return nil, nil, err
-		}
-
-		sigValB64 := sigValElem.Text()
-		sigVal, err = base64.StdEncoding.DecodeString(sigValB64)
-		if err != nil {
-			return nil, nil, fmt.Errorf("decode base64 signature value: %w", err)
+	// BKP is required for accepted sales only, the other responses include it if the message was processed
+	bkp := odpoved.Hlavicka.Bkp
+	if (!trzba.Hlavicka.Overeni && odpoved.Chyba.Kod == 0) || bkp != "" {
+		if trzba.KontrolniKody.Bkp.BkpType != bkp {
+			return fmt.Errorf("different bkp: %w", ErrInvalidBKP)
 		}
 	}
 
-	// digest value
-	{
-		signedInfo, err := findElement(sigElem, "./SignedInfo")
-		if err != nil {
-			return nil, nil, err
+	signature, err := wsse.NewVerifier().Verify(envelope.Root())
+	if errors.Is(err, wsse.ErrNoSignature) {
+		if odpoved.Chyba.Kod == TemporaryErrorCode {
+			return nil
 		}
 
-		// namespace for the XML digital signature
-		signedInfo.CreateAttr("xmlns", "http://www.w3.org/2000/09/xmldsig#")
-
-		digest, err = wsse.CalcDigest(signedInfo)
-		if err != nil {
-			return nil, nil, fmt.Errorf("calculate digest value of signed info: %w", err)
-		}
+		return fmt.Errorf("response with error code %d: %w", odpoved.Chyba.Kod, ErrUnsignedResponse)
+	} else if err != nil {
+		return fmt.Errorf("check digital signature: %w", signatureErr(err))
 	}
 
-	return sigVal, digest, nil
-}
-
-func getCertFromToken(envelope *etree.Document) (*x509.Certificate, error) {
-	tokenElem, err := findElement(envelope.Root(), "./Header/Security/BinarySecurityToken")
+	body, err := findElement(envelope.Root(), "./Body")
 	if err != nil {
-		return nil, err
-	}
-
-	token := tokenElem.Text()
-	rawCert, _ := base64.StdEncoding.DecodeString(token)
-	cert, err := x509.ParseCertificate(rawCert)
-	if err != nil {
-		return nil, fmt.Errorf("parse x509 certificate: %w", err)
+		return err
 	}
 
-	return cert, nil
-}
-
-func validateDigestValue(envelope *etree.Document) error {
-	digest, err := bodyDigest(envelope)
-	if err != nil {
-		return fmt.Errorf("get digest value of the body element: %w", err)
+	if !signature.Signs(body) {
+		return fmt.Errorf("body element not signed: %w", ErrInvalidSignature)
 	}
 
-	digestValElem, err := findElement(envelope.Root(), "./Header/Security/Signature/SignedInfo/Reference/DigestValue")
-	if err != nil {
-		return err
+	if err = verifyCert(signature.Certificate, signingTime(odpoved)); err != nil {
+		return fmt.Errorf("check certificate: verify security token: %w", err)
 	}
 
-	expDigestVal := base64.StdEncoding.EncodeToString(digest)
-	actDigestVal := digestValElem.Text()
+	return nil
+}
 
-	if expDigestVal != actDigestVal {
-		return ErrInvalidXMLDigest
+// signatureErr marks the signature verification error as ErrInvalidSignature.
+func signatureErr(err error) error {
+	if errors.Is(err, wsse.ErrDigestMismatch) {
+		err = multierr.Append(err, ErrInvalidXMLDigest)
 	}
 
-	return nil
+	return multierr.Append(err, ErrInvalidSignature)
 }
 
-func bodyDigest(envelope *etree.Document) ([]byte, error) {
-	bodyElem, err := findElement(envelope.Root(), "./Body")
-	if err != nil {
-		return nil, err
+// signingTime returns the time the response was received or rejected by the FSCR.
+func signingTime(odpoved *OdpovedType) time.Time {
+	if t := time.Time(odpoved.Hlavicka.Datprij); !t.IsZero() {
+		return t
 	}
 
-	// set namespaces
-	bodyElem.CreateAttr("xmlns:eet", "http://fs.mfcr.cz/eet/schema/v3")
-	bodyElem.CreateAttr("xmlns:soapenv", "http://schemas.xmlsoap.org/soap/envelope/")
-	bodyElem.CreateAttr("xmlns:wsu", "http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-utility-1.0.xsd")
-
-	digest, _ := wsse.CalcDigest(bodyElem)
+	if t := time.Time(odpoved.Hlavicka.Datodmit); !t.IsZero() {
+		return t
+	}
 
-	return digest, nil
+	return time.Now()
 }
 
 func findElement(root *etree.Element, path string) (*etree.Element, error) {
@@ -1,11 +1,20 @@
 package eet_test
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"crypto/rsa"
+	"crypto/sha256"
 	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/base64"
 	"encoding/xml"
+	"io"
 	"io/ioutil"
+	"math/big"
+	"strings"
 	"testing"
 	"time"
 
@@ -13,6 +22,7 @@ import (
 	"github.com/chutommy/eetgateway/pkg/ca"
 	"github.com/chutommy/eetgateway/pkg/eet"
 	"github.com/chutommy/eetgateway/pkg/fscr"
+	"github.com/chutommy/eetgateway/pkg/wsse"
 	"github.com/stretchr/testify/require"
 )
 
@@ -109,11 +119,103 @@ func TestNewSoapEnvelope(t *testing.T) {
 	require.EqualValues(t, trzba, processedTrzba)
 }
 
+// externalSigner is an in-process fake of a signer keeping the private key outside
+// of the caller's reach (e.g. a PKCS #11 token). Only its public key is exposed.
+type externalSigner struct {
+	key   crypto.Signer
+	calls int
+}
+
+func (s *externalSigner) Public() crypto.PublicKey {
+	return s.key.Public()
+}
+
+func (s *externalSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	s.calls++
+	return s.key.Sign(rand, digest, opts)
+}
+
+func selfSignedCert(t require.TestingT) (*x509.Certificate, *rsa.PrivateKey) {
+	pk, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "CZ00000019"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, pk.Public(), pk)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return cert, pk
+}
+
+func newTrzba() *eet.TrzbaType {
+	return &eet.TrzbaType{
+		Hlavicka: eet.TrzbaHlavickaType{
+			Uuidzpravy: "878b2e10-c4a5-4f05-8c90-abc181cd6837",
+			Datodesl:   eet.DateTime(parseTime("2019-08-11T15:36:25+02:00")),
+		},
+		Data: eet.TrzbaDataType{
+			Dicpopl:   "CZ00000019",
+			Idprovoz:  141,
+			Idpokl:    "1patro-vpravo",
+			Poradcis:  "141-18543-05",
+			Dattrzby:  eet.DateTime(parseTime("2019-08-11T15:36:14+02:00")),
+			Celktrzba: 236.00,
+		},
+	}
+}
+
+func TestNewRequestEnvelope_ExternalSigner(t *testing.T) {
+	cert, pk := selfSignedCert(t)
+	trzba := newTrzba()
+
+	signer := &externalSigner{key: pk}
+	envelope, err := eet.NewRequestEnvelope(trzba, cert, signer)
+	require.NoError(t, err)
+
+	// both the PKP code and the WS-Security signature
+	require.Equal(t, 2, signer.calls)
+
+	digest := sha256.Sum256([]byte("CZ00000019|141|1patro-vpravo|141-18543-05|2019-08-11T15:36:14+02:00|236.00"))
+	err = rsa.VerifyPKCS1v15(&pk.PublicKey, crypto.SHA256, digest[:], trzba.KontrolniKody.Pkp.PkpType)
+	require.NoError(t, err)
+
+	doc := etree.NewDocument()
+	err = doc.ReadFromBytes(envelope)
+	require.NoError(t, err)
+
+	signature, err := wsse.NewVerifier().Verify(doc.Root())
+	require.NoError(t, err)
+	require.True(t, signature.Signs(doc.FindElement("./Envelope/Body")))
+}
+
+func TestTrzbaType_SetSecurityCodesUnsupportedKey(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	for name, key := range map[string]crypto.Signer{
+		"ECDSA key": ecKey,
+		"no key":    nil,
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := newTrzba().SetSecurityCodes(key)
+			require.ErrorIs(t, err, eet.ErrUnsupportedKey)
+		})
+	}
+}
+
 func TestParseAndVerifyResponse(t *testing.T) {
 	tests := []struct {
 		name     string
 		respFile string
 		bkp      string
+		uuid     eet.UUIDType
+		overeni  bool
 		expErr   error
 	}{
 		{
@@ -121,15 +223,39 @@ func TestParseAndVerifyResponse(t *testing.T) {
 			respFile: "testdata/response_1.xml",
 			bkp:      "36FA2953-0E365CE7-5829441B-8CAFFB11-A89C7372",
 		},
+		{
+			name:     "verification mode",
+			respFile: "testdata/response_1.xml",
+			bkp:      "36FA2953-0E365CE7-5829441B-8CAFFB11-A89C7372",
+			overeni:  true,
+		},
+		{
+			name:     "invalid signature in verification mode",
+			respFile: "testdata/response_5.xml",
+			bkp:      "36FA2953-0E365CE7-5829441B-8CAFFB11-A89C7372",
+			overeni:  true,
+			expErr:   eet.ErrInvalidSignature,
+		},
 		{
 			name:     "denied sale",
 			respFile: "testdata/response_2.xml",
 		},
+		{
+			name:     "denied sale with different uuid",
+			respFile: "testdata/response_2.xml",
+			uuid:     "14fa9cf8-3b38-4fa9-a5fe-5a1a1a6c4e17",
+			expErr:   eet.ErrInvalidUUID,
+		},
+		{
+			name:     "unsigned rejection",
+			respFile: "testdata/response_8.xml",
+			expErr:   eet.ErrUnsignedResponse,
+		},
 		{
 			name:     "invalid reference element",
 			respFile: "testdata/response_3.xml",
 			bkp:      "36FA2953-0E365CE7-5829441B-8CAFFB11-A89C7372",
-			expErr:   rsa.ErrVerification,
+			expErr:   wsse.ErrReferenceNotFound,
 		},
 		{
 			name:     "invalid digest",
@@ -143,6 +269,35 @@ func TestParseAndVerifyResponse(t *testing.T) {
 			bkp:      "36FA2953-0E365CE7-5829441B-8CAFFB11-A89C7372",
 			expErr:   rsa.ErrVerification,
 		},
+		{
+			name:     "invalid signature of the response",
+			respFile: "testdata/response_5.xml",
+			bkp:      "36FA2953-0E365CE7-5829441B-8CAFFB11-A89C7372",
+			expErr:   eet.ErrInvalidSignature,
+		},
+		{
+			name:     "invalid digest of the response",
+			respFile: "testdata/response_4.xml",
+			bkp:      "36FA2953-0E365CE7-5829441B-8CAFFB11-A89C7372",
+			expErr:   eet.ErrInvalidSignature,
+		},
+		{
+			name:     "reformatted response",
+			respFile: "testdata/response_10.xml",
+			bkp:      "36FA2953-0E365CE7-5829441B-8CAFFB11-A89C7372",
+		},
+		{
+			name:     "unsupported canonicalization",
+			respFile: "testdata/response_11.xml",
+			bkp:      "36FA2953-0E365CE7-5829441B-8CAFFB11-A89C7372",
+			expErr:   wsse.ErrUnsupportedAlgorithm,
+		},
+		{
+			name:     "unsupported canonicalization of the response",
+			respFile: "testdata/response_11.xml",
+			bkp:      "36FA2953-0E365CE7-5829441B-8CAFFB11-A89C7372",
+			expErr:   eet.ErrInvalidSignature,
+		},
 		{
 			name:     "invalid xml",
 			respFile: "testdata/response_6.xml",
@@ -156,10 +311,23 @@ func TestParseAndVerifyResponse(t *testing.T) {
 		},
 	}
 
-	pool := x509.NewCertPool()
-	ok := pool.AppendCertsFromPEM(ca.ICACertificate)
-	require.True(t, ok)
-	eetCASvc := fscr.NewCAService(nil, pool)
+	dsigRoots, err := ca.DSigRoots()
+	require.NoError(t, err)
+
+	// the I.CA root isn't built in, so the signing certificates are verified up to the I.CA intermediate
+	icaPool := x509.NewCertPool()
+	for _, c := range dsigRoots {
+		icaPool.AddCert(c)
+	}
+
+	verifyDSig := func(cert *x509.Certificate, signedAt time.Time) error {
+		_, err := cert.Verify(x509.VerifyOptions{
+			Roots:       icaPool,
+			CurrentTime: signedAt,
+			KeyUsages:   []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		})
+		return err
+	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
@@ -169,10 +337,15 @@ func TestParseAndVerifyResponse(t *testing.T) {
 			odp, err := eet.ParseResponseEnvelope(resp)
 			if err == nil {
 				// fill TrzbaType with required control codes
+				uuid := odp.Hlavicka.Uuidzpravy
+				if tc.uuid != "" {
+					uuid = tc.uuid
+				}
+
 				trzba := &eet.TrzbaType{
 					Hlavicka: eet.TrzbaHlavickaType{
-						Uuidzpravy: odp.Hlavicka.Uuidzpravy,
-						Overeni:    false,
+						Uuidzpravy: uuid,
+						Overeni:    tc.overeni,
 					},
 					KontrolniKody: eet.TrzbaKontrolniKodyType{
 						Bkp: eet.BkpElementType{
@@ -181,7 +354,7 @@ func TestParseAndVerifyResponse(t *testing.T) {
 					},
 				}
 
-				err = eet.VerifyResponse(trzba, resp, odp, eetCASvc.VerifyDSig)
+				err = eet.VerifyResponse(trzba, resp, odp, verifyDSig)
 			}
 
 			if tc.expErr == nil {
@@ -193,3 +366,152 @@ func TestParseAndVerifyResponse(t *testing.T) {
 		})
 	}
 }
+
+func TestParseResponseEnvelope_SOAPFault(t *testing.T) {
+	resp, err := ioutil.ReadFile("testdata/response_9.xml")
+	require.NoError(t, err)
+
+	_, err = eet.ParseResponseEnvelope(resp)
+	var fault *eet.SOAPFault
+	require.ErrorAs(t, err, &fault)
+	require.Equal(t, "soapenv:Client", fault.Code)
+	require.Equal(t, "Nespravny format zpravy", fault.String)
+	require.Equal(t, "<chyba>cvc-complex-type.4: Attribute &apos;dic_popl&apos; must appear on element &apos;eet:Data&apos;.</chyba>", fault.Detail)
+}
+
+func TestParseRequest(t *testing.T) {
+	cert, pk := selfSignedCert(t)
+	trzba := newTrzba()
+	envelope, err := eet.NewRequestEnvelope(trzba, cert, pk)
+	require.NoError(t, err)
+
+	doc := etree.NewDocument()
+	require.NoError(t, doc.ReadFromBytes(envelope))
+	doc.SetRoot(doc.FindElement("//Trzba").Copy())
+	bare, err := doc.WriteToBytes()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		req    []byte
+		expErr error
+	}{
+		{
+			name: "envelope",
+			req:  envelope,
+		},
+		{
+			name: "bare trzba",
+			req:  bare,
+		},
+		{
+			name:   "unexpected root",
+			req:    []byte(`<Odpoved xmlns="http://fs.mfcr.cz/eet/schema/v3"/>`),
+			expErr: eet.ErrInvalidSOAPMessage,
+		},
+		{
+			name:   "envelope without trzba",
+			req:    []byte(`<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body/></soap:Envelope>`),
+			expErr: eet.ErrInvalidSOAPMessage,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			parsed, err := eet.ParseRequest(tc.req)
+			if tc.expErr == nil {
+				require.NoError(t, err)
+				require.EqualValues(t, trzba, parsed)
+			} else {
+				require.ErrorIs(t, err, tc.expErr)
+			}
+		})
+	}
+
+	_, err = eet.ParseRequest([]byte("not xml"))
+	require.Error(t, err)
+}
+
+func TestTrzbaType_VerifySecurityCodes(t *testing.T) {
+	cert, pk := selfSignedCert(t)
+	otherCert, _ := selfSignedCert(t)
+
+	signed := newTrzba()
+	require.NoError(t, signed.SetSecurityCodes(pk))
+
+	tests := []struct {
+		name   string
+		trzba  func() *eet.TrzbaType
+		cert   *x509.Certificate
+		expErr error
+	}{
+		{
+			name:  "valid codes",
+			trzba: func() *eet.TrzbaType { trzba := *signed; return &trzba },
+			cert:  cert,
+		},
+		{
+			name:  "without codes",
+			trzba: newTrzba,
+			cert:  cert,
+		},
+		{
+			name: "upper case bkp",
+			trzba: func() *eet.TrzbaType {
+				trzba := *signed
+				trzba.KontrolniKody.Bkp.BkpType = eet.BkpType(strings.ToUpper(string(trzba.KontrolniKody.Bkp.BkpType)))
+				return &trzba
+			},
+			cert: cert,
+		},
+		{
+			name: "different sale data",
+			trzba: func() *eet.TrzbaType {
+				trzba := *signed
+				trzba.Data.Celktrzba = 100.00
+				return &trzba
+			},
+			cert:   cert,
+			expErr: eet.ErrInvalidSecurityCodes,
+		},
+		{
+			name:   "different certificate",
+			trzba:  func() *eet.TrzbaType { trzba := *signed; return &trzba },
+			cert:   otherCert,
+			expErr: eet.ErrInvalidSecurityCodes,
+		},
+		{
+			name: "different bkp",
+			trzba: func() *eet.TrzbaType {
+				trzba := *signed
+				trzba.KontrolniKody.Bkp.BkpType = "aba7eb19-7ad8d753-60ed57b3-9ac9957e-c192030b"
+				return &trzba
+			},
+			cert:   cert,
+			expErr: eet.ErrInvalidSecurityCodes,
+		},
+		{
+			name: "bkp without pkp",
+			trzba: func() *eet.TrzbaType {
+				trzba := *signed
+				trzba.KontrolniKody.Pkp = eet.PkpElementType{}
+				return &trzba
+			},
+			cert:   cert,
+			expErr: eet.ErrInvalidSecurityCodes,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.trzba().VerifySecurityCodes(tc.cert.PublicKey)
+			if tc.expErr == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tc.expErr)
+			}
+		})
+	}
+}
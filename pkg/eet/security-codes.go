@@ -8,11 +8,23 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+
+	"go.uber.org/multierr"
 )
 
-func pkp(plaintext string, pk *rsa.PrivateKey) ([]byte, error) {
+// pkp signs the plaintext by the RSA key. The key may be kept outside the memory
+// of the process (e.g. in a PKCS #11 token).
+func pkp(plaintext string, key crypto.Signer) ([]byte, error) {
+	if key == nil {
+		return nil, fmt.Errorf("no signing key: %w", ErrUnsupportedKey)
+	}
+
+	if _, ok := key.Public().(*rsa.PublicKey); !ok {
+		return nil, fmt.Errorf("unexpected public key type %T: %w", key.Public(), ErrUnsupportedKey)
+	}
+
 	digest := sha256.Sum256([]byte(plaintext))
-	pkp, err := rsa.SignPKCS1v15(rand.Reader, pk, crypto.SHA256, digest[:])
+	pkp, err := key.Sign(rand.Reader, digest[:], crypto.SHA256)
 	if err != nil {
 		return nil, fmt.Errorf("signing PKP: %w", err)
 	}
@@ -20,6 +32,21 @@ func pkp(plaintext string, pk *rsa.PrivateKey) ([]byte, error) {
 	return pkp, err
 }
 
+// verifyPKP checks the PKP signature of the plaintext by the RSA public key.
+func verifyPKP(plaintext string, pkp []byte, pub crypto.PublicKey) error {
+	rsaPub, ok := pub.(*rsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("unexpected public key type %T: %w", pub, ErrUnsupportedKey)
+	}
+
+	digest := sha256.Sum256([]byte(plaintext))
+	if err := rsa.VerifyPKCS1v15(rsaPub, crypto.SHA256, digest[:], pkp); err != nil {
+		return multierr.Append(err, ErrInvalidSecurityCodes)
+	}
+
+	return nil
+}
+
 func bkp(pkp PkpType) BkpType {
 	digest := sha1.Sum(pkp)
 	bkpB16 := hex.EncodeToString(digest[:])
@@ -1,15 +1,21 @@
 package fscr
 
 import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
 	"crypto/rsa"
+	"crypto/sha256"
 	"crypto/x509"
 	"encoding/pem"
 	"errors"
 	"fmt"
+	"sync"
+	"time"
 
-	"github.com/cloudflare/cfssl/revoke"
+	"github.com/chutommy/eetgateway/pkg/ca"
 	"go.uber.org/multierr"
-	"golang.org/x/crypto/pkcs12"
+	"software.sslmate.com/src/go-pkcs12"
 )
 
 // ErrInvalidOrganizationName is returned if the organization name of a certificate is invalid.
@@ -28,97 +34,322 @@ var ErrInvalidCertificate = errors.New("invalid certificate or private key")
 // and can't be trusted.
 var ErrNotTrustedCertificate = errors.New("certificate issued or signed by an unknown authority")
 
+// ErrNoDSigRoot is returned if there is no self-signed DSig certificate to verify the digital signatures against.
+var ErrNoDSigRoot = errors.New("no trusted DSig root certificate")
+
+// ErrInvalidKeyUsage is returned if a certificate isn't issued for the purpose it is used for.
+var ErrInvalidKeyUsage = errors.New("certificate not issued for the key usage")
+
+// ErrCertificateNotValid is returned if a certificate or its issuer isn't valid at the time of use.
+var ErrCertificateNotValid = errors.New("certificate not valid at the time of use")
+
 // OrganizationName is the legal name that the organization is registered with authority at the national level.
 const OrganizationName = "Česká republika - Generální finanční ředitelství"
 
 // CAService verifies certificates signed off by trusted CAs.
 type CAService interface {
-	VerifyDSig(cert *x509.Certificate) error
+	VerifyDSig(cert *x509.Certificate, signedAt time.Time) error
 	ParseTaxpayerCertificate(data []byte, password string) (*x509.Certificate, *rsa.PrivateKey, error)
+	ParseTaxpayerCertificatePEM(certData, keyData []byte) (*x509.Certificate, *rsa.PrivateKey, error)
+	ParseTaxpayerCertificateSigner(certData []byte, key crypto.Signer) (*x509.Certificate, error)
+	Roots() (eetRoots, dsigRoots []*x509.Certificate)
+	SetRoots(eetRoots, dsigRoots []*x509.Certificate)
 }
 
 type caService struct {
-	eetCARoots []*x509.Certificate
-	dsigPool   *x509.CertPool
+	mu                sync.RWMutex
+	eetCARoots        []*x509.Certificate
+	dsigRoots         []*x509.Certificate
+	dsigAnchored      bool
+	dsigPool          *x509.CertPool
+	dsigIntermediates *x509.CertPool
 }
 
-// NewCAService returns a CAService implementation with the given certificate pools for
+// NewCAService returns a CAService implementation with the given certificates for
 // verifying both issued taxpayers' certificates and digital signatures.
-func NewCAService(eetRoots []*x509.Certificate, dsigPool *x509.CertPool) CAService {
-	return &caService{
-		eetCARoots: eetRoots,
-		dsigPool:   dsigPool,
+func NewCAService(eetRoots, dsigRoots []*x509.Certificate) CAService {
+	c := &caService{}
+	c.SetRoots(eetRoots, dsigRoots)
+
+	return c
+}
+
+// Roots returns the trusted EET CA roots and the certificates of the digital signature CA.
+func (c *caService) Roots() (eetRoots, dsigRoots []*x509.Certificate) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.eetCARoots, c.dsigRoots
+}
+
+// SetRoots replaces the trusted certificates. It is safe to call while other certificates are being verified.
+// The self-signed DSig certificates are the trust anchors and the others are intermediates chaining up to them.
+// If there is no self-signed DSig certificate, no digital signature is trusted.
+func (c *caService) SetRoots(eetRoots, dsigRoots []*x509.Certificate) {
+	var anchors, intermediates []*x509.Certificate
+	for _, cert := range dsigRoots {
+		if IsSelfSigned(cert) {
+			anchors = append(anchors, cert)
+		} else {
+			intermediates = append(intermediates, cert)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.eetCARoots = eetRoots
+	c.dsigRoots = dsigRoots
+	c.dsigAnchored = len(anchors) > 0
+	c.dsigPool = certPool(anchors)
+	c.dsigIntermediates = certPool(intermediates)
+}
+
+func certPool(certs []*x509.Certificate) *x509.CertPool {
+	pool := x509.NewCertPool()
+	for _, cert := range certs {
+		pool.AddCert(cert)
 	}
+
+	return pool
 }
 
-// VerifyDSig verifies certificate used for the digital signature.
-func (c *caService) VerifyDSig(cert *x509.Certificate) error {
-	if n := cert.Subject.Organization[0]; n != OrganizationName {
-		return fmt.Errorf("unexpected organization name (%s): %w", n, ErrInvalidOrganizationName)
+// VerifyDSig verifies the certificate used for the digital signature at the time of signing. The certificate
+// must be issued to the FSCR for digital signatures, be valid at the signing time and chain up to the trusted
+// DSig certificates. The revocation status is left to the revocation.Service.
+func (c *caService) VerifyDSig(cert *x509.Certificate, signedAt time.Time) error {
+	if o := cert.Subject.Organization; len(o) == 0 || o[0] != OrganizationName {
+		return fmt.Errorf("unexpected organization name %q: %w", o, ErrInvalidOrganizationName)
+	}
+
+	if cert.KeyUsage&x509.KeyUsageDigitalSignature == 0 {
+		return fmt.Errorf("digital signature key usage required: %w", ErrInvalidKeyUsage)
+	}
+
+	if signedAt.Before(cert.NotBefore) || signedAt.After(cert.NotAfter) {
+		return fmt.Errorf("signed at %s, valid from %s to %s: %w", signedAt.Format(time.RFC3339),
+			cert.NotBefore.Format(time.RFC3339), cert.NotAfter.Format(time.RFC3339), ErrCertificateNotValid)
+	}
+
+	c.mu.RLock()
+	anchored, roots, intermediates := c.dsigAnchored, c.dsigPool, c.dsigIntermediates
+	c.mu.RUnlock()
+
+	if !anchored {
+		return multierr.Append(fmt.Errorf("verify digital signature certificate: %w", ErrNoDSigRoot), ErrNotTrustedCertificate)
 	}
 
 	opts := x509.VerifyOptions{
-		Roots: c.dsigPool,
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   signedAt,
+		// the I.CA issues the signing certificates for e-mail protection only,
+		// the key usage is checked above instead
 		KeyUsages: []x509.ExtKeyUsage{
 			x509.ExtKeyUsageAny,
 		},
 	}
 
 	if _, err := cert.Verify(opts); err != nil {
+		var invalidErr x509.CertificateInvalidError
+		if errors.As(err, &invalidErr) && invalidErr.Reason == x509.Expired {
+			return multierr.Append(fmt.Errorf("verify digital signature certificate chain: %w", err), ErrCertificateNotValid)
+		}
+
 		return multierr.Append(fmt.Errorf("verify digital signature certificate: %w", err), ErrNotTrustedCertificate)
 	}
 
 	return nil
 }
 
-// ParseTaxpayerCertificate takes a raw data of a PFX file and decodes it into PEM blocks.
-// Blocks are expected to be in this order: taxpayer's certificate, certificate authority's certificate
-// and private key. CA's certificate is used to verify taxpayer's certificate. The taxpayer's certificate
-// and the private key must be a valid KeyPair.
+// ParseTaxpayerCertificate takes a raw data of a PFX file and decodes the taxpayer's certificate, the private key
+// and the certificate authority's certificate. Both legacy and PBES2 (AES) encrypted files are supported.
+// The bags may be in any order: the taxpayer's certificate is the one matching the private key and the CA's
+// certificate is the one which issued it, either bundled in the file or one of the EET CA roots.
+// The CA's certificate is used to verify the taxpayer's certificate.
 func (c *caService) ParseTaxpayerCertificate(data []byte, password string) (*x509.Certificate, *rsa.PrivateKey, error) {
-	blocks, err := pkcs12.ToPEM(data, password)
+	cert, chain, pk, err := parsePFX(data, password)
 	if err != nil {
-		return nil, nil, multierr.Append(fmt.Errorf("convert PFX data to PEM blocks: %w", err), ErrInvalidCertificate)
+		return nil, nil, multierr.Append(fmt.Errorf("parse PFX data: %w", err), ErrInvalidCertificate)
 	}
 
-	cert, caCert, pk, err := parsePEMBlocks(blocks)
-	if err != nil {
-		return nil, nil, multierr.Append(fmt.Errorf("parse PEM blocks: %w", err), ErrInvalidCertificate)
+	if err = c.verifyTaxpayerCertificate(cert, chain, &pk.PublicKey); err != nil {
+		return nil, nil, err
 	}
 
-	if err = verifyEETCA(c.eetCARoots, caCert); err != nil {
-		return nil, nil, multierr.Append(fmt.Errorf("verify taxpayer's certificate CA: %w", err), ErrInvalidCertificate)
+	return cert, pk, nil
+}
+
+// ParseTaxpayerCertificatePEM takes a raw data of PEM encoded certificates and a PEM encoded PKCS#1 or PKCS#8
+// private key and decodes the taxpayer's certificate and the private key. The certificates may include
+// the CA's certificate, which is found and used the same way as in ParseTaxpayerCertificate.
+func (c *caService) ParseTaxpayerCertificatePEM(certData, keyData []byte) (*x509.Certificate, *rsa.PrivateKey, error) {
+	pk, err := parsePEMPrivateKey(keyData)
+	if err != nil {
+		return nil, nil, multierr.Append(fmt.Errorf("parse PEM private key: %w", err), ErrInvalidCertificate)
 	}
 
-	if err := checkCRL(cert); err != nil {
-		return nil, nil, multierr.Append(fmt.Errorf("taxpayer's certificate CRL: %w", err), ErrInvalidCertificate)
+	certs, err := ca.ParsePEMCertificates(certData)
+	if err != nil {
+		return nil, nil, multierr.Append(fmt.Errorf("parse PEM certificates: %w", err), ErrInvalidCertificate)
 	}
 
-	err = verifyKeys(caCert, cert, pk)
+	cert, chain, err := splitChain(&pk.PublicKey, certs)
 	if err != nil {
-		return nil, nil, multierr.Append(fmt.Errorf("verify keys of the certificate: %w", err), ErrInvalidCertificate)
+		return nil, nil, multierr.Append(fmt.Errorf("parse PEM certificates: %w", err), ErrInvalidCertificate)
+	}
+
+	if err = c.verifyTaxpayerCertificate(cert, chain, &pk.PublicKey); err != nil {
+		return nil, nil, err
 	}
 
 	return cert, pk, nil
 }
 
-func parsePEMBlocks(blocks []*pem.Block) (cert *x509.Certificate, caCert *x509.Certificate, pk *rsa.PrivateKey, err error) {
-	cert, err = x509.ParseCertificate(blocks[0].Bytes)
+// ParseTaxpayerCertificateSigner takes a raw data of PEM encoded certificates and the Signer of the private key
+// kept outside the gateway and decodes the taxpayer's certificate of the Signer. The certificates may include
+// the CA's certificate, which is found and used the same way as in ParseTaxpayerCertificate. The Signer must
+// sign on behalf of the certificate.
+func (c *caService) ParseTaxpayerCertificateSigner(certData []byte, key crypto.Signer) (*x509.Certificate, error) {
+	pub, ok := key.Public().(*rsa.PublicKey)
+	if !ok {
+		return nil, multierr.Append(fmt.Errorf("unsupported type of the public key: %T", key.Public()), ErrInvalidCertificate)
+	}
+
+	certs, err := ca.ParsePEMCertificates(certData)
 	if err != nil {
-		return nil, nil, nil, fmt.Errorf("parse taxpayer's certificate: %w", err)
+		return nil, multierr.Append(fmt.Errorf("parse PEM certificates: %w", err), ErrInvalidCertificate)
 	}
 
-	caCert, err = x509.ParseCertificate(blocks[1].Bytes)
+	cert, chain, err := splitChain(pub, certs)
 	if err != nil {
-		return nil, nil, nil, fmt.Errorf("parse certificate authority's certificate: %w", err)
+		return nil, multierr.Append(fmt.Errorf("parse PEM certificates: %w", err), ErrInvalidCertificate)
 	}
 
-	pk, err = x509.ParsePKCS1PrivateKey(blocks[2].Bytes)
+	if err = c.verifyTaxpayerCertificate(cert, chain, pub); err != nil {
+		return nil, err
+	}
+
+	if err = verifySigner(key); err != nil {
+		return nil, multierr.Append(fmt.Errorf("verify signer of the certificate: %w", err), ErrInvalidCertificate)
+	}
+
+	return cert, nil
+}
+
+// verifySigner checks that the signer signs by the private key of its public key.
+func verifySigner(key crypto.Signer) error {
+	digest := sha256.Sum256([]byte("eetgateway signer verification"))
+	signature, err := key.Sign(rand.Reader, digest[:], crypto.SHA256)
 	if err != nil {
-		return nil, nil, nil, fmt.Errorf("parse taxpayer's private key: %w", err)
+		return fmt.Errorf("sign: %w", err)
 	}
 
-	return cert, caCert, pk, nil
+	pub, _ := key.Public().(*rsa.PublicKey)
+	if err = rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], signature); err != nil {
+		return multierr.Append(fmt.Errorf("verify signature: %w", err), ErrInvalidKeyPair)
+	}
+
+	return nil
+}
+
+// verifyTaxpayerCertificate verifies the taxpayer's certificate and its public key against the CA's certificate
+// found in the chain or in the EET CA roots. The revocation status is left to the revocation.Service.
+func (c *caService) verifyTaxpayerCertificate(cert *x509.Certificate, chain []*x509.Certificate, pub *rsa.PublicKey) error {
+	roots, _ := c.Roots()
+	caCert, err := findIssuer(cert, append(chain, roots...))
+	if err != nil {
+		return multierr.Append(fmt.Errorf("find taxpayer's certificate CA: %w", err), ErrInvalidCertificate)
+	}
+
+	if err = verifyEETCA(roots, caCert); err != nil {
+		return multierr.Append(fmt.Errorf("verify taxpayer's certificate CA: %w", err), ErrInvalidCertificate)
+	}
+
+	err = verifyKeys(caCert, cert, pub)
+	if err != nil {
+		return multierr.Append(fmt.Errorf("verify keys of the certificate: %w", err), ErrInvalidCertificate)
+	}
+
+	return nil
+}
+
+// parsePFX decodes PFX data into the certificate of the private key, the other bundled certificates
+// and the private key itself.
+func parsePFX(data []byte, password string) (cert *x509.Certificate, chain []*x509.Certificate, pk *rsa.PrivateKey, err error) {
+	key, first, rest, err := pkcs12.DecodeChain(data, password)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("decode PFX data: %w", err)
+	}
+
+	pk, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("unsupported type of the private key: %T", key)
+	}
+
+	cert, chain, err = splitChain(&pk.PublicKey, append([]*x509.Certificate{first}, rest...))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return cert, chain, pk, nil
+}
+
+// parsePEMPrivateKey decodes the first PEM block of the data as a PKCS#1 or PKCS#8 RSA private key.
+func parsePEMPrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, errors.New("no private key PEM block found")
+		}
+
+		switch block.Type {
+		case "RSA PRIVATE KEY":
+			return x509.ParsePKCS1PrivateKey(block.Bytes)
+		case "PRIVATE KEY":
+			key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+
+			pk, ok := key.(*rsa.PrivateKey)
+			if !ok {
+				return nil, fmt.Errorf("unsupported type of the private key: %T", key)
+			}
+
+			return pk, nil
+		}
+	}
+}
+
+// splitChain separates the certificate of the public key from the other certificates.
+func splitChain(pub *rsa.PublicKey, certs []*x509.Certificate) (cert *x509.Certificate, chain []*x509.Certificate, err error) {
+	for i, c := range certs {
+		if pub.Equal(c.PublicKey) {
+			chain = append(chain, certs[:i]...)
+			chain = append(chain, certs[i+1:]...)
+			return c, chain, nil
+		}
+	}
+
+	return nil, nil, fmt.Errorf("no certificate of the private key: %w", ErrInvalidKeyPair)
+}
+
+// IsSelfSigned reports whether the certificate is signed off by its own key.
+func IsSelfSigned(cert *x509.Certificate) bool {
+	return bytes.Equal(cert.RawSubject, cert.RawIssuer) && cert.CheckSignatureFrom(cert) == nil
+}
+
+// findIssuer returns the certificate from the candidates that signed off the cert.
+func findIssuer(cert *x509.Certificate, candidates []*x509.Certificate) (*x509.Certificate, error) {
+	for _, c := range candidates {
+		if c.IsCA && cert.CheckSignatureFrom(c) == nil {
+			return c, nil
+		}
+	}
+
+	return nil, fmt.Errorf("issuer %q not found: %w", cert.Issuer.String(), ErrNotTrustedCertificate)
 }
 
 // verifyEETCA verifies a root certificate used for issuing taxpayers' certificates.
@@ -139,20 +370,7 @@ func verifyEETCA(roots []*x509.Certificate, cert *x509.Certificate) error {
 	return nil
 }
 
-func checkCRL(cert *x509.Certificate) error {
-	revoked, ok, err := revoke.VerifyCertificateError(cert)
-	if err != nil {
-		return fmt.Errorf("verify certificate: %w", err)
-	}
-
-	if revoked || !ok {
-		return fmt.Errorf("invalid certificate: ok=%t, revoked=%t", ok, revoked)
-	}
-
-	return nil
-}
-
-func verifyKeys(caCert *x509.Certificate, cert *x509.Certificate, pk *rsa.PrivateKey) error {
+func verifyKeys(caCert *x509.Certificate, cert *x509.Certificate, pub *rsa.PublicKey) error {
 	if isCa := caCert.IsCA; !isCa {
 		return fmt.Errorf("expected CA's certificate: %w", ErrNotCACertificate)
 	}
@@ -161,7 +379,7 @@ func verifyKeys(caCert *x509.Certificate, cert *x509.Certificate, pk *rsa.Privat
 		return fmt.Errorf("taxpayer's certificate not signed off by the CA's certificate: %w", err)
 	}
 
-	if !pk.PublicKey.Equal(cert.PublicKey) {
+	if !pub.Equal(cert.PublicKey) {
 		return fmt.Errorf("the KeyPair of the taxpayer's private key and the certificate is not valid: %w", ErrInvalidKeyPair)
 	}
 
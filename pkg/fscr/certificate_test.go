@@ -1,16 +1,27 @@
 package fscr_test
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
 	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/base64"
+	"encoding/pem"
+	"io"
 	"io/ioutil"
+	"math/big"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/beevik/etree"
 	"github.com/chutommy/eetgateway/pkg/ca"
 	"github.com/chutommy/eetgateway/pkg/fscr"
 	"github.com/stretchr/testify/require"
+	"software.sslmate.com/src/go-pkcs12"
 )
 
 func TestCaService_VerifyDSig(t *testing.T) {
@@ -21,10 +32,10 @@ func TestCaService_VerifyDSig(t *testing.T) {
 		expErr   error
 	}{
 		{
-			name:     "ok",
+			name:     "no root certificate",
 			p12File:  "testdata/response_1.xml",
 			password: "eet",
-			expErr:   nil,
+			expErr:   fscr.ErrNoDSigRoot,
 		},
 		{
 			name:     "unknown certificate authority",
@@ -34,10 +45,9 @@ func TestCaService_VerifyDSig(t *testing.T) {
 		},
 	}
 
-	pool := x509.NewCertPool()
-	ok := pool.AppendCertsFromPEM(ca.ICACertificate)
-	require.True(t, ok)
-	eetCASvc := fscr.NewCAService(nil, pool)
+	dsigRoots, err := ca.DSigRoots()
+	require.NoError(t, err)
+	eetCASvc := fscr.NewCAService(nil, dsigRoots)
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
@@ -60,7 +70,125 @@ func TestCaService_VerifyDSig(t *testing.T) {
 			cert, err := x509.ParseCertificate(certRaw)
 			require.NoError(t, err)
 
-			err = eetCASvc.VerifyDSig(cert)
+			// retrieve signing time
+			header := doc.FindElement("./Envelope/Body/Odpoved/Hlavicka")
+			signedAt, err := time.Parse(time.RFC3339, header.SelectAttrValue("dat_prij", ""))
+			require.NoError(t, err)
+
+			err = eetCASvc.VerifyDSig(cert, signedAt)
+			if tc.expErr == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tc.expErr)
+			}
+		})
+	}
+}
+
+func TestCaService_VerifyDSigChain(t *testing.T) {
+	rootCert, rootPK := generateCert(t, "I.CA Root CA", nil, nil, true)
+	icaCert, icaPK := generateCert(t, "I.CA Qualified CA", rootCert, rootPK, true)
+	otherCert, otherPK := generateCert(t, "other CA", nil, nil, true)
+	signedAt := time.Now()
+
+	issue := func(modify func(tmpl *x509.Certificate), parent *x509.Certificate, parentPK *rsa.PrivateKey) *x509.Certificate {
+		pk, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+
+		tmpl := &x509.Certificate{
+			SerialNumber: big.NewInt(time.Now().UnixNano()),
+			Subject:      pkix.Name{CommonName: "FSCR", Organization: []string{fscr.OrganizationName}},
+			NotBefore:    signedAt.Add(-time.Hour),
+			NotAfter:     signedAt.Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageContentCommitment,
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageEmailProtection},
+		}
+		if modify != nil {
+			modify(tmpl)
+		}
+
+		raw, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &pk.PublicKey, parentPK)
+		require.NoError(t, err)
+
+		cert, err := x509.ParseCertificate(raw)
+		require.NoError(t, err)
+
+		return cert
+	}
+
+	tests := []struct {
+		name      string
+		cert      *x509.Certificate
+		dsigRoots []*x509.Certificate
+		expErr    error
+	}{
+		{
+			name:      "full chain",
+			cert:      issue(nil, icaCert, icaPK),
+			dsigRoots: []*x509.Certificate{icaCert, rootCert},
+			expErr:    nil,
+		},
+		{
+			name:      "intermediate without root",
+			cert:      issue(nil, icaCert, icaPK),
+			dsigRoots: []*x509.Certificate{icaCert},
+			expErr:    fscr.ErrNoDSigRoot,
+		},
+		{
+			name:      "no certificates",
+			cert:      issue(nil, icaCert, icaPK),
+			dsigRoots: nil,
+			expErr:    fscr.ErrNotTrustedCertificate,
+		},
+		{
+			name:      "missing intermediate",
+			cert:      issue(nil, icaCert, icaPK),
+			dsigRoots: []*x509.Certificate{rootCert},
+			expErr:    fscr.ErrNotTrustedCertificate,
+		},
+		{
+			name:      "unknown certificate authority",
+			cert:      issue(nil, otherCert, otherPK),
+			dsigRoots: []*x509.Certificate{icaCert, rootCert},
+			expErr:    fscr.ErrNotTrustedCertificate,
+		},
+		{
+			name: "missing organization",
+			cert: issue(func(tmpl *x509.Certificate) {
+				tmpl.Subject.Organization = nil
+			}, icaCert, icaPK),
+			dsigRoots: []*x509.Certificate{icaCert, rootCert},
+			expErr:    fscr.ErrInvalidOrganizationName,
+		},
+		{
+			name: "invalid key usage",
+			cert: issue(func(tmpl *x509.Certificate) {
+				tmpl.KeyUsage = x509.KeyUsageKeyEncipherment
+			}, icaCert, icaPK),
+			dsigRoots: []*x509.Certificate{icaCert, rootCert},
+			expErr:    fscr.ErrInvalidKeyUsage,
+		},
+		{
+			name: "expired at the signing time",
+			cert: issue(func(tmpl *x509.Certificate) {
+				tmpl.NotAfter = signedAt.Add(-time.Minute)
+			}, icaCert, icaPK),
+			dsigRoots: []*x509.Certificate{icaCert, rootCert},
+			expErr:    fscr.ErrCertificateNotValid,
+		},
+		{
+			name: "not yet valid at the signing time",
+			cert: issue(func(tmpl *x509.Certificate) {
+				tmpl.NotBefore = signedAt.Add(time.Minute)
+			}, icaCert, icaPK),
+			dsigRoots: []*x509.Certificate{icaCert, rootCert},
+			expErr:    fscr.ErrCertificateNotValid,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := fscr.NewCAService(nil, tc.dsigRoots).VerifyDSig(tc.cert, signedAt)
 			if tc.expErr == nil {
 				require.NoError(t, err)
 			} else {
@@ -123,3 +251,312 @@ func TestParseTaxpayerCertificate(t *testing.T) {
 		})
 	}
 }
+
+func generateCert(t *testing.T, cn string, parent *x509.Certificate, parentPK *rsa.PrivateKey, isCA bool) (*x509.Certificate, *rsa.PrivateKey) {
+	pk, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+	if isCA {
+		tmpl.KeyUsage |= x509.KeyUsageCertSign
+	}
+
+	if parent == nil {
+		parent, parentPK = tmpl, pk
+	}
+
+	raw, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &pk.PublicKey, parentPK)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(raw)
+	require.NoError(t, err)
+
+	return cert, pk
+}
+
+func TestParseTaxpayerCertificate_Encoding(t *testing.T) {
+	caCert, caPK := generateCert(t, "EET CA", nil, nil, true)
+	otherCACert, otherCAPK := generateCert(t, "other CA", nil, nil, true)
+	cert, pk := generateCert(t, "CZ00000019", caCert, caPK, false)
+	foreignCert, _ := generateCert(t, "CZ00000019", otherCACert, otherCAPK, false)
+
+	tests := []struct {
+		name   string
+		encode func() ([]byte, error)
+		roots  []*x509.Certificate
+		errs   []error
+	}{
+		{
+			name: "modern encryption",
+			encode: func() ([]byte, error) {
+				return pkcs12.Modern.Encode(pk, cert, []*x509.Certificate{caCert}, "eet")
+			},
+			roots: []*x509.Certificate{caCert},
+		},
+		{
+			name: "legacy encryption",
+			encode: func() ([]byte, error) {
+				return pkcs12.LegacyRC2.Encode(pk, cert, []*x509.Certificate{caCert}, "eet")
+			},
+			roots: []*x509.Certificate{caCert},
+		},
+		{
+			name: "CA's certificate first",
+			encode: func() ([]byte, error) {
+				return pkcs12.Modern.Encode(pk, caCert, []*x509.Certificate{cert}, "eet")
+			},
+			roots: []*x509.Certificate{caCert},
+		},
+		{
+			name: "additional certificates",
+			encode: func() ([]byte, error) {
+				return pkcs12.Modern.Encode(pk, otherCACert, []*x509.Certificate{foreignCert, caCert, cert}, "eet")
+			},
+			roots: []*x509.Certificate{caCert, otherCACert},
+		},
+		{
+			name: "CA's certificate not bundled",
+			encode: func() ([]byte, error) {
+				return pkcs12.Modern.Encode(pk, cert, nil, "eet")
+			},
+			roots: []*x509.Certificate{otherCACert, caCert},
+		},
+		{
+			name: "untrusted CA",
+			encode: func() ([]byte, error) {
+				return pkcs12.Modern.Encode(pk, cert, []*x509.Certificate{caCert}, "eet")
+			},
+			roots: []*x509.Certificate{otherCACert},
+			errs:  []error{fscr.ErrInvalidCertificate, fscr.ErrNotTrustedCertificate},
+		},
+		{
+			name: "no certificate of the private key",
+			encode: func() ([]byte, error) {
+				return pkcs12.Modern.Encode(pk, foreignCert, []*x509.Certificate{caCert}, "eet")
+			},
+			roots: []*x509.Certificate{caCert},
+			errs:  []error{fscr.ErrInvalidCertificate, fscr.ErrInvalidKeyPair},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := tc.encode()
+			require.NoError(t, err)
+
+			caSvc := fscr.NewCAService(tc.roots, nil)
+			gotCert, gotPK, err := caSvc.ParseTaxpayerCertificate(data, "eet")
+			if tc.errs != nil {
+				for _, e := range tc.errs {
+					require.ErrorIs(t, err, e)
+				}
+				return
+			}
+
+			require.NoError(t, err)
+			require.True(t, cert.Equal(gotCert))
+			require.True(t, pk.Equal(gotPK))
+		})
+	}
+
+	t.Run("invalid password", func(t *testing.T) {
+		data, err := pkcs12.Modern.Encode(pk, cert, []*x509.Certificate{caCert}, "eet")
+		require.NoError(t, err)
+
+		_, _, err = fscr.NewCAService([]*x509.Certificate{caCert}, nil).ParseTaxpayerCertificate(data, "invalid")
+		require.ErrorIs(t, err, fscr.ErrInvalidCertificate)
+	})
+}
+
+func TestParseTaxpayerCertificatePEM(t *testing.T) {
+	caCert, caPK := generateCert(t, "EET CA", nil, nil, true)
+	cert, pk := generateCert(t, "CZ00000019", caCert, caPK, false)
+	_, otherPK := generateCert(t, "CZ00000019", caCert, caPK, false)
+
+	certPEM := func(certs ...*x509.Certificate) []byte {
+		var data []byte
+		for _, c := range certs {
+			data = append(data, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Raw})...)
+		}
+		return data
+	}
+
+	pkcs1PEM := func(pk *rsa.PrivateKey) []byte {
+		return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(pk)})
+	}
+
+	pkcs8PEM := func(pk *rsa.PrivateKey) []byte {
+		der, err := x509.MarshalPKCS8PrivateKey(pk)
+		require.NoError(t, err)
+		return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	}
+
+	tests := []struct {
+		name     string
+		certData []byte
+		keyData  []byte
+		errs     []error
+	}{
+		{
+			name:     "PKCS#1 private key",
+			certData: certPEM(cert),
+			keyData:  pkcs1PEM(pk),
+		},
+		{
+			name:     "PKCS#8 private key",
+			certData: certPEM(cert),
+			keyData:  pkcs8PEM(pk),
+		},
+		{
+			name:     "CA's certificate first",
+			certData: certPEM(caCert, cert),
+			keyData:  pkcs8PEM(pk),
+		},
+		{
+			name:     "private key of another certificate",
+			certData: certPEM(cert),
+			keyData:  pkcs1PEM(otherPK),
+			errs:     []error{fscr.ErrInvalidCertificate, fscr.ErrInvalidKeyPair},
+		},
+		{
+			name:     "no certificate",
+			certData: pkcs1PEM(pk),
+			keyData:  pkcs1PEM(pk),
+			errs:     []error{fscr.ErrInvalidCertificate},
+		},
+		{
+			name:     "no private key",
+			certData: certPEM(cert),
+			keyData:  certPEM(cert),
+			errs:     []error{fscr.ErrInvalidCertificate},
+		},
+	}
+
+	caSvc := fscr.NewCAService([]*x509.Certificate{caCert}, nil)
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			gotCert, gotPK, err := caSvc.ParseTaxpayerCertificatePEM(tc.certData, tc.keyData)
+			if tc.errs != nil {
+				for _, e := range tc.errs {
+					require.ErrorIs(t, err, e)
+				}
+				return
+			}
+
+			require.NoError(t, err)
+			require.True(t, cert.Equal(gotCert))
+			require.True(t, pk.Equal(gotPK))
+		})
+	}
+}
+
+// impostorSigner claims the public key of one private key but signs by another one.
+type impostorSigner struct {
+	pub crypto.PublicKey
+	key crypto.Signer
+}
+
+func (s impostorSigner) Public() crypto.PublicKey {
+	return s.pub
+}
+
+func (s impostorSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return s.key.Sign(rand, digest, opts)
+}
+
+func TestParseTaxpayerCertificateSigner(t *testing.T) {
+	caCert, caPK := generateCert(t, "EET CA", nil, nil, true)
+	cert, pk := generateCert(t, "CZ00000019", caCert, caPK, false)
+	_, otherPK := generateCert(t, "CZ00000019", caCert, caPK, false)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	certPEM := func(certs ...*x509.Certificate) []byte {
+		var data []byte
+		for _, c := range certs {
+			data = append(data, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Raw})...)
+		}
+		return data
+	}
+
+	tests := []struct {
+		name     string
+		certData []byte
+		key      crypto.Signer
+		errs     []error
+	}{
+		{
+			name:     "signer",
+			certData: certPEM(caCert, cert),
+			key:      pk,
+		},
+		{
+			name:     "signer of another certificate",
+			certData: certPEM(cert),
+			key:      otherPK,
+			errs:     []error{fscr.ErrInvalidCertificate, fscr.ErrInvalidKeyPair},
+		},
+		{
+			name:     "signer not signing by the key of the certificate",
+			certData: certPEM(cert),
+			key:      impostorSigner{pub: pk.Public(), key: otherPK},
+			errs:     []error{fscr.ErrInvalidCertificate, fscr.ErrInvalidKeyPair},
+		},
+		{
+			name:     "unsupported key",
+			certData: certPEM(cert),
+			key:      ecKey,
+			errs:     []error{fscr.ErrInvalidCertificate},
+		},
+	}
+
+	caSvc := fscr.NewCAService([]*x509.Certificate{caCert}, nil)
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			gotCert, err := caSvc.ParseTaxpayerCertificateSigner(tc.certData, tc.key)
+			if tc.errs != nil {
+				for _, e := range tc.errs {
+					require.ErrorIs(t, err, e)
+				}
+				return
+			}
+
+			require.NoError(t, err)
+			require.True(t, cert.Equal(gotCert))
+		})
+	}
+}
+
+func TestCAService_SetRoots(t *testing.T) {
+	caCert, _ := generateCert(t, "EET CA", nil, nil, true)
+	newCACert, newCAPK := generateCert(t, "new EET CA", nil, nil, true)
+	cert, pk := generateCert(t, "CZ00000019", newCACert, newCAPK, false)
+
+	certData := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	keyData := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(pk)})
+
+	caSvc := fscr.NewCAService([]*x509.Certificate{caCert}, nil)
+	_, _, err := caSvc.ParseTaxpayerCertificatePEM(certData, keyData)
+	require.ErrorIs(t, err, fscr.ErrNotTrustedCertificate)
+
+	caSvc.SetRoots([]*x509.Certificate{caCert, newCACert}, []*x509.Certificate{caCert})
+	_, _, err = caSvc.ParseTaxpayerCertificatePEM(certData, keyData)
+	require.NoError(t, err)
+
+	eetRoots, dsigRoots := caSvc.Roots()
+	require.Equal(t, []*x509.Certificate{caCert, newCACert}, eetRoots)
+	require.Equal(t, []*x509.Certificate{caCert}, dsigRoots)
+}
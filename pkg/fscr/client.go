@@ -3,13 +3,53 @@ package fscr
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"mime"
 	"net/http"
+	"regexp"
+	"sync"
+	"time"
 
+	"github.com/rs/zerolog/log"
 	"go.uber.org/multierr"
 )
 
+// ErrFSCRHTTPStatus is returned if the FSCR responds with an unexpected HTTP status code.
+// The error is an HTTPStatusError.
+var ErrFSCRHTTPStatus = errors.New("unexpected HTTP status of the FSCR response")
+
+// ErrFSCRContentType is returned if the FSCR response isn't an XML document.
+var ErrFSCRContentType = errors.New("unexpected content type of the FSCR response")
+
+// ErrFSCRResponseTooLarge is returned if the FSCR response body exceeds MaxResponseSize.
+var ErrFSCRResponseTooLarge = errors.New("FSCR response body too large")
+
+// MaxResponseSize is the maximum size of the FSCR response body.
+const MaxResponseSize = 1 << 20
+
+// maxErrorBodySize is the maximum size of the response body included in errors and logs.
+const maxErrorBodySize = 512
+
+// HTTPStatusError is returned if the FSCR responds with an unexpected HTTP status code.
+type HTTPStatusError struct {
+	StatusCode int
+	// Body is the beginning of the response body.
+	Body string
+}
+
+// Error implements the error interface.
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("%s: %d %s: %q", ErrFSCRHTTPStatus, e.StatusCode, http.StatusText(e.StatusCode), e.Body)
+}
+
+// Is reports whether the target is ErrFSCRHTTPStatus.
+func (e *HTTPStatusError) Is(target error) bool {
+	return target == ErrFSCRHTTPStatus
+}
+
 // ProductionURL is the URL of the production EET system.
 const ProductionURL = "https://prod.eet.cz/eet/services/EETServiceSOAP/v3"
 
@@ -22,11 +62,15 @@ const soapAction = "http://fs.mfcr.cz/eet/OdeslaniTrzby"
 type Client interface {
 	Ping() error
 	Do(ctx context.Context, reqBody []byte) ([]byte, error)
+	SetTimeout(timeout time.Duration)
 }
 
 type client struct {
 	c   *http.Client
 	url string
+
+	mu      sync.RWMutex
+	timeout time.Duration
 }
 
 // NewClient returns a Client implementation.
@@ -37,9 +81,38 @@ func NewClient(c *http.Client, url string) Client {
 	}
 }
 
+// SetTimeout limits the duration of the following requests including the reading of the response
+// body. The requests in progress aren't affected. Zero means no limit other than the one of the HTTP client.
+func (c *client) SetTimeout(timeout time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.timeout = timeout
+}
+
+// withTimeout returns the context of a request limited by the current timeout.
+func (c *client) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, c.timeout)
+}
+
 // Ping pings the host and returns the status code of the HTTP response.
-func (c *client) Ping() error {
-	resp, err := c.c.Head(c.url)
+func (c *client) Ping() (err error) {
+	ctx, cancel := c.withTimeout(context.Background())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, c.url, nil)
+	if err != nil {
+		return fmt.Errorf("construct http request: %w", err)
+	}
+
+	resp, err := c.c.Do(req)
 	if err != nil {
 		return fmt.Errorf("ping %s: %w", c.url, err)
 	}
@@ -53,25 +126,103 @@ func (c *client) Ping() error {
 }
 
 // Do makes a valid SOAP request to the FSCR servers with the request body reqBody and
-// redirects the response body to respBody.
+// redirects the response body to respBody. Only XML responses with the status OK or, in case of SOAP faults,
+// Internal Server Error are returned. The exchange is logged at the debug level with the security codes,
+// signatures and certificates redacted.
 func (c *client) Do(ctx context.Context, reqBody []byte) (respBody []byte, err error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
 	req, err := createRequest(ctx, c.url, reqBody)
 	if err != nil {
 		return nil, fmt.Errorf("construct http request: %w", err)
 	}
 
+	start := time.Now()
 	resp, err := c.doHTTP(req)
 	if err != nil {
 		return nil, fmt.Errorf("handle request: %w", err)
 	}
 	defer multierr.AppendInvoke(&err, multierr.Close(resp.Body))
 
-	respBody, err = ioutil.ReadAll(resp.Body)
+	respBody, err = ioutil.ReadAll(io.LimitReader(resp.Body, MaxResponseSize+1))
 	if err != nil {
 		return nil, fmt.Errorf("read response body: %w", err)
 	}
 
-	return respBody, err
+	// the messages are redacted only if they are logged
+	if e := log.Debug(); e.Enabled() {
+		e.Str("entity", "FSCR Client").
+			Str("action", "exchanging SOAP messages").
+			Str("url", c.url).
+			Int("status", resp.StatusCode).
+			Str("contentType", resp.Header.Get("Content-Type")).
+			TimeDiff("latency", time.Now(), start).
+			Bytes("request", redact(reqBody)).
+			Bytes("response", redact(truncate(respBody, MaxResponseSize))).
+			Send()
+	}
+
+	if len(respBody) > MaxResponseSize {
+		return nil, fmt.Errorf("more than %d bytes: %w", MaxResponseSize, ErrFSCRResponseTooLarge)
+	}
+
+	if err = checkResponse(resp, respBody); err != nil {
+		return nil, err
+	}
+
+	return respBody, nil
+}
+
+// checkResponse classifies the response by its status code and content type.
+func checkResponse(resp *http.Response, body []byte) error {
+	xmlBody := isXML(resp.Header.Get("Content-Type"))
+
+	switch {
+	case resp.StatusCode == http.StatusOK && xmlBody:
+		return nil
+	// SOAP faults are returned with the Internal Server Error status
+	case resp.StatusCode == http.StatusInternalServerError && xmlBody:
+		return nil
+	case resp.StatusCode != http.StatusOK:
+		return &HTTPStatusError{
+			StatusCode: resp.StatusCode,
+			Body:       string(truncate(body, maxErrorBodySize)),
+		}
+	}
+
+	return fmt.Errorf("%q: %q: %w", resp.Header.Get("Content-Type"), truncate(body, maxErrorBodySize), ErrFSCRContentType)
+}
+
+func isXML(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	switch mediaType {
+	case "text/xml", "application/xml", "application/soap+xml":
+		return true
+	}
+
+	return false
+}
+
+func truncate(data []byte, size int) []byte {
+	if len(data) > size {
+		return data[:size]
+	}
+
+	return data
+}
+
+// redactedElements matches the contents of the elements with the security codes, signatures
+// and certificates of the SOAP messages.
+var redactedElements = regexp.MustCompile(`(<(?:[\w-]+:)?(?:pkp|SignatureValue|BinarySecurityToken)\b[^>]*>)[^<]*`)
+
+// redact returns a copy of the SOAP message with the sensitive element contents redacted.
+func redact(msg []byte) []byte {
+	return redactedElements.ReplaceAll(msg, []byte("${1}[REDACTED]"))
 }
 
 func (c *client) doHTTP(req *http.Request) (*http.Response, error) {
@@ -1,13 +1,20 @@
 package fscr_test
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
+	"errors"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/chutommy/eetgateway/pkg/fscr"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
 	"github.com/stretchr/testify/require"
 )
 
@@ -81,3 +88,123 @@ func TestClient_Do(t *testing.T) {
 		})
 	}
 }
+
+func TestClient_DoResponses(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		contentType string
+		body        string
+		errs        []error
+	}{
+		{
+			name:        "ok",
+			status:      http.StatusOK,
+			contentType: "text/xml;charset=UTF-8",
+			body:        "<Envelope/>",
+		},
+		{
+			name:        "SOAP fault",
+			status:      http.StatusInternalServerError,
+			contentType: "text/xml; charset=utf-8",
+			body:        "<Envelope><Body><Fault/></Body></Envelope>",
+		},
+		{
+			name:        "HTML error page",
+			status:      http.StatusBadGateway,
+			contentType: "text/html",
+			body:        "<html>" + strings.Repeat("bad gateway ", 100) + "</html>",
+			errs:        []error{fscr.ErrFSCRHTTPStatus},
+		},
+		{
+			name:        "unexpected content type",
+			status:      http.StatusOK,
+			contentType: "text/html",
+			body:        "<html>maintenance</html>",
+			errs:        []error{fscr.ErrFSCRContentType},
+		},
+		{
+			name:        "response too large",
+			status:      http.StatusOK,
+			contentType: "text/xml",
+			body:        strings.Repeat("x", fscr.MaxResponseSize+1),
+			errs:        []error{fscr.ErrFSCRResponseTooLarge},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tc.contentType)
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte(tc.body))
+			}))
+			defer srv.Close()
+
+			resp, err := fscr.NewClient(srv.Client(), srv.URL).Do(context.Background(), []byte("<Envelope/>"))
+			if tc.errs == nil {
+				require.NoError(t, err)
+				require.Equal(t, tc.body, string(resp))
+				return
+			}
+
+			for _, e := range tc.errs {
+				require.ErrorIs(t, err, e)
+			}
+
+			var statusErr *fscr.HTTPStatusError
+			if errors.As(err, &statusErr) {
+				require.Equal(t, tc.status, statusErr.StatusCode)
+				require.Len(t, statusErr.Body, 512)
+			}
+		})
+	}
+}
+
+func TestClient_DoRedactsLogs(t *testing.T) {
+	var buf bytes.Buffer
+	logger := log.Logger
+	log.Logger = zerolog.New(&buf).Level(zerolog.DebugLevel)
+	defer func() {
+		log.Logger = logger
+	}()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/xml")
+		_, _ = w.Write([]byte(`<Envelope><Header><wsse:BinarySecurityToken>response-token</wsse:BinarySecurityToken></Header></Envelope>`))
+	}))
+	defer srv.Close()
+
+	req := `<Envelope><Body><eet:pkp digest="SHA256">secret-pkp</eet:pkp><eet:bkp>public-bkp</eet:bkp>` +
+		`<ds:SignatureValue>secret-signature</ds:SignatureValue></Body></Envelope>`
+	_, err := fscr.NewClient(srv.Client(), srv.URL).Do(context.Background(), []byte(req))
+	require.NoError(t, err)
+
+	out := buf.String()
+	require.Contains(t, out, "public-bkp")
+	require.Contains(t, out, "[REDACTED]")
+	require.NotContains(t, out, "secret-pkp")
+	require.NotContains(t, out, "secret-signature")
+	require.NotContains(t, out, "response-token")
+}
+
+func TestClient_SetTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		w.Header().Set("Content-Type", "text/xml")
+		_, _ = w.Write([]byte("<Envelope/>"))
+	}))
+	defer srv.Close()
+
+	c := fscr.NewClient(srv.Client(), srv.URL)
+	_, err := c.Do(context.Background(), []byte("<Envelope/>"))
+	require.NoError(t, err)
+
+	c.SetTimeout(10 * time.Millisecond)
+	_, err = c.Do(context.Background(), []byte("<Envelope/>"))
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	c.SetTimeout(time.Second)
+	_, err = c.Do(context.Background(), []byte("<Envelope/>"))
+	require.NoError(t, err)
+}
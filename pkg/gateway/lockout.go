@@ -0,0 +1,133 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/chutommy/eetgateway/pkg/keystore"
+	"go.uber.org/multierr"
+)
+
+type clientAddrKey struct{}
+
+// WithClientAddr returns a copy of ctx carrying the address of the client making the request.
+// Failed password attempts are then counted for the client as well as for the certificate.
+func WithClientAddr(ctx context.Context, addr string) context.Context {
+	return context.WithValue(ctx, clientAddrKey{}, addr)
+}
+
+// ClientAddr returns the address of the client making the request set by WithClientAddr.
+func ClientAddr(ctx context.Context) string {
+	addr, _ := ctx.Value(clientAddrKey{}).(string)
+	return addr
+}
+
+func certLockoutKey(certID string) string {
+	return "cert:" + certID
+}
+
+func clientLockoutKey(addr string) string {
+	return "client:" + addr
+}
+
+// lockoutKeys returns the keys under which the failed password attempts for the certificate are counted.
+func lockoutKeys(ctx context.Context, certID string) []string {
+	keys := []string{certLockoutKey(certID)}
+	if addr := ClientAddr(ctx); addr != "" {
+		keys = append(keys, clientLockoutKey(addr))
+	}
+
+	return keys
+}
+
+// reserveAttempt reserves a password attempt for both the certificate and the client. ErrCertificateLocked
+// is returned if either of them is locked out and ErrTooManyAttempts if either of them has too many
+// attempts in progress. It returns the reserved keys, which must be released
+// by releaseAttempt, and reports whether any failed attempts are recorded for the certificate.
+func (g *service) reserveAttempt(ctx context.Context, certID string) ([]string, bool, error) {
+	var reserved []string
+	var failed bool
+	for i, key := range lockoutKeys(ctx, certID) {
+		failures, err := g.keyStore.ReserveAttempt(ctx, key)
+		if err != nil {
+			err = multierr.Append(err, g.releaseAttempt(ctx, reserved, false))
+			switch {
+			case errors.Is(err, keystore.ErrKeyLocked):
+				return nil, false, fmt.Errorf("%s: %w", key, multierr.Append(err, ErrCertificateLocked))
+			case errors.Is(err, keystore.ErrTooManyAttempts):
+				return nil, false, fmt.Errorf("%s: %w", key, multierr.Append(err, ErrTooManyAttempts))
+			case g.keyStore.Ping(ctx) != nil:
+				return nil, false, multierr.Append(err, ErrKeystoreUnavailable)
+			}
+
+			return nil, false, multierr.Append(err, ErrKeystoreUnexpected)
+		}
+
+		reserved = append(reserved, key)
+		if i == 0 {
+			failed = failures > 0
+		}
+	}
+
+	return reserved, failed, nil
+}
+
+// releaseAttempt releases the reserved password attempts and counts them as failed if failed is true.
+func (g *service) releaseAttempt(ctx context.Context, keys []string, failed bool) error {
+	var err error
+	for _, key := range keys {
+		if _, e := g.keyStore.ReleaseAttempt(ctx, key, failed); e != nil {
+			err = multierr.Append(err, fmt.Errorf("release attempt of %s: %w", key, e))
+		}
+	}
+
+	return err
+}
+
+// authenticate runs the keystore operation op which verifies the certificate password. Failed password
+// attempts are counted and lead to a temporary lockout of the certificate and the client. The attempt
+// is reserved before op is run so concurrent attempts can't bypass the lockout. The failures
+// of the certificate are reset once the password is verified.
+func (g *service) authenticate(ctx context.Context, certID string, op func() error) error {
+	keys, failed, err := g.reserveAttempt(ctx, certID)
+	if err != nil {
+		return err
+	}
+
+	err = op()
+	invalid := errors.Is(err, keystore.ErrInvalidDecryptionKey)
+	if e := g.releaseAttempt(ctx, keys, invalid); e != nil && invalid {
+		err = multierr.Append(err, e)
+	}
+
+	if err != nil {
+		switch {
+		case errors.Is(err, keystore.ErrRecordNotFound):
+			return multierr.Append(err, ErrCertificateNotFound)
+		case errors.Is(err, keystore.ErrPreviousNotFound):
+			return multierr.Append(err, ErrPreviousCertificateNotFound)
+		case errors.Is(err, keystore.ErrInvalidDecryptionKey):
+			return multierr.Append(err, ErrInvalidCertificatePassword)
+		case errors.Is(err, keystore.ErrTaxpayerMismatch):
+			return multierr.Append(err, ErrInvalidTaxpayersCertificate)
+		case errors.Is(err, keystore.ErrRecordRevoked):
+			return multierr.Append(err, ErrCertificateRevoked)
+		case errors.Is(err, keystore.ErrReachedMaxAttempts):
+			return multierr.Append(err, ErrMaxTXAttempts)
+		case errors.Is(err, keystore.ErrSignerUnavailable), errors.Is(err, keystore.ErrRemoteSigner):
+			return multierr.Append(err, ErrSignerUnavailable)
+		case g.keyStore.Ping(ctx) != nil:
+			return multierr.Append(err, ErrKeystoreUnavailable)
+		}
+
+		return multierr.Append(err, ErrKeystoreUnexpected)
+	}
+
+	if failed {
+		// the failures expire on their own if the reset fails
+		_ = g.keyStore.ResetFailures(ctx, certLockoutKey(certID))
+	}
+
+	return nil
+}
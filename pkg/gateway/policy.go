@@ -0,0 +1,58 @@
+package gateway
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/chutommy/eetgateway/pkg/eet"
+	"github.com/chutommy/eetgateway/pkg/keystore"
+)
+
+// errPolicyViolation is returned if a sale doesn't satisfy the usage policy of the certificate.
+var errPolicyViolation = errors.New("policy violation")
+
+// validatePolicy checks whether the policy can be enforced.
+func validatePolicy(p *keystore.Policy) error {
+	return p.Compile()
+}
+
+// checkPolicy checks whether the sale trzba can be signed with the certificate restricted by the policy p
+// at the time now.
+func checkPolicy(p *keystore.Policy, trzba *eet.TrzbaType, now time.Time) error {
+	if p == nil {
+		return nil
+	}
+
+	if !p.ValidFrom.IsZero() && now.Before(p.ValidFrom) {
+		return fmt.Errorf("certificate usable since %s: %w", p.ValidFrom.Format(time.RFC3339), errPolicyViolation)
+	}
+
+	if !p.ValidTo.IsZero() && now.After(p.ValidTo) {
+		return fmt.Errorf("certificate usable until %s: %w", p.ValidTo.Format(time.RFC3339), errPolicyViolation)
+	}
+
+	if len(p.IDProvoz) > 0 && !containsInt(p.IDProvoz, trzba.Data.Idprovoz) {
+		return fmt.Errorf("id_provoz %d not allowed: %w", trzba.Data.Idprovoz, errPolicyViolation)
+	}
+
+	if len(p.IDPokl) > 0 && !p.MatchIDPokl(string(trzba.Data.Idpokl)) {
+		return fmt.Errorf("id_pokl %q not allowed: %w", trzba.Data.Idpokl, errPolicyViolation)
+	}
+
+	if p.DICPoverujiciho != "" && string(trzba.Data.Dicpoverujiciho) != p.DICPoverujiciho {
+		return fmt.Errorf("dic_poverujiciho %q not allowed: %w", trzba.Data.Dicpoverujiciho, errPolicyViolation)
+	}
+
+	return nil
+}
+
+func containsInt(values []int, v int) bool {
+	for _, x := range values {
+		if x == v {
+			return true
+		}
+	}
+
+	return false
+}
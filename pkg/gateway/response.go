@@ -0,0 +1,60 @@
+package gateway
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/chutommy/eetgateway/pkg/eet"
+	"github.com/chutommy/eetgateway/pkg/fscr"
+	"github.com/chutommy/eetgateway/pkg/revocation"
+	"go.uber.org/multierr"
+)
+
+// DefaultMaxSigningTimeSkew is a sensible default of the maximum difference between the signing time
+// of a response and the current time. The signing certificate is verified at the signing time,
+// so it can't be set arbitrarily.
+var DefaultMaxSigningTimeSkew = 5 * time.Minute
+
+// errSigningTime is returned if the signing time of a response is out of the allowed clock skew.
+var errSigningTime = errors.New("signing time out of the allowed clock skew")
+
+// verifyDSig verifies the certificate of the response signature and checks its cached revocation status.
+func (g *service) verifyDSig(cert *x509.Certificate, signedAt time.Time) error {
+	if d := time.Since(signedAt); d > g.maxSigningTimeSkew || d < -g.maxSigningTimeSkew {
+		return fmt.Errorf("signed at %s: %w", signedAt.Format(time.RFC3339), errSigningTime)
+	}
+
+	if err := g.caSvc.VerifyDSig(cert, signedAt); err != nil {
+		return err
+	}
+
+	g.watch(cert)
+
+	return g.revocations.Check(cert)
+}
+
+// responseVerifyErr returns the error of the failed response verification with the specific gateway error.
+func responseVerifyErr(err error) error {
+	switch {
+	case errors.Is(err, eet.ErrInvalidUUID), errors.Is(err, eet.ErrInvalidBKP):
+		return multierr.Append(err, ErrFSCRResponseMismatch)
+	case errors.Is(err, eet.ErrInvalidSignature), errors.Is(err, eet.ErrUnsignedResponse):
+		return multierr.Append(err, ErrFSCRResponseSignature)
+	case errors.Is(err, errSigningTime):
+		return multierr.Append(err, ErrFSCRSigningTime)
+	case errors.Is(err, fscr.ErrInvalidOrganizationName), errors.Is(err, fscr.ErrNotTrustedCertificate):
+		return multierr.Append(err, ErrFSCRCertificateUntrusted)
+	case errors.Is(err, fscr.ErrInvalidKeyUsage):
+		return multierr.Append(err, ErrFSCRCertificateKeyUsage)
+	case errors.Is(err, fscr.ErrCertificateNotValid):
+		return multierr.Append(err, ErrFSCRCertificateExpired)
+	case errors.Is(err, revocation.ErrCertificateRevoked):
+		return multierr.Append(err, ErrFSCRCertificateRevoked)
+	case errors.Is(err, revocation.ErrStatusUnknown):
+		return multierr.Append(err, ErrFSCRRevocationStatusUnknown)
+	}
+
+	return multierr.Append(err, ErrFSCRResponseVerify)
+}
@@ -0,0 +1,74 @@
+package gateway
+
+import (
+	"crypto/x509"
+	"testing"
+	"time"
+
+	mfscr "github.com/chutommy/eetgateway/pkg/mocks/fscr"
+	mrevocation "github.com/chutommy/eetgateway/pkg/mocks/revocation"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_verifyDSigSigningTime(t *testing.T) {
+	tests := []struct {
+		name     string
+		skew     time.Duration
+		signedAt time.Duration
+		ok       bool
+	}{
+		{
+			name:     "within default skew",
+			skew:     DefaultMaxSigningTimeSkew,
+			signedAt: -time.Minute,
+			ok:       true,
+		},
+		{
+			name:     "before default skew",
+			skew:     DefaultMaxSigningTimeSkew,
+			signedAt: -10 * time.Minute,
+		},
+		{
+			name:     "after default skew",
+			skew:     DefaultMaxSigningTimeSkew,
+			signedAt: 10 * time.Minute,
+		},
+		{
+			name:     "within configured skew",
+			skew:     15 * time.Minute,
+			signedAt: -10 * time.Minute,
+			ok:       true,
+		},
+		{
+			name:     "out of configured skew",
+			skew:     30 * time.Second,
+			signedAt: -time.Minute,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cert := new(x509.Certificate)
+			caService := new(mfscr.CAService)
+			revocationService := new(mrevocation.Service)
+			if tc.ok {
+				caService.On("VerifyDSig", cert, mock.Anything).Return(nil)
+				revocationService.On("Watch", cert).Once()
+				revocationService.On("Check", cert).Return(nil)
+			}
+
+			g := NewService(nil, caService, nil, revocationService, DefaultSessionPolicy, tc.skew).(*service)
+			err := g.verifyDSig(cert, time.Now().Add(tc.signedAt))
+			if tc.ok {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, errSigningTime)
+				require.ErrorIs(t, responseVerifyErr(err), ErrFSCRSigningTime)
+			}
+
+			caService.AssertExpectations(t)
+			revocationService.AssertExpectations(t)
+		})
+	}
+}
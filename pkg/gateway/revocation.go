@@ -0,0 +1,98 @@
+package gateway
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+
+	"github.com/chutommy/eetgateway/pkg/keystore"
+	"github.com/chutommy/eetgateway/pkg/revocation"
+	"go.uber.org/multierr"
+)
+
+// watch registers the revocation sources of the certificate once it's stored or loaded. The certificates
+// already registered are looked up without locking, so the checks of every sale don't contend.
+func (g *service) watch(cert *x509.Certificate) {
+	key := string(cert.RawIssuer) + "/" + cert.SerialNumber.String()
+	if _, ok := g.watched.Load(key); ok {
+		return
+	}
+
+	if _, loaded := g.watched.LoadOrStore(key, struct{}{}); !loaded {
+		g.revocations.Watch(cert)
+	}
+}
+
+// checkRevocation checks the cached revocation status of the taxpayer's certificate.
+func (g *service) checkRevocation(cert *x509.Certificate) error {
+	g.watch(cert)
+
+	if err := g.revocations.Check(cert); err != nil {
+		if errors.Is(err, revocation.ErrCertificateRevoked) {
+			return multierr.Append(err, ErrCertificateRevoked)
+		}
+
+		return multierr.Append(err, ErrRevocationStatusUnknown)
+	}
+
+	return nil
+}
+
+// CheckRevocations checks the cached revocation status of all stored certificates and marks the revoked ones
+// in the keystore, so they can't be used until replaced. Sessions of the revoked certificates are closed.
+// The distribution points of the stored certificates are registered for the following refreshes.
+// It returns the IDs of the newly revoked certificates.
+func (g *service) CheckRevocations(ctx context.Context) ([]string, error) {
+	ids, err := g.keyStore.List(ctx, 0, -1)
+	if err != nil {
+		if g.keyStore.Ping(ctx) != nil {
+			return nil, multierr.Append(err, ErrKeystoreUnavailable)
+		}
+
+		return nil, multierr.Append(err, ErrKeystoreUnexpected)
+	}
+
+	var revoked []string
+	var errs error
+	for _, id := range ids {
+		identity, err := g.keyStore.Identity(ctx, id)
+		if err != nil {
+			// the certificate may have been deleted in the meantime
+			if !errors.Is(err, keystore.ErrRecordNotFound) {
+				errs = multierr.Append(errs, fmt.Errorf("retrieve identity of %s: %w", id, err))
+			}
+
+			continue
+		}
+
+		// certificates stored without the identity are checked on use only
+		if identity.SerialNumber == nil || !identity.RevokedAt.IsZero() {
+			continue
+		}
+
+		g.revocations.WatchDistributionPoints(identity.DistributionPoints)
+		err = g.revocations.CheckSerialNumber(identity.Issuer, identity.SerialNumber)
+		if !errors.Is(err, revocation.ErrCertificateRevoked) {
+			continue
+		}
+
+		if err = g.keyStore.Revoke(ctx, id, identity.SerialNumber); err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("revoke %s: %w", id, err))
+			continue
+		}
+
+		g.sessions.revoke(id)
+		revoked = append(revoked, id)
+	}
+
+	if errs != nil {
+		if g.keyStore.Ping(ctx) != nil {
+			return revoked, multierr.Append(errs, ErrKeystoreUnavailable)
+		}
+
+		return revoked, multierr.Append(errs, ErrKeystoreUnexpected)
+	}
+
+	return revoked, nil
+}
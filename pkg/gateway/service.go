@@ -2,12 +2,18 @@ package gateway
 
 import (
 	"context"
+	"crypto"
+	"crypto/x509"
 	"errors"
+	"sync"
+	"time"
 
 	"github.com/chutommy/eetgateway/pkg/eet"
 	"github.com/chutommy/eetgateway/pkg/fscr"
 	"github.com/chutommy/eetgateway/pkg/keystore"
+	"github.com/chutommy/eetgateway/pkg/revocation"
 	"go.uber.org/multierr"
+	"software.sslmate.com/src/go-pkcs12"
 )
 
 // ErrCertificateNotFound is returned if a certificate with the given ID can't be found.
@@ -25,21 +31,91 @@ var ErrInvalidCertificatePassword = errors.New("invalid password for the decrypt
 // ErrRequestBuild is returned if a SOAP request envelope can't be built.
 var ErrRequestBuild = errors.New("SOAP request to FSCR not completed")
 
+// ErrInvalidSOAPRequest is returned if a SOAP request doesn't contain a parsable Trzba element.
+var ErrInvalidSOAPRequest = errors.New("invalid SOAP request")
+
+// ErrInvalidSecurityCodes is returned if the PKP or BKP code of a SOAP request doesn't match the sale data
+// and the taxpayer's certificate.
+var ErrInvalidSecurityCodes = errors.New("security codes of the request not valid")
+
 // ErrFSCRConnection is returned if an error occurs during the communication with the FSCR servers.
 var ErrFSCRConnection = errors.New("bad FSCR connection")
 
 // ErrFSCRResponseParse is returned if an error occurs during the FSCR SOAP response parsing.
 var ErrFSCRResponseParse = errors.New("invalid FSCR response structure")
 
-// ErrFSCRResponseVerify is returned if the response doesn't pass security checks and verifications.
+// ErrFSCRFault is returned if the FSCR responds with a SOAP fault instead of a response.
+var ErrFSCRFault = errors.New("FSCR responded with a SOAP fault")
+
+// ErrFSCRResponseVerify is returned if the response doesn't pass security checks and verifications
+// not covered by the more specific errors below.
 var ErrFSCRResponseVerify = errors.New("FSCR response not verified")
 
+// ErrFSCRResponseMismatch is returned if the response UUID or BKP differs from the request.
+var ErrFSCRResponseMismatch = errors.New("FSCR response doesn't match the request")
+
+// ErrFSCRResponseSignature is returned if the digest or the signature of the response is invalid
+// or if the response isn't signed.
+var ErrFSCRResponseSignature = errors.New("invalid signature of the FSCR response")
+
+// ErrFSCRSigningTime is returned if the response is signed at a time too far from the current time.
+var ErrFSCRSigningTime = errors.New("FSCR response signing time out of the allowed clock skew")
+
+// ErrFSCRCertificateUntrusted is returned if the response signing certificate isn't issued to the FSCR
+// or doesn't chain up to the trusted DSig certificates.
+var ErrFSCRCertificateUntrusted = errors.New("FSCR signing certificate not trusted")
+
+// ErrFSCRCertificateKeyUsage is returned if the response signing certificate isn't issued for digital signatures.
+var ErrFSCRCertificateKeyUsage = errors.New("FSCR signing certificate not issued for digital signatures")
+
+// ErrFSCRCertificateExpired is returned if the response signing certificate or its issuer isn't valid
+// at the signing time.
+var ErrFSCRCertificateExpired = errors.New("FSCR signing certificate not valid at the signing time")
+
+// ErrFSCRCertificateRevoked is returned if the response signing certificate has been revoked.
+var ErrFSCRCertificateRevoked = errors.New("FSCR signing certificate revoked")
+
+// ErrFSCRRevocationStatusUnknown is returned if the revocation status of the response signing certificate
+// isn't known and unknown statuses aren't accepted.
+var ErrFSCRRevocationStatusUnknown = errors.New("revocation status of the FSCR signing certificate unknown")
+
 // ErrInvalidTaxpayersCertificate is returned if an invalid taxpayer's certificate is given.
 var ErrInvalidTaxpayersCertificate = errors.New("invalid taxpayer's certificate")
 
+// ErrCertificateExport is returned if a certificate can't be packaged for the export.
+var ErrCertificateExport = errors.New("taxpayer's certificate not exportable")
+
+// ErrCertificatePolicy is returned if a sale isn't permitted by the usage policy of the certificate.
+var ErrCertificatePolicy = errors.New("sale not permitted by the usage policy of the taxpayer's certificate")
+
+// ErrInvalidCertificatePolicy is returned if an invalid usage policy of the certificate is given.
+var ErrInvalidCertificatePolicy = errors.New("invalid usage policy of the taxpayer's certificate")
+
+// ErrPreviousCertificateNotFound is returned if there is no previous version of the certificate to roll back to.
+var ErrPreviousCertificateNotFound = errors.New("previous version of the taxpayer's certificate not found")
+
+// ErrCertificateRevoked is returned if the taxpayer's certificate has been revoked by its issuer.
+var ErrCertificateRevoked = errors.New("taxpayer's certificate revoked")
+
+// ErrRevocationStatusUnknown is returned if the revocation status of the taxpayer's certificate
+// isn't known and unknown statuses aren't accepted.
+var ErrRevocationStatusUnknown = errors.New("revocation status of the taxpayer's certificate unknown")
+
+// ErrCertificateLocked is returned if the certificate or the client is locked out after too many failed attempts.
+var ErrCertificateLocked = errors.New("taxpayer's certificate locked after too many failed attempts")
+
+// ErrTooManyAttempts is returned if too many requests with the certificate or from the client are in progress.
+var ErrTooManyAttempts = errors.New("too many requests with the taxpayer's certificate in progress")
+
+// ErrInvalidSessionToken is returned if a session token is unknown, expired or issued for another certificate.
+var ErrInvalidSessionToken = errors.New("invalid or expired session token")
+
 // ErrMaxTXAttempts is returned if the maximum number of transaction attempts is reached.
 var ErrMaxTXAttempts = errors.New("request discarded caused by maximum transaction attempts")
 
+// ErrSignerUnavailable is returned if the external signer of the taxpayer's certificate fails or isn't configured.
+var ErrSignerUnavailable = errors.New("external signer of the taxpayer's certificate unavailable")
+
 // ErrKeystoreUnavailable is returned if the keystore service can't be reached.
 var ErrKeystoreUnavailable = errors.New("keystore service unavailable")
 
@@ -49,18 +125,44 @@ var ErrKeystoreUnexpected = errors.New("unexpected keystore error")
 // Service handles all functionalities provided by the EET Gateway.
 type Service interface {
 	Ping(ctx context.Context) error
+	TrustedRoots() (eetRoots, dsigRoots []*x509.Certificate)
+	Ready(ctx context.Context) error
+	Dependencies() []DependencyStatus
 	SendSale(ctx context.Context, certID string, pk []byte, trzba *eet.TrzbaType) (*eet.OdpovedType, error)
-	StoreCert(ctx context.Context, certID string, password []byte, pkcsData []byte, pkcsPassword string) error
+	SendSaleWithSession(ctx context.Context, certID string, token string, trzba *eet.TrzbaType) (*eet.OdpovedType, error)
+	SendSaleWithCert(ctx context.Context, pkcsData []byte, pkcsPassword string, trzba *eet.TrzbaType) (*eet.OdpovedType, error)
+	SendSOAP(ctx context.Context, certID string, certPassword []byte, req []byte) ([]byte, error)
+	ComputeCodes(ctx context.Context, certID string, certPassword []byte, trzba *eet.TrzbaType) (*eet.TrzbaKontrolniKodyType, error)
+	ComputeCodesWithSession(ctx context.Context, certID string, token string, trzba *eet.TrzbaType) (*eet.TrzbaKontrolniKodyType, error)
+	SignSale(ctx context.Context, certID string, certPassword []byte, trzba *eet.TrzbaType) ([]byte, error)
+	SignSaleWithCert(ctx context.Context, pkcsData []byte, pkcsPassword string, trzba *eet.TrzbaType) ([]byte, error)
+	OpenSession(ctx context.Context, certID string, password []byte) (string, time.Time, error)
+	StoreCert(ctx context.Context, certID string, password []byte, pkcsData []byte, pkcsPassword string, policy *keystore.Policy) error
+	StoreCertPEM(ctx context.Context, certID string, password []byte, certData, keyData []byte, policy *keystore.Policy) error
+	StoreCertSigner(ctx context.Context, certID string, password []byte, certData []byte, key crypto.Signer, policy *keystore.Policy) error
+	ExportCert(ctx context.Context, certID string, password []byte, pkcsPassword string) ([]byte, error)
 	ListCertIDs(ctx context.Context, start, end int64) ([]string, error)
 	UpdateCertID(ctx context.Context, oldID, newID string) error
 	UpdateCertPassword(ctx context.Context, id string, oldPassword, newPassword []byte) error
+	UpdateCertPolicy(ctx context.Context, id string, password []byte, policy *keystore.Policy) error
+	ReplaceCert(ctx context.Context, id string, password []byte, pkcsData []byte, pkcsPassword string) error
+	RollbackCert(ctx context.Context, id string, password []byte) error
+	UnlockCert(ctx context.Context, id string, client string) error
 	DeleteID(ctx context.Context, id string) error
+	CheckRevocations(ctx context.Context) ([]string, error)
 }
 
 type service struct {
-	fscrClient fscr.Client
-	caSvc      fscr.CAService
-	keyStore   keystore.Service
+	fscrClient   fscr.Client
+	caSvc        fscr.CAService
+	keyStore     keystore.Service
+	revocations  revocation.Service
+	sessions     *sessionCache
+	dependencies *dependencyMonitor
+	// watched holds the certificates registered by the revocation service
+	watched sync.Map
+
+	maxSigningTimeSkew time.Duration
 }
 
 // Ping checks whether the FSCR servers are online. It returns nil if the response status is OK.
@@ -76,62 +178,442 @@ func (g *service) Ping(ctx context.Context) (err error) {
 	return err
 }
 
+// TrustedRoots returns the trusted EET CA roots and the certificates of the digital signature CA.
+func (g *service) TrustedRoots() (eetRoots, dsigRoots []*x509.Certificate) {
+	return g.caSvc.Roots()
+}
+
+// Ready checks whether the gateway can serve the requests independently of the FSCR availability.
+// The keystore must be reachable and the trust store loaded.
+func (g *service) Ready(ctx context.Context) (err error) {
+	if e := g.keyStore.Ping(ctx); e != nil {
+		err = multierr.Append(err, ErrKeystoreUnavailable)
+	}
+
+	if eetRoots, dsigRoots := g.caSvc.Roots(); len(eetRoots) == 0 || len(dsigRoots) == 0 {
+		err = multierr.Append(err, ErrTrustStoreEmpty)
+	}
+
+	return err
+}
+
+// Dependencies returns the statuses of the FSCR servers and the keystore. The dependencies are checked
+// at most once per DependencyCheckInterval, the results of the last check are returned in between.
+func (g *service) Dependencies() []DependencyStatus {
+	return g.dependencies.status()
+}
+
+func (g *service) pingFSCR(context.Context) error {
+	if err := g.fscrClient.Ping(); err != nil {
+		return multierr.Append(err, ErrFSCRConnection)
+	}
+
+	return nil
+}
+
+func (g *service) pingKeystore(ctx context.Context) error {
+	if err := g.keyStore.Ping(ctx); err != nil {
+		return multierr.Append(err, ErrKeystoreUnavailable)
+	}
+
+	return nil
+}
+
 // SendSale sends TrzbaType using fscr.Client, validates and verifies response and returns OdpovedType.
+// Failed password attempts are counted and lead to a temporary lockout of the certificate and the client.
 func (g *service) SendSale(ctx context.Context, certID string, certPassword []byte, trzba *eet.TrzbaType) (*eet.OdpovedType, error) {
-	kp, err := g.keyStore.Get(ctx, certID, certPassword)
+	kp, err := g.openCert(ctx, certID, certPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	defer kp.Zeroize()
+
+	return g.sendSale(ctx, certID, trzba, func(trzba *eet.TrzbaType) ([]byte, error) {
+		return newRequestEnvelope(trzba, kp)
+	})
+}
+
+// SendSaleWithSession sends TrzbaType the same way as SendSale but signs it with the certificate
+// of the session opened by OpenSession.
+func (g *service) SendSaleWithSession(ctx context.Context, certID string, token string, trzba *eet.TrzbaType) (*eet.OdpovedType, error) {
+	err := g.sessions.use(token, certID, func(kp *keystore.KeyPair) error {
+		return g.checkRevocation(kp.Cert)
+	})
+	if err != nil {
+		if errors.Is(err, errSessionNotFound) {
+			return nil, multierr.Append(err, ErrInvalidSessionToken)
+		}
+
+		return nil, err
+	}
+
+	return g.sendSale(ctx, certID, trzba, func(trzba *eet.TrzbaType) (env []byte, err error) {
+		err = g.sessions.use(token, certID, func(kp *keystore.KeyPair) error {
+			env, err = newRequestEnvelope(trzba, kp)
+			return err
+		})
+
+		return env, err
+	})
+}
+
+// SendSOAP sends the sale of a request given either as a bare Trzba element or as a SOAP request envelope
+// the same way as SendSale and returns the verified SOAP response envelope of the FSCR. The security codes
+// of the request are verified if they are given and computed otherwise, the envelope is always signed again.
+func (g *service) SendSOAP(ctx context.Context, certID string, certPassword []byte, req []byte) ([]byte, error) {
+	trzba, err := eet.ParseRequest(req)
+	if err != nil {
+		return nil, multierr.Append(err, ErrInvalidSOAPRequest)
+	}
+
+	kp, err := g.openCert(ctx, certID, certPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	defer kp.Zeroize()
+
+	if err = trzba.VerifySecurityCodes(kp.Cert.PublicKey); err != nil {
+		if errors.Is(err, eet.ErrInvalidSecurityCodes) {
+			return nil, multierr.Append(err, ErrInvalidSecurityCodes)
+		}
+
+		return nil, multierr.Append(err, ErrRequestBuild)
+	}
+
+	if err = g.checkSalePolicy(ctx, certID, trzba); err != nil {
+		return nil, err
+	}
+
+	_, respEnv, err := g.exchange(ctx, trzba, func(trzba *eet.TrzbaType) ([]byte, error) {
+		return newRequestEnvelope(trzba, kp)
+	})
+
+	return respEnv, err
+}
+
+// openCert decrypts the stored certificate and checks its revocation status. Failed password attempts
+// are counted the same way as in authenticate.
+func (g *service) openCert(ctx context.Context, certID string, certPassword []byte) (*keystore.KeyPair, error) {
+	var kp *keystore.KeyPair
+	err := g.authenticate(ctx, certID, func() (err error) {
+		kp, err = g.keyStore.Get(ctx, certID, certPassword)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = g.checkRevocation(kp.Cert); err != nil {
+		kp.Zeroize()
+		return nil, err
+	}
+
+	return kp, nil
+}
+
+// ComputeCodes computes the security codes (PKP and BKP) of TrzbaType without contacting the FSCR.
+// The certificate is checked and failed password attempts are counted the same way as in SendSale.
+func (g *service) ComputeCodes(ctx context.Context, certID string, certPassword []byte, trzba *eet.TrzbaType) (*eet.TrzbaKontrolniKodyType, error) {
+	kp, err := g.openCert(ctx, certID, certPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	defer kp.Zeroize()
+
+	if err = g.checkSalePolicy(ctx, certID, trzba); err != nil {
+		return nil, err
+	}
+
+	if err = setSecurityCodes(trzba, kp); err != nil {
+		return nil, signErr(err)
+	}
+
+	return &trzba.KontrolniKody, nil
+}
+
+// ComputeCodesWithSession computes the security codes of TrzbaType the same way as ComputeCodes
+// but with the certificate of the session opened by OpenSession.
+func (g *service) ComputeCodesWithSession(ctx context.Context, certID string, token string, trzba *eet.TrzbaType) (*eet.TrzbaKontrolniKodyType, error) {
+	err := g.sessions.use(token, certID, func(kp *keystore.KeyPair) error {
+		return g.checkRevocation(kp.Cert)
+	})
+	if err != nil {
+		if errors.Is(err, errSessionNotFound) {
+			return nil, multierr.Append(err, ErrInvalidSessionToken)
+		}
+
+		return nil, err
+	}
+
+	if err = g.checkSalePolicy(ctx, certID, trzba); err != nil {
+		return nil, err
+	}
+
+	err = g.sessions.use(token, certID, func(kp *keystore.KeyPair) error {
+		return setSecurityCodes(trzba, kp)
+	})
+	if err != nil {
+		if errors.Is(err, errSessionNotFound) {
+			return nil, multierr.Append(err, ErrInvalidSessionToken)
+		}
+
+		return nil, signErr(err)
+	}
+
+	return &trzba.KontrolniKody, nil
+}
+
+// SignSale returns the signed request envelope of TrzbaType without sending it to the FSCR.
+// The certificate is checked and failed password attempts are counted the same way as in SendSale.
+func (g *service) SignSale(ctx context.Context, certID string, certPassword []byte, trzba *eet.TrzbaType) ([]byte, error) {
+	kp, err := g.openCert(ctx, certID, certPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	defer kp.Zeroize()
+
+	if err = g.checkSalePolicy(ctx, certID, trzba); err != nil {
+		return nil, err
+	}
+
+	env, err := newRequestEnvelope(trzba, kp)
+	if err != nil {
+		return nil, signErr(err)
+	}
+
+	return env, nil
+}
+
+// SignSaleWithCert returns the signed request envelope of TrzbaType the same way as SignSale but signs it
+// with the taxpayer's certificate given as a PKCS#12 file. The certificate is verified the same way
+// as in SendSaleWithCert.
+func (g *service) SignSaleWithCert(_ context.Context, pkcsData []byte, pkcsPassword string, trzba *eet.TrzbaType) ([]byte, error) {
+	cert, pk, err := g.caSvc.ParseTaxpayerCertificate(pkcsData, pkcsPassword)
+	if err != nil {
+		return nil, parseCertErr(err)
+	}
+
+	if err = g.checkRevocation(cert); err != nil {
+		return nil, err
+	}
+
+	env, err := eet.NewRequestEnvelope(trzba, cert, pk)
+	if err != nil {
+		return nil, multierr.Append(err, ErrRequestBuild)
+	}
+
+	return env, nil
+}
+
+// checkSalePolicy checks the TrzbaType against the usage policy of the certificate.
+func (g *service) checkSalePolicy(ctx context.Context, certID string, trzba *eet.TrzbaType) error {
+	policy, err := g.keyStore.GetPolicy(ctx, certID)
 	if err != nil {
 		switch {
 		case errors.Is(err, keystore.ErrRecordNotFound):
-			return nil, multierr.Append(err, ErrCertificateNotFound)
-		case errors.Is(err, keystore.ErrInvalidDecryptionKey):
-			return nil, multierr.Append(err, ErrInvalidCertificatePassword)
+			return multierr.Append(err, ErrCertificateNotFound)
 		case errors.Is(err, keystore.ErrReachedMaxAttempts):
-			return nil, multierr.Append(err, ErrMaxTXAttempts)
+			return multierr.Append(err, ErrMaxTXAttempts)
 		case g.keyStore.Ping(ctx) != nil:
-			return nil, multierr.Append(err, ErrKeystoreUnavailable)
+			return multierr.Append(err, ErrKeystoreUnavailable)
 		}
 
-		return nil, multierr.Append(err, ErrKeystoreUnexpected)
+		return multierr.Append(err, ErrKeystoreUnexpected)
 	}
 
-	reqEnv, err := eet.NewRequestEnvelope(trzba, kp.Cert, kp.PK)
+	if err = checkPolicy(policy, trzba, time.Now()); err != nil {
+		return multierr.Append(err, ErrCertificatePolicy)
+	}
+
+	return nil
+}
+
+// SendSaleWithCert sends TrzbaType the same way as SendSale but signs it with the taxpayer's certificate
+// given as a PKCS#12 file instead of a stored one. The certificate is verified the same way as in StoreCert.
+// There is no usage policy of such certificate.
+func (g *service) SendSaleWithCert(ctx context.Context, pkcsData []byte, pkcsPassword string, trzba *eet.TrzbaType) (*eet.OdpovedType, error) {
+	cert, pk, err := g.caSvc.ParseTaxpayerCertificate(pkcsData, pkcsPassword)
 	if err != nil {
-		return nil, multierr.Append(err, ErrRequestBuild)
+		return nil, parseCertErr(err)
+	}
+
+	if err = g.checkRevocation(cert); err != nil {
+		return nil, err
+	}
+
+	return g.exchangeSale(ctx, trzba, func(trzba *eet.TrzbaType) ([]byte, error) {
+		return eet.NewRequestEnvelope(trzba, cert, pk)
+	})
+}
+
+// sendSale checks the usage policy of the certificate, signs TrzbaType with sign and sends it.
+func (g *service) sendSale(ctx context.Context, certID string, trzba *eet.TrzbaType, sign func(trzba *eet.TrzbaType) ([]byte, error)) (*eet.OdpovedType, error) {
+	if err := g.checkSalePolicy(ctx, certID, trzba); err != nil {
+		return nil, err
+	}
+
+	return g.exchangeSale(ctx, trzba, sign)
+}
+
+// newRequestEnvelope returns the request envelope of TrzbaType signed by the KeyPair.
+func newRequestEnvelope(trzba *eet.TrzbaType, kp *keystore.KeyPair) ([]byte, error) {
+	key, err := kp.SigningKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return eet.NewRequestEnvelope(trzba, kp.Cert, key)
+}
+
+// setSecurityCodes sets the security codes of TrzbaType signed by the KeyPair.
+func setSecurityCodes(trzba *eet.TrzbaType, kp *keystore.KeyPair) error {
+	key, err := kp.SigningKey()
+	if err != nil {
+		return err
+	}
+
+	return trzba.SetSecurityCodes(key)
+}
+
+// signErr classifies the error of signing by the taxpayer's certificate.
+func signErr(err error) error {
+	if errors.Is(err, keystore.ErrRemoteSigner) {
+		return multierr.Append(err, ErrSignerUnavailable)
+	}
+
+	return multierr.Append(err, ErrRequestBuild)
+}
+
+// exchangeSale signs TrzbaType with sign, sends it to the FSCR and verifies the response.
+func (g *service) exchangeSale(ctx context.Context, trzba *eet.TrzbaType, sign func(trzba *eet.TrzbaType) ([]byte, error)) (*eet.OdpovedType, error) {
+	odpoved, _, err := g.exchange(ctx, trzba, sign)
+	return odpoved, err
+}
+
+// exchange does the same as exchangeSale but returns the response envelope as well.
+func (g *service) exchange(ctx context.Context, trzba *eet.TrzbaType, sign func(trzba *eet.TrzbaType) ([]byte, error)) (*eet.OdpovedType, []byte, error) {
+	reqEnv, err := sign(trzba)
+	if err != nil {
+		if errors.Is(err, errSessionNotFound) {
+			return nil, nil, multierr.Append(err, ErrInvalidSessionToken)
+		}
+
+		return nil, nil, signErr(err)
 	}
 
 	respEnv, err := g.fscrClient.Do(ctx, reqEnv)
 	if err != nil {
-		return nil, multierr.Append(err, ErrFSCRConnection)
+		return nil, nil, multierr.Append(err, ErrFSCRConnection)
 	}
 
 	odpoved, err := eet.ParseResponseEnvelope(respEnv)
 	if err != nil {
-		return nil, multierr.Append(err, ErrFSCRResponseParse)
+		var fault *eet.SOAPFault
+		if errors.As(err, &fault) {
+			return nil, nil, multierr.Append(err, ErrFSCRFault)
+		}
+
+		return nil, nil, multierr.Append(err, ErrFSCRResponseParse)
+	}
+
+	err = eet.VerifyResponse(trzba, respEnv, odpoved, g.verifyDSig)
+	if err != nil {
+		return nil, nil, responseVerifyErr(err)
 	}
 
-	err = eet.VerifyResponse(trzba, respEnv, odpoved, g.caSvc.VerifyDSig)
+	return odpoved, respEnv, nil
+}
+
+// OpenSession decrypts the certificate and keeps it in memory under the returned opaque token until
+// the returned expiration time. The certificate is checked and failed password attempts are counted
+// the same way as in SendSale.
+func (g *service) OpenSession(ctx context.Context, certID string, password []byte) (string, time.Time, error) {
+	kp, err := g.openCert(ctx, certID, password)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	token, expiresAt, err := g.sessions.open(certID, kp)
 	if err != nil {
-		return nil, multierr.Append(err, ErrFSCRResponseVerify)
+		kp.Zeroize()
+		return "", time.Time{}, multierr.Append(err, ErrKeystoreUnexpected)
 	}
 
-	return odpoved, nil
+	return token, expiresAt, nil
 }
 
-// StoreCert verifies and stores the taxpayer's certificate.
-func (g *service) StoreCert(ctx context.Context, id string, password []byte, pkcsData []byte, pkcsPassword string) error {
+// StoreCert verifies and stores the taxpayer's certificate with its usage policy.
+// A nil policy doesn't restrict the certificate.
+func (g *service) StoreCert(ctx context.Context, id string, password []byte, pkcsData []byte, pkcsPassword string, policy *keystore.Policy) error {
+	if err := validatePolicy(policy); err != nil {
+		return multierr.Append(err, ErrInvalidCertificatePolicy)
+	}
+
 	cert, pk, err := g.caSvc.ParseTaxpayerCertificate(pkcsData, pkcsPassword)
 	if err != nil {
-		if errors.Is(err, fscr.ErrInvalidCertificate) {
-			return multierr.Append(err, ErrInvalidTaxpayersCertificate)
-		}
+		return parseCertErr(err)
+	}
 
-		return multierr.Append(err, ErrCertificateParse)
+	if err = g.checkRevocation(cert); err != nil {
+		return err
 	}
 
-	err = g.keyStore.Store(ctx, id, password, &keystore.KeyPair{
-		Cert: cert,
-		PK:   pk,
-	})
+	return g.storeCert(ctx, id, password, &keystore.KeyPair{Cert: cert, PK: pk}, policy)
+}
+
+// StoreCertPEM verifies and stores the taxpayer's certificate given as PEM encoded certificates and private key
+// with its usage policy. A nil policy doesn't restrict the certificate.
+func (g *service) StoreCertPEM(ctx context.Context, id string, password []byte, certData, keyData []byte, policy *keystore.Policy) error {
+	if err := validatePolicy(policy); err != nil {
+		return multierr.Append(err, ErrInvalidCertificatePolicy)
+	}
+
+	cert, pk, err := g.caSvc.ParseTaxpayerCertificatePEM(certData, keyData)
+	if err != nil {
+		return parseCertErr(err)
+	}
+
+	if err = g.checkRevocation(cert); err != nil {
+		return err
+	}
+
+	return g.storeCert(ctx, id, password, &keystore.KeyPair{Cert: cert, PK: pk}, policy)
+}
+
+// StoreCertSigner verifies and stores the taxpayer's certificate given as PEM encoded certificates whose private
+// key is kept outside the gateway and signed by the key. Only the certificate is stored, the keystore provides
+// the signer of the certificate once it's retrieved. A nil policy doesn't restrict the certificate.
+func (g *service) StoreCertSigner(ctx context.Context, id string, password []byte, certData []byte, key crypto.Signer, policy *keystore.Policy) error {
+	if err := validatePolicy(policy); err != nil {
+		return multierr.Append(err, ErrInvalidCertificatePolicy)
+	}
+
+	cert, err := g.caSvc.ParseTaxpayerCertificateSigner(certData, key)
+	if err != nil {
+		return parseCertErr(err)
+	}
+
+	if err = g.checkRevocation(cert); err != nil {
+		return err
+	}
+
+	return g.storeCert(ctx, id, password, &keystore.KeyPair{Cert: cert, Signer: key}, policy)
+}
+
+func parseCertErr(err error) error {
+	if errors.Is(err, fscr.ErrInvalidCertificate) {
+		return multierr.Append(err, ErrInvalidTaxpayersCertificate)
+	}
+
+	return multierr.Append(err, ErrCertificateParse)
+}
+
+func (g *service) storeCert(ctx context.Context, id string, password []byte, kp *keystore.KeyPair, policy *keystore.Policy) error {
+	err := g.keyStore.Store(ctx, id, password, kp, policy)
 	if err != nil {
 		switch {
 		case errors.Is(err, keystore.ErrIDAlreadyExists):
@@ -148,6 +630,29 @@ func (g *service) StoreCert(ctx context.Context, id string, password []byte, pkc
 	return nil
 }
 
+// ExportCert packages the stored certificate and its private key as a PKCS#12 file encrypted
+// with the pkcsPassword. The certificate is checked and failed password attempts are counted the same way
+// as in SendSale.
+func (g *service) ExportCert(ctx context.Context, id string, password []byte, pkcsPassword string) ([]byte, error) {
+	kp, err := g.openCert(ctx, id, password)
+	if err != nil {
+		return nil, err
+	}
+
+	defer kp.Zeroize()
+
+	if kp.PK == nil {
+		return nil, multierr.Append(keystore.ErrKeyNotExportable, ErrCertificateExport)
+	}
+
+	data, err := pkcs12.Modern.Encode(kp.PK, kp.Cert, nil, pkcsPassword)
+	if err != nil {
+		return nil, multierr.Append(err, ErrCertificateExport)
+	}
+
+	return data, nil
+}
+
 // ListCertIDs returns the list of all certificate IDs in the keystore.
 func (g *service) ListCertIDs(ctx context.Context, start, end int64) ([]string, error) {
 	ids, err := g.keyStore.List(ctx, start, end)
@@ -162,7 +667,7 @@ func (g *service) ListCertIDs(ctx context.Context, start, end int64) ([]string,
 	return ids, nil
 }
 
-// UpdateCertID updates the ID of the certificate.
+// UpdateCertID updates the ID of the certificate. Sessions of the certificate are closed.
 func (g *service) UpdateCertID(ctx context.Context, oldID, newID string) error {
 	err := g.keyStore.UpdateID(ctx, oldID, newID)
 	if err != nil {
@@ -180,31 +685,108 @@ func (g *service) UpdateCertID(ctx context.Context, oldID, newID string) error {
 		return multierr.Append(err, ErrKeystoreUnexpected)
 	}
 
+	g.sessions.revoke(oldID)
+
 	return nil
 }
 
-// UpdateCertPassword updates the password of the certificate.
+// UpdateCertPassword updates the password of the certificate. Failed password attempts are counted
+// the same way as in SendSale. Sessions of the certificate are closed.
 func (g *service) UpdateCertPassword(ctx context.Context, id string, oldPassword, newPassword []byte) error {
-	err := g.keyStore.UpdatePassword(ctx, id, oldPassword, newPassword)
+	err := g.authenticate(ctx, id, func() error {
+		return g.keyStore.UpdatePassword(ctx, id, oldPassword, newPassword)
+	})
 	if err != nil {
-		switch {
-		case errors.Is(err, keystore.ErrRecordNotFound):
-			return multierr.Append(err, ErrCertificateNotFound)
-		case errors.Is(err, keystore.ErrInvalidDecryptionKey):
-			return multierr.Append(err, ErrInvalidCertificatePassword)
-		case errors.Is(err, keystore.ErrReachedMaxAttempts):
-			return multierr.Append(err, ErrMaxTXAttempts)
-		case g.keyStore.Ping(ctx) != nil:
-			return multierr.Append(err, ErrKeystoreUnavailable)
+		return err
+	}
+
+	g.sessions.revoke(id)
+
+	return nil
+}
+
+// UpdateCertPolicy replaces the usage policy of the certificate. A nil policy removes all restrictions.
+// Failed password attempts are counted the same way as in SendSale.
+func (g *service) UpdateCertPolicy(ctx context.Context, id string, password []byte, policy *keystore.Policy) error {
+	if err := validatePolicy(policy); err != nil {
+		return multierr.Append(err, ErrInvalidCertificatePolicy)
+	}
+
+	return g.authenticate(ctx, id, func() error {
+		kp, err := g.keyStore.Get(ctx, id, password)
+		if err != nil {
+			return err
 		}
 
-		return multierr.Append(err, ErrKeystoreUnexpected)
+		kp.Zeroize()
+		return g.keyStore.UpdatePolicy(ctx, id, policy)
+	})
+}
+
+// ReplaceCert verifies the new taxpayer's certificate and atomically replaces the stored one under the same ID.
+// The replaced certificate can be restored by RollbackCert within the grace period of the keystore.
+// Failed password attempts are counted the same way as in SendSale. Sessions of the certificate are closed.
+func (g *service) ReplaceCert(ctx context.Context, id string, password []byte, pkcsData []byte, pkcsPassword string) error {
+	cert, pk, err := g.caSvc.ParseTaxpayerCertificate(pkcsData, pkcsPassword)
+	if err != nil {
+		return parseCertErr(err)
+	}
+
+	if err = g.checkRevocation(cert); err != nil {
+		return err
+	}
+
+	err = g.authenticate(ctx, id, func() error {
+		return g.keyStore.Replace(ctx, id, password, &keystore.KeyPair{
+			Cert: cert,
+			PK:   pk,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	g.sessions.revoke(id)
+
+	return nil
+}
+
+// RollbackCert swaps the certificate with its previous version replaced by ReplaceCert.
+// Failed password attempts are counted the same way as in SendSale. Sessions of the certificate are closed.
+func (g *service) RollbackCert(ctx context.Context, id string, password []byte) error {
+	err := g.authenticate(ctx, id, func() error {
+		return g.keyStore.Rollback(ctx, id, password)
+	})
+	if err != nil {
+		return err
 	}
 
+	g.sessions.revoke(id)
+
 	return nil
 }
 
-// DeleteID removes a certificate with the given ID.
+// UnlockCert removes the lockout of the certificate and, if the client address is not empty, of the client.
+func (g *service) UnlockCert(ctx context.Context, id string, client string) error {
+	keys := []string{certLockoutKey(id)}
+	if client != "" {
+		keys = append(keys, clientLockoutKey(client))
+	}
+
+	for _, key := range keys {
+		if err := g.keyStore.ResetFailures(ctx, key); err != nil {
+			if g.keyStore.Ping(ctx) != nil {
+				return multierr.Append(err, ErrKeystoreUnavailable)
+			}
+
+			return multierr.Append(err, ErrKeystoreUnexpected)
+		}
+	}
+
+	return nil
+}
+
+// DeleteID removes a certificate with the given ID and closes its sessions.
 func (g *service) DeleteID(ctx context.Context, id string) error {
 	err := g.keyStore.Delete(ctx, id)
 	if err != nil {
@@ -220,14 +802,28 @@ func (g *service) DeleteID(ctx context.Context, id string) error {
 		return multierr.Append(err, ErrKeystoreUnexpected)
 	}
 
+	g.sessions.revoke(id)
+
 	return nil
 }
 
-// NewService returns Service implementation.
-func NewService(fscrClient fscr.Client, eetCASvc fscr.CAService, keyStore keystore.Service) Service {
-	return &service{
-		fscrClient: fscrClient,
-		caSvc:      eetCASvc,
-		keyStore:   keyStore,
+// NewService returns Service implementation. Taxpayers' certificates are checked against the revocation data
+// cached by the revocation.Service. Sessions are held in memory according to the SessionPolicy. Responses
+// signed at a time differing from the current time by more than maxSigningTimeSkew are rejected.
+func NewService(fscrClient fscr.Client, eetCASvc fscr.CAService, keyStore keystore.Service, revocations revocation.Service, sessions SessionPolicy, maxSigningTimeSkew time.Duration) Service {
+	g := &service{
+		fscrClient:         fscrClient,
+		caSvc:              eetCASvc,
+		keyStore:           keyStore,
+		revocations:        revocations,
+		sessions:           newSessionCache(sessions),
+		maxSigningTimeSkew: maxSigningTimeSkew,
 	}
+
+	g.dependencies = newDependencyMonitor(
+		dependencyCheck{name: FSCRDependency, check: g.pingFSCR},
+		dependencyCheck{name: KeystoreDependency, check: g.pingKeystore},
+	)
+
+	return g
 }
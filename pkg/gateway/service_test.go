@@ -1,21 +1,30 @@
 package gateway_test
 
 import (
+	"bytes"
 	"context"
+	"crypto"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
+	"encoding/xml"
 	"errors"
+	"io"
 	"math/big"
 	"testing"
 	"time"
 
+	"github.com/chutommy/eetgateway/pkg/eet"
 	"github.com/chutommy/eetgateway/pkg/fscr"
 	"github.com/chutommy/eetgateway/pkg/gateway"
 	"github.com/chutommy/eetgateway/pkg/keystore"
 	mfscr "github.com/chutommy/eetgateway/pkg/mocks/fscr"
 	mkeystore "github.com/chutommy/eetgateway/pkg/mocks/keystore"
+	mrevocation "github.com/chutommy/eetgateway/pkg/mocks/revocation"
+	"github.com/chutommy/eetgateway/pkg/revocation"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"software.sslmate.com/src/go-pkcs12"
 )
 
 var errUnexpected = errors.New("unexpected error")
@@ -52,80 +61,1798 @@ func randomKeyPair() *keystore.KeyPair {
 }
 
 var (
-	certID        = "cert1"
-	certID2       = "cert2"
-	certPassword  = []byte("secret1")
-	certPassword2 = []byte("secret2")
-	certKP        = randomKeyPair()
-	pkcsData      = []byte("p12 data")
-	pkcsPassword  = "secret2"
+	certID         = "cert1"
+	certID2        = "cert2"
+	certPassword   = []byte("secret1")
+	certPassword2  = []byte("secret2")
+	certKP         = randomKeyPair()
+	pkcsData       = []byte("p12 data")
+	pkcsPassword   = "secret2"
+	pemCertData    = []byte("pem certificate")
+	pemKeyData     = []byte("pem private key")
+	certLockoutKey = "cert:" + certID
+	certPolicy     = compiled(&keystore.Policy{
+		IDProvoz: []int{11, 12},
+		IDPokl:   []string{"pokl-[0-9]+"},
+	})
 )
 
+// compiled returns the policy compiled the same way as the policies loaded from the keystore.
+func compiled(p *keystore.Policy) *keystore.Policy {
+	if err := p.Compile(); err != nil {
+		panic(err)
+	}
+
+	return p
+}
+
+// notRevoked returns a revocation.Service accepting all certificates.
+func notRevoked() *mrevocation.Service {
+	rs := new(mrevocation.Service)
+	rs.On("Check", mock.Anything).Return(nil).Maybe()
+	rs.On("Watch", mock.Anything).Maybe()
+	return rs
+}
+
 func TestService_Ping(t *testing.T) {
 	tests := []struct {
 		name  string
-		setup func(c *mfscr.Client, ks *mkeystore.Service)
+		setup func(c *mfscr.Client, ks *mkeystore.Service)
+		errs  []error
+	}{
+		{
+			name: "ok",
+			setup: func(c *mfscr.Client, ks *mkeystore.Service) {
+				c.On("Ping").Return(nil)
+				ks.On("Ping", context.Background()).Return(nil)
+			},
+			errs: nil,
+		},
+		{
+			name: "bad fscr",
+			setup: func(c *mfscr.Client, ks *mkeystore.Service) {
+				c.On("Ping").Return(errUnexpected)
+				ks.On("Ping", context.Background()).Return(nil)
+			},
+			errs: []error{gateway.ErrFSCRConnection},
+		},
+		{
+			name: "bad keystore",
+			setup: func(c *mfscr.Client, ks *mkeystore.Service) {
+				c.On("Ping").Return(nil)
+				ks.On("Ping", context.Background()).Return(errUnexpected)
+			},
+			errs: []error{gateway.ErrKeystoreUnavailable},
+		},
+		{
+			name: "bad fscr and keystore",
+			setup: func(c *mfscr.Client, ks *mkeystore.Service) {
+				c.On("Ping").Return(errUnexpected)
+				ks.On("Ping", context.Background()).Return(errUnexpected)
+			},
+			errs: []error{gateway.ErrFSCRConnection, gateway.ErrKeystoreUnavailable},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fscrClient := new(mfscr.Client)
+			keystoreService := new(mkeystore.Service)
+
+			tc.setup(fscrClient, keystoreService)
+
+			g := gateway.NewService(fscrClient, new(mfscr.CAService), keystoreService, notRevoked(), gateway.DefaultSessionPolicy, gateway.DefaultMaxSigningTimeSkew)
+			err := g.Ping(context.Background())
+			if tc.errs == nil {
+				require.NoError(t, err)
+			} else {
+				for _, e := range tc.errs {
+					require.ErrorIs(t, err, e)
+				}
+			}
+
+			fscrClient.AssertExpectations(t)
+			fscrClient.AssertExpectations(t)
+			keystoreService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_Ready(t *testing.T) {
+	roots := []*x509.Certificate{certKP.Cert}
+
+	tests := []struct {
+		name  string
+		setup func(ks *mkeystore.Service, caSvc *mfscr.CAService)
+		errs  []error
+	}{
+		{
+			name: "ok",
+			setup: func(ks *mkeystore.Service, caSvc *mfscr.CAService) {
+				ks.On("Ping", context.Background()).Return(nil)
+				caSvc.On("Roots").Return(roots, roots)
+			},
+			errs: nil,
+		},
+		{
+			name: "bad keystore",
+			setup: func(ks *mkeystore.Service, caSvc *mfscr.CAService) {
+				ks.On("Ping", context.Background()).Return(errUnexpected)
+				caSvc.On("Roots").Return(roots, roots)
+			},
+			errs: []error{gateway.ErrKeystoreUnavailable},
+		},
+		{
+			name: "empty trust store",
+			setup: func(ks *mkeystore.Service, caSvc *mfscr.CAService) {
+				ks.On("Ping", context.Background()).Return(nil)
+				caSvc.On("Roots").Return(roots, nil)
+			},
+			errs: []error{gateway.ErrTrustStoreEmpty},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// the FSCR isn't contacted
+			fscrClient := new(mfscr.Client)
+			keystoreService := new(mkeystore.Service)
+			caSvc := new(mfscr.CAService)
+
+			tc.setup(keystoreService, caSvc)
+
+			g := gateway.NewService(fscrClient, caSvc, keystoreService, notRevoked(), gateway.DefaultSessionPolicy, gateway.DefaultMaxSigningTimeSkew)
+			err := g.Ready(context.Background())
+			if tc.errs == nil {
+				require.NoError(t, err)
+			} else {
+				for _, e := range tc.errs {
+					require.ErrorIs(t, err, e)
+				}
+			}
+
+			fscrClient.AssertExpectations(t)
+			keystoreService.AssertExpectations(t)
+			caSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_Dependencies(t *testing.T) {
+	fscrClient := new(mfscr.Client)
+	fscrClient.On("Ping").Return(errUnexpected).Once()
+	keystoreService := new(mkeystore.Service)
+	keystoreService.On("Ping", mock.Anything).Return(nil).Once()
+
+	g := gateway.NewService(fscrClient, new(mfscr.CAService), keystoreService, notRevoked(), gateway.DefaultSessionPolicy, gateway.DefaultMaxSigningTimeSkew)
+	statuses := g.Dependencies()
+	require.Len(t, statuses, 2)
+
+	require.Equal(t, gateway.FSCRDependency, statuses[0].Name)
+	require.ErrorIs(t, statuses[0].Err, gateway.ErrFSCRConnection)
+	require.True(t, statuses[0].LastSuccess.IsZero())
+	require.False(t, statuses[0].CheckedAt.IsZero())
+
+	require.Equal(t, gateway.KeystoreDependency, statuses[1].Name)
+	require.NoError(t, statuses[1].Err)
+	require.Equal(t, statuses[1].CheckedAt, statuses[1].LastSuccess)
+
+	// the results are reused within the check interval
+	require.Equal(t, statuses, g.Dependencies())
+
+	fscrClient.AssertExpectations(t)
+	keystoreService.AssertExpectations(t)
+}
+
+func TestService_DependenciesTimeout(t *testing.T) {
+	timeout := gateway.DependencyCheckTimeout
+	gateway.DependencyCheckTimeout = 50 * time.Millisecond
+	defer func() {
+		gateway.DependencyCheckTimeout = timeout
+	}()
+
+	// the FSCR ping ignores the deadline
+	release := make(chan struct{})
+	defer close(release)
+	fscrClient := new(mfscr.Client)
+	fscrClient.On("Ping").Return(nil).Run(func(mock.Arguments) {
+		<-release
+	}).Once()
+
+	keystoreService := new(mkeystore.Service)
+	keystoreService.On("Ping", mock.Anything).Return(errUnexpected).Run(func(args mock.Arguments) {
+		<-args.Get(0).(context.Context).Done()
+	}).Once()
+
+	g := gateway.NewService(fscrClient, new(mfscr.CAService), keystoreService, notRevoked(), gateway.DefaultSessionPolicy, gateway.DefaultMaxSigningTimeSkew)
+
+	// the concurrent callers share one check limited by the timeout
+	results := make(chan []gateway.DependencyStatus, 2)
+	for i := 0; i < cap(results); i++ {
+		go func() {
+			results <- g.Dependencies()
+		}()
+	}
+
+	for i := 0; i < cap(results); i++ {
+		select {
+		case statuses := <-results:
+			require.Len(t, statuses, 2)
+			require.ErrorIs(t, statuses[0].Err, context.DeadlineExceeded)
+			require.Error(t, statuses[1].Err)
+		case <-time.After(time.Second):
+			t.Fatal("dependency check not limited by the timeout")
+		}
+	}
+
+	keystoreService.AssertExpectations(t)
+}
+
+func TestService_SendSale(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(ks *mkeystore.Service)
+		errs  []error
+	}{
+		{
+			name: "certificate not found",
+			setup: func(ks *mkeystore.Service) {
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), nil)
+				ks.On("ReleaseAttempt", context.Background(), certLockoutKey, false).Return(time.Duration(0), nil)
+				ks.On("Get", context.Background(), certID, certPassword).Return(nil, keystore.ErrRecordNotFound)
+			},
+			errs: []error{gateway.ErrCertificateNotFound},
+		},
+		{
+			name: "invalid certificate password",
+			setup: func(ks *mkeystore.Service) {
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), nil)
+				ks.On("Get", context.Background(), certID, certPassword).Return(nil, keystore.ErrInvalidDecryptionKey)
+				ks.On("ReleaseAttempt", context.Background(), certLockoutKey, true).Return(time.Duration(0), nil)
+			},
+			errs: []error{gateway.ErrInvalidCertificatePassword},
+		},
+		{
+			name: "certificate locked",
+			setup: func(ks *mkeystore.Service) {
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), keystore.ErrKeyLocked)
+			},
+			errs: []error{gateway.ErrCertificateLocked},
+		},
+		{
+			name: "failures reset",
+			setup: func(ks *mkeystore.Service) {
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(2), nil)
+				ks.On("ReleaseAttempt", context.Background(), certLockoutKey, false).Return(time.Duration(0), nil)
+				ks.On("Get", context.Background(), certID, certPassword).Return(randomKeyPair(), nil)
+				ks.On("ResetFailures", context.Background(), certLockoutKey).Return(nil)
+				ks.On("GetPolicy", context.Background(), certID).Return(&keystore.Policy{
+					IDProvoz: []int{12},
+				}, nil)
+			},
+			errs: []error{gateway.ErrCertificatePolicy},
+		},
+		{
+			name: "id_provoz not allowed",
+			setup: func(ks *mkeystore.Service) {
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), nil)
+				ks.On("ReleaseAttempt", context.Background(), certLockoutKey, false).Return(time.Duration(0), nil)
+				ks.On("Get", context.Background(), certID, certPassword).Return(randomKeyPair(), nil)
+				ks.On("GetPolicy", context.Background(), certID).Return(&keystore.Policy{
+					IDProvoz: []int{12},
+				}, nil)
+			},
+			errs: []error{gateway.ErrCertificatePolicy},
+		},
+		{
+			name: "id_pokl not allowed",
+			setup: func(ks *mkeystore.Service) {
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), nil)
+				ks.On("ReleaseAttempt", context.Background(), certLockoutKey, false).Return(time.Duration(0), nil)
+				ks.On("Get", context.Background(), certID, certPassword).Return(randomKeyPair(), nil)
+				ks.On("GetPolicy", context.Background(), certID).Return(compiled(&keystore.Policy{
+					IDPokl: []string{"pokl-[0-9]+", "kiosk"},
+				}), nil)
+			},
+			errs: []error{gateway.ErrCertificatePolicy},
+		},
+		{
+			name: "dic_poverujiciho not allowed",
+			setup: func(ks *mkeystore.Service) {
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), nil)
+				ks.On("ReleaseAttempt", context.Background(), certLockoutKey, false).Return(time.Duration(0), nil)
+				ks.On("Get", context.Background(), certID, certPassword).Return(randomKeyPair(), nil)
+				ks.On("GetPolicy", context.Background(), certID).Return(&keystore.Policy{
+					DICPoverujiciho: "CZ683555118",
+				}, nil)
+			},
+			errs: []error{gateway.ErrCertificatePolicy},
+		},
+		{
+			name: "validity window expired",
+			setup: func(ks *mkeystore.Service) {
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), nil)
+				ks.On("ReleaseAttempt", context.Background(), certLockoutKey, false).Return(time.Duration(0), nil)
+				ks.On("Get", context.Background(), certID, certPassword).Return(randomKeyPair(), nil)
+				ks.On("GetPolicy", context.Background(), certID).Return(&keystore.Policy{
+					ValidTo: time.Now().Add(-time.Hour),
+				}, nil)
+			},
+			errs: []error{gateway.ErrCertificatePolicy},
+		},
+		{
+			name: "validity window not started",
+			setup: func(ks *mkeystore.Service) {
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), nil)
+				ks.On("ReleaseAttempt", context.Background(), certLockoutKey, false).Return(time.Duration(0), nil)
+				ks.On("Get", context.Background(), certID, certPassword).Return(randomKeyPair(), nil)
+				ks.On("GetPolicy", context.Background(), certID).Return(&keystore.Policy{
+					ValidFrom: time.Now().Add(time.Hour),
+				}, nil)
+			},
+			errs: []error{gateway.ErrCertificatePolicy},
+		},
+		{
+			name: "unknown get policy error",
+			setup: func(ks *mkeystore.Service) {
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), nil)
+				ks.On("ReleaseAttempt", context.Background(), certLockoutKey, false).Return(time.Duration(0), nil)
+				ks.On("Ping", context.Background()).Return(nil)
+				ks.On("Get", context.Background(), certID, certPassword).Return(randomKeyPair(), nil)
+				ks.On("GetPolicy", context.Background(), certID).Return(nil, errUnexpected)
+			},
+			errs: []error{gateway.ErrKeystoreUnexpected},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fscrClient := new(mfscr.Client)
+			caService := new(mfscr.CAService)
+			keystoreService := new(mkeystore.Service)
+
+			tc.setup(keystoreService)
+
+			trzba := &eet.TrzbaType{
+				Data: eet.TrzbaDataType{
+					Dicpopl:         "CZ00000019",
+					Dicpoverujiciho: "CZ1212121218",
+					Idprovoz:        11,
+					Idpokl:          "pokl-a1",
+				},
+			}
+
+			g := gateway.NewService(fscrClient, caService, keystoreService, notRevoked(), gateway.DefaultSessionPolicy, gateway.DefaultMaxSigningTimeSkew)
+			_, err := g.SendSale(context.Background(), certID, certPassword, trzba)
+			for _, e := range tc.errs {
+				require.ErrorIs(t, err, e)
+			}
+
+			fscrClient.AssertExpectations(t)
+			caService.AssertExpectations(t)
+			keystoreService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_SendSaleWithCert(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(c *mfscr.Client, cas *mfscr.CAService, rs *mrevocation.Service)
+		errs  []error
+	}{
+		{
+			name: "invalid certificate",
+			setup: func(c *mfscr.Client, cas *mfscr.CAService, rs *mrevocation.Service) {
+				cas.On("ParseTaxpayerCertificate", pkcsData, pkcsPassword).Return(nil, nil, fscr.ErrInvalidCertificate)
+			},
+			errs: []error{gateway.ErrInvalidTaxpayersCertificate},
+		},
+		{
+			name: "revoked certificate",
+			setup: func(c *mfscr.Client, cas *mfscr.CAService, rs *mrevocation.Service) {
+				cas.On("ParseTaxpayerCertificate", pkcsData, pkcsPassword).Return(certKP.Cert, certKP.PK, nil)
+				rs.On("Check", certKP.Cert).Return(revocation.ErrCertificateRevoked)
+				rs.On("Watch", certKP.Cert).Once()
+			},
+			errs: []error{gateway.ErrCertificateRevoked},
+		},
+		{
+			name: "FSCR unavailable",
+			setup: func(c *mfscr.Client, cas *mfscr.CAService, rs *mrevocation.Service) {
+				cas.On("ParseTaxpayerCertificate", pkcsData, pkcsPassword).Return(certKP.Cert, certKP.PK, nil)
+				rs.On("Check", certKP.Cert).Return(nil)
+				rs.On("Watch", certKP.Cert).Once()
+				c.On("Do", context.Background(), mock.Anything).Return(nil, errUnexpected)
+			},
+			errs: []error{gateway.ErrFSCRConnection},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fscrClient := new(mfscr.Client)
+			caService := new(mfscr.CAService)
+			keystoreService := new(mkeystore.Service)
+			revocationService := new(mrevocation.Service)
+
+			tc.setup(fscrClient, caService, revocationService)
+
+			trzba := &eet.TrzbaType{
+				Data: eet.TrzbaDataType{
+					Idprovoz: 11,
+				},
+			}
+
+			// the keystore isn't used
+			g := gateway.NewService(fscrClient, caService, keystoreService, revocationService, gateway.DefaultSessionPolicy, gateway.DefaultMaxSigningTimeSkew)
+			_, err := g.SendSaleWithCert(context.Background(), pkcsData, pkcsPassword, trzba)
+			for _, e := range tc.errs {
+				require.ErrorIs(t, err, e)
+			}
+
+			fscrClient.AssertExpectations(t)
+			caService.AssertExpectations(t)
+			keystoreService.AssertExpectations(t)
+			revocationService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_WatchOnce(t *testing.T) {
+	fscrClient := new(mfscr.Client)
+	caService := new(mfscr.CAService)
+	keystoreService := new(mkeystore.Service)
+	revocationService := new(mrevocation.Service)
+
+	caService.On("ParseTaxpayerCertificate", pkcsData, pkcsPassword).Return(certKP.Cert, certKP.PK, nil)
+	revocationService.On("Watch", certKP.Cert).Once()
+	revocationService.On("Check", certKP.Cert).Return(nil).Twice()
+
+	// the certificate is registered by its first use only
+	g := gateway.NewService(fscrClient, caService, keystoreService, revocationService, gateway.DefaultSessionPolicy, gateway.DefaultMaxSigningTimeSkew)
+	for i := 0; i < 2; i++ {
+		_, err := g.SignSaleWithCert(context.Background(), pkcsData, pkcsPassword, &eet.TrzbaType{})
+		require.NoError(t, err)
+	}
+
+	caService.AssertExpectations(t)
+	revocationService.AssertExpectations(t)
+	revocationService.AssertNumberOfCalls(t, "Watch", 1)
+}
+
+func TestService_SendSOAP(t *testing.T) {
+	uuid := "878b2e10-c4a5-4f05-8c90-abc181cd6837"
+	trzba := func() *eet.TrzbaType {
+		return &eet.TrzbaType{
+			Hlavicka: eet.TrzbaHlavickaType{
+				Uuidzpravy: eet.UUIDType(uuid),
+			},
+			Data: eet.TrzbaDataType{
+				Dicpopl:   "CZ00000019",
+				Idprovoz:  11,
+				Idpokl:    "pokl-1",
+				Poradcis:  "123",
+				Celktrzba: 100,
+			},
+		}
+	}
+	bareTrzba := func(trzba *eet.TrzbaType) []byte {
+		req, err := xml.Marshal(trzba)
+		require.NoError(t, err)
+		return bytes.Replace(req, []byte("TrzbaType"), []byte("Trzba"), 2)
+	}
+	// temporary error responses are the only unsigned responses passing the verification
+	tmpErrResp := []byte(`<soapenv:Envelope xmlns:soapenv="http://schemas.xmlsoap.org/soap/envelope/"><soapenv:Body>` +
+		`<eet:Odpoved xmlns:eet="http://fs.mfcr.cz/eet/schema/v3"><eet:Hlavicka uuid_zpravy="` + uuid + `"/>` +
+		`<eet:Chyba kod="-1">Docasna technicka chyba zpracovani</eet:Chyba></eet:Odpoved></soapenv:Body></soapenv:Envelope>`)
+	faultResp := []byte(`<soapenv:Envelope xmlns:soapenv="http://schemas.xmlsoap.org/soap/envelope/"><soapenv:Body>` +
+		`<soapenv:Fault><faultcode>soapenv:Client</faultcode><faultstring>Nespravny format zpravy</faultstring></soapenv:Fault>` +
+		`</soapenv:Body></soapenv:Envelope>`)
+
+	tests := []struct {
+		name    string
+		req     func(kp *keystore.KeyPair) []byte
+		setup   func(ks *mkeystore.Service, c *mfscr.Client, kp *keystore.KeyPair)
+		expResp []byte
+		errs    []error
+	}{
+		{
+			name: "codes computed",
+			req: func(*keystore.KeyPair) []byte {
+				return bareTrzba(trzba())
+			},
+			setup: func(ks *mkeystore.Service, c *mfscr.Client, kp *keystore.KeyPair) {
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), nil)
+				ks.On("ReleaseAttempt", context.Background(), certLockoutKey, false).Return(time.Duration(0), nil)
+				ks.On("Get", context.Background(), certID, certPassword).Return(kp, nil)
+				ks.On("GetPolicy", context.Background(), certID).Return(certPolicy, nil)
+				// the key pair is zeroized once the sale is sent
+				pub := kp.Cert.PublicKey
+				c.On("Do", context.Background(), mock.MatchedBy(func(env []byte) bool {
+					parsed, err := eet.ParseRequest(env)
+					return err == nil && len(parsed.KontrolniKody.Pkp.PkpType) > 0 && parsed.VerifySecurityCodes(pub) == nil
+				})).Return(tmpErrResp, nil)
+			},
+			expResp: tmpErrResp,
+		},
+		{
+			name: "codes verified",
+			req: func(kp *keystore.KeyPair) []byte {
+				trzba := trzba()
+				require.NoError(t, trzba.SetSecurityCodes(kp.PK))
+				env, err := eet.NewRequestEnvelope(trzba, kp.Cert, kp.PK)
+				require.NoError(t, err)
+				return env
+			},
+			setup: func(ks *mkeystore.Service, c *mfscr.Client, kp *keystore.KeyPair) {
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), nil)
+				ks.On("ReleaseAttempt", context.Background(), certLockoutKey, false).Return(time.Duration(0), nil)
+				ks.On("Get", context.Background(), certID, certPassword).Return(kp, nil)
+				ks.On("GetPolicy", context.Background(), certID).Return(certPolicy, nil)
+				c.On("Do", context.Background(), mock.Anything).Return(tmpErrResp, nil)
+			},
+			expResp: tmpErrResp,
+		},
+		{
+			name: "codes of another certificate",
+			req: func(*keystore.KeyPair) []byte {
+				trzba := trzba()
+				require.NoError(t, trzba.SetSecurityCodes(randomKeyPair().PK))
+				return bareTrzba(trzba)
+			},
+			setup: func(ks *mkeystore.Service, c *mfscr.Client, kp *keystore.KeyPair) {
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), nil)
+				ks.On("ReleaseAttempt", context.Background(), certLockoutKey, false).Return(time.Duration(0), nil)
+				ks.On("Get", context.Background(), certID, certPassword).Return(kp, nil)
+			},
+			errs: []error{gateway.ErrInvalidSecurityCodes},
+		},
+		{
+			name: "invalid request",
+			req: func(*keystore.KeyPair) []byte {
+				return []byte(`<Odpoved/>`)
+			},
+			setup: func(ks *mkeystore.Service, c *mfscr.Client, kp *keystore.KeyPair) {},
+			errs:  []error{gateway.ErrInvalidSOAPRequest},
+		},
+		{
+			name: "invalid certificate password",
+			req: func(*keystore.KeyPair) []byte {
+				return bareTrzba(trzba())
+			},
+			setup: func(ks *mkeystore.Service, c *mfscr.Client, kp *keystore.KeyPair) {
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), nil)
+				ks.On("Get", context.Background(), certID, certPassword).Return(nil, keystore.ErrInvalidDecryptionKey)
+				ks.On("ReleaseAttempt", context.Background(), certLockoutKey, true).Return(time.Duration(0), nil)
+			},
+			errs: []error{gateway.ErrInvalidCertificatePassword},
+		},
+		{
+			name: "id_provoz not allowed",
+			req: func(*keystore.KeyPair) []byte {
+				return bareTrzba(trzba())
+			},
+			setup: func(ks *mkeystore.Service, c *mfscr.Client, kp *keystore.KeyPair) {
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), nil)
+				ks.On("ReleaseAttempt", context.Background(), certLockoutKey, false).Return(time.Duration(0), nil)
+				ks.On("Get", context.Background(), certID, certPassword).Return(kp, nil)
+				ks.On("GetPolicy", context.Background(), certID).Return(&keystore.Policy{
+					IDProvoz: []int{12},
+				}, nil)
+			},
+			errs: []error{gateway.ErrCertificatePolicy},
+		},
+		{
+			name: "FSCR fault",
+			req: func(*keystore.KeyPair) []byte {
+				return bareTrzba(trzba())
+			},
+			setup: func(ks *mkeystore.Service, c *mfscr.Client, kp *keystore.KeyPair) {
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), nil)
+				ks.On("ReleaseAttempt", context.Background(), certLockoutKey, false).Return(time.Duration(0), nil)
+				ks.On("Get", context.Background(), certID, certPassword).Return(kp, nil)
+				ks.On("GetPolicy", context.Background(), certID).Return(certPolicy, nil)
+				c.On("Do", context.Background(), mock.Anything).Return(faultResp, nil)
+			},
+			errs: []error{gateway.ErrFSCRFault},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fscrClient := new(mfscr.Client)
+			caService := new(mfscr.CAService)
+			keystoreService := new(mkeystore.Service)
+
+			kp := randomKeyPair()
+			req := tc.req(kp)
+			tc.setup(keystoreService, fscrClient, kp)
+
+			g := gateway.NewService(fscrClient, caService, keystoreService, notRevoked(), gateway.DefaultSessionPolicy, gateway.DefaultMaxSigningTimeSkew)
+			resp, err := g.SendSOAP(context.Background(), certID, certPassword, req)
+			for _, e := range tc.errs {
+				require.ErrorIs(t, err, e)
+			}
+
+			if tc.errs == nil {
+				require.NoError(t, err)
+				require.Equal(t, tc.expResp, resp)
+			}
+
+			fscrClient.AssertExpectations(t)
+			caService.AssertExpectations(t)
+			keystoreService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_StoreCert(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy *keystore.Policy
+		setup  func(cas *mfscr.CAService, ks *mkeystore.Service)
+		errs   []error
+	}{
+		{
+			name:   "ok",
+			policy: certPolicy,
+			setup: func(cas *mfscr.CAService, ks *mkeystore.Service) {
+				cas.On("ParseTaxpayerCertificate", pkcsData, pkcsPassword).Return(certKP.Cert, certKP.PK, nil)
+				ks.On("Store", context.Background(), certID, certPassword, certKP, certPolicy).Return(nil)
+			},
+			errs: nil,
+		},
+		{
+			name:   "invalid certificate",
+			policy: certPolicy,
+			setup: func(cas *mfscr.CAService, ks *mkeystore.Service) {
+				cas.On("ParseTaxpayerCertificate", pkcsData, pkcsPassword).Return(nil, nil, fscr.ErrInvalidCertificate)
+			},
+			errs: []error{gateway.ErrInvalidTaxpayersCertificate},
+		},
+		{
+			name:   "unknown certificate parse error",
+			policy: certPolicy,
+			setup: func(cas *mfscr.CAService, ks *mkeystore.Service) {
+				cas.On("ParseTaxpayerCertificate", pkcsData, pkcsPassword).Return(nil, nil, errUnexpected)
+			},
+			errs: []error{gateway.ErrCertificateParse},
+		},
+		{
+			name:   "id already exists",
+			policy: certPolicy,
+			setup: func(cas *mfscr.CAService, ks *mkeystore.Service) {
+				cas.On("ParseTaxpayerCertificate", pkcsData, pkcsPassword).Return(certKP.Cert, certKP.PK, nil)
+				ks.On("Store", context.Background(), certID, certPassword, certKP, certPolicy).Return(keystore.ErrIDAlreadyExists)
+			},
+			errs: []error{gateway.ErrIDAlreadyExists},
+		},
+		{
+			name:   "max tries of db transactions",
+			policy: certPolicy,
+			setup: func(cas *mfscr.CAService, ks *mkeystore.Service) {
+				cas.On("ParseTaxpayerCertificate", pkcsData, pkcsPassword).Return(certKP.Cert, certKP.PK, nil)
+				ks.On("Store", context.Background(), certID, certPassword, certKP, certPolicy).Return(keystore.ErrReachedMaxAttempts)
+			},
+			errs: []error{gateway.ErrMaxTXAttempts},
+		},
+		{
+			name:   "unknown certificate store error",
+			policy: certPolicy,
+			setup: func(cas *mfscr.CAService, ks *mkeystore.Service) {
+				cas.On("ParseTaxpayerCertificate", pkcsData, pkcsPassword).Return(certKP.Cert, certKP.PK, nil)
+				ks.On("Ping", context.Background()).Return(nil)
+				ks.On("Store", context.Background(), certID, certPassword, certKP, certPolicy).Return(errUnexpected)
+			},
+			errs: []error{gateway.ErrKeystoreUnexpected},
+		},
+		{
+			name:   "invalid policy",
+			policy: &keystore.Policy{IDPokl: []string{"pokl-[0-9"}},
+			setup:  func(cas *mfscr.CAService, ks *mkeystore.Service) {},
+			errs:   []error{gateway.ErrInvalidCertificatePolicy},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fscrClient := new(mfscr.Client)
+			caService := new(mfscr.CAService)
+			keystoreService := new(mkeystore.Service)
+
+			tc.setup(caService, keystoreService)
+
+			g := gateway.NewService(fscrClient, caService, keystoreService, notRevoked(), gateway.DefaultSessionPolicy, gateway.DefaultMaxSigningTimeSkew)
+			err := g.StoreCert(context.Background(), certID, certPassword, pkcsData, pkcsPassword, tc.policy)
+			if tc.errs == nil {
+				require.NoError(t, err)
+			} else {
+				for _, e := range tc.errs {
+					require.ErrorIs(t, err, e)
+				}
+			}
+
+			fscrClient.AssertExpectations(t)
+			caService.AssertExpectations(t)
+			keystoreService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_StoreCertPEM(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy *keystore.Policy
+		setup  func(cas *mfscr.CAService, ks *mkeystore.Service)
+		errs   []error
+	}{
+		{
+			name:   "ok",
+			policy: certPolicy,
+			setup: func(cas *mfscr.CAService, ks *mkeystore.Service) {
+				cas.On("ParseTaxpayerCertificatePEM", pemCertData, pemKeyData).Return(certKP.Cert, certKP.PK, nil)
+				ks.On("Store", context.Background(), certID, certPassword, certKP, certPolicy).Return(nil)
+			},
+			errs: nil,
+		},
+		{
+			name:   "invalid certificate",
+			policy: certPolicy,
+			setup: func(cas *mfscr.CAService, ks *mkeystore.Service) {
+				cas.On("ParseTaxpayerCertificatePEM", pemCertData, pemKeyData).Return(nil, nil, fscr.ErrInvalidCertificate)
+			},
+			errs: []error{gateway.ErrInvalidTaxpayersCertificate},
+		},
+		{
+			name:   "id already exists",
+			policy: certPolicy,
+			setup: func(cas *mfscr.CAService, ks *mkeystore.Service) {
+				cas.On("ParseTaxpayerCertificatePEM", pemCertData, pemKeyData).Return(certKP.Cert, certKP.PK, nil)
+				ks.On("Store", context.Background(), certID, certPassword, certKP, certPolicy).Return(keystore.ErrIDAlreadyExists)
+			},
+			errs: []error{gateway.ErrIDAlreadyExists},
+		},
+		{
+			name:   "invalid policy",
+			policy: &keystore.Policy{IDPokl: []string{"pokl-[0-9"}},
+			setup:  func(cas *mfscr.CAService, ks *mkeystore.Service) {},
+			errs:   []error{gateway.ErrInvalidCertificatePolicy},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fscrClient := new(mfscr.Client)
+			caService := new(mfscr.CAService)
+			keystoreService := new(mkeystore.Service)
+
+			tc.setup(caService, keystoreService)
+
+			g := gateway.NewService(fscrClient, caService, keystoreService, notRevoked(), gateway.DefaultSessionPolicy, gateway.DefaultMaxSigningTimeSkew)
+			err := g.StoreCertPEM(context.Background(), certID, certPassword, pemCertData, pemKeyData, tc.policy)
+			if tc.errs == nil {
+				require.NoError(t, err)
+			} else {
+				for _, e := range tc.errs {
+					require.ErrorIs(t, err, e)
+				}
+			}
+
+			fscrClient.AssertExpectations(t)
+			caService.AssertExpectations(t)
+			keystoreService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_StoreCertSigner(t *testing.T) {
+	external := &keystore.KeyPair{
+		Cert:   certKP.Cert,
+		Signer: certKP.PK,
+	}
+
+	tests := []struct {
+		name  string
+		setup func(cas *mfscr.CAService, ks *mkeystore.Service)
+		errs  []error
+	}{
+		{
+			name: "ok",
+			setup: func(cas *mfscr.CAService, ks *mkeystore.Service) {
+				cas.On("ParseTaxpayerCertificateSigner", pemCertData, certKP.PK).Return(certKP.Cert, nil)
+				ks.On("Store", context.Background(), certID, certPassword, external, certPolicy).Return(nil)
+			},
+		},
+		{
+			name: "signer of another certificate",
+			setup: func(cas *mfscr.CAService, ks *mkeystore.Service) {
+				cas.On("ParseTaxpayerCertificateSigner", pemCertData, certKP.PK).Return(nil, fscr.ErrInvalidCertificate)
+			},
+			errs: []error{gateway.ErrInvalidTaxpayersCertificate},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fscrClient := new(mfscr.Client)
+			caService := new(mfscr.CAService)
+			keystoreService := new(mkeystore.Service)
+
+			tc.setup(caService, keystoreService)
+
+			g := gateway.NewService(fscrClient, caService, keystoreService, notRevoked(), gateway.DefaultSessionPolicy, gateway.DefaultMaxSigningTimeSkew)
+			err := g.StoreCertSigner(context.Background(), certID, certPassword, pemCertData, certKP.PK, certPolicy)
+			if tc.errs == nil {
+				require.NoError(t, err)
+			} else {
+				for _, e := range tc.errs {
+					require.ErrorIs(t, err, e)
+				}
+			}
+
+			fscrClient.AssertExpectations(t)
+			caService.AssertExpectations(t)
+			keystoreService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_ExportCert(t *testing.T) {
+	tests := []struct {
+		name  string
+		kp    *keystore.KeyPair
+		setup func(ks *mkeystore.Service, kp *keystore.KeyPair)
+		errs  []error
+	}{
+		{
+			name: "ok",
+			kp:   randomKeyPair(),
+			setup: func(ks *mkeystore.Service, kp *keystore.KeyPair) {
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), nil)
+				ks.On("ReleaseAttempt", context.Background(), certLockoutKey, false).Return(time.Duration(0), nil)
+				ks.On("Get", context.Background(), certID, certPassword).Return(kp, nil)
+			},
+			errs: nil,
+		},
+		{
+			name: "certificate locked",
+			setup: func(ks *mkeystore.Service, kp *keystore.KeyPair) {
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), keystore.ErrKeyLocked)
+			},
+			errs: []error{gateway.ErrCertificateLocked},
+		},
+		{
+			name: "certificate not found",
+			setup: func(ks *mkeystore.Service, kp *keystore.KeyPair) {
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), nil)
+				ks.On("ReleaseAttempt", context.Background(), certLockoutKey, false).Return(time.Duration(0), nil)
+				ks.On("Get", context.Background(), certID, certPassword).Return(nil, keystore.ErrRecordNotFound)
+			},
+			errs: []error{gateway.ErrCertificateNotFound},
+		},
+		{
+			name: "invalid certificate password",
+			setup: func(ks *mkeystore.Service, kp *keystore.KeyPair) {
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), nil)
+				ks.On("Get", context.Background(), certID, certPassword).Return(nil, keystore.ErrInvalidDecryptionKey)
+				ks.On("ReleaseAttempt", context.Background(), certLockoutKey, true).Return(time.Duration(0), nil)
+			},
+			errs: []error{gateway.ErrInvalidCertificatePassword},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fscrClient := new(mfscr.Client)
+			caService := new(mfscr.CAService)
+			keystoreService := new(mkeystore.Service)
+
+			var cert *x509.Certificate
+			if tc.kp != nil {
+				cert = tc.kp.Cert
+			}
+
+			tc.setup(keystoreService, tc.kp)
+
+			g := gateway.NewService(fscrClient, caService, keystoreService, notRevoked(), gateway.DefaultSessionPolicy, gateway.DefaultMaxSigningTimeSkew)
+			data, err := g.ExportCert(context.Background(), certID, certPassword, pkcsPassword)
+			if tc.errs == nil {
+				require.NoError(t, err)
+
+				_, gotCert, err := pkcs12.Decode(data, pkcsPassword)
+				require.NoError(t, err)
+				require.True(t, cert.Equal(gotCert))
+			} else {
+				for _, e := range tc.errs {
+					require.ErrorIs(t, err, e)
+				}
+			}
+
+			fscrClient.AssertExpectations(t)
+			caService.AssertExpectations(t)
+			keystoreService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_ListCertIDs(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(ks *mkeystore.Service)
+		errs  []error
+	}{
+		{
+			name: "ok",
+			setup: func(ks *mkeystore.Service) {
+				ks.On("List", context.Background(), int64(0), int64(0)).Return([]string{certID}, nil)
+			},
+			errs: nil,
+		},
+		{
+			name: "unknown list certificates error",
+			setup: func(ks *mkeystore.Service) {
+				ks.On("Ping", context.Background()).Return(nil)
+				ks.On("List", context.Background(), int64(0), int64(0)).Return(nil, errUnexpected)
+			},
+			errs: []error{gateway.ErrKeystoreUnexpected},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fscrClient := new(mfscr.Client)
+			caService := new(mfscr.CAService)
+			keystoreService := new(mkeystore.Service)
+
+			tc.setup(keystoreService)
+
+			g := gateway.NewService(fscrClient, caService, keystoreService, notRevoked(), gateway.DefaultSessionPolicy, gateway.DefaultMaxSigningTimeSkew)
+			ids, err := g.ListCertIDs(context.Background(), 0, 0)
+			if tc.errs == nil {
+				require.NoError(t, err)
+				require.NotEmpty(t, ids)
+			} else {
+				for _, e := range tc.errs {
+					require.ErrorIs(t, err, e)
+				}
+			}
+
+			fscrClient.AssertExpectations(t)
+			caService.AssertExpectations(t)
+			keystoreService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_UpdateCertID(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(ks *mkeystore.Service)
+		errs  []error
+	}{
+		{
+			name: "ok",
+			setup: func(ks *mkeystore.Service) {
+				ks.On("UpdateID", context.Background(), certID, certID2).Return(nil)
+			},
+			errs: nil,
+		},
+		{
+			name: "certificate not found",
+			setup: func(ks *mkeystore.Service) {
+				ks.On("UpdateID", context.Background(), certID, certID2).Return(keystore.ErrRecordNotFound)
+			},
+			errs: []error{gateway.ErrCertificateNotFound},
+		},
+		{
+			name: "certificate id already exists",
+			setup: func(ks *mkeystore.Service) {
+				ks.On("UpdateID", context.Background(), certID, certID2).Return(keystore.ErrIDAlreadyExists)
+			},
+			errs: []error{gateway.ErrIDAlreadyExists},
+		},
+		{
+			name: "max tries of db transactions",
+			setup: func(ks *mkeystore.Service) {
+				ks.On("UpdateID", context.Background(), certID, certID2).Return(keystore.ErrReachedMaxAttempts)
+			},
+			errs: []error{gateway.ErrMaxTXAttempts},
+		},
+		{
+			name: "unknown update certificate id error",
+			setup: func(ks *mkeystore.Service) {
+				ks.On("Ping", context.Background()).Return(nil)
+				ks.On("UpdateID", context.Background(), certID, certID2).Return(errUnexpected, errUnexpected)
+			},
+			errs: []error{gateway.ErrKeystoreUnexpected},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fscrClient := new(mfscr.Client)
+			caService := new(mfscr.CAService)
+			keystoreService := new(mkeystore.Service)
+
+			tc.setup(keystoreService)
+
+			g := gateway.NewService(fscrClient, caService, keystoreService, notRevoked(), gateway.DefaultSessionPolicy, gateway.DefaultMaxSigningTimeSkew)
+			err := g.UpdateCertID(context.Background(), certID, certID2)
+			if tc.errs == nil {
+				require.NoError(t, err)
+			} else {
+				for _, e := range tc.errs {
+					require.ErrorIs(t, err, e)
+				}
+			}
+
+			fscrClient.AssertExpectations(t)
+			caService.AssertExpectations(t)
+			keystoreService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_UpdateCertPassword(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(ks *mkeystore.Service)
+		errs  []error
+	}{
+		{
+			name: "ok",
+			setup: func(ks *mkeystore.Service) {
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), nil)
+				ks.On("ReleaseAttempt", context.Background(), certLockoutKey, false).Return(time.Duration(0), nil)
+				ks.On("UpdatePassword", context.Background(), certID, certPassword, certPassword2).Return(nil)
+			},
+			errs: nil,
+		},
+		{
+			name: "certificate not found",
+			setup: func(ks *mkeystore.Service) {
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), nil)
+				ks.On("ReleaseAttempt", context.Background(), certLockoutKey, false).Return(time.Duration(0), nil)
+				ks.On("UpdatePassword", context.Background(), certID, certPassword, certPassword2).Return(keystore.ErrRecordNotFound)
+			},
+			errs: []error{gateway.ErrCertificateNotFound},
+		},
+		{
+			name: "invalid certificate password",
+			setup: func(ks *mkeystore.Service) {
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), nil)
+				ks.On("UpdatePassword", context.Background(), certID, certPassword, certPassword2).Return(keystore.ErrInvalidDecryptionKey)
+				ks.On("ReleaseAttempt", context.Background(), certLockoutKey, true).Return(time.Duration(0), nil)
+			},
+			errs: []error{gateway.ErrInvalidCertificatePassword},
+		},
+		{
+			name: "certificate locked",
+			setup: func(ks *mkeystore.Service) {
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), keystore.ErrKeyLocked)
+			},
+			errs: []error{gateway.ErrCertificateLocked},
+		},
+		{
+			name: "max tries of db transactions",
+			setup: func(ks *mkeystore.Service) {
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), nil)
+				ks.On("ReleaseAttempt", context.Background(), certLockoutKey, false).Return(time.Duration(0), nil)
+				ks.On("UpdatePassword", context.Background(), certID, certPassword, certPassword2).Return(keystore.ErrReachedMaxAttempts)
+			},
+			errs: []error{gateway.ErrMaxTXAttempts},
+		},
+		{
+			name: "unknown update certificate password error",
+			setup: func(ks *mkeystore.Service) {
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), nil)
+				ks.On("ReleaseAttempt", context.Background(), certLockoutKey, false).Return(time.Duration(0), nil)
+				ks.On("Ping", context.Background()).Return(nil)
+				ks.On("UpdatePassword", context.Background(), certID, certPassword, certPassword2).Return(errUnexpected)
+			},
+			errs: []error{gateway.ErrKeystoreUnexpected},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fscrClient := new(mfscr.Client)
+			caService := new(mfscr.CAService)
+			keystoreService := new(mkeystore.Service)
+
+			tc.setup(keystoreService)
+
+			g := gateway.NewService(fscrClient, caService, keystoreService, notRevoked(), gateway.DefaultSessionPolicy, gateway.DefaultMaxSigningTimeSkew)
+			err := g.UpdateCertPassword(context.Background(), certID, certPassword, certPassword2)
+			if tc.errs == nil {
+				require.NoError(t, err)
+			} else {
+				for _, e := range tc.errs {
+					require.ErrorIs(t, err, e)
+				}
+			}
+
+			fscrClient.AssertExpectations(t)
+			caService.AssertExpectations(t)
+			keystoreService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_UpdateCertPolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy *keystore.Policy
+		setup  func(ks *mkeystore.Service)
+		errs   []error
+	}{
+		{
+			name:   "ok",
+			policy: certPolicy,
+			setup: func(ks *mkeystore.Service) {
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), nil)
+				ks.On("ReleaseAttempt", context.Background(), certLockoutKey, false).Return(time.Duration(0), nil)
+				ks.On("Get", context.Background(), certID, certPassword).Return(randomKeyPair(), nil)
+				ks.On("UpdatePolicy", context.Background(), certID, certPolicy).Return(nil)
+			},
+			errs: nil,
+		},
+		{
+			name:   "remove restrictions",
+			policy: nil,
+			setup: func(ks *mkeystore.Service) {
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), nil)
+				ks.On("ReleaseAttempt", context.Background(), certLockoutKey, false).Return(time.Duration(0), nil)
+				ks.On("Get", context.Background(), certID, certPassword).Return(randomKeyPair(), nil)
+				ks.On("UpdatePolicy", context.Background(), certID, (*keystore.Policy)(nil)).Return(nil)
+			},
+			errs: nil,
+		},
+		{
+			name: "invalid validity window",
+			policy: &keystore.Policy{
+				ValidFrom: time.Now(),
+				ValidTo:   time.Now().Add(-time.Hour),
+			},
+			setup: func(ks *mkeystore.Service) {},
+			errs:  []error{gateway.ErrInvalidCertificatePolicy, keystore.ErrInvalidPolicy},
+		},
+		{
+			name: "invalid id_pokl pattern",
+			policy: &keystore.Policy{
+				IDPokl: []string{"pokl-[0-9"},
+			},
+			setup: func(ks *mkeystore.Service) {},
+			errs:  []error{gateway.ErrInvalidCertificatePolicy, keystore.ErrInvalidPolicy},
+		},
+		{
+			name:   "invalid password",
+			policy: certPolicy,
+			setup: func(ks *mkeystore.Service) {
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), nil)
+				ks.On("Get", context.Background(), certID, certPassword).Return(nil, keystore.ErrInvalidDecryptionKey)
+				ks.On("ReleaseAttempt", context.Background(), certLockoutKey, true).Return(time.Duration(0), nil)
+			},
+			errs: []error{gateway.ErrInvalidCertificatePassword},
+		},
+		{
+			name:   "locked certificate",
+			policy: certPolicy,
+			setup: func(ks *mkeystore.Service) {
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), keystore.ErrKeyLocked)
+			},
+			errs: []error{gateway.ErrCertificateLocked},
+		},
+		{
+			name:   "certificate not found",
+			policy: certPolicy,
+			setup: func(ks *mkeystore.Service) {
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), nil)
+				ks.On("ReleaseAttempt", context.Background(), certLockoutKey, false).Return(time.Duration(0), nil)
+				ks.On("Get", context.Background(), certID, certPassword).Return(nil, keystore.ErrRecordNotFound)
+			},
+			errs: []error{gateway.ErrCertificateNotFound},
+		},
+		{
+			name:   "unknown update certificate policy error",
+			policy: certPolicy,
+			setup: func(ks *mkeystore.Service) {
+				ks.On("Ping", context.Background()).Return(nil)
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), nil)
+				ks.On("ReleaseAttempt", context.Background(), certLockoutKey, false).Return(time.Duration(0), nil)
+				ks.On("Get", context.Background(), certID, certPassword).Return(randomKeyPair(), nil)
+				ks.On("UpdatePolicy", context.Background(), certID, certPolicy).Return(errUnexpected)
+			},
+			errs: []error{gateway.ErrKeystoreUnexpected},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fscrClient := new(mfscr.Client)
+			caService := new(mfscr.CAService)
+			keystoreService := new(mkeystore.Service)
+
+			tc.setup(keystoreService)
+
+			g := gateway.NewService(fscrClient, caService, keystoreService, notRevoked(), gateway.DefaultSessionPolicy, gateway.DefaultMaxSigningTimeSkew)
+			err := g.UpdateCertPolicy(context.Background(), certID, certPassword, tc.policy)
+			if tc.errs == nil {
+				require.NoError(t, err)
+			} else {
+				for _, e := range tc.errs {
+					require.ErrorIs(t, err, e)
+				}
+			}
+
+			fscrClient.AssertExpectations(t)
+			caService.AssertExpectations(t)
+			keystoreService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_SendSaleLockout(t *testing.T) {
+	clientLockoutKey := "client:192.0.2.1"
+	ctx := gateway.WithClientAddr(context.Background(), "192.0.2.1")
+
+	tests := []struct {
+		name  string
+		setup func(ks *mkeystore.Service)
+		errs  []error
+	}{
+		{
+			name: "client locked",
+			setup: func(ks *mkeystore.Service) {
+				ks.On("ReserveAttempt", ctx, certLockoutKey).Return(int64(0), nil)
+				ks.On("ReleaseAttempt", ctx, certLockoutKey, false).Return(time.Duration(0), nil)
+				ks.On("ReserveAttempt", ctx, clientLockoutKey).Return(int64(0), keystore.ErrKeyLocked)
+			},
+			errs: []error{gateway.ErrCertificateLocked},
+		},
+		{
+			name: "too many attempts in progress",
+			setup: func(ks *mkeystore.Service) {
+				ks.On("ReserveAttempt", ctx, certLockoutKey).Return(int64(0), keystore.ErrTooManyAttempts)
+			},
+			errs: []error{gateway.ErrTooManyAttempts},
+		},
+		{
+			name: "failure recorded for both",
+			setup: func(ks *mkeystore.Service) {
+				ks.On("ReserveAttempt", ctx, certLockoutKey).Return(int64(0), nil)
+				ks.On("ReserveAttempt", ctx, clientLockoutKey).Return(int64(0), nil)
+				ks.On("Get", ctx, certID, certPassword).Return(nil, keystore.ErrInvalidDecryptionKey)
+				ks.On("ReleaseAttempt", ctx, certLockoutKey, true).Return(time.Duration(0), nil)
+				ks.On("ReleaseAttempt", ctx, clientLockoutKey, true).Return(time.Second, nil)
+			},
+			errs: []error{gateway.ErrInvalidCertificatePassword},
+		},
+		{
+			name: "unavailable keystore",
+			setup: func(ks *mkeystore.Service) {
+				ks.On("Ping", ctx).Return(errUnexpected)
+				ks.On("ReserveAttempt", ctx, certLockoutKey).Return(int64(0), errUnexpected)
+			},
+			errs: []error{gateway.ErrKeystoreUnavailable},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fscrClient := new(mfscr.Client)
+			caService := new(mfscr.CAService)
+			keystoreService := new(mkeystore.Service)
+
+			tc.setup(keystoreService)
+
+			g := gateway.NewService(fscrClient, caService, keystoreService, notRevoked(), gateway.DefaultSessionPolicy, gateway.DefaultMaxSigningTimeSkew)
+			_, err := g.SendSale(ctx, certID, certPassword, &eet.TrzbaType{})
+			for _, e := range tc.errs {
+				require.ErrorIs(t, err, e)
+			}
+
+			fscrClient.AssertExpectations(t)
+			caService.AssertExpectations(t)
+			keystoreService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_OpenSession(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(ks *mkeystore.Service)
+		errs  []error
+	}{
+		{
+			name: "ok",
+			setup: func(ks *mkeystore.Service) {
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), nil)
+				ks.On("ReleaseAttempt", context.Background(), certLockoutKey, false).Return(time.Duration(0), nil)
+				ks.On("Get", context.Background(), certID, certPassword).Return(randomKeyPair(), nil)
+			},
+			errs: nil,
+		},
+		{
+			name: "certificate locked",
+			setup: func(ks *mkeystore.Service) {
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), keystore.ErrKeyLocked)
+			},
+			errs: []error{gateway.ErrCertificateLocked},
+		},
+		{
+			name: "certificate not found",
+			setup: func(ks *mkeystore.Service) {
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), nil)
+				ks.On("ReleaseAttempt", context.Background(), certLockoutKey, false).Return(time.Duration(0), nil)
+				ks.On("Get", context.Background(), certID, certPassword).Return(nil, keystore.ErrRecordNotFound)
+			},
+			errs: []error{gateway.ErrCertificateNotFound},
+		},
+		{
+			name: "invalid certificate password",
+			setup: func(ks *mkeystore.Service) {
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), nil)
+				ks.On("Get", context.Background(), certID, certPassword).Return(nil, keystore.ErrInvalidDecryptionKey)
+				ks.On("ReleaseAttempt", context.Background(), certLockoutKey, true).Return(time.Duration(0), nil)
+			},
+			errs: []error{gateway.ErrInvalidCertificatePassword},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fscrClient := new(mfscr.Client)
+			caService := new(mfscr.CAService)
+			keystoreService := new(mkeystore.Service)
+
+			tc.setup(keystoreService)
+
+			g := gateway.NewService(fscrClient, caService, keystoreService, notRevoked(), gateway.DefaultSessionPolicy, gateway.DefaultMaxSigningTimeSkew)
+			token, expiresAt, err := g.OpenSession(context.Background(), certID, certPassword)
+			if tc.errs == nil {
+				require.NoError(t, err)
+				require.NotEmpty(t, token)
+				require.True(t, expiresAt.After(time.Now()))
+			} else {
+				for _, e := range tc.errs {
+					require.ErrorIs(t, err, e)
+				}
+			}
+
+			fscrClient.AssertExpectations(t)
+			caService.AssertExpectations(t)
+			keystoreService.AssertExpectations(t)
+		})
+	}
+}
+
+// failingSigner is an external signer whose remote signing service fails.
+type failingSigner struct {
+	pub crypto.PublicKey
+}
+
+func (s failingSigner) Public() crypto.PublicKey {
+	return s.pub
+}
+
+func (s failingSigner) Sign(io.Reader, []byte, crypto.SignerOpts) ([]byte, error) {
+	return nil, keystore.ErrRemoteSigner
+}
+
+func TestService_ComputeCodes(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(ks *mkeystore.Service)
+		errs  []error
+	}{
+		{
+			name: "ok",
+			setup: func(ks *mkeystore.Service) {
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), nil)
+				ks.On("ReleaseAttempt", context.Background(), certLockoutKey, false).Return(time.Duration(0), nil)
+				ks.On("Get", context.Background(), certID, certPassword).Return(randomKeyPair(), nil)
+				ks.On("GetPolicy", context.Background(), certID).Return(certPolicy, nil)
+			},
+		},
+		{
+			name: "invalid certificate password",
+			setup: func(ks *mkeystore.Service) {
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), nil)
+				ks.On("Get", context.Background(), certID, certPassword).Return(nil, keystore.ErrInvalidDecryptionKey)
+				ks.On("ReleaseAttempt", context.Background(), certLockoutKey, true).Return(time.Duration(0), nil)
+			},
+			errs: []error{gateway.ErrInvalidCertificatePassword},
+		},
+		{
+			name: "id_provoz not allowed",
+			setup: func(ks *mkeystore.Service) {
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), nil)
+				ks.On("ReleaseAttempt", context.Background(), certLockoutKey, false).Return(time.Duration(0), nil)
+				ks.On("Get", context.Background(), certID, certPassword).Return(randomKeyPair(), nil)
+				ks.On("GetPolicy", context.Background(), certID).Return(&keystore.Policy{
+					IDProvoz: []int{12},
+				}, nil)
+			},
+			errs: []error{gateway.ErrCertificatePolicy},
+		},
+		{
+			name: "no signing key",
+			setup: func(ks *mkeystore.Service) {
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), nil)
+				ks.On("ReleaseAttempt", context.Background(), certLockoutKey, false).Return(time.Duration(0), nil)
+				ks.On("Get", context.Background(), certID, certPassword).Return(&keystore.KeyPair{Cert: certKP.Cert}, nil)
+				ks.On("GetPolicy", context.Background(), certID).Return(certPolicy, nil)
+			},
+			errs: []error{gateway.ErrRequestBuild, keystore.ErrNoSigningKey},
+		},
+		{
+			name: "signer not configured",
+			setup: func(ks *mkeystore.Service) {
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), nil)
+				ks.On("ReleaseAttempt", context.Background(), certLockoutKey, false).Return(time.Duration(0), nil)
+				ks.On("Get", context.Background(), certID, certPassword).Return(nil, keystore.ErrSignerUnavailable)
+			},
+			errs: []error{gateway.ErrSignerUnavailable},
+		},
+		{
+			name: "remote signer failed",
+			setup: func(ks *mkeystore.Service) {
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), nil)
+				ks.On("ReleaseAttempt", context.Background(), certLockoutKey, false).Return(time.Duration(0), nil)
+				ks.On("Get", context.Background(), certID, certPassword).Return(&keystore.KeyPair{
+					Cert:   certKP.Cert,
+					Signer: failingSigner{pub: certKP.PK.Public()},
+				}, nil)
+				ks.On("GetPolicy", context.Background(), certID).Return(certPolicy, nil)
+			},
+			errs: []error{gateway.ErrSignerUnavailable},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fscrClient := new(mfscr.Client)
+			caService := new(mfscr.CAService)
+			keystoreService := new(mkeystore.Service)
+
+			tc.setup(keystoreService)
+
+			trzba := &eet.TrzbaType{
+				Data: eet.TrzbaDataType{
+					Dicpopl:  "CZ00000019",
+					Idprovoz: 11,
+					Idpokl:   "pokl-1",
+					Poradcis: "123",
+				},
+			}
+
+			g := gateway.NewService(fscrClient, caService, keystoreService, notRevoked(), gateway.DefaultSessionPolicy, gateway.DefaultMaxSigningTimeSkew)
+			codes, err := g.ComputeCodes(context.Background(), certID, certPassword, trzba)
+			for _, e := range tc.errs {
+				require.ErrorIs(t, err, e)
+			}
+
+			if tc.errs == nil {
+				require.NoError(t, err)
+				require.NotEmpty(t, codes.Pkp.PkpType)
+				require.Len(t, codes.Bkp.BkpType, 44)
+			}
+
+			// the FSCR isn't contacted
+			fscrClient.AssertExpectations(t)
+			caService.AssertExpectations(t)
+			keystoreService.AssertExpectations(t)
+		})
+	}
+
+	t.Run("with session", func(t *testing.T) {
+		keystoreService := new(mkeystore.Service)
+		keystoreService.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), nil)
+		keystoreService.On("ReleaseAttempt", context.Background(), certLockoutKey, false).Return(time.Duration(0), nil)
+		keystoreService.On("Get", context.Background(), certID, certPassword).Return(randomKeyPair(), nil)
+		keystoreService.On("GetPolicy", context.Background(), certID).Return(certPolicy, nil)
+
+		g := gateway.NewService(new(mfscr.Client), new(mfscr.CAService), keystoreService, notRevoked(), gateway.DefaultSessionPolicy, gateway.DefaultMaxSigningTimeSkew)
+		token, _, err := g.OpenSession(context.Background(), certID, certPassword)
+		require.NoError(t, err)
+
+		trzba := &eet.TrzbaType{
+			Data: eet.TrzbaDataType{
+				Idprovoz: 11,
+				Idpokl:   "pokl-1",
+			},
+		}
+
+		codes, err := g.ComputeCodesWithSession(context.Background(), certID, token, trzba)
+		require.NoError(t, err)
+		require.NotEmpty(t, codes.Pkp.PkpType)
+
+		_, err = g.ComputeCodesWithSession(context.Background(), certID, "invalid", trzba)
+		require.ErrorIs(t, err, gateway.ErrInvalidSessionToken)
+	})
+}
+
+func TestService_SignSale(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(ks *mkeystore.Service, rs *mrevocation.Service)
 		errs  []error
 	}{
 		{
 			name: "ok",
-			setup: func(c *mfscr.Client, ks *mkeystore.Service) {
-				c.On("Ping").Return(nil)
-				ks.On("Ping", context.Background()).Return(nil)
+			setup: func(ks *mkeystore.Service, rs *mrevocation.Service) {
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), nil)
+				ks.On("ReleaseAttempt", context.Background(), certLockoutKey, false).Return(time.Duration(0), nil)
+				ks.On("Get", context.Background(), certID, certPassword).Return(randomKeyPair(), nil)
+				ks.On("GetPolicy", context.Background(), certID).Return(certPolicy, nil)
+				rs.On("Check", mock.Anything).Return(nil)
+				rs.On("Watch", mock.Anything).Once()
 			},
-			errs: nil,
 		},
 		{
-			name: "bad fscr",
-			setup: func(c *mfscr.Client, ks *mkeystore.Service) {
-				c.On("Ping").Return(errUnexpected)
-				ks.On("Ping", context.Background()).Return(nil)
+			name: "invalid certificate password",
+			setup: func(ks *mkeystore.Service, rs *mrevocation.Service) {
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), nil)
+				ks.On("Get", context.Background(), certID, certPassword).Return(nil, keystore.ErrInvalidDecryptionKey)
+				ks.On("ReleaseAttempt", context.Background(), certLockoutKey, true).Return(time.Duration(0), nil)
 			},
-			errs: []error{gateway.ErrFSCRConnection},
+			errs: []error{gateway.ErrInvalidCertificatePassword},
 		},
 		{
-			name: "bad keystore",
-			setup: func(c *mfscr.Client, ks *mkeystore.Service) {
-				c.On("Ping").Return(nil)
-				ks.On("Ping", context.Background()).Return(errUnexpected)
+			name: "certificate locked",
+			setup: func(ks *mkeystore.Service, rs *mrevocation.Service) {
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), keystore.ErrKeyLocked)
 			},
-			errs: []error{gateway.ErrKeystoreUnavailable},
+			errs: []error{gateway.ErrCertificateLocked},
 		},
 		{
-			name: "bad fscr and keystore",
-			setup: func(c *mfscr.Client, ks *mkeystore.Service) {
-				c.On("Ping").Return(errUnexpected)
-				ks.On("Ping", context.Background()).Return(errUnexpected)
+			name: "revoked certificate",
+			setup: func(ks *mkeystore.Service, rs *mrevocation.Service) {
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), nil)
+				ks.On("ReleaseAttempt", context.Background(), certLockoutKey, false).Return(time.Duration(0), nil)
+				ks.On("Get", context.Background(), certID, certPassword).Return(randomKeyPair(), nil)
+				rs.On("Check", mock.Anything).Return(revocation.ErrCertificateRevoked)
+				rs.On("Watch", mock.Anything).Once()
 			},
-			errs: []error{gateway.ErrFSCRConnection, gateway.ErrKeystoreUnavailable},
+			errs: []error{gateway.ErrCertificateRevoked},
+		},
+		{
+			name: "id_provoz not allowed",
+			setup: func(ks *mkeystore.Service, rs *mrevocation.Service) {
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), nil)
+				ks.On("ReleaseAttempt", context.Background(), certLockoutKey, false).Return(time.Duration(0), nil)
+				ks.On("Get", context.Background(), certID, certPassword).Return(randomKeyPair(), nil)
+				ks.On("GetPolicy", context.Background(), certID).Return(&keystore.Policy{
+					IDProvoz: []int{12},
+				}, nil)
+				rs.On("Check", mock.Anything).Return(nil)
+				rs.On("Watch", mock.Anything).Once()
+			},
+			errs: []error{gateway.ErrCertificatePolicy},
 		},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
 			fscrClient := new(mfscr.Client)
+			caService := new(mfscr.CAService)
 			keystoreService := new(mkeystore.Service)
+			revocationService := new(mrevocation.Service)
 
-			tc.setup(fscrClient, keystoreService)
+			tc.setup(keystoreService, revocationService)
+
+			trzba := &eet.TrzbaType{
+				Data: eet.TrzbaDataType{
+					Dicpopl:  "CZ00000019",
+					Idprovoz: 11,
+					Idpokl:   "pokl-1",
+					Poradcis: "123",
+				},
+			}
+
+			g := gateway.NewService(fscrClient, caService, keystoreService, revocationService, gateway.DefaultSessionPolicy, gateway.DefaultMaxSigningTimeSkew)
+			env, err := g.SignSale(context.Background(), certID, certPassword, trzba)
+			for _, e := range tc.errs {
+				require.ErrorIs(t, err, e)
+			}
 
-			g := gateway.NewService(fscrClient, new(mfscr.CAService), keystoreService)
-			err := g.Ping(context.Background())
 			if tc.errs == nil {
 				require.NoError(t, err)
-			} else {
-				for _, e := range tc.errs {
-					require.ErrorIs(t, err, e)
-				}
+				require.Contains(t, string(env), string(trzba.KontrolniKody.Bkp.BkpType))
 			}
 
+			// the FSCR isn't contacted
 			fscrClient.AssertExpectations(t)
+			caService.AssertExpectations(t)
+			keystoreService.AssertExpectations(t)
+			revocationService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_SignSaleWithCert(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(cas *mfscr.CAService, rs *mrevocation.Service)
+		errs  []error
+	}{
+		{
+			name: "ok",
+			setup: func(cas *mfscr.CAService, rs *mrevocation.Service) {
+				cas.On("ParseTaxpayerCertificate", pkcsData, pkcsPassword).Return(certKP.Cert, certKP.PK, nil)
+				rs.On("Check", certKP.Cert).Return(nil)
+				rs.On("Watch", certKP.Cert).Once()
+			},
+		},
+		{
+			name: "invalid certificate",
+			setup: func(cas *mfscr.CAService, rs *mrevocation.Service) {
+				cas.On("ParseTaxpayerCertificate", pkcsData, pkcsPassword).Return(nil, nil, fscr.ErrInvalidCertificate)
+			},
+			errs: []error{gateway.ErrInvalidTaxpayersCertificate},
+		},
+		{
+			name: "revoked certificate",
+			setup: func(cas *mfscr.CAService, rs *mrevocation.Service) {
+				cas.On("ParseTaxpayerCertificate", pkcsData, pkcsPassword).Return(certKP.Cert, certKP.PK, nil)
+				rs.On("Check", certKP.Cert).Return(revocation.ErrCertificateRevoked)
+				rs.On("Watch", certKP.Cert).Once()
+			},
+			errs: []error{gateway.ErrCertificateRevoked},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fscrClient := new(mfscr.Client)
+			caService := new(mfscr.CAService)
+			keystoreService := new(mkeystore.Service)
+			revocationService := new(mrevocation.Service)
+
+			tc.setup(caService, revocationService)
+
+			trzba := &eet.TrzbaType{
+				Data: eet.TrzbaDataType{
+					Idprovoz: 11,
+				},
+			}
+
+			// neither the keystore nor the FSCR is used
+			g := gateway.NewService(fscrClient, caService, keystoreService, revocationService, gateway.DefaultSessionPolicy, gateway.DefaultMaxSigningTimeSkew)
+			env, err := g.SignSaleWithCert(context.Background(), pkcsData, pkcsPassword, trzba)
+			for _, e := range tc.errs {
+				require.ErrorIs(t, err, e)
+			}
+
+			if tc.errs == nil {
+				require.NoError(t, err)
+				require.NotEmpty(t, env)
+			}
+
 			fscrClient.AssertExpectations(t)
+			caService.AssertExpectations(t)
 			keystoreService.AssertExpectations(t)
+			revocationService.AssertExpectations(t)
 		})
 	}
 }
 
-func TestService_StoreCert(t *testing.T) {
+func TestService_SendSaleWithSession(t *testing.T) {
+	openSession := func(t *testing.T, g gateway.Service, id string) string {
+		token, _, err := g.OpenSession(context.Background(), id, certPassword)
+		require.NoError(t, err)
+		return token
+	}
+
+	expectSession := func(ks *mkeystore.Service, id string) {
+		ks.On("ReserveAttempt", context.Background(), "cert:"+id).Return(int64(0), nil).Once()
+		ks.On("ReleaseAttempt", context.Background(), "cert:"+id, false).Return(time.Duration(0), nil).Once()
+		ks.On("Get", context.Background(), id, certPassword).Return(randomKeyPair(), nil).Once()
+	}
+
+	tests := []struct {
+		name   string
+		policy gateway.SessionPolicy
+		setup  func(t *testing.T, ks *mkeystore.Service, g gateway.Service) string
+		errs   []error
+	}{
+		{
+			name:   "ok",
+			policy: gateway.DefaultSessionPolicy,
+			setup: func(t *testing.T, ks *mkeystore.Service, g gateway.Service) string {
+				expectSession(ks, certID)
+				ks.On("GetPolicy", context.Background(), certID).Return(&keystore.Policy{
+					IDProvoz: []int{12},
+				}, nil)
+				return openSession(t, g, certID)
+			},
+			errs: []error{gateway.ErrCertificatePolicy}, // the token is accepted
+		},
+		{
+			name:   "invalid token",
+			policy: gateway.DefaultSessionPolicy,
+			setup: func(t *testing.T, ks *mkeystore.Service, g gateway.Service) string {
+				return "invalid"
+			},
+			errs: []error{gateway.ErrInvalidSessionToken},
+		},
+		{
+			name:   "session of another certificate",
+			policy: gateway.DefaultSessionPolicy,
+			setup: func(t *testing.T, ks *mkeystore.Service, g gateway.Service) string {
+				expectSession(ks, certID2)
+				return openSession(t, g, certID2)
+			},
+			errs: []error{gateway.ErrInvalidSessionToken},
+		},
+		{
+			name:   "session expired",
+			policy: gateway.SessionPolicy{TTL: time.Millisecond, Capacity: 1},
+			setup: func(t *testing.T, ks *mkeystore.Service, g gateway.Service) string {
+				expectSession(ks, certID)
+				token := openSession(t, g, certID)
+				time.Sleep(10 * time.Millisecond)
+				return token
+			},
+			errs: []error{gateway.ErrInvalidSessionToken},
+		},
+		{
+			name:   "session evicted",
+			policy: gateway.SessionPolicy{TTL: time.Minute, Capacity: 1},
+			setup: func(t *testing.T, ks *mkeystore.Service, g gateway.Service) string {
+				expectSession(ks, certID)
+				expectSession(ks, certID)
+				token := openSession(t, g, certID)
+				openSession(t, g, certID)
+				return token
+			},
+			errs: []error{gateway.ErrInvalidSessionToken},
+		},
+		{
+			name:   "session closed on delete",
+			policy: gateway.DefaultSessionPolicy,
+			setup: func(t *testing.T, ks *mkeystore.Service, g gateway.Service) string {
+				expectSession(ks, certID)
+				ks.On("Delete", context.Background(), certID).Return(nil)
+				token := openSession(t, g, certID)
+				require.NoError(t, g.DeleteID(context.Background(), certID))
+				return token
+			},
+			errs: []error{gateway.ErrInvalidSessionToken},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fscrClient := new(mfscr.Client)
+			caService := new(mfscr.CAService)
+			keystoreService := new(mkeystore.Service)
+
+			g := gateway.NewService(fscrClient, caService, keystoreService, notRevoked(), tc.policy, gateway.DefaultMaxSigningTimeSkew)
+			token := tc.setup(t, keystoreService, g)
+
+			trzba := &eet.TrzbaType{
+				Data: eet.TrzbaDataType{
+					Idprovoz: 11,
+				},
+			}
+
+			_, err := g.SendSaleWithSession(context.Background(), certID, token, trzba)
+			for _, e := range tc.errs {
+				require.ErrorIs(t, err, e)
+			}
+
+			fscrClient.AssertExpectations(t)
+			caService.AssertExpectations(t)
+			keystoreService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_ReplaceCert(t *testing.T) {
 	tests := []struct {
 		name  string
 		setup func(cas *mfscr.CAService, ks *mkeystore.Service)
@@ -135,7 +1862,9 @@ func TestService_StoreCert(t *testing.T) {
 			name: "ok",
 			setup: func(cas *mfscr.CAService, ks *mkeystore.Service) {
 				cas.On("ParseTaxpayerCertificate", pkcsData, pkcsPassword).Return(certKP.Cert, certKP.PK, nil)
-				ks.On("Store", context.Background(), certID, certPassword, certKP).Return(nil)
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), nil)
+				ks.On("ReleaseAttempt", context.Background(), certLockoutKey, false).Return(time.Duration(0), nil)
+				ks.On("Replace", context.Background(), certID, certPassword, certKP).Return(nil)
 			},
 			errs: nil,
 		},
@@ -147,36 +1876,42 @@ func TestService_StoreCert(t *testing.T) {
 			errs: []error{gateway.ErrInvalidTaxpayersCertificate},
 		},
 		{
-			name: "unknown certificate parse error",
+			name: "certificate not found",
 			setup: func(cas *mfscr.CAService, ks *mkeystore.Service) {
-				cas.On("ParseTaxpayerCertificate", pkcsData, pkcsPassword).Return(nil, nil, errUnexpected)
+				cas.On("ParseTaxpayerCertificate", pkcsData, pkcsPassword).Return(certKP.Cert, certKP.PK, nil)
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), nil)
+				ks.On("ReleaseAttempt", context.Background(), certLockoutKey, false).Return(time.Duration(0), nil)
+				ks.On("Replace", context.Background(), certID, certPassword, certKP).Return(keystore.ErrRecordNotFound)
 			},
-			errs: []error{gateway.ErrCertificateParse},
+			errs: []error{gateway.ErrCertificateNotFound},
 		},
 		{
-			name: "id already exists",
+			name: "another taxpayer",
 			setup: func(cas *mfscr.CAService, ks *mkeystore.Service) {
 				cas.On("ParseTaxpayerCertificate", pkcsData, pkcsPassword).Return(certKP.Cert, certKP.PK, nil)
-				ks.On("Store", context.Background(), certID, certPassword, certKP).Return(keystore.ErrIDAlreadyExists)
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), nil)
+				ks.On("ReleaseAttempt", context.Background(), certLockoutKey, false).Return(time.Duration(0), nil)
+				ks.On("Replace", context.Background(), certID, certPassword, certKP).Return(keystore.ErrTaxpayerMismatch)
 			},
-			errs: []error{gateway.ErrIDAlreadyExists},
+			errs: []error{gateway.ErrInvalidTaxpayersCertificate},
 		},
 		{
-			name: "max tries of db transactions",
+			name: "invalid certificate password",
 			setup: func(cas *mfscr.CAService, ks *mkeystore.Service) {
 				cas.On("ParseTaxpayerCertificate", pkcsData, pkcsPassword).Return(certKP.Cert, certKP.PK, nil)
-				ks.On("Store", context.Background(), certID, certPassword, certKP).Return(keystore.ErrReachedMaxAttempts)
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), nil)
+				ks.On("Replace", context.Background(), certID, certPassword, certKP).Return(keystore.ErrInvalidDecryptionKey)
+				ks.On("ReleaseAttempt", context.Background(), certLockoutKey, true).Return(time.Duration(0), nil)
 			},
-			errs: []error{gateway.ErrMaxTXAttempts},
+			errs: []error{gateway.ErrInvalidCertificatePassword},
 		},
 		{
-			name: "unknown certificate store error",
+			name: "certificate locked",
 			setup: func(cas *mfscr.CAService, ks *mkeystore.Service) {
 				cas.On("ParseTaxpayerCertificate", pkcsData, pkcsPassword).Return(certKP.Cert, certKP.PK, nil)
-				ks.On("Ping", context.Background()).Return(nil)
-				ks.On("Store", context.Background(), certID, certPassword, certKP).Return(errUnexpected)
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), keystore.ErrKeyLocked)
 			},
-			errs: []error{gateway.ErrKeystoreUnexpected},
+			errs: []error{gateway.ErrCertificateLocked},
 		},
 	}
 
@@ -188,8 +1923,8 @@ func TestService_StoreCert(t *testing.T) {
 
 			tc.setup(caService, keystoreService)
 
-			g := gateway.NewService(fscrClient, caService, keystoreService)
-			err := g.StoreCert(context.Background(), certID, certPassword, pkcsData, pkcsPassword)
+			g := gateway.NewService(fscrClient, caService, keystoreService, notRevoked(), gateway.DefaultSessionPolicy, gateway.DefaultMaxSigningTimeSkew)
+			err := g.ReplaceCert(context.Background(), certID, certPassword, pkcsData, pkcsPassword)
 			if tc.errs == nil {
 				require.NoError(t, err)
 			} else {
@@ -205,7 +1940,7 @@ func TestService_StoreCert(t *testing.T) {
 	}
 }
 
-func TestService_ListCertIDs(t *testing.T) {
+func TestService_RollbackCert(t *testing.T) {
 	tests := []struct {
 		name  string
 		setup func(ks *mkeystore.Service)
@@ -214,17 +1949,39 @@ func TestService_ListCertIDs(t *testing.T) {
 		{
 			name: "ok",
 			setup: func(ks *mkeystore.Service) {
-				ks.On("List", context.Background(), int64(0), int64(0)).Return([]string{certID}, nil)
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), nil)
+				ks.On("ReleaseAttempt", context.Background(), certLockoutKey, false).Return(time.Duration(0), nil)
+				ks.On("Rollback", context.Background(), certID, certPassword).Return(nil)
 			},
 			errs: nil,
 		},
 		{
-			name: "unknown list certificates error",
+			name: "previous certificate not found",
 			setup: func(ks *mkeystore.Service) {
-				ks.On("Ping", context.Background()).Return(nil)
-				ks.On("List", context.Background(), int64(0), int64(0)).Return(nil, errUnexpected)
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), nil)
+				ks.On("ReleaseAttempt", context.Background(), certLockoutKey, false).Return(time.Duration(0), nil)
+				ks.On("Rollback", context.Background(), certID, certPassword).Return(keystore.ErrPreviousNotFound)
 			},
-			errs: []error{gateway.ErrKeystoreUnexpected},
+			errs: []error{gateway.ErrPreviousCertificateNotFound},
+		},
+		{
+			name: "invalid certificate password",
+			setup: func(ks *mkeystore.Service) {
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), nil)
+				ks.On("Rollback", context.Background(), certID, certPassword).Return(keystore.ErrInvalidDecryptionKey)
+				ks.On("ReleaseAttempt", context.Background(), certLockoutKey, true).Return(time.Duration(0), nil)
+			},
+			errs: []error{gateway.ErrInvalidCertificatePassword},
+		},
+		{
+			name: "unavailable keystore",
+			setup: func(ks *mkeystore.Service) {
+				ks.On("Ping", context.Background()).Return(errUnexpected)
+				ks.On("ReserveAttempt", context.Background(), certLockoutKey).Return(int64(0), nil)
+				ks.On("ReleaseAttempt", context.Background(), certLockoutKey, false).Return(time.Duration(0), nil)
+				ks.On("Rollback", context.Background(), certID, certPassword).Return(errUnexpected)
+			},
+			errs: []error{gateway.ErrKeystoreUnavailable},
 		},
 	}
 
@@ -236,11 +1993,10 @@ func TestService_ListCertIDs(t *testing.T) {
 
 			tc.setup(keystoreService)
 
-			g := gateway.NewService(fscrClient, caService, keystoreService)
-			ids, err := g.ListCertIDs(context.Background(), 0, 0)
+			g := gateway.NewService(fscrClient, caService, keystoreService, notRevoked(), gateway.DefaultSessionPolicy, gateway.DefaultMaxSigningTimeSkew)
+			err := g.RollbackCert(context.Background(), certID, certPassword)
 			if tc.errs == nil {
 				require.NoError(t, err)
-				require.NotEmpty(t, ids)
 			} else {
 				for _, e := range tc.errs {
 					require.ErrorIs(t, err, e)
@@ -254,47 +2010,36 @@ func TestService_ListCertIDs(t *testing.T) {
 	}
 }
 
-func TestService_UpdateCertID(t *testing.T) {
+func TestService_UnlockCert(t *testing.T) {
 	tests := []struct {
-		name  string
-		setup func(ks *mkeystore.Service)
-		errs  []error
+		name   string
+		client string
+		setup  func(ks *mkeystore.Service)
+		errs   []error
 	}{
 		{
 			name: "ok",
 			setup: func(ks *mkeystore.Service) {
-				ks.On("UpdateID", context.Background(), certID, certID2).Return(nil)
+				ks.On("ResetFailures", context.Background(), certLockoutKey).Return(nil)
 			},
 			errs: nil,
 		},
 		{
-			name: "certificate not found",
-			setup: func(ks *mkeystore.Service) {
-				ks.On("UpdateID", context.Background(), certID, certID2).Return(keystore.ErrRecordNotFound)
-			},
-			errs: []error{gateway.ErrCertificateNotFound},
-		},
-		{
-			name: "certificate id already exists",
-			setup: func(ks *mkeystore.Service) {
-				ks.On("UpdateID", context.Background(), certID, certID2).Return(keystore.ErrIDAlreadyExists)
-			},
-			errs: []error{gateway.ErrIDAlreadyExists},
-		},
-		{
-			name: "max tries of db transactions",
+			name:   "ok with client",
+			client: "192.0.2.1",
 			setup: func(ks *mkeystore.Service) {
-				ks.On("UpdateID", context.Background(), certID, certID2).Return(keystore.ErrReachedMaxAttempts)
+				ks.On("ResetFailures", context.Background(), certLockoutKey).Return(nil)
+				ks.On("ResetFailures", context.Background(), "client:192.0.2.1").Return(nil)
 			},
-			errs: []error{gateway.ErrMaxTXAttempts},
+			errs: nil,
 		},
 		{
-			name: "unknown update certificate id error",
+			name: "unavailable keystore",
 			setup: func(ks *mkeystore.Service) {
-				ks.On("Ping", context.Background()).Return(nil)
-				ks.On("UpdateID", context.Background(), certID, certID2).Return(errUnexpected, errUnexpected)
+				ks.On("Ping", context.Background()).Return(errUnexpected)
+				ks.On("ResetFailures", context.Background(), certLockoutKey).Return(errUnexpected)
 			},
-			errs: []error{gateway.ErrKeystoreUnexpected},
+			errs: []error{gateway.ErrKeystoreUnavailable},
 		},
 	}
 
@@ -306,8 +2051,8 @@ func TestService_UpdateCertID(t *testing.T) {
 
 			tc.setup(keystoreService)
 
-			g := gateway.NewService(fscrClient, caService, keystoreService)
-			err := g.UpdateCertID(context.Background(), certID, certID2)
+			g := gateway.NewService(fscrClient, caService, keystoreService, notRevoked(), gateway.DefaultSessionPolicy, gateway.DefaultMaxSigningTimeSkew)
+			err := g.UnlockCert(context.Background(), certID, tc.client)
 			if tc.errs == nil {
 				require.NoError(t, err)
 			} else {
@@ -323,7 +2068,7 @@ func TestService_UpdateCertID(t *testing.T) {
 	}
 }
 
-func TestService_UpdateCertPassword(t *testing.T) {
+func TestService_DeleteID(t *testing.T) {
 	tests := []struct {
 		name  string
 		setup func(ks *mkeystore.Service)
@@ -332,36 +2077,22 @@ func TestService_UpdateCertPassword(t *testing.T) {
 		{
 			name: "ok",
 			setup: func(ks *mkeystore.Service) {
-				ks.On("UpdatePassword", context.Background(), certID, certPassword, certPassword2).Return(nil)
+				ks.On("Delete", context.Background(), certID).Return(nil)
 			},
 			errs: nil,
 		},
 		{
 			name: "certificate not found",
 			setup: func(ks *mkeystore.Service) {
-				ks.On("UpdatePassword", context.Background(), certID, certPassword, certPassword2).Return(keystore.ErrRecordNotFound)
+				ks.On("Delete", context.Background(), certID).Return(keystore.ErrRecordNotFound)
 			},
 			errs: []error{gateway.ErrCertificateNotFound},
 		},
 		{
-			name: "invalid certificate password",
-			setup: func(ks *mkeystore.Service) {
-				ks.On("UpdatePassword", context.Background(), certID, certPassword, certPassword2).Return(keystore.ErrInvalidDecryptionKey)
-			},
-			errs: []error{gateway.ErrInvalidCertificatePassword},
-		},
-		{
-			name: "max tries of db transactions",
-			setup: func(ks *mkeystore.Service) {
-				ks.On("UpdatePassword", context.Background(), certID, certPassword, certPassword2).Return(keystore.ErrReachedMaxAttempts)
-			},
-			errs: []error{gateway.ErrMaxTXAttempts},
-		},
-		{
-			name: "unknown update certificate password error",
+			name: "unknown certificate delete error",
 			setup: func(ks *mkeystore.Service) {
 				ks.On("Ping", context.Background()).Return(nil)
-				ks.On("UpdatePassword", context.Background(), certID, certPassword, certPassword2).Return(errUnexpected)
+				ks.On("Delete", context.Background(), certID).Return(errUnexpected)
 			},
 			errs: []error{gateway.ErrKeystoreUnexpected},
 		},
@@ -375,8 +2106,8 @@ func TestService_UpdateCertPassword(t *testing.T) {
 
 			tc.setup(keystoreService)
 
-			g := gateway.NewService(fscrClient, caService, keystoreService)
-			err := g.UpdateCertPassword(context.Background(), certID, certPassword, certPassword2)
+			g := gateway.NewService(fscrClient, caService, keystoreService, notRevoked(), gateway.DefaultSessionPolicy, gateway.DefaultMaxSigningTimeSkew)
+			err := g.DeleteID(context.Background(), certID)
 			if tc.errs == nil {
 				require.NoError(t, err)
 			} else {
@@ -392,33 +2123,86 @@ func TestService_UpdateCertPassword(t *testing.T) {
 	}
 }
 
-func TestService_DeleteID(t *testing.T) {
+func TestService_CheckRevocations(t *testing.T) {
+	identity := &keystore.Identity{
+		SerialNumber:       certKP.Cert.SerialNumber,
+		Issuer:             certKP.Cert.RawIssuer,
+		DistributionPoints: []string{"http://crl.example.com/ca.crl"},
+	}
+
 	tests := []struct {
-		name  string
-		setup func(ks *mkeystore.Service)
-		errs  []error
+		name    string
+		setup   func(ks *mkeystore.Service, rs *mrevocation.Service)
+		revoked []string
+		errs    []error
 	}{
 		{
-			name: "ok",
-			setup: func(ks *mkeystore.Service) {
-				ks.On("Delete", context.Background(), certID).Return(nil)
+			name: "revoked",
+			setup: func(ks *mkeystore.Service, rs *mrevocation.Service) {
+				ks.On("List", context.Background(), int64(0), int64(-1)).Return([]string{certID, certID2}, nil)
+				ks.On("Identity", context.Background(), certID).Return(identity, nil)
+				ks.On("Identity", context.Background(), certID2).Return(&keystore.Identity{}, nil)
+				rs.On("WatchDistributionPoints", identity.DistributionPoints).Once()
+				rs.On("CheckSerialNumber", identity.Issuer, identity.SerialNumber).Return(revocation.ErrCertificateRevoked)
+				ks.On("Revoke", context.Background(), certID, identity.SerialNumber).Return(nil)
 			},
-			errs: nil,
+			revoked: []string{certID},
+			errs:    nil,
 		},
 		{
-			name: "certificate not found",
-			setup: func(ks *mkeystore.Service) {
-				ks.On("Delete", context.Background(), certID).Return(keystore.ErrRecordNotFound)
+			name: "not revoked",
+			setup: func(ks *mkeystore.Service, rs *mrevocation.Service) {
+				ks.On("List", context.Background(), int64(0), int64(-1)).Return([]string{certID}, nil)
+				ks.On("Identity", context.Background(), certID).Return(identity, nil)
+				rs.On("WatchDistributionPoints", identity.DistributionPoints).Once()
+				rs.On("CheckSerialNumber", identity.Issuer, identity.SerialNumber).Return(revocation.ErrStatusUnknown)
 			},
-			errs: []error{gateway.ErrCertificateNotFound},
+			revoked: nil,
+			errs:    nil,
 		},
 		{
-			name: "unknown certificate delete error",
-			setup: func(ks *mkeystore.Service) {
+			name: "already revoked",
+			setup: func(ks *mkeystore.Service, rs *mrevocation.Service) {
+				ks.On("List", context.Background(), int64(0), int64(-1)).Return([]string{certID}, nil)
+				ks.On("Identity", context.Background(), certID).Return(&keystore.Identity{
+					SerialNumber: identity.SerialNumber,
+					Issuer:       identity.Issuer,
+					RevokedAt:    time.Now(),
+				}, nil)
+			},
+			revoked: nil,
+			errs:    nil,
+		},
+		{
+			name: "deleted certificate",
+			setup: func(ks *mkeystore.Service, rs *mrevocation.Service) {
+				ks.On("List", context.Background(), int64(0), int64(-1)).Return([]string{certID}, nil)
+				ks.On("Identity", context.Background(), certID).Return(nil, keystore.ErrRecordNotFound)
+			},
+			revoked: nil,
+			errs:    nil,
+		},
+		{
+			name: "list error",
+			setup: func(ks *mkeystore.Service, rs *mrevocation.Service) {
+				ks.On("Ping", context.Background()).Return(errUnexpected)
+				ks.On("List", context.Background(), int64(0), int64(-1)).Return(nil, errUnexpected)
+			},
+			revoked: nil,
+			errs:    []error{gateway.ErrKeystoreUnavailable},
+		},
+		{
+			name: "revoke error",
+			setup: func(ks *mkeystore.Service, rs *mrevocation.Service) {
 				ks.On("Ping", context.Background()).Return(nil)
-				ks.On("Delete", context.Background(), certID).Return(errUnexpected)
+				ks.On("List", context.Background(), int64(0), int64(-1)).Return([]string{certID}, nil)
+				ks.On("Identity", context.Background(), certID).Return(identity, nil)
+				rs.On("WatchDistributionPoints", identity.DistributionPoints).Once()
+				rs.On("CheckSerialNumber", identity.Issuer, identity.SerialNumber).Return(revocation.ErrCertificateRevoked)
+				ks.On("Revoke", context.Background(), certID, identity.SerialNumber).Return(errUnexpected)
 			},
-			errs: []error{gateway.ErrKeystoreUnexpected},
+			revoked: nil,
+			errs:    []error{gateway.ErrKeystoreUnexpected},
 		},
 	}
 
@@ -427,11 +2211,12 @@ func TestService_DeleteID(t *testing.T) {
 			fscrClient := new(mfscr.Client)
 			caService := new(mfscr.CAService)
 			keystoreService := new(mkeystore.Service)
+			revocationService := new(mrevocation.Service)
 
-			tc.setup(keystoreService)
+			tc.setup(keystoreService, revocationService)
 
-			g := gateway.NewService(fscrClient, caService, keystoreService)
-			err := g.DeleteID(context.Background(), certID)
+			g := gateway.NewService(fscrClient, caService, keystoreService, revocationService, gateway.DefaultSessionPolicy, gateway.DefaultMaxSigningTimeSkew)
+			revoked, err := g.CheckRevocations(context.Background())
 			if tc.errs == nil {
 				require.NoError(t, err)
 			} else {
@@ -439,10 +2224,12 @@ func TestService_DeleteID(t *testing.T) {
 					require.ErrorIs(t, err, e)
 				}
 			}
+			require.Equal(t, tc.revoked, revoked)
 
 			fscrClient.AssertExpectations(t)
 			caService.AssertExpectations(t)
 			keystoreService.AssertExpectations(t)
+			revocationService.AssertExpectations(t)
 		})
 	}
 }
@@ -0,0 +1,173 @@
+package gateway
+
+import (
+	"container/list"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/chutommy/eetgateway/pkg/keystore"
+)
+
+// errSessionNotFound is returned if a session token doesn't refer to an open session of the certificate.
+var errSessionNotFound = errors.New("session not found")
+
+// SessionPolicy configures the in-memory sessions of decrypted certificates.
+type SessionPolicy struct {
+	// TTL is the lifetime of a session.
+	TTL time.Duration
+	// Capacity is the maximum number of open sessions. The oldest session is closed if exceeded.
+	Capacity int
+}
+
+// DefaultSessionPolicy is a SessionPolicy with sensible defaults.
+var DefaultSessionPolicy = SessionPolicy{
+	TTL:      15 * time.Minute,
+	Capacity: 1024,
+}
+
+type session struct {
+	// mu guards kp, which is zeroized when the session is closed
+	mu        sync.RWMutex
+	kp        *keystore.KeyPair
+	certID    string
+	expiresAt time.Time
+
+	timer *time.Timer
+	elem  *list.Element
+}
+
+func (s *session) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.kp != nil {
+		s.kp.Zeroize()
+		s.kp = nil
+	}
+}
+
+// sessionCache holds decrypted KeyPairs under opaque tokens. Sessions are local to the process.
+type sessionCache struct {
+	mu       sync.Mutex
+	policy   SessionPolicy
+	sessions map[string]*session
+	// order lists the tokens by the time of creation, the oldest first
+	order *list.List
+}
+
+func newSessionCache(policy SessionPolicy) *sessionCache {
+	return &sessionCache{
+		policy:   policy,
+		sessions: make(map[string]*session),
+		order:    list.New(),
+	}
+}
+
+// open stores the KeyPair kp of the certificate and returns the token of the new session
+// with the time of its expiration.
+func (c *sessionCache) open(certID string, kp *keystore.KeyPair) (string, time.Time, error) {
+	token, err := newSessionToken()
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("generate session token: %w", err)
+	}
+
+	s := &session{
+		kp:        kp,
+		certID:    certID,
+		expiresAt: time.Now().Add(c.policy.TTL),
+	}
+
+	var evicted []*session
+	c.mu.Lock()
+	for c.order.Len() > 0 && c.order.Len() >= c.policy.Capacity {
+		evicted = append(evicted, c.removeLocked(c.order.Front().Value.(string)))
+	}
+
+	s.elem = c.order.PushBack(token)
+	s.timer = time.AfterFunc(c.policy.TTL, func() { c.close(token) })
+	c.sessions[token] = s
+	c.mu.Unlock()
+
+	for _, e := range evicted {
+		e.close()
+	}
+
+	return token, s.expiresAt, nil
+}
+
+// use calls fn with the KeyPair of the certificate's session. The KeyPair must not be retained
+// after fn returns.
+func (c *sessionCache) use(token, certID string, fn func(kp *keystore.KeyPair) error) error {
+	c.mu.Lock()
+	s, ok := c.sessions[token]
+	c.mu.Unlock()
+	if !ok || s.certID != certID {
+		return errSessionNotFound
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// the session may be closed meanwhile or its timer may be late
+	if s.kp == nil || time.Now().After(s.expiresAt) {
+		return errSessionNotFound
+	}
+
+	return fn(s.kp)
+}
+
+// close closes the session and zeroizes its KeyPair.
+func (c *sessionCache) close(token string) {
+	c.mu.Lock()
+	s := c.removeLocked(token)
+	c.mu.Unlock()
+
+	if s != nil {
+		s.close()
+	}
+}
+
+// revoke closes all sessions of the certificate.
+func (c *sessionCache) revoke(certID string) {
+	var revoked []*session
+	c.mu.Lock()
+	for token, s := range c.sessions {
+		if s.certID == certID {
+			revoked = append(revoked, c.removeLocked(token))
+		}
+	}
+	c.mu.Unlock()
+
+	for _, s := range revoked {
+		s.close()
+	}
+}
+
+// removeLocked removes the session from the cache and returns it to be closed.
+// The cache must be locked.
+func (c *sessionCache) removeLocked(token string) *session {
+	s, ok := c.sessions[token]
+	if !ok {
+		return nil
+	}
+
+	delete(c.sessions, token)
+	c.order.Remove(s.elem)
+	s.timer.Stop()
+
+	return s
+}
+
+func newSessionToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, b); err != nil {
+		return "", fmt.Errorf("read random bytes: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
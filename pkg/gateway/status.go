@@ -0,0 +1,156 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrTrustStoreEmpty is returned if no EET CA root or no DSig CA certificate is trusted.
+var ErrTrustStoreEmpty = errors.New("trust store not loaded")
+
+// DependencyCheckInterval is the minimal interval between the checks of the dependencies.
+// The results of the last check are returned in between.
+const DependencyCheckInterval = 10 * time.Second
+
+// DependencyCheckTimeout limits the duration of each check of a dependency.
+var DependencyCheckTimeout = 5 * time.Second
+
+// Names of the checked dependencies.
+const (
+	FSCRDependency     = "fscr"
+	KeystoreDependency = "keystore"
+)
+
+// DependencyStatus is the result of the last check of a dependency.
+type DependencyStatus struct {
+	Name string
+	// Err is the error of the last check, nil if the dependency is online.
+	Err error
+	// Latency is the duration of the last check.
+	Latency time.Duration
+	// CheckedAt is the time of the last check.
+	CheckedAt time.Time
+	// LastSuccess is the time of the last successful check. It's zero if no check succeeded.
+	LastSuccess time.Time
+}
+
+type dependencyCheck struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+// dependencyMonitor checks the dependencies at most once per DependencyCheckInterval.
+type dependencyMonitor struct {
+	mu        sync.Mutex
+	checks    []dependencyCheck
+	statuses  []DependencyStatus
+	checkedAt time.Time
+	// checking is closed once the check in progress is done, nil if no check is in progress
+	checking chan struct{}
+}
+
+func newDependencyMonitor(checks ...dependencyCheck) *dependencyMonitor {
+	statuses := make([]DependencyStatus, len(checks))
+	for i, c := range checks {
+		statuses[i].Name = c.name
+	}
+
+	return &dependencyMonitor{
+		checks:   checks,
+		statuses: statuses,
+	}
+}
+
+// status returns the statuses of the dependencies. The dependencies are checked concurrently
+// if the last check is older than DependencyCheckInterval, the concurrent callers wait for its results.
+func (m *dependencyMonitor) status() []DependencyStatus {
+	m.mu.Lock()
+	checking, refresh := m.checking, false
+	if checking == nil && (m.checkedAt.IsZero() || time.Since(m.checkedAt) >= DependencyCheckInterval) {
+		checking, refresh = make(chan struct{}), true
+		m.checking = checking
+	}
+	m.mu.Unlock()
+
+	// the lock isn't held during the checks, so the callers are blocked at most by the check timeout
+	if refresh {
+		m.refresh(checking)
+	} else if checking != nil {
+		<-checking
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	statuses := make([]DependencyStatus, len(m.statuses))
+	copy(statuses, m.statuses)
+
+	return statuses
+}
+
+// refresh checks the dependencies, stores their statuses and closes done.
+func (m *dependencyMonitor) refresh(done chan struct{}) {
+	m.mu.Lock()
+	statuses := make([]DependencyStatus, len(m.statuses))
+	copy(statuses, m.statuses)
+	m.mu.Unlock()
+
+	m.check(statuses)
+
+	m.mu.Lock()
+	m.statuses = statuses
+	m.checkedAt = time.Now()
+	m.checking = nil
+	m.mu.Unlock()
+
+	close(done)
+}
+
+// check checks the dependencies concurrently and updates their statuses.
+func (m *dependencyMonitor) check(statuses []DependencyStatus) {
+	var wg sync.WaitGroup
+	for i, c := range m.checks {
+		wg.Add(1)
+		go func(s *DependencyStatus, c dependencyCheck) {
+			defer wg.Done()
+
+			start := time.Now()
+			s.Err = probe(c)
+			s.Latency = time.Since(start)
+			s.CheckedAt = start
+			if s.Err == nil {
+				s.LastSuccess = start
+			}
+		}(&statuses[i], c)
+	}
+
+	wg.Wait()
+}
+
+// probe runs the check within DependencyCheckTimeout. A check ignoring its context is abandoned
+// once the timeout elapses.
+func probe(c dependencyCheck) error {
+	// the checks are shared by the callers, so they aren't canceled with the request of one of them
+	ctx, cancel := context.WithTimeout(context.Background(), DependencyCheckTimeout)
+	defer cancel()
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- c.check(ctx)
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+		select {
+		case err := <-errc:
+			return err
+		default:
+			return fmt.Errorf("check %s: %w", c.name, ctx.Err())
+		}
+	}
+}
@@ -0,0 +1,395 @@
+package keystore
+
+import (
+	"container/list"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// InvalidationChannel is the redis pub/sub channel on which the IDs of modified records are published
+// to invalidate the caches of all gateway replicas.
+var InvalidationChannel = "invalidations"
+
+// CachePolicy configures the in-memory cache of decrypted KeyPairs.
+type CachePolicy struct {
+	// TTL is the lifetime of a cached KeyPair.
+	TTL time.Duration
+	// Size is the maximum number of cached KeyPairs. The least recently used one is evicted if exceeded.
+	Size int
+}
+
+// DefaultCachePolicy is a CachePolicy with sensible defaults.
+var DefaultCachePolicy = CachePolicy{
+	TTL:  time.Minute,
+	Size: 256,
+}
+
+type cacheKey struct {
+	id string
+	// mac is a keyed hash of the password, the password itself is never kept
+	mac [sha256.Size]byte
+}
+
+type cacheEntry struct {
+	key       cacheKey
+	kp        *KeyPair
+	expiresAt time.Time
+	// refs counts the users of the key material of kp including the cache itself,
+	// the key material is zeroized once the last of them is done
+	refs int
+}
+
+type policyEntry struct {
+	policy    *Policy
+	expiresAt time.Time
+	// entries counts the cached KeyPairs of the record, the policy is dropped with the last of them
+	entries int
+}
+
+// cachedService is a Service caching decrypted KeyPairs and their usage policies in memory.
+type cachedService struct {
+	Service
+
+	rdb    *redis.Client
+	policy CachePolicy
+	macKey []byte
+
+	mu       sync.Mutex
+	entries  map[cacheKey]*list.Element
+	policies map[string]*policyEntry
+	// lru lists the entries by the time of the last use, the most recent first
+	lru *list.List
+	// generation is increased by every invalidation, so a record modified while being
+	// retrieved isn't cached
+	generation uint64
+}
+
+// NewCachedService returns a Service caching KeyPairs retrieved from svc in memory according to the policy.
+// The usage policy of a record is cached together with its KeyPairs.
+// The cached KeyPairs are invalidated by UpdateID, UpdatePassword, UpdatePolicy, Replace, Rollback, Revoke
+// and Delete of any replica subscribed to the InvalidationChannel of rdb. The subscription is closed
+// once ctx is done.
+func NewCachedService(ctx context.Context, svc Service, rdb *redis.Client, policy CachePolicy) (Service, error) {
+	macKey := make([]byte, sha256.Size)
+	if _, err := io.ReadFull(rand.Reader, macKey); err != nil {
+		return nil, fmt.Errorf("generate a random cache key: %w", err)
+	}
+
+	c := &cachedService{
+		Service:  svc,
+		rdb:      rdb,
+		policy:   policy,
+		macKey:   macKey,
+		entries:  make(map[cacheKey]*list.Element),
+		policies: make(map[string]*policyEntry),
+		lru:      list.New(),
+	}
+
+	ps := rdb.Subscribe(ctx, InvalidationChannel)
+	// wait for the confirmation so no invalidation is missed
+	if _, err := ps.Receive(ctx); err != nil {
+		_ = ps.Close()
+		return nil, fmt.Errorf("subscribe to invalidations: %w", err)
+	}
+
+	go c.listen(ctx, ps)
+
+	return c, nil
+}
+
+func (c *cachedService) listen(ctx context.Context, ps *redis.PubSub) {
+	defer func() {
+		_ = ps.Close()
+	}()
+
+	ch := ps.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+
+			c.invalidate(msg.Payload)
+		}
+	}
+}
+
+// Get retrieves a KeyPair by the ID from the cache or, if not cached, from the underlying Service.
+// A cached KeyPair shares its key material with other callers and must not be modified. Zeroize
+// releases it, the key material is zeroized once it's neither cached nor used by anyone else.
+func (c *cachedService) Get(ctx context.Context, id string, password []byte) (*KeyPair, error) {
+	key := c.key(id, password)
+	if kp := c.get(key); kp != nil {
+		return kp, nil
+	}
+
+	generation := c.currentGeneration()
+	kp, err := c.Service.Get(ctx, id, password)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := c.Service.GetPolicy(ctx, id)
+	if err != nil {
+		// the KeyPair can't be cached without its policy
+		return kp, nil
+	}
+
+	return c.add(key, generation, kp, policy), nil
+}
+
+// GetPolicy retrieves the usage policy of the record with the ID from the cache or, if not cached,
+// from the underlying Service. The policy is cached together with the KeyPairs of the record.
+// The cached policy is shared with other callers and must not be modified.
+func (c *cachedService) GetPolicy(ctx context.Context, id string) (*Policy, error) {
+	if policy, ok := c.getPolicy(id); ok {
+		return policy, nil
+	}
+
+	return c.Service.GetPolicy(ctx, id)
+}
+
+// UpdateID updates the ID of the record and invalidates the cached KeyPairs of both IDs.
+func (c *cachedService) UpdateID(ctx context.Context, oldID, newID string) error {
+	if err := c.Service.UpdateID(ctx, oldID, newID); err != nil {
+		return err
+	}
+
+	c.publish(ctx, oldID)
+	c.publish(ctx, newID)
+
+	return nil
+}
+
+// UpdatePassword updates the password of the record and invalidates its cached KeyPairs.
+func (c *cachedService) UpdatePassword(ctx context.Context, id string, oldPassword, newPassword []byte) error {
+	if err := c.Service.UpdatePassword(ctx, id, oldPassword, newPassword); err != nil {
+		return err
+	}
+
+	c.publish(ctx, id)
+
+	return nil
+}
+
+// UpdatePolicy overwrites the usage policy of the record and invalidates its cached KeyPairs.
+func (c *cachedService) UpdatePolicy(ctx context.Context, id string, policy *Policy) error {
+	if err := c.Service.UpdatePolicy(ctx, id, policy); err != nil {
+		return err
+	}
+
+	c.publish(ctx, id)
+
+	return nil
+}
+
+// Replace swaps the KeyPair of the record and invalidates its cached KeyPairs.
+func (c *cachedService) Replace(ctx context.Context, id string, password []byte, kp *KeyPair) error {
+	if err := c.Service.Replace(ctx, id, password, kp); err != nil {
+		return err
+	}
+
+	c.publish(ctx, id)
+
+	return nil
+}
+
+// Rollback restores the previous KeyPair of the record and invalidates its cached KeyPairs.
+func (c *cachedService) Rollback(ctx context.Context, id string, password []byte) error {
+	if err := c.Service.Rollback(ctx, id, password); err != nil {
+		return err
+	}
+
+	c.publish(ctx, id)
+
+	return nil
+}
+
+// Revoke marks the certificate of the record as revoked and invalidates its cached KeyPairs.
+func (c *cachedService) Revoke(ctx context.Context, id string, serialNumber *big.Int) error {
+	if err := c.Service.Revoke(ctx, id, serialNumber); err != nil {
+		return err
+	}
+
+	c.publish(ctx, id)
+
+	return nil
+}
+
+// Delete removes the record and invalidates its cached KeyPairs.
+func (c *cachedService) Delete(ctx context.Context, id string) error {
+	if err := c.Service.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	c.publish(ctx, id)
+
+	return nil
+}
+
+// publish invalidates the cached KeyPairs of the ID locally and announces the invalidation to other replicas.
+func (c *cachedService) publish(ctx context.Context, id string) {
+	c.invalidate(id)
+
+	// other replicas drop the KeyPair after the TTL if the announcement fails
+	_ = c.rdb.Publish(ctx, InvalidationChannel, id).Err()
+}
+
+func (c *cachedService) key(id string, password []byte) cacheKey {
+	mac := hmac.New(sha256.New, c.macKey)
+	_, _ = mac.Write(password)
+
+	key := cacheKey{id: id}
+	copy(key.mac[:], mac.Sum(nil))
+
+	return key
+}
+
+// get returns the cached KeyPair sharing the key material or nil if the key isn't cached.
+func (c *cachedService) get(key cacheKey) *KeyPair {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+
+	e := elem.Value.(*cacheEntry)
+	if time.Now().After(e.expiresAt) {
+		c.removeLocked(elem)
+		return nil
+	}
+
+	c.lru.MoveToFront(elem)
+
+	return c.shareLocked(e)
+}
+
+// getPolicy returns the cached policy of the record and reports whether it's cached.
+func (c *cachedService) getPolicy(id string) (*Policy, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p, ok := c.policies[id]
+	if !ok || time.Now().After(p.expiresAt) {
+		return nil, false
+	}
+
+	return p.policy, true
+}
+
+func (c *cachedService) currentGeneration() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.generation
+}
+
+// add caches the KeyPair with the policy of its record and returns the KeyPair sharing its key material.
+// The KeyPair isn't cached and is returned as it is if any record has been invalidated since
+// the generation, as the KeyPair or the policy may be outdated.
+func (c *cachedService) add(key cacheKey, generation uint64, kp *KeyPair, policy *Policy) *KeyPair {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.generation != generation {
+		return kp
+	}
+
+	if elem, ok := c.entries[key]; ok {
+		c.removeLocked(elem)
+	}
+
+	for c.lru.Len() > 0 && c.lru.Len() >= c.policy.Size {
+		c.removeLocked(c.lru.Back())
+	}
+
+	expiresAt := time.Now().Add(c.policy.TTL)
+	e := &cacheEntry{
+		key:       key,
+		kp:        kp,
+		expiresAt: expiresAt,
+		refs:      1,
+	}
+	c.entries[key] = c.lru.PushFront(e)
+
+	p, ok := c.policies[key.id]
+	if !ok {
+		p = new(policyEntry)
+		c.policies[key.id] = p
+	}
+
+	p.policy = policy
+	p.expiresAt = expiresAt
+	p.entries++
+
+	return c.shareLocked(e)
+}
+
+// invalidate removes all cached KeyPairs of the ID together with its policy.
+func (c *cachedService) invalidate(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	for key, elem := range c.entries {
+		if key.id == id {
+			c.removeLocked(elem)
+		}
+	}
+}
+
+// removeLocked removes the entry from the cache. Its key material is zeroized unless it's still used.
+// The cache must be locked.
+func (c *cachedService) removeLocked(elem *list.Element) {
+	e := c.lru.Remove(elem).(*cacheEntry)
+	delete(c.entries, e.key)
+
+	if p := c.policies[e.key.id]; p != nil {
+		if p.entries--; p.entries == 0 {
+			delete(c.policies, e.key.id)
+		}
+	}
+
+	c.releaseLocked(e)
+}
+
+// shareLocked returns a KeyPair sharing the key material of the entry. The key material isn't zeroized
+// until the KeyPair is released by its Zeroize. The cache must be locked.
+func (c *cachedService) shareLocked(e *cacheEntry) *KeyPair {
+	e.refs++
+
+	return &KeyPair{
+		Cert:     e.kp.Cert,
+		PK:       e.kp.PK,
+		Signer:   e.kp.Signer,
+		external: e.kp.external,
+		release: func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+
+			c.releaseLocked(e)
+		},
+	}
+}
+
+// releaseLocked drops a reference to the key material of the entry and zeroizes it
+// if it was the last one. The cache must be locked.
+func (c *cachedService) releaseLocked(e *cacheEntry) {
+	if e.refs--; e.refs == 0 {
+		e.kp.Zeroize()
+	}
+}
@@ -0,0 +1,302 @@
+package keystore_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/chutommy/eetgateway/pkg/keystore"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/require"
+)
+
+func newCachedSvc(t *testing.T, ctx context.Context, m *miniredis.Miniredis, policy keystore.CachePolicy) keystore.Service {
+	rdb := redis.NewClient(&redis.Options{
+		Addr: m.Addr(),
+	})
+
+	ks, err := keystore.NewCachedService(ctx, keystore.NewRedisService(rdb, keystore.DefaultLockoutPolicy, keystore.DefaultGracePeriod, nil), rdb, policy)
+	require.NoError(t, err)
+
+	return ks
+}
+
+func TestCachedService_Get(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   keystore.CachePolicy
+		id       string
+		password []byte
+		setup    func(ks keystore.Service)
+		ok       bool
+	}{
+		{
+			name:     "cached",
+			policy:   keystore.DefaultCachePolicy,
+			id:       certID,
+			password: certPassword,
+			ok:       true,
+		},
+		{
+			name:     "invalid password",
+			policy:   keystore.DefaultCachePolicy,
+			id:       certID,
+			password: certPassword2,
+			ok:       false,
+		},
+		{
+			name:     "expired",
+			policy:   keystore.CachePolicy{TTL: time.Millisecond, Size: 1},
+			id:       certID,
+			password: certPassword,
+			setup: func(ks keystore.Service) {
+				time.Sleep(10 * time.Millisecond)
+			},
+			ok: false,
+		},
+		{
+			name:     "evicted",
+			policy:   keystore.CachePolicy{TTL: time.Minute, Size: 1},
+			id:       certID,
+			password: certPassword,
+			setup: func(ks keystore.Service) {
+				_, err := ks.Get(context.Background(), certID2, certPassword2)
+				require.NoError(t, err)
+			},
+			ok: false,
+		},
+		{
+			name:     "zeroized by caller",
+			policy:   keystore.DefaultCachePolicy,
+			id:       certID,
+			password: certPassword,
+			setup: func(ks keystore.Service) {
+				kp, err := ks.Get(context.Background(), certID, certPassword)
+				require.NoError(t, err)
+				kp.Zeroize()
+			},
+			ok: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			m := miniredis.NewMiniRedis()
+			require.NoError(t, m.StartAddr(redisAddr))
+			defer m.Close()
+
+			ks := newCachedSvc(t, ctx, m, tc.policy)
+			require.NoError(t, ks.Store(context.Background(), certID, certPassword, certKP, nil))
+			require.NoError(t, ks.Store(context.Background(), certID2, certPassword2, certKP, nil))
+
+			_, err := ks.Get(context.Background(), certID, certPassword)
+			require.NoError(t, err)
+
+			if tc.setup != nil {
+				tc.setup(ks)
+			}
+
+			// only the cache can serve the record from now on
+			m.Del(certIDx)
+
+			kp, err := ks.Get(context.Background(), tc.id, tc.password)
+			if tc.ok {
+				require.NoError(t, err)
+				require.Equal(t, certKP.PK.D, kp.PK.D)
+				require.Equal(t, certKP.Cert.Raw, kp.Cert.Raw)
+
+				// the key material released by the caller isn't zeroized while cached
+				digest := sha256.Sum256([]byte("sale"))
+				_, err = rsa.SignPKCS1v15(nil, kp.PK, crypto.SHA256, digest[:])
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestCachedService_Invalidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(ks keystore.Service) error
+	}{
+		{
+			name: "update id",
+			modify: func(ks keystore.Service) error {
+				return ks.UpdateID(context.Background(), certID, certID2)
+			},
+		},
+		{
+			name: "update password",
+			modify: func(ks keystore.Service) error {
+				return ks.UpdatePassword(context.Background(), certID, certPassword, certPassword2)
+			},
+		},
+		{
+			name: "update policy",
+			modify: func(ks keystore.Service) error {
+				return ks.UpdatePolicy(context.Background(), certID, certPolicy)
+			},
+		},
+		{
+			name: "delete",
+			modify: func(ks keystore.Service) error {
+				return ks.Delete(context.Background(), certID)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			m := miniredis.NewMiniRedis()
+			require.NoError(t, m.StartAddr(redisAddr))
+			defer m.Close()
+
+			// two replicas sharing the keystore
+			ks := newCachedSvc(t, ctx, m, keystore.DefaultCachePolicy)
+			replica := newCachedSvc(t, ctx, m, keystore.DefaultCachePolicy)
+			require.NoError(t, ks.Store(context.Background(), certID, certPassword, certKP, nil))
+
+			_, err := ks.Get(context.Background(), certID, certPassword)
+			require.NoError(t, err)
+			_, err = replica.Get(context.Background(), certID, certPassword)
+			require.NoError(t, err)
+
+			require.NoError(t, tc.modify(ks))
+			// only invalidated caches reach the keystore from now on
+			m.Del(certIDx)
+
+			_, err = ks.Get(context.Background(), certID, certPassword)
+			require.Error(t, err)
+			_, err = ks.GetPolicy(context.Background(), certID)
+			require.Error(t, err)
+			require.Eventually(t, func() bool {
+				_, err := replica.Get(context.Background(), certID, certPassword)
+				return err != nil
+			}, time.Second, 10*time.Millisecond)
+			_, err = replica.GetPolicy(context.Background(), certID)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestCachedService_GetPolicy(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	m := miniredis.NewMiniRedis()
+	require.NoError(t, m.StartAddr(redisAddr))
+	defer m.Close()
+
+	ks := newCachedSvc(t, ctx, m, keystore.DefaultCachePolicy)
+	require.NoError(t, ks.Store(context.Background(), certID, certPassword, certKP, certPolicy))
+
+	// the policy isn't cached without the KeyPair
+	_, err := ks.GetPolicy(context.Background(), certID)
+	require.NoError(t, err)
+
+	kp, err := ks.Get(context.Background(), certID, certPassword)
+	require.NoError(t, err)
+	kp.Zeroize()
+
+	// only the cache can serve the record from now on
+	m.Del(certIDx)
+
+	policy, err := ks.GetPolicy(context.Background(), certID)
+	require.NoError(t, err)
+	require.Equal(t, certPolicy.IDPokl, policy.IDPokl)
+	require.True(t, policy.MatchIDPokl("pokl-1"))
+
+	_, err = ks.GetPolicy(context.Background(), certID2)
+	require.ErrorIs(t, err, keystore.ErrRecordNotFound)
+}
+
+func TestCachedService_Evicted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	m := miniredis.NewMiniRedis()
+	require.NoError(t, m.StartAddr(redisAddr))
+	defer m.Close()
+
+	ks := newCachedSvc(t, ctx, m, keystore.CachePolicy{TTL: time.Minute, Size: 1})
+	require.NoError(t, ks.Store(context.Background(), certID, certPassword, certKP, nil))
+	require.NoError(t, ks.Store(context.Background(), certID2, certPassword2, certKP, nil))
+
+	kp, err := ks.Get(context.Background(), certID, certPassword)
+	require.NoError(t, err)
+	pk := kp.PK
+
+	// evict the KeyPair in use
+	kp2, err := ks.Get(context.Background(), certID2, certPassword2)
+	require.NoError(t, err)
+	defer kp2.Zeroize()
+
+	// the key material isn't zeroized until the last user releases it
+	digest := sha256.Sum256([]byte("sale"))
+	_, err = rsa.SignPKCS1v15(nil, kp.PK, crypto.SHA256, digest[:])
+	require.NoError(t, err)
+
+	kp.Zeroize()
+	require.Zero(t, pk.D.Sign())
+}
+
+func BenchmarkCachedService_Get(b *testing.B) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	m := miniredis.NewMiniRedis()
+	if err := m.StartAddr(redisAddr); err != nil {
+		b.Fatal(err)
+	}
+	defer m.Close()
+
+	rdb := redis.NewClient(&redis.Options{
+		Addr: m.Addr(),
+	})
+
+	ks := keystore.NewRedisService(rdb, keystore.DefaultLockoutPolicy, keystore.DefaultGracePeriod, nil)
+	cached, err := keystore.NewCachedService(ctx, ks, rdb, keystore.DefaultCachePolicy)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	if err = ks.Store(ctx, certID, certPassword, certKP, certPolicy); err != nil {
+		b.Fatal(err)
+	}
+
+	for _, bc := range []struct {
+		name string
+		ks   keystore.Service
+	}{
+		{name: "keystore", ks: ks},
+		{name: "cache hit", ks: cached},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			// a sale retrieves both the KeyPair and the policy
+			for i := 0; i < b.N; i++ {
+				kp, err := bc.ks.Get(ctx, certID, certPassword)
+				if err != nil {
+					b.Fatal(err)
+				}
+
+				if _, err = bc.ks.GetPolicy(ctx, certID); err != nil {
+					b.Fatal(err)
+				}
+
+				kp.Zeroize()
+			}
+		})
+	}
+}
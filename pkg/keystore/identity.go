@@ -0,0 +1,184 @@
+package keystore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// ErrRecordRevoked is returned if the certificate of a record has been revoked.
+var ErrRecordRevoked = errors.New("certificate of the record revoked")
+
+var (
+	// SerialNumberKey is the redis key of the unencrypted serial number field of the certificate.
+	SerialNumberKey = "serial-number"
+	// IssuerKey is the redis key of the unencrypted DER encoded issuer field of the certificate.
+	IssuerKey = "issuer"
+	// DistributionPointsKey is the redis key of the unencrypted CRL distribution points field of the certificate.
+	DistributionPointsKey = "distribution-points"
+	// RevokedAtKey is the redis key of the time of the revocation of the certificate.
+	RevokedAtKey = "revoked-at"
+	// PreviousSerialNumberKey is the redis key of the serial number field of the previous version.
+	PreviousSerialNumberKey = "previous-serial-number"
+	// PreviousIssuerKey is the redis key of the issuer field of the previous version.
+	PreviousIssuerKey = "previous-issuer"
+	// PreviousDistributionPointsKey is the redis key of the distribution points field of the previous version.
+	PreviousDistributionPointsKey = "previous-distribution-points"
+)
+
+// previousKeys are the redis keys of all fields of the previous version.
+var previousKeys = []string{
+	PreviousPublicKey,
+	PreviousPrivateKeyKey,
+	PreviousExpiresAtKey,
+	PreviousSerialNumberKey,
+	PreviousIssuerKey,
+	PreviousDistributionPointsKey,
+}
+
+// Identity identifies the certificate of a record without its decryption. It's kept unencrypted,
+// so the revocation status of stored certificates can be checked without their passwords.
+type Identity struct {
+	// SerialNumber is nil if the record was stored without the identity.
+	SerialNumber *big.Int
+	// Issuer is the DER encoded issuer of the certificate.
+	Issuer []byte
+	// DistributionPoints are the CRL distribution points of the certificate.
+	DistributionPoints []string
+	// RevokedAt is zero if the certificate hasn't been revoked.
+	RevokedAt time.Time
+}
+
+// withIdentity adds the identity of the certificate of kp to the fields.
+func withIdentity(fields map[string]interface{}, kp *KeyPair) map[string]interface{} {
+	fields[SerialNumberKey] = kp.Cert.SerialNumber.String()
+	fields[IssuerKey] = kp.Cert.RawIssuer
+	// URLs can't contain unescaped spaces
+	fields[DistributionPointsKey] = strings.Join(kp.Cert.CRLDistributionPoints, " ")
+
+	return fields
+}
+
+// Identity retrieves the identity of the certificate of the record with the ID.
+func (r *redisService) Identity(ctx context.Context, id string) (*Identity, error) {
+	idx := ToCertObjectKey(id)
+
+	var vals []interface{}
+	txf := func(tx *redis.Tx) error {
+		// check if exists
+		i, err := tx.Exists(ctx, idx).Result()
+		if err != nil {
+			return fmt.Errorf("check if id exists: %w", err)
+		}
+
+		if i == 0 {
+			return fmt.Errorf("not found record with the id: %w", ErrRecordNotFound)
+		}
+
+		// read from database
+		vals, err = tx.HMGet(ctx, idx, SerialNumberKey, IssuerKey, RevokedAtKey, DistributionPointsKey).Result()
+		if err != nil {
+			return fmt.Errorf("retrieve stored identity from database: %w", err)
+		}
+
+		return nil
+	}
+
+	for k := 0; k < 3; k++ {
+		err := r.rdb.Watch(ctx, txf, idx)
+		if errors.Is(err, redis.TxFailedErr) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("transaction failed: %w", err)
+		}
+
+		identity := new(Identity)
+		if s, ok := vals[0].(string); ok && s != "" {
+			if identity.SerialNumber, ok = new(big.Int).SetString(s, 10); !ok {
+				return nil, fmt.Errorf("invalid serial number: %s", s)
+			}
+		}
+
+		if s, ok := vals[1].(string); ok && s != "" {
+			identity.Issuer = []byte(s)
+		}
+
+		if s, ok := vals[2].(string); ok {
+			nsec, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid time of the revocation: %w", err)
+			}
+
+			identity.RevokedAt = time.Unix(0, nsec)
+		}
+
+		if s, ok := vals[3].(string); ok {
+			identity.DistributionPoints = strings.Fields(s)
+		}
+
+		return identity, nil
+	}
+
+	return nil, ErrReachedMaxAttempts
+}
+
+// Revoke marks the certificate of the record as revoked if its serial number still matches. Get then
+// returns ErrRecordRevoked until the certificate is replaced or rolled back.
+func (r *redisService) Revoke(ctx context.Context, id string, serialNumber *big.Int) error {
+	idx := ToCertObjectKey(id)
+
+	txf := func(tx *redis.Tx) error {
+		// check if exists
+		i, err := tx.Exists(ctx, idx).Result()
+		if err != nil {
+			return fmt.Errorf("check if id exists: %w", err)
+		}
+
+		if i == 0 {
+			return fmt.Errorf("record not found by the id: %w", ErrRecordNotFound)
+		}
+
+		// the certificate may have been replaced in the meantime
+		s, err := tx.HGet(ctx, idx, SerialNumberKey).Result()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return fmt.Errorf("retrieve stored serial number from database: %w", err)
+		}
+
+		if s != serialNumber.String() {
+			return nil
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			_, err = pipe.HSetNX(ctx, idx, RevokedAtKey, time.Now().UnixNano()).Result()
+			if err != nil {
+				return fmt.Errorf("store time of the revocation in database: %w", err)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}
+
+	for k := 0; k < 3; k++ {
+		err := r.rdb.Watch(ctx, txf, idx)
+		if errors.Is(err, redis.TxFailedErr) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("transaction failed: %w", err)
+		}
+
+		return nil
+	}
+
+	return ErrReachedMaxAttempts
+}
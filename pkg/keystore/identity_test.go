@@ -0,0 +1,161 @@
+package keystore_test
+
+import (
+	"context"
+	"crypto/x509"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/chutommy/eetgateway/pkg/keystore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisService_Identity(t *testing.T) {
+	ks, m := newRedisSvc(t)
+	defer m.Close()
+
+	_, err := ks.Identity(context.Background(), certID)
+	require.ErrorIs(t, err, keystore.ErrRecordNotFound)
+
+	err = ks.Store(context.Background(), certID, certPassword, certKP, nil)
+	require.NoError(t, err)
+
+	identity, err := ks.Identity(context.Background(), certID)
+	require.NoError(t, err)
+	require.Equal(t, certKP.Cert.SerialNumber, identity.SerialNumber)
+	require.Equal(t, certKP.Cert.RawIssuer, identity.Issuer)
+	require.True(t, identity.RevokedAt.IsZero())
+
+	require.Empty(t, identity.DistributionPoints)
+
+	// records stored without the identity
+	m.HDel(certIDx, keystore.SerialNumberKey)
+	m.HDel(certIDx, keystore.IssuerKey)
+	m.HDel(certIDx, keystore.DistributionPointsKey)
+	identity, err = ks.Identity(context.Background(), certID)
+	require.NoError(t, err)
+	require.Nil(t, identity.SerialNumber)
+	require.Empty(t, identity.DistributionPoints)
+}
+
+func TestRedisService_IdentityDistributionPoints(t *testing.T) {
+	ks, m := newRedisSvc(t)
+	defer m.Close()
+
+	urls := []string{"http://crl.example.com/a.crl", "http://crl.example.com/b.crl"}
+	kp := keyPairOf(func(tmpl *x509.Certificate) {
+		tmpl.CRLDistributionPoints = urls
+	})
+
+	err := ks.Store(context.Background(), certID, certPassword, kp, nil)
+	require.NoError(t, err)
+
+	identity, err := ks.Identity(context.Background(), certID)
+	require.NoError(t, err)
+	require.Equal(t, urls, identity.DistributionPoints)
+
+	// the distribution points follow the replaced and rolled back versions
+	err = ks.Replace(context.Background(), certID, certPassword, renewedKP)
+	require.NoError(t, err)
+
+	identity, err = ks.Identity(context.Background(), certID)
+	require.NoError(t, err)
+	require.Empty(t, identity.DistributionPoints)
+
+	err = ks.Rollback(context.Background(), certID, certPassword)
+	require.NoError(t, err)
+
+	identity, err = ks.Identity(context.Background(), certID)
+	require.NoError(t, err)
+	require.Equal(t, urls, identity.DistributionPoints)
+}
+
+func TestRedisService_Revoke(t *testing.T) {
+	tests := []struct {
+		name   string
+		serial *big.Int
+		setup  func(ks keystore.Service)
+		err    error
+	}{
+		{
+			name:   "revoked",
+			serial: certKP.Cert.SerialNumber,
+			err:    keystore.ErrRecordRevoked,
+		},
+		{
+			name:   "serial number mismatch",
+			serial: big.NewInt(0),
+			err:    nil,
+		},
+		{
+			name:   "replaced",
+			serial: certKP.Cert.SerialNumber,
+			setup: func(ks keystore.Service) {
+				err := ks.Replace(context.Background(), certID, certPassword, renewedKP)
+				require.NoError(t, err)
+			},
+			err: nil,
+		},
+		{
+			name:   "rolled back",
+			serial: certKP.Cert.SerialNumber,
+			setup: func(ks keystore.Service) {
+				err := ks.Replace(context.Background(), certID, certPassword, renewedKP)
+				require.NoError(t, err)
+				err = ks.Rollback(context.Background(), certID, certPassword)
+				require.NoError(t, err)
+			},
+			err: nil,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ks, m := newRedisSvc(t)
+			defer m.Close()
+
+			err := ks.Store(context.Background(), certID, certPassword, certKP, nil)
+			require.NoError(t, err)
+
+			err = ks.Revoke(context.Background(), certID, tc.serial)
+			require.NoError(t, err)
+
+			if tc.setup != nil {
+				tc.setup(ks)
+			}
+
+			_, err = ks.Get(context.Background(), certID, certPassword)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+
+				identity, err := ks.Identity(context.Background(), certID)
+				require.NoError(t, err)
+				require.WithinDuration(t, time.Now(), identity.RevokedAt, time.Minute)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRedisService_RollbackIdentity(t *testing.T) {
+	ks, m := newRedisSvc(t)
+	defer m.Close()
+
+	err := ks.Store(context.Background(), certID, certPassword, certKP, nil)
+	require.NoError(t, err)
+	err = ks.Replace(context.Background(), certID, certPassword, renewedKP)
+	require.NoError(t, err)
+
+	identity, err := ks.Identity(context.Background(), certID)
+	require.NoError(t, err)
+	require.Equal(t, renewedKP.Cert.SerialNumber, identity.SerialNumber)
+
+	err = ks.Rollback(context.Background(), certID, certPassword)
+	require.NoError(t, err)
+
+	identity, err = ks.Identity(context.Background(), certID)
+	require.NoError(t, err)
+	require.Equal(t, certKP.Cert.SerialNumber, identity.SerialNumber)
+}
@@ -1,6 +1,7 @@
 package keystore
 
 import (
+	"crypto"
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
@@ -11,6 +12,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math/big"
 
 	"go.uber.org/multierr"
 )
@@ -18,13 +20,45 @@ import (
 // ErrInvalidDecryptionKey is returned if the given password for the decryption is invalid and can't be used.
 var ErrInvalidDecryptionKey = errors.New("given password can't decrypt the message")
 
+// ErrKeyNotExportable is returned if the private key material of the KeyPair isn't available.
+var ErrKeyNotExportable = errors.New("private key not exportable")
+
 // KeyPair represents a combination of a certificate and a private key.
 type KeyPair struct {
 	Cert *x509.Certificate
 	PK   *rsa.PrivateKey
+	// Signer signs by the private key of the certificate kept outside the memory of the gateway,
+	// e.g. in a PKCS #11 token or by a remote signing service. It takes precedence over PK.
+	// Such KeyPair is stored without the private key and its Signer is provided by the SignerProvider
+	// of the keystore once retrieved. It can't be exported.
+	Signer crypto.Signer
+
+	// external reports whether the private key is kept outside the gateway
+	external bool
+	// release returns the key material shared with its owner instead of zeroizing it
+	release func()
+}
+
+// SigningKey returns the key signing on behalf of the certificate: the external Signer if set,
+// the PK otherwise. ErrNoSigningKey is returned if there is neither of them.
+func (kp *KeyPair) SigningKey() (crypto.Signer, error) {
+	switch {
+	case kp.Signer != nil:
+		return kp.Signer, nil
+	case kp.PK != nil:
+		return kp.PK, nil
+	}
+
+	return nil, ErrNoSigningKey
 }
 
+// encrypt encrypts the certificate and the private key. The private key of an external Signer
+// is left out.
 func (kp *KeyPair) encrypt(password, salt []byte) (cert []byte, pk []byte, err error) {
+	if kp.PK == nil && kp.Signer == nil && !kp.external {
+		return nil, nil, fmt.Errorf("encrypt private key: %w", ErrNoSigningKey)
+	}
+
 	gcm, err := gcmCipher(salt, password)
 	if err != nil {
 		return nil, nil, fmt.Errorf("generate GCM: %w", err)
@@ -37,8 +71,13 @@ func (kp *KeyPair) encrypt(password, salt []byte) (cert []byte, pk []byte, err e
 		return nil, nil, fmt.Errorf("encrypt certificate with GCM: %w", err)
 	}
 
+	if kp.PK == nil {
+		return cert, nil, nil
+	}
+
 	// encrypt private key
 	derPK := x509.MarshalPKCS1PrivateKey(kp.PK)
+	defer zeroizeBytes(derPK)
 	pk, err = encryptPEMWithGCM(gcm, "RSA PRIVATE KEY", derPK)
 	if err != nil {
 		return nil, nil, fmt.Errorf("encrypt private key with GCM: %w", err)
@@ -79,12 +118,19 @@ func (kp *KeyPair) decrypt(password, salt, cert, pk []byte) error {
 		return fmt.Errorf("parse certificate: %w", err)
 	}
 
+	// the private key is kept outside the gateway
+	if len(pk) == 0 {
+		kp.external = true
+		return nil
+	}
+
 	// private key
 	pkPem, err := decryptPemWithGCM(gcm, pk)
 	if err != nil {
 		return fmt.Errorf("decrypt private key: %w", err)
 	}
 
+	defer zeroizeBytes(pkPem)
 	kp.PK, err = x509.ParsePKCS1PrivateKey(pkPem)
 	if err != nil {
 		return fmt.Errorf("parse private key: %w", err)
@@ -141,3 +187,54 @@ func addSalt(salt []byte, password []byte) []byte {
 
 	return out
 }
+
+// Zeroize overwrites the private key material of the KeyPair and drops its references.
+// The key material shared by a cached KeyPair is released instead and zeroized by the cache
+// once no longer used. The KeyPair must not be used afterwards.
+func (kp *KeyPair) Zeroize() {
+	if kp.release != nil {
+		kp.release()
+		kp.release = nil
+	} else if kp.PK != nil {
+		zeroizeInt(kp.PK.D)
+		for _, p := range kp.PK.Primes {
+			zeroizeInt(p)
+		}
+
+		zeroizeInt(kp.PK.Precomputed.Dp)
+		zeroizeInt(kp.PK.Precomputed.Dq)
+		zeroizeInt(kp.PK.Precomputed.Qinv)
+		for _, v := range kp.PK.Precomputed.CRTValues {
+			zeroizeInt(v.Exp)
+			zeroizeInt(v.Coeff)
+			zeroizeInt(v.R)
+		}
+
+		// crypto/rsa keeps its own unexported copy of the key material in the precomputed values,
+		// dropping it makes the key unusable as it can't be precomputed from the zeroed values again
+		kp.PK.Precomputed = rsa.PrecomputedValues{}
+	}
+
+	kp.PK = nil
+	kp.Signer = nil
+	kp.Cert = nil
+}
+
+func zeroizeInt(n *big.Int) {
+	if n == nil {
+		return
+	}
+
+	words := n.Bits()
+	for i := range words {
+		words[i] = 0
+	}
+
+	n.SetInt64(0)
+}
+
+func zeroizeBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
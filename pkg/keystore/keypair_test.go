@@ -0,0 +1,123 @@
+package keystore_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"errors"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/chutommy/eetgateway/pkg/keystore"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyPair_Zeroize(t *testing.T) {
+	pk, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+
+	digest := sha256.Sum256([]byte("sale"))
+	_, err = rsa.SignPKCS1v15(nil, pk, crypto.SHA256, digest[:])
+	require.NoError(t, err)
+
+	kp := &keystore.KeyPair{PK: pk}
+	kp.Zeroize()
+	require.Nil(t, kp.PK)
+
+	// the key material precomputed by crypto/rsa must not be usable either
+	_, err = rsa.SignPKCS1v15(nil, pk, crypto.SHA256, digest[:])
+	require.Error(t, err)
+}
+
+func TestKeyPair_SigningKey(t *testing.T) {
+	pk, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+
+	external, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+
+	_, err = (&keystore.KeyPair{}).SigningKey()
+	require.ErrorIs(t, err, keystore.ErrNoSigningKey)
+
+	key, err := (&keystore.KeyPair{PK: pk}).SigningKey()
+	require.NoError(t, err)
+	require.Equal(t, pk, key)
+
+	key, err = (&keystore.KeyPair{PK: pk, Signer: external}).SigningKey()
+	require.NoError(t, err)
+	require.Equal(t, external, key)
+}
+
+// signers is an in-process fake of a SignerProvider keeping the private keys outside the keystore.
+type signers map[string]crypto.Signer
+
+func (s signers) Signer(cert *x509.Certificate) (crypto.Signer, error) {
+	key, ok := s[string(cert.Raw)]
+	if !ok {
+		return nil, errors.New("unknown certificate")
+	}
+
+	return key, nil
+}
+
+func TestKeyPair_External(t *testing.T) {
+	m := miniredis.NewMiniRedis()
+	require.NoError(t, m.StartAddr(redisAddr))
+	defer m.Close()
+
+	rdb := redis.NewClient(&redis.Options{
+		Addr: m.Addr(),
+	})
+
+	ks := keystore.NewRedisService(rdb, keystore.DefaultLockoutPolicy, keystore.DefaultGracePeriod,
+		signers{string(certKP.Cert.Raw): certKP.PK})
+
+	kp := &keystore.KeyPair{
+		Cert:   certKP.Cert,
+		Signer: certKP.PK,
+	}
+
+	err := ks.Store(context.Background(), certID, certPassword, kp, nil)
+	require.NoError(t, err)
+
+	// only the certificate is stored
+	pk := m.HGet(certIDx, keystore.PrivateKeyKey)
+	require.Empty(t, pk)
+
+	err = ks.UpdatePassword(context.Background(), certID, certPassword, certPassword2)
+	require.NoError(t, err)
+
+	_, err = ks.Get(context.Background(), certID, certPassword)
+	require.ErrorIs(t, err, keystore.ErrInvalidDecryptionKey)
+
+	kp, err = ks.Get(context.Background(), certID, certPassword2)
+	require.NoError(t, err)
+	require.Nil(t, kp.PK)
+	require.Equal(t, certKP.Cert.Raw, kp.Cert.Raw)
+
+	key, err := kp.SigningKey()
+	require.NoError(t, err)
+	require.Equal(t, certKP.PK, key)
+
+	// no signer configured
+	_, err = keystore.NewRedisService(rdb, keystore.DefaultLockoutPolicy, keystore.DefaultGracePeriod, nil).
+		Get(context.Background(), certID, certPassword2)
+	require.ErrorIs(t, err, keystore.ErrSignerUnavailable)
+}
+
+func TestKeyPair_NoSigningKey(t *testing.T) {
+	ks, m := newRedisSvc(t)
+	defer m.Close()
+
+	kp := &keystore.KeyPair{
+		Cert: certKP.Cert,
+	}
+
+	err := ks.Store(context.Background(), certID, certPassword, kp, nil)
+	require.ErrorIs(t, err, keystore.ErrNoSigningKey)
+	require.False(t, m.Exists(certIDx))
+}
@@ -0,0 +1,224 @@
+package keystore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// ErrKeyLocked is returned if an attempt can't be reserved because the key is locked out.
+var ErrKeyLocked = errors.New("key locked after too many failed attempts")
+
+// ErrTooManyAttempts is returned if an attempt can't be reserved because the limit of the attempts
+// in progress under the key is reached.
+var ErrTooManyAttempts = errors.New("too many attempts in progress")
+
+var (
+	// FailuresObjectKey is the redis object key for counting failed attempts.
+	FailuresObjectKey = "failures"
+	// LockObjectKey is the redis object key for active lockouts.
+	LockObjectKey = "lock"
+	// PendingObjectKey is the redis object key for counting attempts in progress.
+	PendingObjectKey = "pending"
+)
+
+// PendingAttemptTTL is the time after which the attempts in progress are forgotten if they aren't released,
+// e.g. if the gateway is stopped in the middle of an attempt.
+var PendingAttemptTTL = time.Minute
+
+// ToFailuresObjectKey converts a lockout key to a keystore object key of the failure counter.
+func ToFailuresObjectKey(key string) string {
+	return fmt.Sprintf("%s:%s", FailuresObjectKey, key)
+}
+
+// ToLockObjectKey converts a lockout key to a keystore object key of the lock.
+func ToLockObjectKey(key string) string {
+	return fmt.Sprintf("%s:%s", LockObjectKey, key)
+}
+
+// ToPendingObjectKey converts a lockout key to a keystore object key of the attempts in progress.
+func ToPendingObjectKey(key string) string {
+	return fmt.Sprintf("%s:%s", PendingObjectKey, key)
+}
+
+// LockoutPolicy configures the exponential lockout after repeated failed attempts.
+type LockoutPolicy struct {
+	// Threshold is the number of failed attempts tolerated before the first lockout.
+	Threshold int64
+	// BaseDelay is the duration of the first lockout. Each next failure doubles it.
+	BaseDelay time.Duration
+	// MaxDelay caps the duration of a single lockout.
+	MaxDelay time.Duration
+	// ResetAfter is the period without failures after which the counter is forgotten.
+	ResetAfter time.Duration
+	// MaxInProgress limits the number of attempts in progress under a key. It doesn't lock the key out,
+	// the exceeding attempts are just rejected. Zero means no limit.
+	MaxInProgress int64
+}
+
+// DefaultLockoutPolicy is a LockoutPolicy with sensible defaults.
+var DefaultLockoutPolicy = LockoutPolicy{
+	Threshold:  5,
+	BaseDelay:  time.Second,
+	MaxDelay:   time.Hour,
+	ResetAfter: 24 * time.Hour,
+}
+
+// delay returns the lockout duration after the given number of failures.
+func (p LockoutPolicy) delay(failures int64) time.Duration {
+	if p.Threshold <= 0 || failures < p.Threshold {
+		return 0
+	}
+
+	d := p.BaseDelay
+	for i := p.Threshold; i < failures; i++ {
+		d *= 2
+		if d >= p.MaxDelay || d <= 0 {
+			return p.MaxDelay
+		}
+	}
+
+	if d > p.MaxDelay {
+		return p.MaxDelay
+	}
+
+	return d
+}
+
+// schedule returns the lockout durations in milliseconds after the threshold is reached, the i-th one
+// after Threshold+i failures. The last one applies to all further failures.
+func (p LockoutPolicy) schedule() []interface{} {
+	var s []interface{}
+	for n := p.Threshold; n > 0 && len(s) < 64; n++ {
+		d := p.delay(n)
+		s = append(s, d.Milliseconds())
+		if d >= p.MaxDelay {
+			break
+		}
+	}
+
+	return s
+}
+
+// reserveScript reserves an attempt unless the key is locked or the limit of the attempts in progress
+// is reached. It returns the number of recorded failures, or -1 and the remaining lockout,
+// or -2 and the number of attempts in progress.
+var reserveScript = redis.NewScript(`
+local ttl = redis.call('PTTL', KEYS[2])
+if ttl > 0 then
+	return {-1, ttl}
+end
+
+local pending = tonumber(redis.call('GET', KEYS[3]) or '0')
+local limit = tonumber(ARGV[1])
+if limit > 0 and pending >= limit then
+	return {-2, pending}
+end
+
+local failures = tonumber(redis.call('GET', KEYS[1]) or '0')
+
+redis.call('INCR', KEYS[3])
+redis.call('PEXPIRE', KEYS[3], ARGV[2])
+return {failures, 0}
+`)
+
+// releaseScript releases an attempt in progress. A failed attempt is counted and locks the key
+// by the schedule given from the 4th argument on if the threshold is reached. It returns the duration
+// of the lockout in milliseconds.
+var releaseScript = redis.NewScript(`
+if redis.call('DECR', KEYS[3]) <= 0 then
+	redis.call('DEL', KEYS[3])
+end
+
+if ARGV[1] ~= '1' then
+	return 0
+end
+
+local n = redis.call('INCR', KEYS[1])
+redis.call('PEXPIRE', KEYS[1], ARGV[2])
+
+local threshold = tonumber(ARGV[3])
+if threshold <= 0 or n < threshold or #ARGV < 4 then
+	return 0
+end
+
+local d = tonumber(ARGV[4 + math.min(n - threshold, #ARGV - 4)])
+if d <= 0 then
+	return 0
+end
+
+redis.call('SET', KEYS[2], n, 'PX', d)
+return d
+`)
+
+// ReserveAttempt atomically checks the lockout of the key and reserves an attempt. ErrTooManyAttempts
+// is returned if the MaxInProgress limit of the LockoutPolicy is reached. The reserved attempt must be
+// released by ReleaseAttempt. The number of failed attempts recorded under the key is returned.
+func (r *redisService) ReserveAttempt(ctx context.Context, key string) (int64, error) {
+	keys := []string{ToFailuresObjectKey(key), ToLockObjectKey(key), ToPendingObjectKey(key)}
+	res, err := reserveScript.Run(ctx, r.rdb, keys, r.lockout.MaxInProgress, PendingAttemptTTL.Milliseconds()).Int64Slice()
+	if err != nil {
+		return 0, fmt.Errorf("reserve attempt: %w", err)
+	}
+
+	switch res[0] {
+	case -1:
+		return 0, fmt.Errorf("locked for %s: %w", time.Duration(res[1])*time.Millisecond, ErrKeyLocked)
+	case -2:
+		return 0, fmt.Errorf("%d attempts in progress: %w", res[1], ErrTooManyAttempts)
+	}
+
+	return res[0], nil
+}
+
+// ReleaseAttempt releases the attempt reserved by ReserveAttempt. A failed attempt is counted and locks
+// the key if the threshold of the LockoutPolicy is reached. The duration of the lockout is returned.
+func (r *redisService) ReleaseAttempt(ctx context.Context, key string, failed bool) (time.Duration, error) {
+	keys := []string{ToFailuresObjectKey(key), ToLockObjectKey(key), ToPendingObjectKey(key)}
+	args := append([]interface{}{failed, r.lockout.ResetAfter.Milliseconds(), r.lockout.Threshold}, r.lockout.schedule()...)
+	ms, err := releaseScript.Run(ctx, r.rdb, keys, args...).Int64()
+	if err != nil {
+		return 0, fmt.Errorf("release attempt: %w", err)
+	}
+
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+// Attempts returns the number of failed attempts recorded under the key and the remaining
+// duration of its lockout.
+func (r *redisService) Attempts(ctx context.Context, key string) (int64, time.Duration, error) {
+	var failures *redis.StringCmd
+	var lock *redis.DurationCmd
+	_, err := r.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		failures = pipe.Get(ctx, ToFailuresObjectKey(key))
+		lock = pipe.PTTL(ctx, ToLockObjectKey(key))
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return 0, 0, fmt.Errorf("retrieve failed attempts: %w", err)
+	}
+
+	n, err := failures.Int64()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return 0, 0, fmt.Errorf("parse number of failed attempts: %w", err)
+	}
+
+	remaining := lock.Val()
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	return n, remaining, nil
+}
+
+// ResetFailures removes both the counter of failed attempts and the lockout of the key.
+func (r *redisService) ResetFailures(ctx context.Context, key string) error {
+	if err := r.rdb.Del(ctx, ToFailuresObjectKey(key), ToLockObjectKey(key)).Err(); err != nil {
+		return fmt.Errorf("delete failed attempts: %w", err)
+	}
+
+	return nil
+}
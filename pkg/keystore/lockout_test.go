@@ -0,0 +1,330 @@
+package keystore_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/chutommy/eetgateway/pkg/keystore"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/require"
+)
+
+const lockoutKey = "cert:cert1"
+
+// fail records a failed attempt, lifting the previous lockout first.
+func fail(t *testing.T, ks keystore.Service, m *miniredis.Miniredis) time.Duration {
+	m.Del(keystore.ToLockObjectKey(lockoutKey))
+
+	_, err := ks.ReserveAttempt(context.Background(), lockoutKey)
+	require.NoError(t, err)
+
+	lock, err := ks.ReleaseAttempt(context.Background(), lockoutKey, true)
+	require.NoError(t, err)
+
+	return lock
+}
+
+func TestRedisService_ReserveAttempt(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    func(t *testing.T, ks keystore.Service, m *miniredis.Miniredis)
+		failures int64
+		err      error
+	}{
+		{
+			name:  "ok",
+			setup: func(t *testing.T, ks keystore.Service, m *miniredis.Miniredis) {},
+		},
+		{
+			name: "failures recorded",
+			setup: func(t *testing.T, ks keystore.Service, m *miniredis.Miniredis) {
+				fail(t, ks, m)
+				fail(t, ks, m)
+			},
+			failures: 2,
+		},
+		{
+			name: "locked",
+			setup: func(t *testing.T, ks keystore.Service, m *miniredis.Miniredis) {
+				for i := 0; i < 5; i++ {
+					fail(t, ks, m)
+				}
+			},
+			err: keystore.ErrKeyLocked,
+		},
+		{
+			name: "lock expired",
+			setup: func(t *testing.T, ks keystore.Service, m *miniredis.Miniredis) {
+				for i := 0; i < 5; i++ {
+					fail(t, ks, m)
+				}
+				m.FastForward(time.Minute)
+			},
+			failures: 5,
+		},
+		{
+			name: "attempts in progress",
+			setup: func(t *testing.T, ks keystore.Service, m *miniredis.Miniredis) {
+				for i := 0; i < 4; i++ {
+					fail(t, ks, m)
+				}
+				_, err := ks.ReserveAttempt(context.Background(), lockoutKey)
+				require.NoError(t, err)
+			},
+			failures: 4,
+		},
+		{
+			name: "offline",
+			setup: func(t *testing.T, ks keystore.Service, m *miniredis.Miniredis) {
+				m.Close()
+			},
+			err: syscall.ECONNREFUSED,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ks, m := newRedisSvc(t)
+			defer m.Close()
+
+			tc.setup(t, ks, m)
+
+			failures, err := ks.ReserveAttempt(context.Background(), lockoutKey)
+			if tc.err == nil {
+				require.NoError(t, err)
+				require.Equal(t, tc.failures, failures)
+			} else {
+				require.ErrorIs(t, err, tc.err)
+			}
+		})
+	}
+}
+
+// reserveConcurrently reserves n attempts at once and returns the number of the reserved ones
+// and the errors of the others.
+func reserveConcurrently(ks keystore.Service, n int) (int64, []error) {
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = ks.ReserveAttempt(context.Background(), lockoutKey)
+		}(i)
+	}
+	wg.Wait()
+
+	var reserved int64
+	var failed []error
+	for _, err := range errs {
+		if err == nil {
+			reserved++
+		} else {
+			failed = append(failed, err)
+		}
+	}
+
+	return reserved, failed
+}
+
+func TestRedisService_ReserveAttemptConcurrent(t *testing.T) {
+	ks, m := newRedisSvc(t)
+	defer m.Close()
+
+	// the attempts in progress don't count towards the lockout
+	reserved, errs := reserveConcurrently(ks, 20)
+	require.Equal(t, int64(20), reserved)
+	require.Empty(t, errs)
+
+	for i := int64(0); i < reserved; i++ {
+		_, err := ks.ReleaseAttempt(context.Background(), lockoutKey, false)
+		require.NoError(t, err)
+	}
+
+	failures, err := ks.ReserveAttempt(context.Background(), lockoutKey)
+	require.NoError(t, err)
+	require.Zero(t, failures)
+}
+
+func TestRedisService_ReserveAttemptMaxInProgress(t *testing.T) {
+	m := miniredis.NewMiniRedis()
+	require.NoError(t, m.StartAddr(redisAddr))
+	defer m.Close()
+
+	policy := keystore.DefaultLockoutPolicy
+	policy.MaxInProgress = 5
+	ks := keystore.NewRedisService(redis.NewClient(&redis.Options{
+		Addr: m.Addr(),
+	}), policy, keystore.DefaultGracePeriod, nil)
+
+	reserved, errs := reserveConcurrently(ks, 20)
+	require.Equal(t, int64(5), reserved)
+	for _, err := range errs {
+		require.ErrorIs(t, err, keystore.ErrTooManyAttempts)
+		require.NotErrorIs(t, err, keystore.ErrKeyLocked)
+	}
+
+	// a released attempt frees its slot
+	_, err := ks.ReleaseAttempt(context.Background(), lockoutKey, false)
+	require.NoError(t, err)
+	_, err = ks.ReserveAttempt(context.Background(), lockoutKey)
+	require.NoError(t, err)
+
+	// the attempts never released are forgotten
+	m.FastForward(keystore.PendingAttemptTTL)
+	_, err = ks.ReserveAttempt(context.Background(), lockoutKey)
+	require.NoError(t, err)
+}
+
+func TestRedisService_ReleaseAttempt(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures int
+		lock     time.Duration
+	}{
+		{
+			name:     "below threshold",
+			failures: 4,
+			lock:     0,
+		},
+		{
+			name:     "threshold reached",
+			failures: 5,
+			lock:     time.Second,
+		},
+		{
+			name:     "exponential delay",
+			failures: 8,
+			lock:     8 * time.Second,
+		},
+		{
+			name:     "max delay",
+			failures: 100,
+			lock:     time.Hour,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ks, m := newRedisSvc(t)
+			defer m.Close()
+
+			var lock time.Duration
+			for i := 0; i < tc.failures; i++ {
+				lock = fail(t, ks, m)
+			}
+
+			require.Equal(t, tc.lock, lock)
+
+			_, err := ks.ReserveAttempt(context.Background(), lockoutKey)
+			require.Equal(t, tc.lock > 0, errors.Is(err, keystore.ErrKeyLocked))
+
+			failures, remaining, err := ks.Attempts(context.Background(), lockoutKey)
+			require.NoError(t, err)
+			require.Equal(t, int64(tc.failures), failures)
+			require.Equal(t, tc.lock, remaining)
+		})
+	}
+}
+
+func TestRedisService_Attempts(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    func(m *miniredis.Miniredis)
+		failures int64
+		locked   bool
+		err      error
+	}{
+		{
+			name:     "ok",
+			setup:    func(m *miniredis.Miniredis) {},
+			failures: 5,
+			locked:   true,
+		},
+		{
+			name: "lock expired",
+			setup: func(m *miniredis.Miniredis) {
+				m.FastForward(time.Minute)
+			},
+			failures: 5,
+			locked:   false,
+		},
+		{
+			name: "failures forgotten",
+			setup: func(m *miniredis.Miniredis) {
+				m.FastForward(25 * time.Hour)
+			},
+			failures: 0,
+			locked:   false,
+		},
+		{
+			name: "offline",
+			setup: func(m *miniredis.Miniredis) {
+				m.Close()
+			},
+			err: syscall.ECONNREFUSED,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ks, m := newRedisSvc(t)
+			defer m.Close()
+
+			for i := 0; i < 5; i++ {
+				fail(t, ks, m)
+			}
+
+			tc.setup(m)
+
+			failures, remaining, err := ks.Attempts(context.Background(), lockoutKey)
+			if tc.err == nil {
+				require.NoError(t, err)
+				require.Equal(t, tc.failures, failures)
+				require.Equal(t, tc.locked, remaining > 0)
+			} else {
+				require.ErrorIs(t, err, tc.err)
+			}
+		})
+	}
+}
+
+func TestRedisService_ResetFailures(t *testing.T) {
+	ks, m := newRedisSvc(t)
+	defer m.Close()
+
+	for i := 0; i < 5; i++ {
+		fail(t, ks, m)
+	}
+
+	err := ks.ResetFailures(context.Background(), lockoutKey)
+	require.NoError(t, err)
+
+	failures, remaining, err := ks.Attempts(context.Background(), lockoutKey)
+	require.NoError(t, err)
+	require.Zero(t, failures)
+	require.Zero(t, remaining)
+
+	require.False(t, m.Exists(keystore.ToFailuresObjectKey(lockoutKey)))
+	require.False(t, m.Exists(keystore.ToLockObjectKey(lockoutKey)))
+}
+
+func TestRedisService_ReleaseAttemptSucceeded(t *testing.T) {
+	ks, m := newRedisSvc(t)
+	defer m.Close()
+
+	_, err := ks.ReserveAttempt(context.Background(), lockoutKey)
+	require.NoError(t, err)
+
+	lock, err := ks.ReleaseAttempt(context.Background(), lockoutKey, false)
+	require.NoError(t, err)
+	require.Zero(t, lock)
+
+	require.False(t, m.Exists(keystore.ToFailuresObjectKey(lockoutKey)))
+	require.False(t, m.Exists(keystore.ToPendingObjectKey(lockoutKey)))
+}
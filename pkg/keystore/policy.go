@@ -0,0 +1,101 @@
+package keystore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// ErrInvalidPolicy is returned if the usage policy can't be enforced.
+var ErrInvalidPolicy = errors.New("invalid policy")
+
+// Policy restricts the usage of a stored certificate. Empty fields are not restricted.
+type Policy struct {
+	IDProvoz        []int     `json:"id_provoz,omitempty"`
+	IDPokl          []string  `json:"id_pokl,omitempty"`
+	DICPoverujiciho string    `json:"dic_poverujiciho,omitempty"`
+	ValidFrom       time.Time `json:"valid_from,omitempty"`
+	ValidTo         time.Time `json:"valid_to,omitempty"`
+
+	idPokl []*regexp.Regexp
+}
+
+// compileIDPoklPattern compiles the pattern as a regular expression that must match the whole value.
+func compileIDPoklPattern(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile("^(?:" + pattern + ")$")
+}
+
+// Compile validates the policy and compiles its id_pokl patterns. It is done once when the policy
+// is stored or loaded, so an invalid policy is rejected before any sale is checked against it
+// and the loaded policy can be shared by concurrent sales.
+func (p *Policy) Compile() error {
+	if p == nil {
+		return nil
+	}
+
+	idPokl := make([]*regexp.Regexp, 0, len(p.IDPokl))
+	for _, pattern := range p.IDPokl {
+		re, err := compileIDPoklPattern(pattern)
+		if err != nil {
+			return fmt.Errorf("compile id_pokl pattern %q: %v: %w", pattern, err, ErrInvalidPolicy)
+		}
+
+		idPokl = append(idPokl, re)
+	}
+
+	if !p.ValidFrom.IsZero() && !p.ValidTo.IsZero() && p.ValidTo.Before(p.ValidFrom) {
+		return fmt.Errorf("validity window ends before it starts: %w", ErrInvalidPolicy)
+	}
+
+	p.idPokl = idPokl
+	return nil
+}
+
+// MatchIDPokl reports whether the value matches any of the id_pokl patterns of the policy. It doesn't
+// modify the policy, so it's safe for concurrent use. Nothing matches a policy not compiled by Compile,
+// the policies loaded from the keystore are always compiled.
+func (p *Policy) MatchIDPokl(s string) bool {
+	for _, re := range p.idPokl {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *Policy) marshal() ([]byte, error) {
+	if p == nil {
+		return nil, nil
+	}
+
+	if err := p.Compile(); err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(p)
+	if err != nil {
+		return nil, fmt.Errorf("marshal policy: %w", err)
+	}
+
+	return data, nil
+}
+
+func unmarshalPolicy(data []byte) (*Policy, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	p := new(Policy)
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("unmarshal policy: %w", err)
+	}
+
+	if err := p.Compile(); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
@@ -0,0 +1,234 @@
+package keystore
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// ErrPreviousNotFound is returned if a record has no previous version within the grace period.
+var ErrPreviousNotFound = errors.New("previous version of the record not found")
+
+// ErrTaxpayerMismatch is returned if a replacing certificate is issued to another taxpayer than the replaced one.
+var ErrTaxpayerMismatch = errors.New("certificate issued to another taxpayer")
+
+// DefaultGracePeriod is a sensible default of the period for which replaced KeyPairs are kept.
+var DefaultGracePeriod = 72 * time.Hour
+
+var (
+	// PreviousPublicKey is the redis key of the certificate field of the previous version.
+	PreviousPublicKey = "previous-public-key"
+	// PreviousPrivateKeyKey is the redis key of the private key field of the previous version.
+	PreviousPrivateKeyKey = "previous-private-key"
+	// PreviousExpiresAtKey is the redis key of the end of the grace period of the previous version.
+	PreviousExpiresAtKey = "previous-expires-at"
+)
+
+// previousFields returns the fields of the previous version if it hasn't expired at the time now.
+func previousFields(m map[string]string, now time.Time) (cert, pk []byte, expiresAt time.Time, ok bool) {
+	nsec, err := strconv.ParseInt(m[PreviousExpiresAtKey], 10, 64)
+	if err != nil {
+		return nil, nil, time.Time{}, false
+	}
+
+	expiresAt = time.Unix(0, nsec)
+	if !now.Before(expiresAt) {
+		return nil, nil, time.Time{}, false
+	}
+
+	return []byte(m[PreviousPublicKey]), []byte(m[PreviousPrivateKeyKey]), expiresAt, true
+}
+
+// Replace swaps the KeyPair of the record with kp encrypted with the same password. The replaced KeyPair
+// is kept as the previous version for the grace period of the keystore and can be restored by Rollback.
+func (r *redisService) Replace(ctx context.Context, id string, password []byte, kp *KeyPair) error {
+	idx := ToCertObjectKey(id)
+
+	txf := func(tx *redis.Tx) error {
+		// check if exists
+		i, err := tx.Exists(ctx, idx).Result()
+		if err != nil {
+			return fmt.Errorf("check if id exists: %w", err)
+		}
+
+		if i == 0 {
+			return fmt.Errorf("record not found by the id: %w", ErrRecordNotFound)
+		}
+
+		// read from database
+		m, err := tx.HGetAll(ctx, idx).Result()
+		if err != nil {
+			return fmt.Errorf("retrieve stored certificate from database: %w", err)
+		}
+
+		// the password must open the current KeyPair
+		salt := []byte(m[SaltKey])
+		oldCert := []byte(m[PublicKey])
+		oldPK := []byte(m[PrivateKeyKey])
+		old := new(KeyPair)
+		if err = old.decrypt(password, salt, oldCert, oldPK); err != nil {
+			return fmt.Errorf("decrypt a KeyPair: %w", err)
+		}
+
+		defer old.Zeroize()
+
+		if err = sameTaxpayer(old.Cert, kp.Cert); err != nil {
+			return err
+		}
+
+		cert, pk, err := kp.encrypt(password, salt)
+		if err != nil {
+			return fmt.Errorf("encrypt a KeyPair: %w", err)
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			if r.grace <= 0 {
+				pipe.HDel(ctx, idx, previousKeys...)
+			} else {
+				pipe.HSet(ctx, idx, map[string]interface{}{
+					PreviousPublicKey:             oldCert,
+					PreviousPrivateKeyKey:         oldPK,
+					PreviousExpiresAtKey:          time.Now().Add(r.grace).UnixNano(),
+					PreviousSerialNumberKey:       m[SerialNumberKey],
+					PreviousIssuerKey:             m[IssuerKey],
+					PreviousDistributionPointsKey: m[DistributionPointsKey],
+				})
+			}
+
+			// the revocation applies to the replaced certificate only
+			pipe.HDel(ctx, idx, RevokedAtKey)
+
+			// overwrite in database
+			_, err = pipe.HSet(ctx, idx, withIdentity(map[string]interface{}{
+				PublicKey:     cert,
+				PrivateKeyKey: pk,
+			}, kp)).Result()
+			if err != nil {
+				return fmt.Errorf("store certificate in database: %w", err)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}
+
+	for k := 0; k < 3; k++ {
+		err := r.rdb.Watch(ctx, txf, idx)
+		if errors.Is(err, redis.TxFailedErr) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("transaction failed: %w", err)
+		}
+
+		return nil
+	}
+
+	return ErrReachedMaxAttempts
+}
+
+// sameTaxpayer checks that the replacing certificate is issued to the same subject as the replaced one,
+// including the taxpayer's DIC held by the common name.
+func sameTaxpayer(old, cert *x509.Certificate) error {
+	if old.Subject.CommonName != cert.Subject.CommonName {
+		return fmt.Errorf("DIC %q instead of %q: %w", cert.Subject.CommonName, old.Subject.CommonName, ErrTaxpayerMismatch)
+	}
+
+	if old.Subject.String() != cert.Subject.String() {
+		return fmt.Errorf("subject %q instead of %q: %w", cert.Subject, old.Subject, ErrTaxpayerMismatch)
+	}
+
+	return nil
+}
+
+// Rollback swaps the KeyPair of the record with its previous version. The rolled back KeyPair becomes
+// the previous version until the end of the original grace period.
+func (r *redisService) Rollback(ctx context.Context, id string, password []byte) error {
+	idx := ToCertObjectKey(id)
+
+	txf := func(tx *redis.Tx) error {
+		// check if exists
+		i, err := tx.Exists(ctx, idx).Result()
+		if err != nil {
+			return fmt.Errorf("check if id exists: %w", err)
+		}
+
+		if i == 0 {
+			return fmt.Errorf("record not found by the id: %w", ErrRecordNotFound)
+		}
+
+		// read from database
+		m, err := tx.HGetAll(ctx, idx).Result()
+		if err != nil {
+			return fmt.Errorf("retrieve stored certificate from database: %w", err)
+		}
+
+		prevCert, prevPK, expiresAt, ok := previousFields(m, time.Now())
+		if !ok {
+			return fmt.Errorf("roll back record %s: %w", id, ErrPreviousNotFound)
+		}
+
+		// the password must open both versions
+		salt := []byte(m[SaltKey])
+		cert := []byte(m[PublicKey])
+		pk := []byte(m[PrivateKeyKey])
+		if err = new(KeyPair).decrypt(password, salt, cert, pk); err != nil {
+			return fmt.Errorf("decrypt a KeyPair: %w", err)
+		}
+
+		if err = new(KeyPair).decrypt(password, salt, prevCert, prevPK); err != nil {
+			return fmt.Errorf("decrypt the previous KeyPair: %w", err)
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			// the restored certificate is checked again by the next revocation check
+			pipe.HDel(ctx, idx, RevokedAtKey)
+
+			// swap the versions
+			_, err = pipe.HSet(ctx, idx, map[string]interface{}{
+				PublicKey:                     prevCert,
+				PrivateKeyKey:                 prevPK,
+				PreviousPublicKey:             cert,
+				PreviousPrivateKeyKey:         pk,
+				PreviousExpiresAtKey:          expiresAt.UnixNano(),
+				SerialNumberKey:               m[PreviousSerialNumberKey],
+				IssuerKey:                     m[PreviousIssuerKey],
+				DistributionPointsKey:         m[PreviousDistributionPointsKey],
+				PreviousSerialNumberKey:       m[SerialNumberKey],
+				PreviousIssuerKey:             m[IssuerKey],
+				PreviousDistributionPointsKey: m[DistributionPointsKey],
+			}).Result()
+			if err != nil {
+				return fmt.Errorf("store certificate in database: %w", err)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}
+
+	for k := 0; k < 3; k++ {
+		err := r.rdb.Watch(ctx, txf, idx)
+		if errors.Is(err, redis.TxFailedErr) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("transaction failed: %w", err)
+		}
+
+		return nil
+	}
+
+	return ErrReachedMaxAttempts
+}
@@ -0,0 +1,242 @@
+package keystore_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/chutommy/eetgateway/pkg/keystore"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/require"
+)
+
+var renewedKP = randomKeyPair()
+
+// keyPairOf returns a random KeyPair of a self-signed certificate of the default template modified by edit.
+func keyPairOf(edit func(tmpl *x509.Certificate)) *keystore.KeyPair {
+	pk, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+
+	tmpl := *defaultCertTmpl
+	edit(&tmpl)
+	der, err := x509.CreateCertificate(rand.Reader, &tmpl, &tmpl, pk.Public(), pk)
+	if err != nil {
+		panic(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		panic(err)
+	}
+
+	return &keystore.KeyPair{
+		Cert: cert,
+		PK:   pk,
+	}
+}
+
+func newRedisSvcWithGrace(t *testing.T, grace time.Duration) (keystore.Service, *miniredis.Miniredis) {
+	m := miniredis.NewMiniRedis()
+	err := m.StartAddr(redisAddr)
+	require.NoError(t, err)
+
+	ks := keystore.NewRedisService(redis.NewClient(&redis.Options{
+		Addr: m.Addr(),
+	}), keystore.DefaultLockoutPolicy, grace, nil)
+
+	return ks, m
+}
+
+func TestRedisService_Replace(t *testing.T) {
+	otherDIC := keyPairOf(func(tmpl *x509.Certificate) {
+		tmpl.Subject = pkix.Name{CommonName: "CZ00000019"}
+	})
+	otherSubject := keyPairOf(func(tmpl *x509.Certificate) {
+		tmpl.Subject = pkix.Name{Organization: []string{"Another Taxpayer"}}
+	})
+
+	tests := []struct {
+		name     string
+		id       string
+		password []byte
+		kp       *keystore.KeyPair
+		err      error
+	}{
+		{
+			name:     "ok",
+			id:       certID,
+			password: certPassword,
+			kp:       renewedKP,
+			err:      nil,
+		},
+		{
+			name:     "not found",
+			id:       certID2,
+			password: certPassword,
+			kp:       renewedKP,
+			err:      keystore.ErrRecordNotFound,
+		},
+		{
+			name:     "invalid password",
+			id:       certID,
+			password: certPassword2,
+			kp:       renewedKP,
+			err:      keystore.ErrInvalidDecryptionKey,
+		},
+		{
+			name:     "another DIC",
+			id:       certID,
+			password: certPassword,
+			kp:       otherDIC,
+			err:      keystore.ErrTaxpayerMismatch,
+		},
+		{
+			name:     "another subject",
+			id:       certID,
+			password: certPassword,
+			kp:       otherSubject,
+			err:      keystore.ErrTaxpayerMismatch,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ks, m := newRedisSvc(t)
+			defer m.Close()
+
+			err := ks.Store(context.Background(), certID, certPassword, certKP, nil)
+			require.NoError(t, err)
+
+			err = ks.Replace(context.Background(), tc.id, tc.password, tc.kp)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+
+				// the stored certificate is kept
+				if tc.id == certID {
+					kp, err := ks.Get(context.Background(), certID, certPassword)
+					require.NoError(t, err)
+					require.Equal(t, certKP.Cert.Raw, kp.Cert.Raw)
+				}
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			kp, err := ks.Get(context.Background(), certID, certPassword)
+			require.NoError(t, err)
+			require.Equal(t, tc.kp.Cert.Raw, kp.Cert.Raw)
+			require.Equal(t, tc.kp.PK.D, kp.PK.D)
+		})
+	}
+}
+
+func TestRedisService_Rollback(t *testing.T) {
+	tests := []struct {
+		name     string
+		grace    time.Duration
+		setup    func(ks keystore.Service)
+		password []byte
+		kp       *keystore.KeyPair
+		err      error
+	}{
+		{
+			name:     "ok",
+			grace:    time.Hour,
+			password: certPassword,
+			kp:       certKP,
+			err:      nil,
+		},
+		{
+			name:  "rollback of rollback",
+			grace: time.Hour,
+			setup: func(ks keystore.Service) {
+				err := ks.Rollback(context.Background(), certID, certPassword)
+				require.NoError(t, err)
+			},
+			password: certPassword,
+			kp:       renewedKP,
+			err:      nil,
+		},
+		{
+			name:  "after password update",
+			grace: time.Hour,
+			setup: func(ks keystore.Service) {
+				err := ks.UpdatePassword(context.Background(), certID, certPassword, certPassword2)
+				require.NoError(t, err)
+			},
+			password: certPassword2,
+			kp:       certKP,
+			err:      nil,
+		},
+		{
+			name:     "invalid password",
+			grace:    time.Hour,
+			password: certPassword2,
+			err:      keystore.ErrInvalidDecryptionKey,
+		},
+		{
+			name:     "grace period expired",
+			grace:    time.Millisecond,
+			setup:    func(ks keystore.Service) { time.Sleep(10 * time.Millisecond) },
+			password: certPassword,
+			err:      keystore.ErrPreviousNotFound,
+		},
+		{
+			name:     "no grace period",
+			grace:    0,
+			password: certPassword,
+			err:      keystore.ErrPreviousNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ks, m := newRedisSvcWithGrace(t, tc.grace)
+			defer m.Close()
+
+			err := ks.Store(context.Background(), certID, certPassword, certKP, nil)
+			require.NoError(t, err)
+			err = ks.Replace(context.Background(), certID, certPassword, renewedKP)
+			require.NoError(t, err)
+
+			if tc.setup != nil {
+				tc.setup(ks)
+			}
+
+			err = ks.Rollback(context.Background(), certID, tc.password)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+
+			kp, err := ks.Get(context.Background(), certID, tc.password)
+			require.NoError(t, err)
+			require.Equal(t, tc.kp.Cert.Raw, kp.Cert.Raw)
+			require.Equal(t, tc.kp.PK.D, kp.PK.D)
+		})
+	}
+}
+
+func TestRedisService_RollbackNotFound(t *testing.T) {
+	ks, m := newRedisSvc(t)
+	defer m.Close()
+
+	err := ks.Rollback(context.Background(), certID, certPassword)
+	require.ErrorIs(t, err, keystore.ErrRecordNotFound)
+
+	err = ks.Store(context.Background(), certID, certPassword, certKP, nil)
+	require.NoError(t, err)
+
+	err = ks.Rollback(context.Background(), certID, certPassword)
+	require.ErrorIs(t, err, keystore.ErrPreviousNotFound)
+}
@@ -6,6 +6,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math/big"
+	"time"
 
 	"github.com/go-redis/redis/v8"
 )
@@ -31,6 +33,8 @@ var (
 	PrivateKeyKey = "private-key"
 	// SaltKey is the redis key of the salt field.
 	SaltKey = "salt"
+	// PolicyKey is the redis key of the usage policy field.
+	PolicyKey = "policy"
 )
 
 // ToCertObjectKey converts a certificate ID to a keystore object key.
@@ -41,16 +45,31 @@ func ToCertObjectKey(id string) string {
 // Service represents a keystore abstraction for KeyPair management.
 type Service interface {
 	Ping(ctx context.Context) error
-	Store(ctx context.Context, id string, password []byte, kp *KeyPair) error
+	Store(ctx context.Context, id string, password []byte, kp *KeyPair, policy *Policy) error
 	Get(ctx context.Context, id string, password []byte) (*KeyPair, error)
+	GetPolicy(ctx context.Context, id string) (*Policy, error)
 	List(ctx context.Context, start, end int64) ([]string, error)
 	UpdateID(ctx context.Context, oldID, newID string) error
 	UpdatePassword(ctx context.Context, id string, oldPassword, newPassword []byte) error
+	UpdatePolicy(ctx context.Context, id string, policy *Policy) error
+	Replace(ctx context.Context, id string, password []byte, kp *KeyPair) error
+	Rollback(ctx context.Context, id string, password []byte) error
 	Delete(ctx context.Context, id string) error
+
+	Identity(ctx context.Context, id string) (*Identity, error)
+	Revoke(ctx context.Context, id string, serialNumber *big.Int) error
+
+	Attempts(ctx context.Context, key string) (int64, time.Duration, error)
+	ReserveAttempt(ctx context.Context, key string) (int64, error)
+	ReleaseAttempt(ctx context.Context, key string, failed bool) (time.Duration, error)
+	ResetFailures(ctx context.Context, key string) error
 }
 
 type redisService struct {
-	rdb *redis.Client
+	rdb     *redis.Client
+	lockout LockoutPolicy
+	grace   time.Duration
+	signers SignerProvider
 }
 
 // Ping tries to connect to the database and find out whether it is online.
@@ -58,8 +77,9 @@ func (r *redisService) Ping(ctx context.Context) error {
 	return r.rdb.Ping(ctx).Err()
 }
 
-// Store stores the given KeyPair kp in the database encrypted with the password.
-func (r *redisService) Store(ctx context.Context, id string, password []byte, kp *KeyPair) error {
+// Store stores the given KeyPair kp in the database encrypted with the password. The private key
+// of a KeyPair with an external Signer isn't stored. The usage policy is stored unencrypted alongside. A nil policy doesn't restrict the KeyPair.
+func (r *redisService) Store(ctx context.Context, id string, password []byte, kp *KeyPair, policy *Policy) error {
 	idx := ToCertObjectKey(id)
 
 	// generate random salt for each record
@@ -73,6 +93,11 @@ func (r *redisService) Store(ctx context.Context, id string, password []byte, kp
 		return fmt.Errorf("encrypt a KeyPair: %w", err)
 	}
 
+	rawPolicy, err := policy.marshal()
+	if err != nil {
+		return fmt.Errorf("encode the policy: %w", err)
+	}
+
 	txf := func(tx *redis.Tx) error {
 		// check if already exists
 		i, err := tx.Exists(ctx, idx).Result()
@@ -86,11 +111,12 @@ func (r *redisService) Store(ctx context.Context, id string, password []byte, kp
 
 		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
 			// store in database
-			_, err = pipe.HSet(ctx, idx, map[string]interface{}{
+			_, err = pipe.HSet(ctx, idx, withIdentity(map[string]interface{}{
 				PublicKey:     cert,
 				PrivateKeyKey: pk,
 				SaltKey:       salt,
-			}).Result()
+				PolicyKey:     rawPolicy,
+			}, kp)).Result()
 			if err != nil {
 				return fmt.Errorf("store certificate in database: %w", err)
 			}
@@ -124,7 +150,9 @@ func (r *redisService) Store(ctx context.Context, id string, password []byte, kp
 	return ErrReachedMaxAttempts
 }
 
-// Get retrieves a KeyPair by the ID.
+// Get retrieves a KeyPair by the ID. ErrRecordRevoked is returned if the certificate has been revoked.
+// The Signer of a KeyPair whose private key is kept outside the gateway is provided by the signers
+// of the keystore.
 func (r *redisService) Get(ctx context.Context, id string, password []byte) (*KeyPair, error) {
 	idx := ToCertObjectKey(id)
 
@@ -167,12 +195,73 @@ func (r *redisService) Get(ctx context.Context, id string, password []byte) (*Ke
 			return nil, fmt.Errorf("decrypt a KeyPair: %w", err)
 		}
 
+		if m[RevokedAtKey] != "" {
+			kp.Zeroize()
+			return nil, fmt.Errorf("revoked at %s: %w", m[RevokedAtKey], ErrRecordRevoked)
+		}
+
+		if kp.external {
+			if r.signers == nil {
+				return nil, fmt.Errorf("sign by the private key of %s: %w", id, ErrSignerUnavailable)
+			}
+
+			kp.Signer, err = r.signers.Signer(kp.Cert)
+			if err != nil {
+				return nil, fmt.Errorf("provide the signer: %w", err)
+			}
+		}
+
 		return kp, nil
 	}
 
 	return nil, ErrReachedMaxAttempts
 }
 
+// GetPolicy retrieves the usage policy of the record with the ID. A nil policy is returned
+// if the record isn't restricted.
+func (r *redisService) GetPolicy(ctx context.Context, id string) (*Policy, error) {
+	idx := ToCertObjectKey(id)
+
+	var rawPolicy string
+	txf := func(tx *redis.Tx) error {
+		// check if exists
+		i, err := tx.Exists(ctx, idx).Result()
+		if err != nil {
+			return fmt.Errorf("check if id exists: %w", err)
+		}
+
+		if i == 0 {
+			return fmt.Errorf("not found record with the id: %w", ErrRecordNotFound)
+		}
+
+		// read from database
+		rawPolicy, err = tx.HGet(ctx, idx, PolicyKey).Result()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return fmt.Errorf("retrieve stored policy from database: %w", err)
+		}
+
+		return nil
+	}
+
+	for k := 0; k < 3; k++ {
+		err := r.rdb.Watch(ctx, txf, idx)
+		if errors.Is(err, redis.TxFailedErr) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("transaction failed: %w", err)
+		}
+
+		policy, err := unmarshalPolicy([]byte(rawPolicy))
+		if err != nil {
+			return nil, fmt.Errorf("decode the policy: %w", err)
+		}
+
+		return policy, nil
+	}
+
+	return nil, ErrReachedMaxAttempts
+}
+
 // List returns all record keys in the database.
 func (r *redisService) List(ctx context.Context, start, end int64) ([]string, error) {
 	ids, err := r.rdb.LRange(ctx, IDsObjectKey, start, end).Result()
@@ -288,12 +377,32 @@ func (r *redisService) UpdatePassword(ctx context.Context, id string, oldPasswor
 			return fmt.Errorf("encrypt a KeyPair: %w", err)
 		}
 
+		fields := map[string]interface{}{
+			PublicKey:     cert,
+			PrivateKeyKey: pk,
+		}
+
+		// re-encrypt the previous version to keep it restorable
+		prevCert, prevPK, _, hasPrevious := previousFields(m, time.Now())
+		if hasPrevious {
+			prev := new(KeyPair)
+			if err = prev.decrypt(oldPassword, salt, prevCert, prevPK); err != nil {
+				return fmt.Errorf("decrypt the previous KeyPair: %w", err)
+			}
+
+			fields[PreviousPublicKey], fields[PreviousPrivateKeyKey], err = prev.encrypt(newPassword, salt)
+			if err != nil {
+				return fmt.Errorf("encrypt the previous KeyPair: %w", err)
+			}
+		}
+
 		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			if !hasPrevious {
+				pipe.HDel(ctx, idx, previousKeys...)
+			}
+
 			// overwrite in database
-			_, err = pipe.HSet(ctx, idx, map[string]interface{}{
-				PublicKey:     cert,
-				PrivateKeyKey: pk,
-			}).Result()
+			_, err = pipe.HSet(ctx, idx, fields).Result()
 			if err != nil {
 				return fmt.Errorf("store certificate in database: %w", err)
 			}
@@ -321,6 +430,56 @@ func (r *redisService) UpdatePassword(ctx context.Context, id string, oldPasswor
 	return ErrReachedMaxAttempts
 }
 
+// UpdatePolicy overwrites the usage policy of the record. A nil policy removes all restrictions.
+func (r *redisService) UpdatePolicy(ctx context.Context, id string, policy *Policy) error {
+	idx := ToCertObjectKey(id)
+
+	rawPolicy, err := policy.marshal()
+	if err != nil {
+		return fmt.Errorf("encode the policy: %w", err)
+	}
+
+	txf := func(tx *redis.Tx) error {
+		// check if exists
+		i, err := tx.Exists(ctx, idx).Result()
+		if err != nil {
+			return fmt.Errorf("check if id exists: %w", err)
+		}
+
+		if i == 0 {
+			return fmt.Errorf("record not found by the id: %w", ErrRecordNotFound)
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			// overwrite in database
+			_, err = pipe.HSet(ctx, idx, PolicyKey, rawPolicy).Result()
+			if err != nil {
+				return fmt.Errorf("store policy in database: %w", err)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}
+
+	for k := 0; k < 3; k++ {
+		err := r.rdb.Watch(ctx, txf, idx)
+		if errors.Is(err, redis.TxFailedErr) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("transaction failed: %w", err)
+		}
+
+		return nil
+	}
+
+	return ErrReachedMaxAttempts
+}
+
 // Delete removes the KeyPair with the ID.
 func (r *redisService) Delete(ctx context.Context, id string) error {
 	idx := ToCertObjectKey(id)
@@ -372,9 +531,15 @@ func (r *redisService) Delete(ctx context.Context, id string) error {
 	return ErrReachedMaxAttempts
 }
 
-// NewRedisService returns an implementation of the Service.
-func NewRedisService(rdb *redis.Client) Service {
+// NewRedisService returns an implementation of the Service. Failed attempts are locked
+// out according to the lockout policy. Replaced KeyPairs are kept for the grace period.
+// The signers provide the Signers of the KeyPairs whose private keys are kept outside the gateway,
+// they can't be retrieved if the signers are nil.
+func NewRedisService(rdb *redis.Client, lockout LockoutPolicy, grace time.Duration, signers SignerProvider) Service {
 	return &redisService{
-		rdb: rdb,
+		rdb:     rdb,
+		lockout: lockout,
+		grace:   grace,
+		signers: signers,
 	}
 }
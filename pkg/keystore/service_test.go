@@ -27,7 +27,7 @@ func newRedisSvc(t *testing.T) (keystore.Service, *miniredis.Miniredis) {
 
 	ks := keystore.NewRedisService(redis.NewClient(&redis.Options{
 		Addr: m.Addr(),
-	}))
+	}), keystore.DefaultLockoutPolicy, keystore.DefaultGracePeriod, nil)
 
 	return ks, m
 }
@@ -71,6 +71,13 @@ var (
 	certPassword  = []byte("secret1")
 	certPassword2 = []byte("secret2")
 	certKP        = randomKeyPair()
+	certPolicy    = &keystore.Policy{
+		IDProvoz:        []int{11, 12},
+		IDPokl:          []string{"pokl-[0-9]+"},
+		DICPoverujiciho: "CZ00000019",
+		ValidFrom:       time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+		ValidTo:         time.Date(2032, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
 )
 
 func TestRedisService_Ping(t *testing.T) {
@@ -145,7 +152,7 @@ func TestRedisService_Store(t *testing.T) {
 
 			tc.setup(m)
 
-			err := ks.Store(context.Background(), certID, certPassword, certKP)
+			err := ks.Store(context.Background(), certID, certPassword, certKP, nil)
 			if tc.err == nil {
 				require.NoError(t, err)
 
@@ -204,7 +211,7 @@ func TestRedisService_Get(t *testing.T) {
 			ks, m := newRedisSvc(t)
 			defer m.Close()
 
-			err := ks.Store(context.Background(), certID, certPassword, certKP)
+			err := ks.Store(context.Background(), certID, certPassword, certKP, nil)
 			require.NoError(t, err)
 
 			tc.setup(m)
@@ -245,7 +252,7 @@ func TestRedisService_List(t *testing.T) {
 			ks, m := newRedisSvc(t)
 			defer m.Close()
 
-			err := ks.Store(context.Background(), certID, certPassword, certKP)
+			err := ks.Store(context.Background(), certID, certPassword, certKP, nil)
 			require.NoError(t, err)
 
 			tc.setup(m)
@@ -312,7 +319,7 @@ func TestRedisService_UpdateID(t *testing.T) {
 			ks, m := newRedisSvc(t)
 			defer m.Close()
 
-			err := ks.Store(context.Background(), certID, certPassword, certKP)
+			err := ks.Store(context.Background(), certID, certPassword, certKP, nil)
 			require.NoError(t, err)
 
 			tc.setup(m)
@@ -373,7 +380,7 @@ func TestRedisService_UpdatePassword(t *testing.T) {
 			ks, m := newRedisSvc(t)
 			defer m.Close()
 
-			err := ks.Store(context.Background(), certID, certPassword, certKP)
+			err := ks.Store(context.Background(), certID, certPassword, certKP, nil)
 			require.NoError(t, err)
 
 			tc.setup(m)
@@ -394,6 +401,149 @@ func TestRedisService_UpdatePassword(t *testing.T) {
 	}
 }
 
+func TestRedisService_GetPolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy *keystore.Policy
+		setup  func(m *miniredis.Miniredis)
+		err    error
+	}{
+		{
+			name:   "ok",
+			policy: certPolicy,
+			setup:  func(m *miniredis.Miniredis) {},
+			err:    nil,
+		},
+		{
+			name:   "unrestricted",
+			policy: nil,
+			setup:  func(m *miniredis.Miniredis) {},
+			err:    nil,
+		},
+		{
+			name:   "id not found",
+			policy: certPolicy,
+			setup: func(m *miniredis.Miniredis) {
+				ok := m.Del(certIDx)
+				require.True(t, ok)
+			},
+			err: keystore.ErrRecordNotFound,
+		},
+		{
+			name:   "invalid stored policy",
+			policy: certPolicy,
+			setup: func(m *miniredis.Miniredis) {
+				m.HSet(certIDx, keystore.PolicyKey, `{"id_pokl":["pokl-[0-9"]}`)
+			},
+			err: keystore.ErrInvalidPolicy,
+		},
+		{
+			name:   "offline",
+			policy: certPolicy,
+			setup: func(m *miniredis.Miniredis) {
+				m.Close()
+			},
+			err: io.EOF,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ks, m := newRedisSvc(t)
+			defer m.Close()
+
+			err := ks.Store(context.Background(), certID, certPassword, certKP, tc.policy)
+			require.NoError(t, err)
+
+			tc.setup(m)
+
+			policy, err := ks.GetPolicy(context.Background(), certID)
+			if tc.err == nil {
+				require.NoError(t, err)
+				require.Equal(t, tc.policy, policy)
+			} else {
+				require.ErrorIs(t, err, tc.err)
+			}
+		})
+	}
+}
+
+func TestRedisService_UpdatePolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy *keystore.Policy
+		setup  func(m *miniredis.Miniredis)
+		err    error
+	}{
+		{
+			name:   "ok",
+			policy: certPolicy,
+			setup:  func(m *miniredis.Miniredis) {},
+			err:    nil,
+		},
+		{
+			name:   "remove restrictions",
+			policy: nil,
+			setup:  func(m *miniredis.Miniredis) {},
+			err:    nil,
+		},
+		{
+			name:   "id not found",
+			policy: certPolicy,
+			setup: func(m *miniredis.Miniredis) {
+				ok := m.Del(certIDx)
+				require.True(t, ok)
+			},
+			err: keystore.ErrRecordNotFound,
+		},
+		{
+			name: "invalid policy",
+			policy: &keystore.Policy{
+				IDPokl: []string{"pokl-[0-9"},
+			},
+			setup: func(m *miniredis.Miniredis) {},
+			err:   keystore.ErrInvalidPolicy,
+		},
+		{
+			name:   "offline",
+			policy: certPolicy,
+			setup: func(m *miniredis.Miniredis) {
+				m.Close()
+			},
+			err: io.EOF,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ks, m := newRedisSvc(t)
+			defer m.Close()
+
+			err := ks.Store(context.Background(), certID, certPassword, certKP, &keystore.Policy{IDProvoz: []int{1}})
+			require.NoError(t, err)
+
+			tc.setup(m)
+
+			err = ks.UpdatePolicy(context.Background(), certID, tc.policy)
+			if tc.err == nil {
+				require.NoError(t, err)
+
+				// verify the policy
+				policy, err := ks.GetPolicy(context.Background(), certID)
+				require.NoError(t, err)
+				require.Equal(t, tc.policy, policy)
+
+				// the KeyPair must stay untouched
+				kp, err := ks.Get(context.Background(), certID, certPassword)
+				require.NoError(t, err)
+				equalKeyPairs(t, certKP, kp)
+			} else {
+				require.ErrorIs(t, err, tc.err)
+			}
+		})
+	}
+}
+
 func TestRedisService_Delete(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -427,7 +577,7 @@ func TestRedisService_Delete(t *testing.T) {
 			ks, m := newRedisSvc(t)
 			defer m.Close()
 
-			err := ks.Store(context.Background(), certID, certPassword, certKP)
+			err := ks.Store(context.Background(), certID, certPassword, certKP, nil)
 			require.NoError(t, err)
 
 			tc.setup(m)
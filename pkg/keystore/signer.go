@@ -0,0 +1,134 @@
+package keystore
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"go.uber.org/multierr"
+)
+
+// ErrNoSigningKey is returned if a KeyPair has neither a private key nor an external Signer.
+var ErrNoSigningKey = errors.New("no signing key")
+
+// ErrSignerUnavailable is returned if the private key of a record is kept outside the gateway
+// but no SignerProvider is configured.
+var ErrSignerUnavailable = errors.New("external signer not configured")
+
+// ErrRemoteSigner is returned if the remote signing service fails to sign.
+var ErrRemoteSigner = errors.New("remote signer failed")
+
+// SignerProvider provides the Signers of the certificates whose private keys are kept outside
+// the gateway, e.g. in a PKCS #11 token or by a remote signing service.
+type SignerProvider interface {
+	Signer(cert *x509.Certificate) (crypto.Signer, error)
+}
+
+type remoteSigners struct {
+	url    string
+	client *http.Client
+}
+
+// NewRemoteSigners returns a SignerProvider signing by the remote signing service at the url.
+// The service receives the SHA-256 digests to sign as POST requests with a JSON body
+//
+//	{"key_id": "<hex SHA-256 fingerprint of the certificate>", "digest": "<base64 digest>"}
+//
+// and responds by {"signature": "<base64 PKCS #1 v1.5 signature>"}.
+func NewRemoteSigners(url string, client *http.Client) SignerProvider {
+	return &remoteSigners{
+		url:    url,
+		client: client,
+	}
+}
+
+// Signer returns the Signer of the private key of the certificate kept by the remote signing service.
+func (r *remoteSigners) Signer(cert *x509.Certificate) (crypto.Signer, error) {
+	pub, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("unexpected public key type %T: %w", cert.PublicKey, ErrRemoteSigner)
+	}
+
+	fingerprint := sha256.Sum256(cert.Raw)
+
+	return &remoteSigner{
+		remoteSigners: r,
+		keyID:         hex.EncodeToString(fingerprint[:]),
+		pub:           pub,
+	}, nil
+}
+
+type remoteSignRequest struct {
+	KeyID  string `json:"key_id"`
+	Digest string `json:"digest"`
+}
+
+type remoteSignResponse struct {
+	Signature string `json:"signature"`
+}
+
+type remoteSigner struct {
+	*remoteSigners
+
+	keyID string
+	pub   *rsa.PublicKey
+}
+
+// Public returns the public key of the certificate.
+func (s *remoteSigner) Public() crypto.PublicKey {
+	return s.pub
+}
+
+// Sign signs the SHA-256 digest by the remote signing service. The returned signature is verified
+// by the public key of the certificate.
+func (s *remoteSigner) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if opts.HashFunc() != crypto.SHA256 {
+		return nil, fmt.Errorf("unsupported hash function %v: %w", opts.HashFunc(), ErrRemoteSigner)
+	}
+
+	body, err := json.Marshal(remoteSignRequest{
+		KeyID:  s.keyID,
+		Digest: base64.StdEncoding.EncodeToString(digest),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshal sign request: %w", err)
+	}
+
+	resp, err := s.client.Post(s.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, multierr.Append(fmt.Errorf("send sign request: %w", err), ErrRemoteSigner)
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d: %w", resp.StatusCode, ErrRemoteSigner)
+	}
+
+	var signResp remoteSignResponse
+	if err = json.NewDecoder(resp.Body).Decode(&signResp); err != nil {
+		return nil, multierr.Append(fmt.Errorf("decode sign response: %w", err), ErrRemoteSigner)
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(signResp.Signature)
+	if err != nil {
+		return nil, multierr.Append(fmt.Errorf("decode signature: %w", err), ErrRemoteSigner)
+	}
+
+	if err = rsa.VerifyPKCS1v15(s.pub, crypto.SHA256, digest, signature); err != nil {
+		return nil, multierr.Append(fmt.Errorf("verify signature: %w", err), ErrRemoteSigner)
+	}
+
+	return signature, nil
+}
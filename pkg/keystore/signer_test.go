@@ -0,0 +1,94 @@
+package keystore_test
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/chutommy/eetgateway/pkg/keystore"
+	"github.com/stretchr/testify/require"
+)
+
+// remoteSigningService is a fake of a remote signing service keeping the private key of the certificate.
+func remoteSigningService(t *testing.T, key *rsa.PrivateKey, keyID string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			KeyID  string `json:"key_id"`
+			Digest string `json:"digest"`
+		}
+
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		if req.KeyID != keyID {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		digest, err := base64.StdEncoding.DecodeString(req.Digest)
+		require.NoError(t, err)
+
+		signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest)
+		require.NoError(t, err)
+
+		require.NoError(t, json.NewEncoder(w).Encode(map[string]string{
+			"signature": base64.StdEncoding.EncodeToString(signature),
+		}))
+	}))
+}
+
+func TestRemoteSigners(t *testing.T) {
+	fingerprint := sha256.Sum256(certKP.Cert.Raw)
+	digest := sha256.Sum256([]byte("sale"))
+
+	tests := []struct {
+		name  string
+		key   *rsa.PrivateKey
+		keyID string
+		ok    bool
+	}{
+		{
+			name:  "signed",
+			key:   certKP.PK,
+			keyID: hex.EncodeToString(fingerprint[:]),
+			ok:    true,
+		},
+		{
+			name:  "unknown key",
+			key:   certKP.PK,
+			keyID: "unknown",
+			ok:    false,
+		},
+		{
+			name:  "invalid signature",
+			key:   randomKeyPair().PK,
+			keyID: hex.EncodeToString(fingerprint[:]),
+			ok:    false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := remoteSigningService(t, tc.key, tc.keyID)
+			defer srv.Close()
+
+			signer, err := keystore.NewRemoteSigners(srv.URL, srv.Client()).Signer(certKP.Cert)
+			require.NoError(t, err)
+			require.Equal(t, certKP.Cert.PublicKey, signer.Public())
+
+			signature, err := signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+			if !tc.ok {
+				require.ErrorIs(t, err, keystore.ErrRemoteSigner)
+				return
+			}
+
+			require.NoError(t, err)
+			require.NoError(t, rsa.VerifyPKCS1v15(&certKP.PK.PublicKey, crypto.SHA256, digest[:], signature))
+		})
+	}
+}
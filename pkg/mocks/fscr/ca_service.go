@@ -5,11 +5,15 @@
 package mocks
 
 import (
-	rsa "crypto/rsa"
+	crypto "crypto"
 
-	mock "github.com/stretchr/testify/mock"
+	rsa "crypto/rsa"
 
 	x509 "crypto/x509"
+
+	time "time"
+
+	mock "github.com/stretchr/testify/mock"
 )
 
 // CAService is an autogenerated mock type for the CAService type
@@ -49,13 +53,98 @@ func (_m *CAService) ParseTaxpayerCertificate(data []byte, password string) (*x5
 	return r0, r1, r2
 }
 
-// VerifyDSig provides a mock function with given fields: cert
-func (_m *CAService) VerifyDSig(cert *x509.Certificate) error {
-	ret := _m.Called(cert)
+// ParseTaxpayerCertificatePEM provides a mock function with given fields: certData, keyData
+func (_m *CAService) ParseTaxpayerCertificatePEM(certData []byte, keyData []byte) (*x509.Certificate, *rsa.PrivateKey, error) {
+	ret := _m.Called(certData, keyData)
+
+	var r0 *x509.Certificate
+	if rf, ok := ret.Get(0).(func([]byte, []byte) *x509.Certificate); ok {
+		r0 = rf(certData, keyData)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*x509.Certificate)
+		}
+	}
+
+	var r1 *rsa.PrivateKey
+	if rf, ok := ret.Get(1).(func([]byte, []byte) *rsa.PrivateKey); ok {
+		r1 = rf(certData, keyData)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*rsa.PrivateKey)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func([]byte, []byte) error); ok {
+		r2 = rf(certData, keyData)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// ParseTaxpayerCertificateSigner provides a mock function with given fields: certData, key
+func (_m *CAService) ParseTaxpayerCertificateSigner(certData []byte, key crypto.Signer) (*x509.Certificate, error) {
+	ret := _m.Called(certData, key)
+
+	var r0 *x509.Certificate
+	if rf, ok := ret.Get(0).(func([]byte, crypto.Signer) *x509.Certificate); ok {
+		r0 = rf(certData, key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*x509.Certificate)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]byte, crypto.Signer) error); ok {
+		r1 = rf(certData, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Roots provides a mock function with given fields:
+func (_m *CAService) Roots() ([]*x509.Certificate, []*x509.Certificate) {
+	ret := _m.Called()
+
+	var r0 []*x509.Certificate
+	if rf, ok := ret.Get(0).(func() []*x509.Certificate); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*x509.Certificate)
+		}
+	}
+
+	var r1 []*x509.Certificate
+	if rf, ok := ret.Get(1).(func() []*x509.Certificate); ok {
+		r1 = rf()
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).([]*x509.Certificate)
+		}
+	}
+
+	return r0, r1
+}
+
+// SetRoots provides a mock function with given fields: eetRoots, dsigRoots
+func (_m *CAService) SetRoots(eetRoots []*x509.Certificate, dsigRoots []*x509.Certificate) {
+	_m.Called(eetRoots, dsigRoots)
+}
+
+// VerifyDSig provides a mock function with given fields: cert, signedAt
+func (_m *CAService) VerifyDSig(cert *x509.Certificate, signedAt time.Time) error {
+	ret := _m.Called(cert, signedAt)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(*x509.Certificate) error); ok {
-		r0 = rf(cert)
+	if rf, ok := ret.Get(0).(func(*x509.Certificate, time.Time) error); ok {
+		r0 = rf(cert, signedAt)
 	} else {
 		r0 = ret.Error(0)
 	}
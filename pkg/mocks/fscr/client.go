@@ -7,6 +7,8 @@ package mocks
 import (
 	context "context"
 
+	time "time"
+
 	mock "github.com/stretchr/testify/mock"
 )
 
@@ -51,3 +53,8 @@ func (_m *Client) Ping() error {
 
 	return r0
 }
+
+// SetTimeout provides a mock function with given fields: timeout
+func (_m *Client) SetTimeout(timeout time.Duration) {
+	_m.Called(timeout)
+}
@@ -7,8 +7,18 @@ package mocks
 import (
 	context "context"
 
+	crypto "crypto"
+
+	x509 "crypto/x509"
+
 	eet "github.com/chutommy/eetgateway/pkg/eet"
 
+	gateway "github.com/chutommy/eetgateway/pkg/gateway"
+
+	keystore "github.com/chutommy/eetgateway/pkg/keystore"
+
+	time "time"
+
 	mock "github.com/stretchr/testify/mock"
 )
 
@@ -17,6 +27,75 @@ type Service struct {
 	mock.Mock
 }
 
+// CheckRevocations provides a mock function with given fields: ctx
+func (_m *Service) CheckRevocations(ctx context.Context) ([]string, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(context.Context) []string); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ComputeCodes provides a mock function with given fields: ctx, certID, certPassword, trzba
+func (_m *Service) ComputeCodes(ctx context.Context, certID string, certPassword []byte, trzba *eet.TrzbaType) (*eet.TrzbaKontrolniKodyType, error) {
+	ret := _m.Called(ctx, certID, certPassword, trzba)
+
+	var r0 *eet.TrzbaKontrolniKodyType
+	if rf, ok := ret.Get(0).(func(context.Context, string, []byte, *eet.TrzbaType) *eet.TrzbaKontrolniKodyType); ok {
+		r0 = rf(ctx, certID, certPassword, trzba)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*eet.TrzbaKontrolniKodyType)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, []byte, *eet.TrzbaType) error); ok {
+		r1 = rf(ctx, certID, certPassword, trzba)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ComputeCodesWithSession provides a mock function with given fields: ctx, certID, token, trzba
+func (_m *Service) ComputeCodesWithSession(ctx context.Context, certID string, token string, trzba *eet.TrzbaType) (*eet.TrzbaKontrolniKodyType, error) {
+	ret := _m.Called(ctx, certID, token, trzba)
+
+	var r0 *eet.TrzbaKontrolniKodyType
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, *eet.TrzbaType) *eet.TrzbaKontrolniKodyType); ok {
+		r0 = rf(ctx, certID, token, trzba)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*eet.TrzbaKontrolniKodyType)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, *eet.TrzbaType) error); ok {
+		r1 = rf(ctx, certID, token, trzba)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // DeleteID provides a mock function with given fields: ctx, id
 func (_m *Service) DeleteID(ctx context.Context, id string) error {
 	ret := _m.Called(ctx, id)
@@ -31,6 +110,45 @@ func (_m *Service) DeleteID(ctx context.Context, id string) error {
 	return r0
 }
 
+// Dependencies provides a mock function with given fields:
+func (_m *Service) Dependencies() []gateway.DependencyStatus {
+	ret := _m.Called()
+
+	var r0 []gateway.DependencyStatus
+	if rf, ok := ret.Get(0).(func() []gateway.DependencyStatus); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]gateway.DependencyStatus)
+		}
+	}
+
+	return r0
+}
+
+// ExportCert provides a mock function with given fields: ctx, certID, password, pkcsPassword
+func (_m *Service) ExportCert(ctx context.Context, certID string, password []byte, pkcsPassword string) ([]byte, error) {
+	ret := _m.Called(ctx, certID, password, pkcsPassword)
+
+	var r0 []byte
+	if rf, ok := ret.Get(0).(func(context.Context, string, []byte, string) []byte); ok {
+		r0 = rf(ctx, certID, password, pkcsPassword)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, []byte, string) error); ok {
+		r1 = rf(ctx, certID, password, pkcsPassword)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ListCertIDs provides a mock function with given fields: ctx, start, end
 func (_m *Service) ListCertIDs(ctx context.Context, start int64, end int64) ([]string, error) {
 	ret := _m.Called(ctx, start, end)
@@ -54,6 +172,34 @@ func (_m *Service) ListCertIDs(ctx context.Context, start int64, end int64) ([]s
 	return r0, r1
 }
 
+// OpenSession provides a mock function with given fields: ctx, certID, password
+func (_m *Service) OpenSession(ctx context.Context, certID string, password []byte) (string, time.Time, error) {
+	ret := _m.Called(ctx, certID, password)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context, string, []byte) string); ok {
+		r0 = rf(ctx, certID, password)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 time.Time
+	if rf, ok := ret.Get(1).(func(context.Context, string, []byte) time.Time); ok {
+		r1 = rf(ctx, certID, password)
+	} else {
+		r1 = ret.Get(1).(time.Time)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string, []byte) error); ok {
+		r2 = rf(ctx, certID, password)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // Ping provides a mock function with given fields: ctx
 func (_m *Service) Ping(ctx context.Context) error {
 	ret := _m.Called(ctx)
@@ -68,6 +214,71 @@ func (_m *Service) Ping(ctx context.Context) error {
 	return r0
 }
 
+// Ready provides a mock function with given fields: ctx
+func (_m *Service) Ready(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ReplaceCert provides a mock function with given fields: ctx, id, password, pkcsData, pkcsPassword
+func (_m *Service) ReplaceCert(ctx context.Context, id string, password []byte, pkcsData []byte, pkcsPassword string) error {
+	ret := _m.Called(ctx, id, password, pkcsData, pkcsPassword)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []byte, []byte, string) error); ok {
+		r0 = rf(ctx, id, password, pkcsData, pkcsPassword)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RollbackCert provides a mock function with given fields: ctx, id, password
+func (_m *Service) RollbackCert(ctx context.Context, id string, password []byte) error {
+	ret := _m.Called(ctx, id, password)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []byte) error); ok {
+		r0 = rf(ctx, id, password)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SendSOAP provides a mock function with given fields: ctx, certID, certPassword, req
+func (_m *Service) SendSOAP(ctx context.Context, certID string, certPassword []byte, req []byte) ([]byte, error) {
+	ret := _m.Called(ctx, certID, certPassword, req)
+
+	var r0 []byte
+	if rf, ok := ret.Get(0).(func(context.Context, string, []byte, []byte) []byte); ok {
+		r0 = rf(ctx, certID, certPassword, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, []byte, []byte) error); ok {
+		r1 = rf(ctx, certID, certPassword, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // SendSale provides a mock function with given fields: ctx, certID, pk, trzba
 func (_m *Service) SendSale(ctx context.Context, certID string, pk []byte, trzba *eet.TrzbaType) (*eet.OdpovedType, error) {
 	ret := _m.Called(ctx, certID, pk, trzba)
@@ -91,13 +302,172 @@ func (_m *Service) SendSale(ctx context.Context, certID string, pk []byte, trzba
 	return r0, r1
 }
 
-// StoreCert provides a mock function with given fields: ctx, certID, password, pkcsData, pkcsPassword
-func (_m *Service) StoreCert(ctx context.Context, certID string, password []byte, pkcsData []byte, pkcsPassword string) error {
-	ret := _m.Called(ctx, certID, password, pkcsData, pkcsPassword)
+// SendSaleWithCert provides a mock function with given fields: ctx, pkcsData, pkcsPassword, trzba
+func (_m *Service) SendSaleWithCert(ctx context.Context, pkcsData []byte, pkcsPassword string, trzba *eet.TrzbaType) (*eet.OdpovedType, error) {
+	ret := _m.Called(ctx, pkcsData, pkcsPassword, trzba)
+
+	var r0 *eet.OdpovedType
+	if rf, ok := ret.Get(0).(func(context.Context, []byte, string, *eet.TrzbaType) *eet.OdpovedType); ok {
+		r0 = rf(ctx, pkcsData, pkcsPassword, trzba)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*eet.OdpovedType)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []byte, string, *eet.TrzbaType) error); ok {
+		r1 = rf(ctx, pkcsData, pkcsPassword, trzba)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SendSaleWithSession provides a mock function with given fields: ctx, certID, token, trzba
+func (_m *Service) SendSaleWithSession(ctx context.Context, certID string, token string, trzba *eet.TrzbaType) (*eet.OdpovedType, error) {
+	ret := _m.Called(ctx, certID, token, trzba)
+
+	var r0 *eet.OdpovedType
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, *eet.TrzbaType) *eet.OdpovedType); ok {
+		r0 = rf(ctx, certID, token, trzba)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*eet.OdpovedType)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, *eet.TrzbaType) error); ok {
+		r1 = rf(ctx, certID, token, trzba)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SignSale provides a mock function with given fields: ctx, certID, certPassword, trzba
+func (_m *Service) SignSale(ctx context.Context, certID string, certPassword []byte, trzba *eet.TrzbaType) ([]byte, error) {
+	ret := _m.Called(ctx, certID, certPassword, trzba)
+
+	var r0 []byte
+	if rf, ok := ret.Get(0).(func(context.Context, string, []byte, *eet.TrzbaType) []byte); ok {
+		r0 = rf(ctx, certID, certPassword, trzba)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, []byte, *eet.TrzbaType) error); ok {
+		r1 = rf(ctx, certID, certPassword, trzba)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SignSaleWithCert provides a mock function with given fields: ctx, pkcsData, pkcsPassword, trzba
+func (_m *Service) SignSaleWithCert(ctx context.Context, pkcsData []byte, pkcsPassword string, trzba *eet.TrzbaType) ([]byte, error) {
+	ret := _m.Called(ctx, pkcsData, pkcsPassword, trzba)
+
+	var r0 []byte
+	if rf, ok := ret.Get(0).(func(context.Context, []byte, string, *eet.TrzbaType) []byte); ok {
+		r0 = rf(ctx, pkcsData, pkcsPassword, trzba)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []byte, string, *eet.TrzbaType) error); ok {
+		r1 = rf(ctx, pkcsData, pkcsPassword, trzba)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// StoreCert provides a mock function with given fields: ctx, certID, password, pkcsData, pkcsPassword, policy
+func (_m *Service) StoreCert(ctx context.Context, certID string, password []byte, pkcsData []byte, pkcsPassword string, policy *keystore.Policy) error {
+	ret := _m.Called(ctx, certID, password, pkcsData, pkcsPassword, policy)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, []byte, []byte, string) error); ok {
-		r0 = rf(ctx, certID, password, pkcsData, pkcsPassword)
+	if rf, ok := ret.Get(0).(func(context.Context, string, []byte, []byte, string, *keystore.Policy) error); ok {
+		r0 = rf(ctx, certID, password, pkcsData, pkcsPassword, policy)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// StoreCertPEM provides a mock function with given fields: ctx, certID, password, certData, keyData, policy
+func (_m *Service) StoreCertPEM(ctx context.Context, certID string, password []byte, certData []byte, keyData []byte, policy *keystore.Policy) error {
+	ret := _m.Called(ctx, certID, password, certData, keyData, policy)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []byte, []byte, []byte, *keystore.Policy) error); ok {
+		r0 = rf(ctx, certID, password, certData, keyData, policy)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// StoreCertSigner provides a mock function with given fields: ctx, certID, password, certData, key, policy
+func (_m *Service) StoreCertSigner(ctx context.Context, certID string, password []byte, certData []byte, key crypto.Signer, policy *keystore.Policy) error {
+	ret := _m.Called(ctx, certID, password, certData, key, policy)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []byte, []byte, crypto.Signer, *keystore.Policy) error); ok {
+		r0 = rf(ctx, certID, password, certData, key, policy)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// TrustedRoots provides a mock function with given fields:
+func (_m *Service) TrustedRoots() ([]*x509.Certificate, []*x509.Certificate) {
+	ret := _m.Called()
+
+	var r0 []*x509.Certificate
+	if rf, ok := ret.Get(0).(func() []*x509.Certificate); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*x509.Certificate)
+		}
+	}
+
+	var r1 []*x509.Certificate
+	if rf, ok := ret.Get(1).(func() []*x509.Certificate); ok {
+		r1 = rf()
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).([]*x509.Certificate)
+		}
+	}
+
+	return r0, r1
+}
+
+// UnlockCert provides a mock function with given fields: ctx, id, client
+func (_m *Service) UnlockCert(ctx context.Context, id string, client string) error {
+	ret := _m.Called(ctx, id, client)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, id, client)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -132,3 +502,17 @@ func (_m *Service) UpdateCertPassword(ctx context.Context, id string, oldPasswor
 
 	return r0
 }
+
+// UpdateCertPolicy provides a mock function with given fields: ctx, id, password, policy
+func (_m *Service) UpdateCertPolicy(ctx context.Context, id string, password []byte, policy *keystore.Policy) error {
+	ret := _m.Called(ctx, id, password, policy)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []byte, *keystore.Policy) error); ok {
+		r0 = rf(ctx, id, password, policy)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -8,6 +8,11 @@ import (
 	context "context"
 
 	keystore "github.com/chutommy/eetgateway/pkg/keystore"
+
+	big "math/big"
+
+	time "time"
+
 	mock "github.com/stretchr/testify/mock"
 )
 
@@ -16,6 +21,34 @@ type Service struct {
 	mock.Mock
 }
 
+// Attempts provides a mock function with given fields: ctx, key
+func (_m *Service) Attempts(ctx context.Context, key string) (int64, time.Duration, error) {
+	ret := _m.Called(ctx, key)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, string) int64); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 time.Duration
+	if rf, ok := ret.Get(1).(func(context.Context, string) time.Duration); ok {
+		r1 = rf(ctx, key)
+	} else {
+		r1 = ret.Get(1).(time.Duration)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string) error); ok {
+		r2 = rf(ctx, key)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // Delete provides a mock function with given fields: ctx, id
 func (_m *Service) Delete(ctx context.Context, id string) error {
 	ret := _m.Called(ctx, id)
@@ -53,6 +86,52 @@ func (_m *Service) Get(ctx context.Context, id string, password []byte) (*keysto
 	return r0, r1
 }
 
+// GetPolicy provides a mock function with given fields: ctx, id
+func (_m *Service) GetPolicy(ctx context.Context, id string) (*keystore.Policy, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *keystore.Policy
+	if rf, ok := ret.Get(0).(func(context.Context, string) *keystore.Policy); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*keystore.Policy)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Identity provides a mock function with given fields: ctx, id
+func (_m *Service) Identity(ctx context.Context, id string) (*keystore.Identity, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *keystore.Identity
+	if rf, ok := ret.Get(0).(func(context.Context, string) *keystore.Identity); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*keystore.Identity)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // List provides a mock function with given fields: ctx, start, end
 func (_m *Service) List(ctx context.Context, start int64, end int64) ([]string, error) {
 	ret := _m.Called(ctx, start, end)
@@ -90,8 +169,29 @@ func (_m *Service) Ping(ctx context.Context) error {
 	return r0
 }
 
-// Store provides a mock function with given fields: ctx, id, password, kp
-func (_m *Service) Store(ctx context.Context, id string, password []byte, kp *keystore.KeyPair) error {
+// ReleaseAttempt provides a mock function with given fields: ctx, key, failed
+func (_m *Service) ReleaseAttempt(ctx context.Context, key string, failed bool) (time.Duration, error) {
+	ret := _m.Called(ctx, key, failed)
+
+	var r0 time.Duration
+	if rf, ok := ret.Get(0).(func(context.Context, string, bool) time.Duration); ok {
+		r0 = rf(ctx, key, failed)
+	} else {
+		r0 = ret.Get(0).(time.Duration)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, bool) error); ok {
+		r1 = rf(ctx, key, failed)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Replace provides a mock function with given fields: ctx, id, password, kp
+func (_m *Service) Replace(ctx context.Context, id string, password []byte, kp *keystore.KeyPair) error {
 	ret := _m.Called(ctx, id, password, kp)
 
 	var r0 error
@@ -104,6 +204,83 @@ func (_m *Service) Store(ctx context.Context, id string, password []byte, kp *ke
 	return r0
 }
 
+// ReserveAttempt provides a mock function with given fields: ctx, key
+func (_m *Service) ReserveAttempt(ctx context.Context, key string) (int64, error) {
+	ret := _m.Called(ctx, key)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, string) int64); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ResetFailures provides a mock function with given fields: ctx, key
+func (_m *Service) ResetFailures(ctx context.Context, key string) error {
+	ret := _m.Called(ctx, key)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Revoke provides a mock function with given fields: ctx, id, serialNumber
+func (_m *Service) Revoke(ctx context.Context, id string, serialNumber *big.Int) error {
+	ret := _m.Called(ctx, id, serialNumber)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *big.Int) error); ok {
+		r0 = rf(ctx, id, serialNumber)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Rollback provides a mock function with given fields: ctx, id, password
+func (_m *Service) Rollback(ctx context.Context, id string, password []byte) error {
+	ret := _m.Called(ctx, id, password)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []byte) error); ok {
+		r0 = rf(ctx, id, password)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Store provides a mock function with given fields: ctx, id, password, kp, policy
+func (_m *Service) Store(ctx context.Context, id string, password []byte, kp *keystore.KeyPair, policy *keystore.Policy) error {
+	ret := _m.Called(ctx, id, password, kp, policy)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []byte, *keystore.KeyPair, *keystore.Policy) error); ok {
+		r0 = rf(ctx, id, password, kp, policy)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // UpdateID provides a mock function with given fields: ctx, oldID, newID
 func (_m *Service) UpdateID(ctx context.Context, oldID string, newID string) error {
 	ret := _m.Called(ctx, oldID, newID)
@@ -131,3 +308,17 @@ func (_m *Service) UpdatePassword(ctx context.Context, id string, oldPassword []
 
 	return r0
 }
+
+// UpdatePolicy provides a mock function with given fields: ctx, id, policy
+func (_m *Service) UpdatePolicy(ctx context.Context, id string, policy *keystore.Policy) error {
+	ret := _m.Called(ctx, id, policy)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *keystore.Policy) error); ok {
+		r0 = rf(ctx, id, policy)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -0,0 +1,98 @@
+package revocation
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+type crl struct {
+	issuer     []byte
+	number     *big.Int
+	nextUpdate time.Time
+	// revoked maps the serial numbers of revoked certificates to the times of the revocations
+	revoked map[string]time.Time
+}
+
+// loadCRL parses the DER or PEM encoded CRL, verifies it and caches it under the source.
+func (s *service) loadCRL(source string, data []byte) error {
+	if block, _ := pem.Decode(data); block != nil {
+		if block.Type != "X509 CRL" {
+			return fmt.Errorf("unexpected PEM block type %s: %w", block.Type, ErrInvalidCRL)
+		}
+
+		data = block.Bytes
+	}
+
+	rl, err := x509.ParseRevocationList(data)
+	if err != nil {
+		return fmt.Errorf("parse CRL: %v: %w", err, ErrInvalidCRL)
+	}
+
+	var trusted bool
+	for _, iss := range s.trustedIssuers() {
+		if bytes.Equal(iss.RawSubject, rl.RawIssuer) && rl.CheckSignatureFrom(iss) == nil {
+			trusted = true
+			break
+		}
+	}
+
+	if !trusted {
+		return fmt.Errorf("CRL not signed off by a trusted issuer: %w", ErrInvalidCRL)
+	}
+
+	l := &crl{
+		issuer:     rl.RawIssuer,
+		number:     rl.Number,
+		nextUpdate: rl.NextUpdate,
+		revoked:    make(map[string]time.Time, len(rl.RevokedCertificateEntries)),
+	}
+
+	for _, e := range rl.RevokedCertificateEntries {
+		l.revoked[e.SerialNumber.String()] = e.RevocationTime
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// an older CRL could hide recent revocations
+	if prev, ok := s.crls[source]; ok && prev.number != nil && l.number != nil && l.number.Cmp(prev.number) < 0 {
+		return fmt.Errorf("CRL number %s older than the cached %s: %w", l.number, prev.number, ErrInvalidCRL)
+	}
+
+	s.crls[source] = l
+
+	return nil
+}
+
+// crlURLs returns the configured CRL URLs and the distribution points of checked certificates.
+func (s *service) crlURLs() []string {
+	seen := make(map[string]struct{})
+	var urls []string
+	for _, url := range s.policy.CRLURLs {
+		if _, ok := seen[url]; !ok {
+			seen[url] = struct{}{}
+			urls = append(urls, url)
+		}
+	}
+
+	s.watchMu.Lock()
+	defer s.watchMu.Unlock()
+
+	for url := range s.distributionPoints {
+		if _, ok := seen[url]; !ok {
+			seen[url] = struct{}{}
+			urls = append(urls, url)
+		}
+	}
+
+	return urls
+}
+
+func isHTTPURL(url string) bool {
+	return strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://")
+}
@@ -0,0 +1,125 @@
+package revocation
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.uber.org/multierr"
+	"golang.org/x/crypto/ocsp"
+)
+
+// ocspValidity is the lifetime of OCSP responses without the next update time.
+const ocspValidity = 24 * time.Hour
+
+type ocspStatus struct {
+	good       bool
+	revoked    bool
+	revokedAt  time.Time
+	nextUpdate time.Time
+}
+
+// Watch registers the distribution points and the OCSP responders of the certificate for the following
+// refreshes. It's meant to be called once the certificate is stored or loaded, not on every check.
+func (s *service) Watch(cert *x509.Certificate) {
+	if s.policy.Offline {
+		return
+	}
+
+	s.watchMu.Lock()
+	defer s.watchMu.Unlock()
+
+	s.watchDistributionPoints(cert.CRLDistributionPoints)
+	if s.policy.OCSP && len(cert.OCSPServer) > 0 {
+		key := statusKey{issuer: string(cert.RawIssuer), serialNumber: cert.SerialNumber.String()}
+		s.watched[key] = cert
+	}
+}
+
+// WatchDistributionPoints registers the CRL distribution points for the following refreshes, e.g. those
+// of the stored certificates known without the certificates themselves.
+func (s *service) WatchDistributionPoints(urls []string) {
+	if s.policy.Offline {
+		return
+	}
+
+	s.watchMu.Lock()
+	defer s.watchMu.Unlock()
+
+	s.watchDistributionPoints(urls)
+}
+
+// watchDistributionPoints registers the HTTP distribution points. The watchMu must be held.
+func (s *service) watchDistributionPoints(urls []string) {
+	for _, url := range urls {
+		if isHTTPURL(url) {
+			s.distributionPoints[url] = struct{}{}
+		}
+	}
+}
+
+// watchedCerts returns the certificates whose OCSP responders are requested.
+func (s *service) watchedCerts() map[statusKey]*x509.Certificate {
+	s.watchMu.Lock()
+	defer s.watchMu.Unlock()
+
+	certs := make(map[statusKey]*x509.Certificate, len(s.watched))
+	for key, cert := range s.watched {
+		certs[key] = cert
+	}
+
+	return certs
+}
+
+// refreshOCSP requests the OCSP responders of the certificate until one of them responds.
+func (s *service) refreshOCSP(ctx context.Context, key statusKey, cert *x509.Certificate) error {
+	issuer, ok := s.issuer(cert)
+	if !ok {
+		return fmt.Errorf("issuer of the certificate not trusted: %w", ErrInvalidOCSPResponse)
+	}
+
+	req, err := ocsp.CreateRequest(cert, issuer, nil)
+	if err != nil {
+		return fmt.Errorf("create OCSP request: %w", err)
+	}
+
+	var errs error
+	for _, url := range cert.OCSPServer {
+		if !isHTTPURL(url) {
+			continue
+		}
+
+		data, err := s.fetch(ctx, http.MethodPost, url, "application/ocsp-request", req)
+		if err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("request OCSP responder %s: %w", url, err))
+			continue
+		}
+
+		resp, err := ocsp.ParseResponseForCert(data, cert, issuer)
+		if err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("parse response of OCSP responder %s: %v: %w", url, err, ErrInvalidOCSPResponse))
+			continue
+		}
+
+		status := &ocspStatus{
+			good:       resp.Status == ocsp.Good,
+			revoked:    resp.Status == ocsp.Revoked,
+			revokedAt:  resp.RevokedAt,
+			nextUpdate: resp.NextUpdate,
+		}
+
+		if status.nextUpdate.IsZero() {
+			status.nextUpdate = resp.ThisUpdate.Add(ocspValidity)
+		}
+
+		s.mu.Lock()
+		s.ocsp[key] = status
+		s.mu.Unlock()
+
+		return nil
+	}
+
+	return errs
+}
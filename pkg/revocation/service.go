@@ -0,0 +1,245 @@
+package revocation
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/multierr"
+)
+
+// ErrCertificateRevoked is returned if a certificate has been revoked by its issuer.
+var ErrCertificateRevoked = errors.New("certificate revoked")
+
+// ErrStatusUnknown is returned in the strict mode if the revocation status of a certificate isn't known.
+var ErrStatusUnknown = errors.New("revocation status of the certificate unknown")
+
+// ErrInvalidCRL is returned if a CRL can't be parsed or isn't signed off by a trusted issuer.
+var ErrInvalidCRL = errors.New("invalid certificate revocation list")
+
+// ErrInvalidOCSPResponse is returned if an OCSP response can't be parsed or verified.
+var ErrInvalidOCSPResponse = errors.New("invalid OCSP response")
+
+// maxResponseSize limits the size of downloaded CRLs and OCSP responses.
+const maxResponseSize = 64 << 20
+
+// Policy configures the sources of the revocation data.
+type Policy struct {
+	// CRLURLs are downloaded on every refresh in addition to the distribution points of watched certificates.
+	CRLURLs []string
+	// CRLFiles are loaded on every refresh, e.g. in air-gapped setups.
+	CRLFiles []string
+	// Offline disables all network requests, only the CRLFiles are loaded.
+	Offline bool
+	// OCSP enables requests to the OCSP responders of watched certificates.
+	OCSP bool
+	// Strict rejects certificates with unknown revocation status instead of accepting them.
+	Strict bool
+}
+
+// DefaultPolicy is a Policy with sensible defaults.
+var DefaultPolicy = Policy{
+	OCSP: true,
+}
+
+// Service checks revocation status of certificates. The checks never block on the network, they
+// use the revocation data cached by the last Refresh. The revocation data of the certificates
+// registered by Watch and WatchDistributionPoints are refreshed.
+type Service interface {
+	Check(cert *x509.Certificate) error
+	CheckSerialNumber(issuer []byte, serialNumber *big.Int) error
+	Watch(cert *x509.Certificate)
+	WatchDistributionPoints(urls []string)
+	Refresh(ctx context.Context) error
+	SetIssuers(issuers []*x509.Certificate)
+}
+
+type statusKey struct {
+	issuer       string
+	serialNumber string
+}
+
+type service struct {
+	client *http.Client
+	policy Policy
+
+	issuersMu sync.RWMutex
+	issuers   []*x509.Certificate
+
+	mu   sync.RWMutex
+	crls map[string]*crl
+	ocsp map[statusKey]*ocspStatus
+
+	// watchMu guards the registered sources, it isn't taken by the checks
+	watchMu            sync.Mutex
+	distributionPoints map[string]struct{}
+	watched            map[statusKey]*x509.Certificate
+}
+
+// NewService returns a Service accepting the revocation data signed off by one of the issuers.
+// The client is used for downloading CRLs and for OCSP requests.
+func NewService(client *http.Client, issuers []*x509.Certificate, policy Policy) Service {
+	return &service{
+		client:             client,
+		issuers:            issuers,
+		policy:             policy,
+		crls:               make(map[string]*crl),
+		ocsp:               make(map[statusKey]*ocspStatus),
+		distributionPoints: make(map[string]struct{}),
+		watched:            make(map[statusKey]*x509.Certificate),
+	}
+}
+
+// Check returns the cached revocation status of the certificate. The certificate's distribution points
+// and OCSP responders are used only once it's registered by Watch.
+func (s *service) Check(cert *x509.Certificate) error {
+	return s.CheckSerialNumber(cert.RawIssuer, cert.SerialNumber)
+}
+
+// CheckSerialNumber returns the cached revocation status of the certificate with the serial number issued
+// by the DER encoded issuer. Revoked certificates return ErrCertificateRevoked. Certificates with unknown
+// status return ErrStatusUnknown in the strict mode, otherwise they are accepted.
+func (s *service) CheckSerialNumber(issuer []byte, serialNumber *big.Int) error {
+	key := statusKey{issuer: string(issuer), serialNumber: serialNumber.String()}
+	now := time.Now()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var known bool
+	if status, ok := s.ocsp[key]; ok {
+		if status.revoked {
+			return fmt.Errorf("revoked at %s by OCSP: %w", status.revokedAt.Format(time.RFC3339), ErrCertificateRevoked)
+		}
+
+		known = status.good && now.Before(status.nextUpdate)
+	}
+
+	for source, l := range s.crls {
+		if !bytes.Equal(l.issuer, issuer) {
+			continue
+		}
+
+		if revokedAt, ok := l.revoked[key.serialNumber]; ok {
+			return fmt.Errorf("revoked at %s by CRL %s: %w", revokedAt.Format(time.RFC3339), source, ErrCertificateRevoked)
+		}
+
+		if l.nextUpdate.IsZero() || now.Before(l.nextUpdate) {
+			known = true
+		}
+	}
+
+	if !known && s.policy.Strict {
+		return fmt.Errorf("no up-to-date CRL or OCSP response: %w", ErrStatusUnknown)
+	}
+
+	return nil
+}
+
+// Refresh loads the CRL files and, unless offline, downloads the CRLs and requests the OCSP responders.
+// Revocation data which fail to refresh are kept until replaced by the next successful refresh.
+func (s *service) Refresh(ctx context.Context) error {
+	var errs error
+	for _, path := range s.policy.CRLFiles {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("read CRL file %s: %w", path, err))
+			continue
+		}
+
+		if err = s.loadCRL(path, data); err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("load CRL file %s: %w", path, err))
+		}
+	}
+
+	if s.policy.Offline {
+		return errs
+	}
+
+	for _, url := range s.crlURLs() {
+		data, err := s.fetch(ctx, http.MethodGet, url, "", nil)
+		if err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("download CRL %s: %w", url, err))
+			continue
+		}
+
+		if err = s.loadCRL(url, data); err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("load CRL %s: %w", url, err))
+		}
+	}
+
+	if s.policy.OCSP {
+		for key, cert := range s.watchedCerts() {
+			if err := s.refreshOCSP(ctx, key, cert); err != nil {
+				errs = multierr.Append(errs, fmt.Errorf("OCSP status of %s: %w", key.serialNumber, err))
+			}
+		}
+	}
+
+	return errs
+}
+
+// SetIssuers replaces the issuers trusted to sign off the revocation data. The already cached data are kept.
+func (s *service) SetIssuers(issuers []*x509.Certificate) {
+	s.issuersMu.Lock()
+	defer s.issuersMu.Unlock()
+
+	s.issuers = issuers
+}
+
+// trustedIssuers returns the issuers trusted to sign off the revocation data.
+func (s *service) trustedIssuers() []*x509.Certificate {
+	s.issuersMu.RLock()
+	defer s.issuersMu.RUnlock()
+
+	return s.issuers
+}
+
+// issuer returns the trusted issuer of the certificate.
+func (s *service) issuer(cert *x509.Certificate) (*x509.Certificate, bool) {
+	for _, iss := range s.trustedIssuers() {
+		if bytes.Equal(iss.RawSubject, cert.RawIssuer) && cert.CheckSignatureFrom(iss) == nil {
+			return iss, true
+		}
+	}
+
+	return nil, false
+}
+
+func (s *service) fetch(ctx context.Context, method, url, contentType string, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("send request: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("read response body: %w", err)
+	}
+
+	return data, nil
+}
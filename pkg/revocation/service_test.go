@@ -0,0 +1,368 @@
+package revocation_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/chutommy/eetgateway/pkg/revocation"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ocsp"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	pk   *rsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	pk, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "EET CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	raw, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &pk.PublicKey, pk)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(raw)
+	require.NoError(t, err)
+
+	return &testCA{cert: cert, pk: pk}
+}
+
+func (ca *testCA) issue(t *testing.T, serial int64, crlURL, ocspURL string) *x509.Certificate {
+	pk, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "CZ00000019"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+
+	if crlURL != "" {
+		tmpl.CRLDistributionPoints = []string{crlURL}
+	}
+
+	if ocspURL != "" {
+		tmpl.OCSPServer = []string{ocspURL}
+	}
+
+	raw, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &pk.PublicKey, ca.pk)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(raw)
+	require.NoError(t, err)
+
+	return cert
+}
+
+func (ca *testCA) crl(t *testing.T, number int64, revoked ...int64) []byte {
+	tmpl := &x509.RevocationList{
+		Number:     big.NewInt(number),
+		ThisUpdate: time.Now().Add(-time.Minute),
+		NextUpdate: time.Now().Add(time.Hour),
+	}
+
+	for _, serial := range revoked {
+		tmpl.RevokedCertificateEntries = append(tmpl.RevokedCertificateEntries, x509.RevocationListEntry{
+			SerialNumber:   big.NewInt(serial),
+			RevocationTime: time.Now().Add(-time.Minute),
+		})
+	}
+
+	data, err := x509.CreateRevocationList(rand.Reader, tmpl, ca.cert, ca.pk)
+	require.NoError(t, err)
+
+	return data
+}
+
+func writeFile(t *testing.T, data []byte) string {
+	path := filepath.Join(t.TempDir(), "ca.crl")
+	require.NoError(t, ioutil.WriteFile(path, data, 0o600))
+
+	return path
+}
+
+func TestService_CRLFiles(t *testing.T) {
+	ca := newTestCA(t)
+	other := newTestCA(t)
+
+	tests := []struct {
+		name   string
+		data   []byte
+		strict bool
+		serial int64
+		err    error
+		valid  bool
+	}{
+		{
+			name:   "revoked",
+			data:   ca.crl(t, 1, 2, 3),
+			serial: 3,
+			err:    revocation.ErrCertificateRevoked,
+			valid:  true,
+		},
+		{
+			name:   "not revoked",
+			data:   ca.crl(t, 1, 2, 3),
+			strict: true,
+			serial: 4,
+			err:    nil,
+			valid:  true,
+		},
+		{
+			name:   "PEM encoded",
+			data:   pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: ca.crl(t, 1, 2)}),
+			serial: 2,
+			err:    revocation.ErrCertificateRevoked,
+			valid:  true,
+		},
+		{
+			name:   "untrusted CRL",
+			data:   other.crl(t, 1, 2),
+			serial: 2,
+			err:    nil,
+			valid:  false,
+		},
+		{
+			name:   "untrusted CRL in strict mode",
+			data:   other.crl(t, 1, 2),
+			strict: true,
+			serial: 2,
+			err:    revocation.ErrStatusUnknown,
+			valid:  false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := revocation.NewService(http.DefaultClient, []*x509.Certificate{ca.cert}, revocation.Policy{
+				CRLFiles: []string{writeFile(t, tc.data)},
+				Offline:  true,
+				Strict:   tc.strict,
+			})
+
+			err := svc.Refresh(context.Background())
+			if tc.valid {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, revocation.ErrInvalidCRL)
+			}
+
+			err = svc.Check(ca.issue(t, tc.serial, "", ""))
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestService_CRLDistributionPoints(t *testing.T) {
+	ca := newTestCA(t)
+
+	var crl atomic.Value
+	crl.Store(ca.crl(t, 1))
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		_, _ = w.Write(crl.Load().([]byte))
+	}))
+	defer srv.Close()
+
+	svc := revocation.NewService(srv.Client(), []*x509.Certificate{ca.cert}, revocation.Policy{Strict: true})
+	cert := ca.issue(t, 2, srv.URL, "")
+
+	// the checks don't register the distribution point
+	require.ErrorIs(t, svc.Check(cert), revocation.ErrStatusUnknown)
+	require.NoError(t, svc.Refresh(context.Background()))
+	require.ErrorIs(t, svc.Check(cert), revocation.ErrStatusUnknown)
+	require.Equal(t, int32(0), atomic.LoadInt32(&requests))
+
+	// unknown until the distribution point is downloaded
+	svc.Watch(cert)
+	require.ErrorIs(t, svc.Check(cert), revocation.ErrStatusUnknown)
+	require.NoError(t, svc.Refresh(context.Background()))
+	require.NoError(t, svc.Check(cert))
+
+	crl.Store(ca.crl(t, 2, 2))
+	require.NoError(t, svc.Refresh(context.Background()))
+	require.ErrorIs(t, svc.Check(cert), revocation.ErrCertificateRevoked)
+	require.ErrorIs(t, svc.CheckSerialNumber(cert.RawIssuer, cert.SerialNumber), revocation.ErrCertificateRevoked)
+
+	// an older CRL doesn't replace the newer one
+	crl.Store(ca.crl(t, 1))
+	require.ErrorIs(t, svc.Refresh(context.Background()), revocation.ErrInvalidCRL)
+	require.ErrorIs(t, svc.Check(cert), revocation.ErrCertificateRevoked)
+	require.Equal(t, int32(3), atomic.LoadInt32(&requests))
+}
+
+func TestService_WatchDistributionPoints(t *testing.T) {
+	ca := newTestCA(t)
+
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		_, _ = w.Write(ca.crl(t, 1, 2))
+	}))
+	defer srv.Close()
+
+	// the stored certificates are known by the serial numbers and the distribution points only
+	cert := ca.issue(t, 2, srv.URL, "")
+	svc := revocation.NewService(srv.Client(), []*x509.Certificate{ca.cert}, revocation.Policy{})
+	svc.WatchDistributionPoints(cert.CRLDistributionPoints)
+	svc.WatchDistributionPoints(cert.CRLDistributionPoints)
+	svc.WatchDistributionPoints([]string{"ldap://ldap.example.com/crl"})
+
+	require.NoError(t, svc.Refresh(context.Background()))
+	require.ErrorIs(t, svc.CheckSerialNumber(cert.RawIssuer, cert.SerialNumber), revocation.ErrCertificateRevoked)
+	require.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+	// nothing is downloaded offline
+	offline := revocation.NewService(srv.Client(), []*x509.Certificate{ca.cert}, revocation.Policy{Offline: true})
+	offline.WatchDistributionPoints(cert.CRLDistributionPoints)
+	require.NoError(t, offline.Refresh(context.Background()))
+	require.NoError(t, offline.CheckSerialNumber(cert.RawIssuer, cert.SerialNumber))
+	require.Equal(t, int32(1), atomic.LoadInt32(&requests))
+}
+
+func TestService_OCSP(t *testing.T) {
+	ca := newTestCA(t)
+
+	var status int32 = ocsp.Good
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		req, err := ocsp.ParseRequest(body)
+		require.NoError(t, err)
+
+		resp, err := ocsp.CreateResponse(ca.cert, ca.cert, ocsp.Response{
+			Status:       int(atomic.LoadInt32(&status)),
+			SerialNumber: req.SerialNumber,
+			ThisUpdate:   time.Now().Add(-time.Minute),
+			NextUpdate:   time.Now().Add(time.Hour),
+			RevokedAt:    time.Now().Add(-time.Minute),
+		}, ca.pk)
+		require.NoError(t, err)
+
+		_, _ = w.Write(resp)
+	}))
+	defer srv.Close()
+
+	cert := ca.issue(t, 2, "", srv.URL)
+
+	tests := []struct {
+		name   string
+		policy revocation.Policy
+		status int32
+		err    error
+	}{
+		{
+			name:   "good",
+			policy: revocation.Policy{OCSP: true, Strict: true},
+			status: ocsp.Good,
+			err:    nil,
+		},
+		{
+			name:   "revoked",
+			policy: revocation.Policy{OCSP: true},
+			status: ocsp.Revoked,
+			err:    revocation.ErrCertificateRevoked,
+		},
+		{
+			name:   "unknown",
+			policy: revocation.Policy{OCSP: true, Strict: true},
+			status: ocsp.Unknown,
+			err:    revocation.ErrStatusUnknown,
+		},
+		{
+			name:   "OCSP disabled",
+			policy: revocation.Policy{OCSP: false, Strict: true},
+			status: ocsp.Good,
+			err:    revocation.ErrStatusUnknown,
+		},
+		{
+			name:   "offline",
+			policy: revocation.Policy{OCSP: true, Offline: true},
+			status: ocsp.Revoked,
+			err:    nil,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			atomic.StoreInt32(&status, tc.status)
+
+			svc := revocation.NewService(srv.Client(), []*x509.Certificate{ca.cert}, tc.policy)
+			svc.Watch(cert)
+			require.NoError(t, svc.Refresh(context.Background()))
+
+			err := svc.Check(cert)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestService_RefreshErrors(t *testing.T) {
+	ca := newTestCA(t)
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	svc := revocation.NewService(srv.Client(), []*x509.Certificate{ca.cert}, revocation.Policy{
+		CRLURLs:  []string{srv.URL},
+		CRLFiles: []string{filepath.Join(t.TempDir(), "missing.crl")},
+	})
+
+	err := svc.Refresh(context.Background())
+	require.Error(t, err)
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	// the certificates are accepted if the status isn't known in the non-strict mode
+	require.NoError(t, svc.Check(ca.issue(t, 2, "", "")))
+}
+
+func TestService_SetIssuers(t *testing.T) {
+	ca := newTestCA(t)
+	other := newTestCA(t)
+
+	svc := revocation.NewService(http.DefaultClient, []*x509.Certificate{ca.cert}, revocation.Policy{
+		CRLFiles: []string{writeFile(t, other.crl(t, 1, 2))},
+		Offline:  true,
+	})
+
+	require.ErrorIs(t, svc.Refresh(context.Background()), revocation.ErrInvalidCRL)
+	require.NoError(t, svc.Check(other.issue(t, 2, "", "")))
+
+	svc.SetIssuers([]*x509.Certificate{ca.cert, other.cert})
+	require.NoError(t, svc.Refresh(context.Background()))
+	require.ErrorIs(t, svc.Check(other.issue(t, 2, "", "")), revocation.ErrCertificateRevoked)
+}
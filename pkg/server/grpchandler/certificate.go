@@ -0,0 +1,260 @@
+package grpchandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/chutommy/eetgateway/pkg/keystore"
+	"github.com/chutommy/eetgateway/pkg/server/grpchandler/pb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// defaultListLimit is the number of the listed IDs if the limit isn't given.
+const defaultListLimit = 1000
+
+// StoreCert implements pb.CertificateServiceServer.
+func (h *Handler) StoreCert(ctx context.Context, r *pb.StoreCertRequest) (*pb.CertResponse, error) {
+	if err := requiredFields(map[string]string{
+		"cert_id":       r.GetCertId(),
+		"cert_password": r.GetCertPassword(),
+	}); err != nil {
+		return nil, err
+	}
+
+	policy, err := validPolicy(r.GetPolicy())
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case r.GetPem() != nil:
+		if err = requiredFields(map[string]string{
+			"pem.certificate": string(r.GetPem().GetCertificate()),
+			"pem.private_key": string(r.GetPem().GetPrivateKey()),
+		}); err != nil {
+			return nil, err
+		}
+
+		err = h.gateway.StoreCertPEM(ctx, r.GetCertId(), []byte(r.GetCertPassword()), r.GetPem().GetCertificate(), r.GetPem().GetPrivateKey(), policy)
+	case r.GetPkcs12() != nil:
+		if err = requiredFields(map[string]string{
+			"pkcs12.data":     string(r.GetPkcs12().GetData()),
+			"pkcs12.password": r.GetPkcs12().GetPassword(),
+		}); err != nil {
+			return nil, err
+		}
+
+		err = h.gateway.StoreCert(ctx, r.GetCertId(), []byte(r.GetCertPassword()), r.GetPkcs12().GetData(), r.GetPkcs12().GetPassword(), policy)
+	default:
+		return nil, invalidArgument(errors.New("invalid request pkcs12|pem=required"))
+	}
+
+	if err != nil {
+		return nil, gatewayErr(err)
+	}
+
+	return &pb.CertResponse{CertId: r.GetCertId()}, nil
+}
+
+// ListCertIDs implements pb.CertificateServiceServer.
+func (h *Handler) ListCertIDs(ctx context.Context, r *pb.ListCertIDsRequest) (*pb.ListCertIDsResponse, error) {
+	limit := int64(defaultListLimit)
+	if r.Limit != nil {
+		limit = r.GetLimit()
+	}
+
+	if r.GetOffset() < 0 || limit < 0 {
+		return nil, invalidArgument(errors.New("invalid request offset=gte(0) limit=gte(0)"))
+	}
+
+	start, end := r.GetOffset(), r.GetOffset()+limit-1
+	if limit == 0 {
+		end = -1
+	}
+
+	ids, err := h.gateway.ListCertIDs(ctx, start, end)
+	if err != nil {
+		return nil, gatewayErr(err)
+	}
+
+	return &pb.ListCertIDsResponse{CertIds: ids}, nil
+}
+
+// UpdateCertID implements pb.CertificateServiceServer.
+func (h *Handler) UpdateCertID(ctx context.Context, r *pb.UpdateCertIDRequest) (*pb.CertResponse, error) {
+	if err := requiredFields(map[string]string{
+		"cert_id": r.GetCertId(),
+		"new_id":  r.GetNewId(),
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := h.gateway.UpdateCertID(ctx, r.GetCertId(), r.GetNewId()); err != nil {
+		return nil, gatewayErr(err)
+	}
+
+	return &pb.CertResponse{CertId: r.GetNewId()}, nil
+}
+
+// UpdateCertPassword implements pb.CertificateServiceServer.
+func (h *Handler) UpdateCertPassword(ctx context.Context, r *pb.UpdateCertPasswordRequest) (*pb.CertResponse, error) {
+	if err := requiredFields(map[string]string{
+		"cert_id":       r.GetCertId(),
+		"cert_password": r.GetCertPassword(),
+		"new_password":  r.GetNewPassword(),
+	}); err != nil {
+		return nil, err
+	}
+
+	if r.GetNewPassword() == r.GetCertPassword() {
+		return nil, invalidArgument(errors.New("invalid request new_password=necsfield(cert_password)"))
+	}
+
+	ctx = h.withClientAddr(ctx)
+	err := h.gateway.UpdateCertPassword(ctx, r.GetCertId(), []byte(r.GetCertPassword()), []byte(r.GetNewPassword()))
+	if err != nil {
+		return nil, gatewayErr(err)
+	}
+
+	return &pb.CertResponse{CertId: r.GetCertId()}, nil
+}
+
+// UpdateCertPolicy implements pb.CertificateServiceServer.
+func (h *Handler) UpdateCertPolicy(ctx context.Context, r *pb.UpdateCertPolicyRequest) (*pb.CertResponse, error) {
+	if err := requiredFields(map[string]string{
+		"cert_id":       r.GetCertId(),
+		"cert_password": r.GetCertPassword(),
+	}); err != nil {
+		return nil, err
+	}
+
+	// a missing policy removes the restrictions the same way as an empty policy of the HTTP API
+	p := r.GetPolicy()
+	if p == nil {
+		p = &pb.Policy{}
+	}
+
+	policy, err := validPolicy(p)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = h.withClientAddr(ctx)
+	if err = h.gateway.UpdateCertPolicy(ctx, r.GetCertId(), []byte(r.GetCertPassword()), policy); err != nil {
+		return nil, gatewayErr(err)
+	}
+
+	return &pb.CertResponse{CertId: r.GetCertId()}, nil
+}
+
+// ReplaceCert implements pb.CertificateServiceServer.
+func (h *Handler) ReplaceCert(ctx context.Context, r *pb.ReplaceCertRequest) (*pb.CertResponse, error) {
+	if err := requiredFields(map[string]string{
+		"cert_id":         r.GetCertId(),
+		"cert_password":   r.GetCertPassword(),
+		"pkcs12.data":     string(r.GetPkcs12().GetData()),
+		"pkcs12.password": r.GetPkcs12().GetPassword(),
+	}); err != nil {
+		return nil, err
+	}
+
+	ctx = h.withClientAddr(ctx)
+	err := h.gateway.ReplaceCert(ctx, r.GetCertId(), []byte(r.GetCertPassword()), r.GetPkcs12().GetData(), r.GetPkcs12().GetPassword())
+	if err != nil {
+		return nil, gatewayErr(err)
+	}
+
+	return &pb.CertResponse{CertId: r.GetCertId()}, nil
+}
+
+// RollbackCert implements pb.CertificateServiceServer.
+func (h *Handler) RollbackCert(ctx context.Context, r *pb.RollbackCertRequest) (*pb.CertResponse, error) {
+	if err := requiredFields(map[string]string{
+		"cert_id":       r.GetCertId(),
+		"cert_password": r.GetCertPassword(),
+	}); err != nil {
+		return nil, err
+	}
+
+	ctx = h.withClientAddr(ctx)
+	if err := h.gateway.RollbackCert(ctx, r.GetCertId(), []byte(r.GetCertPassword())); err != nil {
+		return nil, gatewayErr(err)
+	}
+
+	return &pb.CertResponse{CertId: r.GetCertId()}, nil
+}
+
+// ExportCert implements pb.CertificateServiceServer.
+func (h *Handler) ExportCert(ctx context.Context, r *pb.ExportCertRequest) (*pb.ExportCertResponse, error) {
+	if err := requiredFields(map[string]string{
+		"cert_id":         r.GetCertId(),
+		"cert_password":   r.GetCertPassword(),
+		"pkcs12_password": r.GetPkcs12Password(),
+	}); err != nil {
+		return nil, err
+	}
+
+	ctx = h.withClientAddr(ctx)
+	data, err := h.gateway.ExportCert(ctx, r.GetCertId(), []byte(r.GetCertPassword()), r.GetPkcs12Password())
+	if err != nil {
+		return nil, gatewayErr(err)
+	}
+
+	return &pb.ExportCertResponse{
+		CertId:     r.GetCertId(),
+		Pkcs12Data: data,
+	}, nil
+}
+
+// OpenSession implements pb.CertificateServiceServer.
+func (h *Handler) OpenSession(ctx context.Context, r *pb.OpenSessionRequest) (*pb.OpenSessionResponse, error) {
+	if err := requiredFields(map[string]string{
+		"cert_id":       r.GetCertId(),
+		"cert_password": r.GetCertPassword(),
+	}); err != nil {
+		return nil, err
+	}
+
+	ctx = h.withClientAddr(ctx)
+	token, expiresAt, err := h.gateway.OpenSession(ctx, r.GetCertId(), []byte(r.GetCertPassword()))
+	if err != nil {
+		return nil, gatewayErr(err)
+	}
+
+	return &pb.OpenSessionResponse{
+		CertId:       r.GetCertId(),
+		SessionToken: token,
+		ExpiresAt:    timestamppb.New(expiresAt),
+	}, nil
+}
+
+// DeleteCert implements pb.CertificateServiceServer.
+func (h *Handler) DeleteCert(ctx context.Context, r *pb.DeleteCertRequest) (*pb.CertResponse, error) {
+	if err := requiredFields(map[string]string{
+		"cert_id": r.GetCertId(),
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := h.gateway.DeleteID(ctx, r.GetCertId()); err != nil {
+		return nil, gatewayErr(err)
+	}
+
+	return &pb.CertResponse{CertId: r.GetCertId()}, nil
+}
+
+// validPolicy returns the usage policy validated the same way as by the HTTP handler,
+// nil if the policy is nil.
+func validPolicy(p *pb.Policy) (*keystore.Policy, error) {
+	req := policyReq(p)
+	if req == nil {
+		return nil, nil
+	}
+
+	if err := req.Validate(); err != nil {
+		return nil, invalidArgument(fmt.Errorf("policy: %w", err))
+	}
+
+	return req.Policy(), nil
+}
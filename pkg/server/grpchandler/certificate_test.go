@@ -0,0 +1,208 @@
+package grpchandler_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/chutommy/eetgateway/pkg/gateway"
+	"github.com/chutommy/eetgateway/pkg/keystore"
+	"github.com/chutommy/eetgateway/pkg/server/grpchandler/pb"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (suite *GRPCHandlerTestSuite) TestStoreCert() {
+	ctx := context.Background()
+
+	suite.Run("missing certificate", func() {
+		_, err := suite.certs.StoreCert(ctx, &pb.StoreCertRequest{
+			CertId:       uuid.New().String(),
+			CertPassword: "secret",
+		})
+		suite.requireCode(codes.InvalidArgument, err)
+	})
+
+	suite.Run("invalid policy", func() {
+		_, err := suite.certs.StoreCert(ctx, &pb.StoreCertRequest{
+			CertId:       uuid.New().String(),
+			CertPassword: "secret",
+			Certificate:  &pb.StoreCertRequest_Pkcs12{Pkcs12: &pb.PKCS12{Data: []byte("valid"), Password: "eet"}},
+			Policy:       &pb.Policy{DicPoverujiciho: "invalid"},
+		})
+		suite.requireCode(codes.InvalidArgument, err)
+	})
+
+	suite.Run("id already exists", func() {
+		certID := uuid.New().String()
+		suite.gSvc.On("StoreCert", mock.Anything, certID, []byte("secret"), []byte("valid"), "eet", (*keystore.Policy)(nil)).
+			Return(gateway.ErrIDAlreadyExists).Once()
+		_, err := suite.certs.StoreCert(ctx, &pb.StoreCertRequest{
+			CertId:       certID,
+			CertPassword: "secret",
+			Certificate:  &pb.StoreCertRequest_Pkcs12{Pkcs12: &pb.PKCS12{Data: []byte("valid"), Password: "eet"}},
+		})
+		suite.requireCode(codes.AlreadyExists, err)
+	})
+
+	suite.Run("ok pem", func() {
+		certID := uuid.New().String()
+		validTo := time.Now().Add(time.Hour).Truncate(time.Second).UTC()
+		policy := &keystore.Policy{
+			IDProvoz: []int{11},
+			IDPokl:   []string{"ABC"},
+			ValidTo:  validTo,
+		}
+
+		suite.gSvc.On("StoreCertPEM", mock.Anything, certID, []byte("secret"), []byte("cert"), []byte("key"), policy).
+			Return(nil).Once()
+		resp, err := suite.certs.StoreCert(ctx, &pb.StoreCertRequest{
+			CertId:       certID,
+			CertPassword: "secret",
+			Certificate:  &pb.StoreCertRequest_Pem{Pem: &pb.PEM{Certificate: []byte("cert"), PrivateKey: []byte("key")}},
+			Policy: &pb.Policy{
+				IdProvoz: []int32{11},
+				IdPokl:   []string{"ABC"},
+				ValidTo:  timestamppb.New(validTo),
+			},
+		})
+		suite.Require().NoError(err)
+		suite.Equal(certID, resp.CertId)
+	})
+}
+
+func (suite *GRPCHandlerTestSuite) TestListCertIDs() {
+	ctx := context.Background()
+
+	suite.Run("default limit", func() {
+		suite.gSvc.On("ListCertIDs", mock.Anything, int64(10), int64(1009)).
+			Return([]string{"a", "b"}, nil).Once()
+		resp, err := suite.certs.ListCertIDs(ctx, &pb.ListCertIDsRequest{Offset: 10})
+		suite.Require().NoError(err)
+		suite.Equal([]string{"a", "b"}, resp.CertIds)
+	})
+
+	suite.Run("no limit", func() {
+		suite.gSvc.On("ListCertIDs", mock.Anything, int64(0), int64(-1)).
+			Return([]string{"a"}, nil).Once()
+		_, err := suite.certs.ListCertIDs(ctx, &pb.ListCertIDsRequest{Limit: proto.Int64(0)})
+		suite.Require().NoError(err)
+	})
+
+	suite.Run("negative offset", func() {
+		_, err := suite.certs.ListCertIDs(ctx, &pb.ListCertIDsRequest{Offset: -1})
+		suite.requireCode(codes.InvalidArgument, err)
+	})
+
+	suite.Run("unavailable keystore", func() {
+		suite.gSvc.On("ListCertIDs", mock.Anything, int64(0), int64(4)).
+			Return(nil, gateway.ErrKeystoreUnavailable).Once()
+		_, err := suite.certs.ListCertIDs(ctx, &pb.ListCertIDsRequest{Limit: proto.Int64(5)})
+		suite.requireCode(codes.Unavailable, err)
+	})
+}
+
+func (suite *GRPCHandlerTestSuite) TestUpdateCertPassword() {
+	ctx := context.Background()
+
+	suite.Run("same password", func() {
+		_, err := suite.certs.UpdateCertPassword(ctx, &pb.UpdateCertPasswordRequest{
+			CertId:       uuid.New().String(),
+			CertPassword: "secret",
+			NewPassword:  "secret",
+		})
+		suite.requireCode(codes.InvalidArgument, err)
+	})
+
+	suite.Run("invalid password", func() {
+		certID := uuid.New().String()
+		suite.gSvc.On("UpdateCertPassword", mock.Anything, certID, []byte("secret"), []byte("new")).
+			Return(gateway.ErrInvalidCertificatePassword).Once()
+		_, err := suite.certs.UpdateCertPassword(ctx, &pb.UpdateCertPasswordRequest{
+			CertId:       certID,
+			CertPassword: "secret",
+			NewPassword:  "new",
+		})
+		suite.requireCode(codes.Unauthenticated, err)
+	})
+}
+
+func (suite *GRPCHandlerTestSuite) TestUpdateCertPolicy() {
+	ctx := context.Background()
+
+	suite.Run("missing password", func() {
+		_, err := suite.certs.UpdateCertPolicy(ctx, &pb.UpdateCertPolicyRequest{
+			CertId: uuid.New().String(),
+		})
+		suite.requireCode(codes.InvalidArgument, err)
+	})
+
+	suite.Run("invalid password", func() {
+		certID := uuid.New().String()
+		suite.gSvc.On("UpdateCertPolicy", mock.Anything, certID, []byte("secret"), &keystore.Policy{IDProvoz: []int{11}}).
+			Return(gateway.ErrInvalidCertificatePassword).Once()
+		_, err := suite.certs.UpdateCertPolicy(ctx, &pb.UpdateCertPolicyRequest{
+			CertId:       certID,
+			CertPassword: "secret",
+			Policy:       &pb.Policy{IdProvoz: []int32{11}},
+		})
+		suite.requireCode(codes.Unauthenticated, err)
+	})
+}
+
+func (suite *GRPCHandlerTestSuite) TestRollbackCert() {
+	certID := uuid.New().String()
+	suite.gSvc.On("RollbackCert", mock.Anything, certID, []byte("secret")).
+		Return(gateway.ErrPreviousCertificateNotFound).Once()
+	_, err := suite.certs.RollbackCert(context.Background(), &pb.RollbackCertRequest{
+		CertId:       certID,
+		CertPassword: "secret",
+	})
+	suite.requireCode(codes.NotFound, err)
+}
+
+func (suite *GRPCHandlerTestSuite) TestExportCert() {
+	certID := uuid.New().String()
+	suite.gSvc.On("ExportCert", mock.Anything, certID, []byte("secret"), "eet").
+		Return([]byte("pkcs12"), nil).Once()
+	resp, err := suite.certs.ExportCert(context.Background(), &pb.ExportCertRequest{
+		CertId:         certID,
+		CertPassword:   "secret",
+		Pkcs12Password: "eet",
+	})
+	suite.Require().NoError(err)
+	suite.Equal([]byte("pkcs12"), resp.Pkcs12Data)
+}
+
+func (suite *GRPCHandlerTestSuite) TestOpenSession() {
+	certID := uuid.New().String()
+	expiresAt := time.Now().Add(15 * time.Minute)
+
+	suite.gSvc.On("OpenSession", mock.Anything, certID, []byte("secret")).
+		Return("token", expiresAt, nil).Once()
+	resp, err := suite.certs.OpenSession(context.Background(), &pb.OpenSessionRequest{
+		CertId:       certID,
+		CertPassword: "secret",
+	})
+	suite.Require().NoError(err)
+	suite.Equal("token", resp.SessionToken)
+	suite.True(expiresAt.Equal(resp.ExpiresAt.AsTime()))
+}
+
+func (suite *GRPCHandlerTestSuite) TestDeleteCert() {
+	ctx := context.Background()
+
+	suite.Run("missing id", func() {
+		_, err := suite.certs.DeleteCert(ctx, &pb.DeleteCertRequest{})
+		suite.requireCode(codes.InvalidArgument, err)
+	})
+
+	suite.Run("not found", func() {
+		certID := uuid.New().String()
+		suite.gSvc.On("DeleteID", mock.Anything, certID).Return(gateway.ErrCertificateNotFound).Once()
+		_, err := suite.certs.DeleteCert(ctx, &pb.DeleteCertRequest{CertId: certID})
+		suite.requireCode(codes.NotFound, err)
+	})
+}
@@ -0,0 +1,118 @@
+package grpchandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/chutommy/eetgateway/pkg/gateway"
+	"github.com/chutommy/eetgateway/pkg/server/grpchandler/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// ErrUnexpected is returned if unexpected error is raised.
+var ErrUnexpected = errors.New("unexpected error")
+
+// Handler is gRPC requests handler.
+type Handler struct {
+	pb.UnimplementedSaleServiceServer
+	pb.UnimplementedCertificateServiceServer
+	pb.UnimplementedStatusServiceServer
+
+	gateway   gateway.Service
+	perClient bool
+}
+
+// NewHandler returns an implementation of Handler. The failed password attempts are counted per peer
+// address besides per certificate if perClient is true.
+func NewHandler(g gateway.Service, perClient bool) *Handler {
+	return &Handler{
+		gateway:   g,
+		perClient: perClient,
+	}
+}
+
+// GRPCServer implements server.GRPCHandler. It returns a gRPC server with the services
+// of the handler registered.
+func (h *Handler) GRPCServer(opts ...grpc.ServerOption) *grpc.Server {
+	opts = append(opts, grpc.ChainUnaryInterceptor(loggingInterceptor, recoverInterceptor))
+	s := grpc.NewServer(opts...)
+
+	pb.RegisterSaleServiceServer(s, h)
+	pb.RegisterCertificateServiceServer(s, h)
+	pb.RegisterStatusServiceServer(s, h)
+
+	return s
+}
+
+// withClientAddr returns a copy of ctx carrying the IP address of the peer for the gateway
+// if the failed password attempts are counted per client.
+func (h *Handler) withClientAddr(ctx context.Context) context.Context {
+	if !h.perClient {
+		return ctx
+	}
+
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ctx
+	}
+
+	addr := p.Addr.String()
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+
+	return gateway.WithClientAddr(ctx, addr)
+}
+
+// requiredFields returns an InvalidArgument error naming the empty fields. The values are
+// given by the names of the fields.
+func requiredFields(fields map[string]string) error {
+	var missing []string
+	for name, value := range fields {
+		if value == "" {
+			missing = append(missing, fmt.Sprintf("%s=required", name))
+		}
+	}
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	sort.Strings(missing)
+
+	return invalidArgument(fmt.Errorf("invalid request %s", strings.Join(missing, " ")))
+}
+
+func invalidArgument(err error) error {
+	return &statusError{
+		status: status.New(codes.InvalidArgument, err.Error()),
+		cause:  err,
+	}
+}
+
+// statusError is an error with the gRPC status returned to the client. The cause
+// of the error is kept for the logs.
+type statusError struct {
+	status *status.Status
+	cause  error
+}
+
+func (e *statusError) Error() string {
+	return e.cause.Error()
+}
+
+func (e *statusError) Unwrap() error {
+	return e.cause
+}
+
+// GRPCStatus returns the status of the error sent to the client.
+func (e *statusError) GRPCStatus() *status.Status {
+	return e.status
+}
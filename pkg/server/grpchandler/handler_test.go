@@ -0,0 +1,69 @@
+package grpchandler_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	mocks "github.com/chutommy/eetgateway/pkg/mocks/gateway"
+	"github.com/chutommy/eetgateway/pkg/server/grpchandler"
+	"github.com/chutommy/eetgateway/pkg/server/grpchandler/pb"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type GRPCHandlerTestSuite struct {
+	suite.Suite
+	gSvc   *mocks.Service
+	server *grpc.Server
+	conn   *grpc.ClientConn
+
+	sales  pb.SaleServiceClient
+	certs  pb.CertificateServiceClient
+	status pb.StatusServiceClient
+}
+
+func (suite *GRPCHandlerTestSuite) SetupSuite() {
+	log.Logger = zerolog.Nop()
+	suite.gSvc = new(mocks.Service)
+	suite.server = grpchandler.NewHandler(suite.gSvc, true).GRPCServer()
+
+	lis := bufconn.Listen(1 << 20)
+	go func() {
+		_ = suite.server.Serve(lis)
+	}()
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			return lis.Dial()
+		}),
+		grpc.WithInsecure(),
+	)
+	suite.Require().NoError(err)
+
+	suite.conn = conn
+	suite.sales = pb.NewSaleServiceClient(conn)
+	suite.certs = pb.NewCertificateServiceClient(conn)
+	suite.status = pb.NewStatusServiceClient(conn)
+}
+
+func (suite *GRPCHandlerTestSuite) TearDownSuite() {
+	_ = suite.conn.Close()
+	suite.server.Stop()
+	suite.gSvc.AssertExpectations(suite.T())
+}
+
+// requireCode asserts the status code of the error.
+func (suite *GRPCHandlerTestSuite) requireCode(c codes.Code, err error) {
+	suite.Require().Error(err)
+	suite.Equal(c, status.Code(err), err.Error())
+}
+
+func TestGRPCHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(GRPCHandlerTestSuite))
+}
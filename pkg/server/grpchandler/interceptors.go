@@ -0,0 +1,60 @@
+package grpchandler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func loggingInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+
+	resp, err := handler(ctx, req)
+
+	var client string
+	if p, ok := peer.FromContext(ctx); ok {
+		client = p.Addr.String()
+	}
+
+	log.Info().
+		Str("entity", "gRPC Handler").
+		Str("action", "serving request").
+		Str("client", client).
+		Str("method", info.FullMethod).
+		Str("status", status.Code(err).String()).
+		TimeDiff("latency", time.Now(), start).
+		Err(err).
+		Send()
+
+	return resp, err
+}
+
+func recoverInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			e, ok := r.(error)
+			if !ok {
+				e = fmt.Errorf("%v", r)
+			}
+
+			log.Error().
+				Str("entity", "System Recovery").
+				Str("action", "recovering from fatal error").
+				Err(e).
+				Send()
+
+			resp, err = nil, &statusError{
+				status: status.New(codes.Internal, ErrUnexpected.Error()),
+				cause:  e,
+			}
+		}
+	}()
+
+	return handler(ctx, req)
+}
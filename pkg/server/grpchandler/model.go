@@ -0,0 +1,304 @@
+package grpchandler
+
+import (
+	"crypto/x509"
+	"errors"
+	"time"
+
+	"github.com/chutommy/eetgateway/pkg/eet"
+	"github.com/chutommy/eetgateway/pkg/gateway"
+	"github.com/chutommy/eetgateway/pkg/server/grpchandler/pb"
+	"github.com/chutommy/eetgateway/pkg/server/httphandler"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// saleReq returns the sale request of the HTTP API with the sale data. The unset header
+// fields are left with the default values.
+func saleReq(certID string, s *pb.Sale) *httphandler.SendSaleReq {
+	req := httphandler.NewSendSaleReq()
+	req.CertID = certID
+	if s == nil {
+		s = &pb.Sale{}
+	}
+
+	if s.UuidZpravy != "" {
+		req.UUIDZpravy = eet.UUIDType(s.UuidZpravy)
+	}
+
+	if s.DatOdesl != nil {
+		req.DatOdesl = dateTime(s.DatOdesl)
+	}
+
+	if s.PrvniZaslani != nil {
+		req.PrvniZaslani = *s.PrvniZaslani
+	}
+
+	req.Overeni = s.Overeni
+	req.DICPopl = eet.CZDICType(s.DicPopl)
+	req.DICPoverujiciho = eet.CZDICType(s.DicPoverujiciho)
+	req.IDProvoz = int(s.IdProvoz)
+	req.IDPokl = eet.String20(s.IdPokl)
+	req.PoradCis = eet.String25(s.PoradCis)
+	if s.DatTrzby != nil {
+		req.DatTrzby = dateTime(s.DatTrzby)
+	}
+
+	req.CelkTrzba = eet.CastkaType(s.CelkTrzba)
+	req.ZaklNepodlDPH = eet.CastkaType(s.ZaklNepodlDph)
+	req.ZaklDan1 = eet.CastkaType(s.ZaklDan1)
+	req.Dan1 = eet.CastkaType(s.Dan1)
+	req.ZaklDan2 = eet.CastkaType(s.ZaklDan2)
+	req.Dan2 = eet.CastkaType(s.Dan2)
+	req.ZaklDan3 = eet.CastkaType(s.ZaklDan3)
+	req.Dan3 = eet.CastkaType(s.Dan3)
+	req.CestSluz = eet.CastkaType(s.CestSluz)
+	req.PouzitZboz1 = eet.CastkaType(s.PouzitZboz1)
+	req.PouzitZboz2 = eet.CastkaType(s.PouzitZboz2)
+	req.PouzitZboz3 = eet.CastkaType(s.PouzitZboz3)
+	req.UrcenoCerpzZuct = eet.CastkaType(s.UrcenoCerpZuct)
+	req.CerpZuct = eet.CastkaType(s.CerpZuct)
+	req.Rezim = eet.RezimType(s.Rezim)
+
+	return req
+}
+
+func dateTime(ts *timestamppb.Timestamp) *eet.DateTime {
+	t := eet.DateTime(ts.AsTime().Local())
+	t.Normalize()
+
+	return &t
+}
+
+func timestamp(t eet.DateTime) *timestamppb.Timestamp {
+	return timestamppb.New(time.Time(t))
+}
+
+func sale(req *httphandler.SendSaleReq) *pb.Sale {
+	return &pb.Sale{
+		UuidZpravy:      string(req.UUIDZpravy),
+		DatOdesl:        timestamp(*req.DatOdesl),
+		PrvniZaslani:    &req.PrvniZaslani,
+		Overeni:         req.Overeni,
+		DicPopl:         string(req.DICPopl),
+		DicPoverujiciho: string(req.DICPoverujiciho),
+		IdProvoz:        int32(req.IDProvoz),
+		IdPokl:          string(req.IDPokl),
+		PoradCis:        string(req.PoradCis),
+		DatTrzby:        timestamp(*req.DatTrzby),
+		CelkTrzba:       float64(req.CelkTrzba),
+		ZaklNepodlDph:   float64(req.ZaklNepodlDPH),
+		ZaklDan1:        float64(req.ZaklDan1),
+		Dan1:            float64(req.Dan1),
+		ZaklDan2:        float64(req.ZaklDan2),
+		Dan2:            float64(req.Dan2),
+		ZaklDan3:        float64(req.ZaklDan3),
+		Dan3:            float64(req.Dan3),
+		CestSluz:        float64(req.CestSluz),
+		PouzitZboz1:     float64(req.PouzitZboz1),
+		PouzitZboz2:     float64(req.PouzitZboz2),
+		PouzitZboz3:     float64(req.PouzitZboz3),
+		UrcenoCerpZuct:  float64(req.UrcenoCerpzZuct),
+		CerpZuct:        float64(req.CerpZuct),
+		Rezim:           int32(req.Rezim),
+	}
+}
+
+func sendSaleResponse(req *httphandler.SendSaleReq, odpoved *eet.OdpovedType) *pb.SendSaleResponse {
+	varovani := make([]*pb.Warning, 0, len(odpoved.Varovani))
+	for _, v := range odpoved.Varovani {
+		varovani = append(varovani, &pb.Warning{
+			KodVarov: int32(v.Kodvarov),
+			Zprava:   v.Zprava,
+		})
+	}
+
+	if (odpoved.Hlavicka.Datodmit != eet.DateTime{}) {
+		return &pb.SendSaleResponse{
+			CertId:     req.CertID,
+			DatOdmit:   timestamp(odpoved.Hlavicka.Datodmit),
+			ChybZprava: odpoved.Chyba.Zprava,
+			ChybKod:    int32(odpoved.Chyba.Kod),
+			Test:       odpoved.Potvrzeni.Test || odpoved.Chyba.Test,
+			Varovani:   varovani,
+		}
+	}
+
+	return &pb.SendSaleResponse{
+		CertId:   req.CertID,
+		DatPrij:  timestamp(odpoved.Hlavicka.Datprij),
+		Fik:      string(odpoved.Potvrzeni.Fik),
+		Bkp:      string(odpoved.Hlavicka.Bkp),
+		Test:     odpoved.Potvrzeni.Test,
+		Varovani: varovani,
+
+		Trzba: sale(req),
+	}
+}
+
+func computeCodesResponse(certID string, codes *eet.TrzbaKontrolniKodyType) *pb.ComputeCodesResponse {
+	return &pb.ComputeCodesResponse{
+		CertId: certID,
+		Pkp:    codes.Pkp.PkpType,
+		Bkp:    string(codes.Bkp.BkpType),
+	}
+}
+
+// policyReq returns the policy request of the HTTP API with the policy, nil if the policy is nil.
+func policyReq(p *pb.Policy) *httphandler.CertPolicyReq {
+	if p == nil {
+		return nil
+	}
+
+	req := &httphandler.CertPolicyReq{
+		IDPokl:          p.IdPokl,
+		DICPoverujiciho: eet.CZDICType(p.DicPoverujiciho),
+	}
+
+	for _, id := range p.IdProvoz {
+		req.IDProvoz = append(req.IDProvoz, int(id))
+	}
+
+	if p.ValidFrom != nil {
+		validFrom := p.ValidFrom.AsTime()
+		req.ValidFrom = &validFrom
+	}
+
+	if p.ValidTo != nil {
+		validTo := p.ValidTo.AsTime()
+		req.ValidTo = &validTo
+	}
+
+	return req
+}
+
+func caRoots(purpose string, certs []*x509.Certificate) []*pb.CARoot {
+	now := time.Now()
+	roots := make([]*pb.CARoot, 0, len(certs))
+	for _, c := range certs {
+		roots = append(roots, &pb.CARoot{
+			Purpose:      purpose,
+			Subject:      c.Subject.String(),
+			SerialNumber: c.SerialNumber.String(),
+			NotBefore:    timestamppb.New(c.NotBefore),
+			NotAfter:     timestamppb.New(c.NotAfter),
+			Expired:      now.After(c.NotAfter),
+		})
+	}
+
+	return roots
+}
+
+func dependencies(statuses []gateway.DependencyStatus) []*pb.Dependency {
+	deps := make([]*pb.Dependency, 0, len(statuses))
+	for _, s := range statuses {
+		dep := &pb.Dependency{
+			Name:      s.Name,
+			Status:    "online",
+			LatencyMs: float64(s.Latency) / float64(time.Millisecond),
+			CheckedAt: timestamppb.New(s.CheckedAt),
+		}
+
+		if s.Err != nil {
+			dep.Status = s.Err.Error()
+		}
+
+		if !s.LastSuccess.IsZero() {
+			dep.LastSuccess = timestamppb.New(s.LastSuccess)
+		}
+
+		deps = append(deps, dep)
+	}
+
+	return deps
+}
+
+// gatewayErr returns the error with the status code of the gateway error. The status codes
+// correspond to the HTTP status codes of the HTTP API.
+func gatewayErr(err error) error {
+	c, e := codes.Internal, ErrUnexpected
+
+	switch {
+	case errors.Is(err, gateway.ErrCertificateNotFound):
+		c, e = codes.NotFound, gateway.ErrCertificateNotFound
+	case errors.Is(err, gateway.ErrPreviousCertificateNotFound):
+		c, e = codes.NotFound, gateway.ErrPreviousCertificateNotFound
+	case errors.Is(err, gateway.ErrInvalidCertificatePassword):
+		c, e = codes.Unauthenticated, gateway.ErrInvalidCertificatePassword
+	case errors.Is(err, gateway.ErrInvalidSessionToken):
+		c, e = codes.Unauthenticated, gateway.ErrInvalidSessionToken
+	case errors.Is(err, gateway.ErrIDAlreadyExists):
+		c, e = codes.AlreadyExists, gateway.ErrIDAlreadyExists
+	case errors.Is(err, gateway.ErrCertificateLocked):
+		c, e = codes.ResourceExhausted, gateway.ErrCertificateLocked
+	case errors.Is(err, gateway.ErrTooManyAttempts):
+		c, e = codes.ResourceExhausted, gateway.ErrTooManyAttempts
+	case errors.Is(err, gateway.ErrCertificateRevoked):
+		c, e = codes.PermissionDenied, gateway.ErrCertificateRevoked
+	case errors.Is(err, gateway.ErrRevocationStatusUnknown):
+		c, e = codes.Unavailable, gateway.ErrRevocationStatusUnknown
+	case errors.Is(err, gateway.ErrCertificatePolicy):
+		c, e = codes.PermissionDenied, gateway.ErrCertificatePolicy
+	case errors.Is(err, gateway.ErrInvalidCertificatePolicy):
+		c, e = codes.InvalidArgument, gateway.ErrInvalidCertificatePolicy
+	case errors.Is(err, gateway.ErrInvalidTaxpayersCertificate):
+		c, e = codes.InvalidArgument, gateway.ErrInvalidTaxpayersCertificate
+	case errors.Is(err, gateway.ErrFSCRConnection):
+		c, e = codes.Unavailable, gateway.ErrFSCRConnection
+	case errors.Is(err, gateway.ErrKeystoreUnavailable):
+		c, e = codes.Unavailable, gateway.ErrKeystoreUnavailable
+	case errors.Is(err, gateway.ErrSignerUnavailable):
+		c, e = codes.Unavailable, gateway.ErrSignerUnavailable
+	case errors.Is(err, gateway.ErrRequestBuild):
+		c, e = codes.Internal, gateway.ErrRequestBuild
+	case errors.Is(err, gateway.ErrFSCRResponseParse):
+		c, e = codes.Internal, gateway.ErrFSCRResponseParse
+	case errors.Is(err, gateway.ErrFSCRFault):
+		// the error of the upstream FSCR (502 of the HTTP API) is described by the attached fault
+		c, e = codes.Unknown, gateway.ErrFSCRFault
+
+		var fault *eet.SOAPFault
+		if errors.As(err, &fault) {
+			s, detailErr := status.New(c, e.Error()).WithDetails(&pb.FSCRFault{
+				Faultcode:   fault.Code,
+				Faultstring: fault.String,
+				Detail:      fault.Detail,
+			})
+			if detailErr == nil {
+				return &statusError{status: s, cause: err}
+			}
+		}
+	case errors.Is(err, gateway.ErrFSCRResponseMismatch):
+		c, e = codes.Internal, gateway.ErrFSCRResponseMismatch
+	case errors.Is(err, gateway.ErrFSCRResponseSignature):
+		c, e = codes.Internal, gateway.ErrFSCRResponseSignature
+	case errors.Is(err, gateway.ErrFSCRSigningTime):
+		c, e = codes.Internal, gateway.ErrFSCRSigningTime
+	case errors.Is(err, gateway.ErrFSCRCertificateUntrusted):
+		c, e = codes.Internal, gateway.ErrFSCRCertificateUntrusted
+	case errors.Is(err, gateway.ErrFSCRCertificateKeyUsage):
+		c, e = codes.Internal, gateway.ErrFSCRCertificateKeyUsage
+	case errors.Is(err, gateway.ErrFSCRCertificateExpired):
+		c, e = codes.Internal, gateway.ErrFSCRCertificateExpired
+	case errors.Is(err, gateway.ErrFSCRCertificateRevoked):
+		c, e = codes.Internal, gateway.ErrFSCRCertificateRevoked
+	case errors.Is(err, gateway.ErrFSCRRevocationStatusUnknown):
+		c, e = codes.Unavailable, gateway.ErrFSCRRevocationStatusUnknown
+	case errors.Is(err, gateway.ErrFSCRResponseVerify):
+		c, e = codes.Internal, gateway.ErrFSCRResponseVerify
+	case errors.Is(err, gateway.ErrCertificateExport):
+		c, e = codes.Internal, gateway.ErrCertificateExport
+	case errors.Is(err, gateway.ErrCertificateParse):
+		c, e = codes.Internal, gateway.ErrCertificateParse
+	case errors.Is(err, gateway.ErrKeystoreUnexpected):
+		c, e = codes.Internal, gateway.ErrKeystoreUnexpected
+	case errors.Is(err, gateway.ErrMaxTXAttempts):
+		c, e = codes.Internal, gateway.ErrMaxTXAttempts
+	}
+
+	return &statusError{
+		status: status.New(c, e.Error()),
+		cause:  err,
+	}
+}
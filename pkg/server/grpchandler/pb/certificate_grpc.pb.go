@@ -0,0 +1,429 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             (unknown)
+// source: certificate.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// CertificateServiceClient is the client API for CertificateService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type CertificateServiceClient interface {
+	StoreCert(ctx context.Context, in *StoreCertRequest, opts ...grpc.CallOption) (*CertResponse, error)
+	ListCertIDs(ctx context.Context, in *ListCertIDsRequest, opts ...grpc.CallOption) (*ListCertIDsResponse, error)
+	UpdateCertID(ctx context.Context, in *UpdateCertIDRequest, opts ...grpc.CallOption) (*CertResponse, error)
+	UpdateCertPassword(ctx context.Context, in *UpdateCertPasswordRequest, opts ...grpc.CallOption) (*CertResponse, error)
+	UpdateCertPolicy(ctx context.Context, in *UpdateCertPolicyRequest, opts ...grpc.CallOption) (*CertResponse, error)
+	ReplaceCert(ctx context.Context, in *ReplaceCertRequest, opts ...grpc.CallOption) (*CertResponse, error)
+	RollbackCert(ctx context.Context, in *RollbackCertRequest, opts ...grpc.CallOption) (*CertResponse, error)
+	ExportCert(ctx context.Context, in *ExportCertRequest, opts ...grpc.CallOption) (*ExportCertResponse, error)
+	OpenSession(ctx context.Context, in *OpenSessionRequest, opts ...grpc.CallOption) (*OpenSessionResponse, error)
+	DeleteCert(ctx context.Context, in *DeleteCertRequest, opts ...grpc.CallOption) (*CertResponse, error)
+}
+
+type certificateServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCertificateServiceClient(cc grpc.ClientConnInterface) CertificateServiceClient {
+	return &certificateServiceClient{cc}
+}
+
+func (c *certificateServiceClient) StoreCert(ctx context.Context, in *StoreCertRequest, opts ...grpc.CallOption) (*CertResponse, error) {
+	out := new(CertResponse)
+	err := c.cc.Invoke(ctx, "/eetgateway.v1.CertificateService/StoreCert", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *certificateServiceClient) ListCertIDs(ctx context.Context, in *ListCertIDsRequest, opts ...grpc.CallOption) (*ListCertIDsResponse, error) {
+	out := new(ListCertIDsResponse)
+	err := c.cc.Invoke(ctx, "/eetgateway.v1.CertificateService/ListCertIDs", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *certificateServiceClient) UpdateCertID(ctx context.Context, in *UpdateCertIDRequest, opts ...grpc.CallOption) (*CertResponse, error) {
+	out := new(CertResponse)
+	err := c.cc.Invoke(ctx, "/eetgateway.v1.CertificateService/UpdateCertID", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *certificateServiceClient) UpdateCertPassword(ctx context.Context, in *UpdateCertPasswordRequest, opts ...grpc.CallOption) (*CertResponse, error) {
+	out := new(CertResponse)
+	err := c.cc.Invoke(ctx, "/eetgateway.v1.CertificateService/UpdateCertPassword", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *certificateServiceClient) UpdateCertPolicy(ctx context.Context, in *UpdateCertPolicyRequest, opts ...grpc.CallOption) (*CertResponse, error) {
+	out := new(CertResponse)
+	err := c.cc.Invoke(ctx, "/eetgateway.v1.CertificateService/UpdateCertPolicy", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *certificateServiceClient) ReplaceCert(ctx context.Context, in *ReplaceCertRequest, opts ...grpc.CallOption) (*CertResponse, error) {
+	out := new(CertResponse)
+	err := c.cc.Invoke(ctx, "/eetgateway.v1.CertificateService/ReplaceCert", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *certificateServiceClient) RollbackCert(ctx context.Context, in *RollbackCertRequest, opts ...grpc.CallOption) (*CertResponse, error) {
+	out := new(CertResponse)
+	err := c.cc.Invoke(ctx, "/eetgateway.v1.CertificateService/RollbackCert", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *certificateServiceClient) ExportCert(ctx context.Context, in *ExportCertRequest, opts ...grpc.CallOption) (*ExportCertResponse, error) {
+	out := new(ExportCertResponse)
+	err := c.cc.Invoke(ctx, "/eetgateway.v1.CertificateService/ExportCert", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *certificateServiceClient) OpenSession(ctx context.Context, in *OpenSessionRequest, opts ...grpc.CallOption) (*OpenSessionResponse, error) {
+	out := new(OpenSessionResponse)
+	err := c.cc.Invoke(ctx, "/eetgateway.v1.CertificateService/OpenSession", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *certificateServiceClient) DeleteCert(ctx context.Context, in *DeleteCertRequest, opts ...grpc.CallOption) (*CertResponse, error) {
+	out := new(CertResponse)
+	err := c.cc.Invoke(ctx, "/eetgateway.v1.CertificateService/DeleteCert", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CertificateServiceServer is the server API for CertificateService service.
+// All implementations must embed UnimplementedCertificateServiceServer
+// for forward compatibility
+type CertificateServiceServer interface {
+	StoreCert(context.Context, *StoreCertRequest) (*CertResponse, error)
+	ListCertIDs(context.Context, *ListCertIDsRequest) (*ListCertIDsResponse, error)
+	UpdateCertID(context.Context, *UpdateCertIDRequest) (*CertResponse, error)
+	UpdateCertPassword(context.Context, *UpdateCertPasswordRequest) (*CertResponse, error)
+	UpdateCertPolicy(context.Context, *UpdateCertPolicyRequest) (*CertResponse, error)
+	ReplaceCert(context.Context, *ReplaceCertRequest) (*CertResponse, error)
+	RollbackCert(context.Context, *RollbackCertRequest) (*CertResponse, error)
+	ExportCert(context.Context, *ExportCertRequest) (*ExportCertResponse, error)
+	OpenSession(context.Context, *OpenSessionRequest) (*OpenSessionResponse, error)
+	DeleteCert(context.Context, *DeleteCertRequest) (*CertResponse, error)
+	mustEmbedUnimplementedCertificateServiceServer()
+}
+
+// UnimplementedCertificateServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedCertificateServiceServer struct {
+}
+
+func (UnimplementedCertificateServiceServer) StoreCert(context.Context, *StoreCertRequest) (*CertResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StoreCert not implemented")
+}
+func (UnimplementedCertificateServiceServer) ListCertIDs(context.Context, *ListCertIDsRequest) (*ListCertIDsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListCertIDs not implemented")
+}
+func (UnimplementedCertificateServiceServer) UpdateCertID(context.Context, *UpdateCertIDRequest) (*CertResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateCertID not implemented")
+}
+func (UnimplementedCertificateServiceServer) UpdateCertPassword(context.Context, *UpdateCertPasswordRequest) (*CertResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateCertPassword not implemented")
+}
+func (UnimplementedCertificateServiceServer) UpdateCertPolicy(context.Context, *UpdateCertPolicyRequest) (*CertResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateCertPolicy not implemented")
+}
+func (UnimplementedCertificateServiceServer) ReplaceCert(context.Context, *ReplaceCertRequest) (*CertResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReplaceCert not implemented")
+}
+func (UnimplementedCertificateServiceServer) RollbackCert(context.Context, *RollbackCertRequest) (*CertResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RollbackCert not implemented")
+}
+func (UnimplementedCertificateServiceServer) ExportCert(context.Context, *ExportCertRequest) (*ExportCertResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExportCert not implemented")
+}
+func (UnimplementedCertificateServiceServer) OpenSession(context.Context, *OpenSessionRequest) (*OpenSessionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method OpenSession not implemented")
+}
+func (UnimplementedCertificateServiceServer) DeleteCert(context.Context, *DeleteCertRequest) (*CertResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteCert not implemented")
+}
+func (UnimplementedCertificateServiceServer) mustEmbedUnimplementedCertificateServiceServer() {}
+
+// UnsafeCertificateServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CertificateServiceServer will
+// result in compilation errors.
+type UnsafeCertificateServiceServer interface {
+	mustEmbedUnimplementedCertificateServiceServer()
+}
+
+func RegisterCertificateServiceServer(s grpc.ServiceRegistrar, srv CertificateServiceServer) {
+	s.RegisterService(&CertificateService_ServiceDesc, srv)
+}
+
+func _CertificateService_StoreCert_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StoreCertRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CertificateServiceServer).StoreCert(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/eetgateway.v1.CertificateService/StoreCert",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CertificateServiceServer).StoreCert(ctx, req.(*StoreCertRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CertificateService_ListCertIDs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListCertIDsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CertificateServiceServer).ListCertIDs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/eetgateway.v1.CertificateService/ListCertIDs",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CertificateServiceServer).ListCertIDs(ctx, req.(*ListCertIDsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CertificateService_UpdateCertID_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateCertIDRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CertificateServiceServer).UpdateCertID(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/eetgateway.v1.CertificateService/UpdateCertID",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CertificateServiceServer).UpdateCertID(ctx, req.(*UpdateCertIDRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CertificateService_UpdateCertPassword_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateCertPasswordRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CertificateServiceServer).UpdateCertPassword(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/eetgateway.v1.CertificateService/UpdateCertPassword",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CertificateServiceServer).UpdateCertPassword(ctx, req.(*UpdateCertPasswordRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CertificateService_UpdateCertPolicy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateCertPolicyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CertificateServiceServer).UpdateCertPolicy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/eetgateway.v1.CertificateService/UpdateCertPolicy",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CertificateServiceServer).UpdateCertPolicy(ctx, req.(*UpdateCertPolicyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CertificateService_ReplaceCert_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReplaceCertRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CertificateServiceServer).ReplaceCert(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/eetgateway.v1.CertificateService/ReplaceCert",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CertificateServiceServer).ReplaceCert(ctx, req.(*ReplaceCertRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CertificateService_RollbackCert_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RollbackCertRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CertificateServiceServer).RollbackCert(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/eetgateway.v1.CertificateService/RollbackCert",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CertificateServiceServer).RollbackCert(ctx, req.(*RollbackCertRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CertificateService_ExportCert_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExportCertRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CertificateServiceServer).ExportCert(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/eetgateway.v1.CertificateService/ExportCert",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CertificateServiceServer).ExportCert(ctx, req.(*ExportCertRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CertificateService_OpenSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OpenSessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CertificateServiceServer).OpenSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/eetgateway.v1.CertificateService/OpenSession",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CertificateServiceServer).OpenSession(ctx, req.(*OpenSessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CertificateService_DeleteCert_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteCertRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CertificateServiceServer).DeleteCert(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/eetgateway.v1.CertificateService/DeleteCert",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CertificateServiceServer).DeleteCert(ctx, req.(*DeleteCertRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// CertificateService_ServiceDesc is the grpc.ServiceDesc for CertificateService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var CertificateService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "eetgateway.v1.CertificateService",
+	HandlerType: (*CertificateServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "StoreCert",
+			Handler:    _CertificateService_StoreCert_Handler,
+		},
+		{
+			MethodName: "ListCertIDs",
+			Handler:    _CertificateService_ListCertIDs_Handler,
+		},
+		{
+			MethodName: "UpdateCertID",
+			Handler:    _CertificateService_UpdateCertID_Handler,
+		},
+		{
+			MethodName: "UpdateCertPassword",
+			Handler:    _CertificateService_UpdateCertPassword_Handler,
+		},
+		{
+			MethodName: "UpdateCertPolicy",
+			Handler:    _CertificateService_UpdateCertPolicy_Handler,
+		},
+		{
+			MethodName: "ReplaceCert",
+			Handler:    _CertificateService_ReplaceCert_Handler,
+		},
+		{
+			MethodName: "RollbackCert",
+			Handler:    _CertificateService_RollbackCert_Handler,
+		},
+		{
+			MethodName: "ExportCert",
+			Handler:    _CertificateService_ExportCert_Handler,
+		},
+		{
+			MethodName: "OpenSession",
+			Handler:    _CertificateService_OpenSession_Handler,
+		},
+		{
+			MethodName: "DeleteCert",
+			Handler:    _CertificateService_DeleteCert_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "certificate.proto",
+}
@@ -0,0 +1,852 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.27.1
+// 	protoc        (unknown)
+// source: sale.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type SaleRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	CertId string `protobuf:"bytes,1,opt,name=cert_id,json=certId,proto3" json:"cert_id,omitempty"`
+	// The certificate is opened either with its password or with an open session.
+	//
+	// Types that are assignable to Credentials:
+	//	*SaleRequest_CertPassword
+	//	*SaleRequest_SessionToken
+	Credentials isSaleRequest_Credentials `protobuf_oneof:"credentials"`
+	Sale        *Sale                     `protobuf:"bytes,4,opt,name=sale,proto3" json:"sale,omitempty"`
+}
+
+func (x *SaleRequest) Reset() {
+	*x = SaleRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sale_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SaleRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SaleRequest) ProtoMessage() {}
+
+func (x *SaleRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sale_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SaleRequest.ProtoReflect.Descriptor instead.
+func (*SaleRequest) Descriptor() ([]byte, []int) {
+	return file_sale_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SaleRequest) GetCertId() string {
+	if x != nil {
+		return x.CertId
+	}
+	return ""
+}
+
+func (m *SaleRequest) GetCredentials() isSaleRequest_Credentials {
+	if m != nil {
+		return m.Credentials
+	}
+	return nil
+}
+
+func (x *SaleRequest) GetCertPassword() string {
+	if x, ok := x.GetCredentials().(*SaleRequest_CertPassword); ok {
+		return x.CertPassword
+	}
+	return ""
+}
+
+func (x *SaleRequest) GetSessionToken() string {
+	if x, ok := x.GetCredentials().(*SaleRequest_SessionToken); ok {
+		return x.SessionToken
+	}
+	return ""
+}
+
+func (x *SaleRequest) GetSale() *Sale {
+	if x != nil {
+		return x.Sale
+	}
+	return nil
+}
+
+type isSaleRequest_Credentials interface {
+	isSaleRequest_Credentials()
+}
+
+type SaleRequest_CertPassword struct {
+	CertPassword string `protobuf:"bytes,2,opt,name=cert_password,json=certPassword,proto3,oneof"`
+}
+
+type SaleRequest_SessionToken struct {
+	SessionToken string `protobuf:"bytes,3,opt,name=session_token,json=sessionToken,proto3,oneof"`
+}
+
+func (*SaleRequest_CertPassword) isSaleRequest_Credentials() {}
+
+func (*SaleRequest_SessionToken) isSaleRequest_Credentials() {}
+
+// Sale holds the header and the data of a sale. The unset header fields are given the default
+// values: a random message UUID, the current sending time and the first sending of the sale.
+type Sale struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	UuidZpravy      string                 `protobuf:"bytes,1,opt,name=uuid_zpravy,json=uuidZpravy,proto3" json:"uuid_zpravy,omitempty"`
+	DatOdesl        *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=dat_odesl,json=datOdesl,proto3" json:"dat_odesl,omitempty"`
+	PrvniZaslani    *bool                  `protobuf:"varint,3,opt,name=prvni_zaslani,json=prvniZaslani,proto3,oneof" json:"prvni_zaslani,omitempty"`
+	Overeni         bool                   `protobuf:"varint,4,opt,name=overeni,proto3" json:"overeni,omitempty"`
+	DicPopl         string                 `protobuf:"bytes,5,opt,name=dic_popl,json=dicPopl,proto3" json:"dic_popl,omitempty"`
+	DicPoverujiciho string                 `protobuf:"bytes,6,opt,name=dic_poverujiciho,json=dicPoverujiciho,proto3" json:"dic_poverujiciho,omitempty"`
+	IdProvoz        int32                  `protobuf:"varint,7,opt,name=id_provoz,json=idProvoz,proto3" json:"id_provoz,omitempty"`
+	IdPokl          string                 `protobuf:"bytes,8,opt,name=id_pokl,json=idPokl,proto3" json:"id_pokl,omitempty"`
+	PoradCis        string                 `protobuf:"bytes,9,opt,name=porad_cis,json=poradCis,proto3" json:"porad_cis,omitempty"`
+	DatTrzby        *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=dat_trzby,json=datTrzby,proto3" json:"dat_trzby,omitempty"`
+	CelkTrzba       float64                `protobuf:"fixed64,11,opt,name=celk_trzba,json=celkTrzba,proto3" json:"celk_trzba,omitempty"`
+	ZaklNepodlDph   float64                `protobuf:"fixed64,12,opt,name=zakl_nepodl_dph,json=zaklNepodlDph,proto3" json:"zakl_nepodl_dph,omitempty"`
+	ZaklDan1        float64                `protobuf:"fixed64,13,opt,name=zakl_dan1,json=zaklDan1,proto3" json:"zakl_dan1,omitempty"`
+	Dan1            float64                `protobuf:"fixed64,14,opt,name=dan1,proto3" json:"dan1,omitempty"`
+	ZaklDan2        float64                `protobuf:"fixed64,15,opt,name=zakl_dan2,json=zaklDan2,proto3" json:"zakl_dan2,omitempty"`
+	Dan2            float64                `protobuf:"fixed64,16,opt,name=dan2,proto3" json:"dan2,omitempty"`
+	ZaklDan3        float64                `protobuf:"fixed64,17,opt,name=zakl_dan3,json=zaklDan3,proto3" json:"zakl_dan3,omitempty"`
+	Dan3            float64                `protobuf:"fixed64,18,opt,name=dan3,proto3" json:"dan3,omitempty"`
+	CestSluz        float64                `protobuf:"fixed64,19,opt,name=cest_sluz,json=cestSluz,proto3" json:"cest_sluz,omitempty"`
+	PouzitZboz1     float64                `protobuf:"fixed64,20,opt,name=pouzit_zboz1,json=pouzitZboz1,proto3" json:"pouzit_zboz1,omitempty"`
+	PouzitZboz2     float64                `protobuf:"fixed64,21,opt,name=pouzit_zboz2,json=pouzitZboz2,proto3" json:"pouzit_zboz2,omitempty"`
+	PouzitZboz3     float64                `protobuf:"fixed64,22,opt,name=pouzit_zboz3,json=pouzitZboz3,proto3" json:"pouzit_zboz3,omitempty"`
+	UrcenoCerpZuct  float64                `protobuf:"fixed64,23,opt,name=urceno_cerp_zuct,json=urcenoCerpZuct,proto3" json:"urceno_cerp_zuct,omitempty"`
+	CerpZuct        float64                `protobuf:"fixed64,24,opt,name=cerp_zuct,json=cerpZuct,proto3" json:"cerp_zuct,omitempty"`
+	Rezim           int32                  `protobuf:"varint,25,opt,name=rezim,proto3" json:"rezim,omitempty"`
+}
+
+func (x *Sale) Reset() {
+	*x = Sale{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sale_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Sale) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Sale) ProtoMessage() {}
+
+func (x *Sale) ProtoReflect() protoreflect.Message {
+	mi := &file_sale_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Sale.ProtoReflect.Descriptor instead.
+func (*Sale) Descriptor() ([]byte, []int) {
+	return file_sale_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Sale) GetUuidZpravy() string {
+	if x != nil {
+		return x.UuidZpravy
+	}
+	return ""
+}
+
+func (x *Sale) GetDatOdesl() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DatOdesl
+	}
+	return nil
+}
+
+func (x *Sale) GetPrvniZaslani() bool {
+	if x != nil && x.PrvniZaslani != nil {
+		return *x.PrvniZaslani
+	}
+	return false
+}
+
+func (x *Sale) GetOvereni() bool {
+	if x != nil {
+		return x.Overeni
+	}
+	return false
+}
+
+func (x *Sale) GetDicPopl() string {
+	if x != nil {
+		return x.DicPopl
+	}
+	return ""
+}
+
+func (x *Sale) GetDicPoverujiciho() string {
+	if x != nil {
+		return x.DicPoverujiciho
+	}
+	return ""
+}
+
+func (x *Sale) GetIdProvoz() int32 {
+	if x != nil {
+		return x.IdProvoz
+	}
+	return 0
+}
+
+func (x *Sale) GetIdPokl() string {
+	if x != nil {
+		return x.IdPokl
+	}
+	return ""
+}
+
+func (x *Sale) GetPoradCis() string {
+	if x != nil {
+		return x.PoradCis
+	}
+	return ""
+}
+
+func (x *Sale) GetDatTrzby() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DatTrzby
+	}
+	return nil
+}
+
+func (x *Sale) GetCelkTrzba() float64 {
+	if x != nil {
+		return x.CelkTrzba
+	}
+	return 0
+}
+
+func (x *Sale) GetZaklNepodlDph() float64 {
+	if x != nil {
+		return x.ZaklNepodlDph
+	}
+	return 0
+}
+
+func (x *Sale) GetZaklDan1() float64 {
+	if x != nil {
+		return x.ZaklDan1
+	}
+	return 0
+}
+
+func (x *Sale) GetDan1() float64 {
+	if x != nil {
+		return x.Dan1
+	}
+	return 0
+}
+
+func (x *Sale) GetZaklDan2() float64 {
+	if x != nil {
+		return x.ZaklDan2
+	}
+	return 0
+}
+
+func (x *Sale) GetDan2() float64 {
+	if x != nil {
+		return x.Dan2
+	}
+	return 0
+}
+
+func (x *Sale) GetZaklDan3() float64 {
+	if x != nil {
+		return x.ZaklDan3
+	}
+	return 0
+}
+
+func (x *Sale) GetDan3() float64 {
+	if x != nil {
+		return x.Dan3
+	}
+	return 0
+}
+
+func (x *Sale) GetCestSluz() float64 {
+	if x != nil {
+		return x.CestSluz
+	}
+	return 0
+}
+
+func (x *Sale) GetPouzitZboz1() float64 {
+	if x != nil {
+		return x.PouzitZboz1
+	}
+	return 0
+}
+
+func (x *Sale) GetPouzitZboz2() float64 {
+	if x != nil {
+		return x.PouzitZboz2
+	}
+	return 0
+}
+
+func (x *Sale) GetPouzitZboz3() float64 {
+	if x != nil {
+		return x.PouzitZboz3
+	}
+	return 0
+}
+
+func (x *Sale) GetUrcenoCerpZuct() float64 {
+	if x != nil {
+		return x.UrcenoCerpZuct
+	}
+	return 0
+}
+
+func (x *Sale) GetCerpZuct() float64 {
+	if x != nil {
+		return x.CerpZuct
+	}
+	return 0
+}
+
+func (x *Sale) GetRezim() int32 {
+	if x != nil {
+		return x.Rezim
+	}
+	return 0
+}
+
+type Warning struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	KodVarov int32  `protobuf:"varint,1,opt,name=kod_varov,json=kodVarov,proto3" json:"kod_varov,omitempty"`
+	Zprava   string `protobuf:"bytes,2,opt,name=zprava,proto3" json:"zprava,omitempty"`
+}
+
+func (x *Warning) Reset() {
+	*x = Warning{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sale_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Warning) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Warning) ProtoMessage() {}
+
+func (x *Warning) ProtoReflect() protoreflect.Message {
+	mi := &file_sale_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Warning.ProtoReflect.Descriptor instead.
+func (*Warning) Descriptor() ([]byte, []int) {
+	return file_sale_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Warning) GetKodVarov() int32 {
+	if x != nil {
+		return x.KodVarov
+	}
+	return 0
+}
+
+func (x *Warning) GetZprava() string {
+	if x != nil {
+		return x.Zprava
+	}
+	return ""
+}
+
+// SendSaleResponse holds either the confirmation or the rejection of the sale by the FSCR.
+type SendSaleResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	CertId     string                 `protobuf:"bytes,1,opt,name=cert_id,json=certId,proto3" json:"cert_id,omitempty"`
+	DatOdmit   *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=dat_odmit,json=datOdmit,proto3" json:"dat_odmit,omitempty"`
+	ChybZprava string                 `protobuf:"bytes,3,opt,name=chyb_zprava,json=chybZprava,proto3" json:"chyb_zprava,omitempty"`
+	ChybKod    int32                  `protobuf:"varint,4,opt,name=chyb_kod,json=chybKod,proto3" json:"chyb_kod,omitempty"`
+	DatPrij    *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=dat_prij,json=datPrij,proto3" json:"dat_prij,omitempty"`
+	Fik        string                 `protobuf:"bytes,6,opt,name=fik,proto3" json:"fik,omitempty"`
+	Bkp        string                 `protobuf:"bytes,7,opt,name=bkp,proto3" json:"bkp,omitempty"`
+	Test       bool                   `protobuf:"varint,8,opt,name=test,proto3" json:"test,omitempty"`
+	Varovani   []*Warning             `protobuf:"bytes,9,rep,name=varovani,proto3" json:"varovani,omitempty"`
+	// The sent sale with the header values, set only if the sale is confirmed.
+	Trzba *Sale `protobuf:"bytes,10,opt,name=trzba,proto3" json:"trzba,omitempty"`
+}
+
+func (x *SendSaleResponse) Reset() {
+	*x = SendSaleResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sale_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SendSaleResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SendSaleResponse) ProtoMessage() {}
+
+func (x *SendSaleResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sale_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SendSaleResponse.ProtoReflect.Descriptor instead.
+func (*SendSaleResponse) Descriptor() ([]byte, []int) {
+	return file_sale_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *SendSaleResponse) GetCertId() string {
+	if x != nil {
+		return x.CertId
+	}
+	return ""
+}
+
+func (x *SendSaleResponse) GetDatOdmit() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DatOdmit
+	}
+	return nil
+}
+
+func (x *SendSaleResponse) GetChybZprava() string {
+	if x != nil {
+		return x.ChybZprava
+	}
+	return ""
+}
+
+func (x *SendSaleResponse) GetChybKod() int32 {
+	if x != nil {
+		return x.ChybKod
+	}
+	return 0
+}
+
+func (x *SendSaleResponse) GetDatPrij() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DatPrij
+	}
+	return nil
+}
+
+func (x *SendSaleResponse) GetFik() string {
+	if x != nil {
+		return x.Fik
+	}
+	return ""
+}
+
+func (x *SendSaleResponse) GetBkp() string {
+	if x != nil {
+		return x.Bkp
+	}
+	return ""
+}
+
+func (x *SendSaleResponse) GetTest() bool {
+	if x != nil {
+		return x.Test
+	}
+	return false
+}
+
+func (x *SendSaleResponse) GetVarovani() []*Warning {
+	if x != nil {
+		return x.Varovani
+	}
+	return nil
+}
+
+func (x *SendSaleResponse) GetTrzba() *Sale {
+	if x != nil {
+		return x.Trzba
+	}
+	return nil
+}
+
+type ComputeCodesResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	CertId string `protobuf:"bytes,1,opt,name=cert_id,json=certId,proto3" json:"cert_id,omitempty"`
+	Pkp    []byte `protobuf:"bytes,2,opt,name=pkp,proto3" json:"pkp,omitempty"`
+	Bkp    string `protobuf:"bytes,3,opt,name=bkp,proto3" json:"bkp,omitempty"`
+}
+
+func (x *ComputeCodesResponse) Reset() {
+	*x = ComputeCodesResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sale_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ComputeCodesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ComputeCodesResponse) ProtoMessage() {}
+
+func (x *ComputeCodesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sale_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ComputeCodesResponse.ProtoReflect.Descriptor instead.
+func (*ComputeCodesResponse) Descriptor() ([]byte, []int) {
+	return file_sale_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ComputeCodesResponse) GetCertId() string {
+	if x != nil {
+		return x.CertId
+	}
+	return ""
+}
+
+func (x *ComputeCodesResponse) GetPkp() []byte {
+	if x != nil {
+		return x.Pkp
+	}
+	return nil
+}
+
+func (x *ComputeCodesResponse) GetBkp() string {
+	if x != nil {
+		return x.Bkp
+	}
+	return ""
+}
+
+var File_sale_proto protoreflect.FileDescriptor
+
+var file_sale_proto_rawDesc = []byte{
+	0x0a, 0x0a, 0x73, 0x61, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0d, 0x65, 0x65,
+	0x74, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2e, 0x76, 0x31, 0x1a, 0x1f, 0x67, 0x6f, 0x6f,
+	0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74, 0x69, 0x6d,
+	0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xac, 0x01, 0x0a,
+	0x0b, 0x53, 0x61, 0x6c, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x17, 0x0a, 0x07,
+	0x63, 0x65, 0x72, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x63,
+	0x65, 0x72, 0x74, 0x49, 0x64, 0x12, 0x25, 0x0a, 0x0d, 0x63, 0x65, 0x72, 0x74, 0x5f, 0x70, 0x61,
+	0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x0c,
+	0x63, 0x65, 0x72, 0x74, 0x50, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x12, 0x25, 0x0a, 0x0d,
+	0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x5f, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x0c, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x54, 0x6f,
+	0x6b, 0x65, 0x6e, 0x12, 0x27, 0x0a, 0x04, 0x73, 0x61, 0x6c, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x13, 0x2e, 0x65, 0x65, 0x74, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2e, 0x76,
+	0x31, 0x2e, 0x53, 0x61, 0x6c, 0x65, 0x52, 0x04, 0x73, 0x61, 0x6c, 0x65, 0x42, 0x0d, 0x0a, 0x0b,
+	0x63, 0x72, 0x65, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x61, 0x6c, 0x73, 0x22, 0xc5, 0x06, 0x0a, 0x04,
+	0x53, 0x61, 0x6c, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x75, 0x75, 0x69, 0x64, 0x5f, 0x7a, 0x70, 0x72,
+	0x61, 0x76, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x75, 0x75, 0x69, 0x64, 0x5a,
+	0x70, 0x72, 0x61, 0x76, 0x79, 0x12, 0x37, 0x0a, 0x09, 0x64, 0x61, 0x74, 0x5f, 0x6f, 0x64, 0x65,
+	0x73, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c,
+	0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73,
+	0x74, 0x61, 0x6d, 0x70, 0x52, 0x08, 0x64, 0x61, 0x74, 0x4f, 0x64, 0x65, 0x73, 0x6c, 0x12, 0x28,
+	0x0a, 0x0d, 0x70, 0x72, 0x76, 0x6e, 0x69, 0x5f, 0x7a, 0x61, 0x73, 0x6c, 0x61, 0x6e, 0x69, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x08, 0x48, 0x00, 0x52, 0x0c, 0x70, 0x72, 0x76, 0x6e, 0x69, 0x5a, 0x61,
+	0x73, 0x6c, 0x61, 0x6e, 0x69, 0x88, 0x01, 0x01, 0x12, 0x18, 0x0a, 0x07, 0x6f, 0x76, 0x65, 0x72,
+	0x65, 0x6e, 0x69, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x6f, 0x76, 0x65, 0x72, 0x65,
+	0x6e, 0x69, 0x12, 0x19, 0x0a, 0x08, 0x64, 0x69, 0x63, 0x5f, 0x70, 0x6f, 0x70, 0x6c, 0x18, 0x05,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x64, 0x69, 0x63, 0x50, 0x6f, 0x70, 0x6c, 0x12, 0x29, 0x0a,
+	0x10, 0x64, 0x69, 0x63, 0x5f, 0x70, 0x6f, 0x76, 0x65, 0x72, 0x75, 0x6a, 0x69, 0x63, 0x69, 0x68,
+	0x6f, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f, 0x64, 0x69, 0x63, 0x50, 0x6f, 0x76, 0x65,
+	0x72, 0x75, 0x6a, 0x69, 0x63, 0x69, 0x68, 0x6f, 0x12, 0x1b, 0x0a, 0x09, 0x69, 0x64, 0x5f, 0x70,
+	0x72, 0x6f, 0x76, 0x6f, 0x7a, 0x18, 0x07, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x69, 0x64, 0x50,
+	0x72, 0x6f, 0x76, 0x6f, 0x7a, 0x12, 0x17, 0x0a, 0x07, 0x69, 0x64, 0x5f, 0x70, 0x6f, 0x6b, 0x6c,
+	0x18, 0x08, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x69, 0x64, 0x50, 0x6f, 0x6b, 0x6c, 0x12, 0x1b,
+	0x0a, 0x09, 0x70, 0x6f, 0x72, 0x61, 0x64, 0x5f, 0x63, 0x69, 0x73, 0x18, 0x09, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x08, 0x70, 0x6f, 0x72, 0x61, 0x64, 0x43, 0x69, 0x73, 0x12, 0x37, 0x0a, 0x09, 0x64,
+	0x61, 0x74, 0x5f, 0x74, 0x72, 0x7a, 0x62, 0x79, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a,
+	0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
+	0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x08, 0x64, 0x61, 0x74, 0x54,
+	0x72, 0x7a, 0x62, 0x79, 0x12, 0x1d, 0x0a, 0x0a, 0x63, 0x65, 0x6c, 0x6b, 0x5f, 0x74, 0x72, 0x7a,
+	0x62, 0x61, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x01, 0x52, 0x09, 0x63, 0x65, 0x6c, 0x6b, 0x54, 0x72,
+	0x7a, 0x62, 0x61, 0x12, 0x26, 0x0a, 0x0f, 0x7a, 0x61, 0x6b, 0x6c, 0x5f, 0x6e, 0x65, 0x70, 0x6f,
+	0x64, 0x6c, 0x5f, 0x64, 0x70, 0x68, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0d, 0x7a, 0x61,
+	0x6b, 0x6c, 0x4e, 0x65, 0x70, 0x6f, 0x64, 0x6c, 0x44, 0x70, 0x68, 0x12, 0x1b, 0x0a, 0x09, 0x7a,
+	0x61, 0x6b, 0x6c, 0x5f, 0x64, 0x61, 0x6e, 0x31, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x01, 0x52, 0x08,
+	0x7a, 0x61, 0x6b, 0x6c, 0x44, 0x61, 0x6e, 0x31, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x6e, 0x31,
+	0x18, 0x0e, 0x20, 0x01, 0x28, 0x01, 0x52, 0x04, 0x64, 0x61, 0x6e, 0x31, 0x12, 0x1b, 0x0a, 0x09,
+	0x7a, 0x61, 0x6b, 0x6c, 0x5f, 0x64, 0x61, 0x6e, 0x32, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x01, 0x52,
+	0x08, 0x7a, 0x61, 0x6b, 0x6c, 0x44, 0x61, 0x6e, 0x32, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x6e,
+	0x32, 0x18, 0x10, 0x20, 0x01, 0x28, 0x01, 0x52, 0x04, 0x64, 0x61, 0x6e, 0x32, 0x12, 0x1b, 0x0a,
+	0x09, 0x7a, 0x61, 0x6b, 0x6c, 0x5f, 0x64, 0x61, 0x6e, 0x33, 0x18, 0x11, 0x20, 0x01, 0x28, 0x01,
+	0x52, 0x08, 0x7a, 0x61, 0x6b, 0x6c, 0x44, 0x61, 0x6e, 0x33, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61,
+	0x6e, 0x33, 0x18, 0x12, 0x20, 0x01, 0x28, 0x01, 0x52, 0x04, 0x64, 0x61, 0x6e, 0x33, 0x12, 0x1b,
+	0x0a, 0x09, 0x63, 0x65, 0x73, 0x74, 0x5f, 0x73, 0x6c, 0x75, 0x7a, 0x18, 0x13, 0x20, 0x01, 0x28,
+	0x01, 0x52, 0x08, 0x63, 0x65, 0x73, 0x74, 0x53, 0x6c, 0x75, 0x7a, 0x12, 0x21, 0x0a, 0x0c, 0x70,
+	0x6f, 0x75, 0x7a, 0x69, 0x74, 0x5f, 0x7a, 0x62, 0x6f, 0x7a, 0x31, 0x18, 0x14, 0x20, 0x01, 0x28,
+	0x01, 0x52, 0x0b, 0x70, 0x6f, 0x75, 0x7a, 0x69, 0x74, 0x5a, 0x62, 0x6f, 0x7a, 0x31, 0x12, 0x21,
+	0x0a, 0x0c, 0x70, 0x6f, 0x75, 0x7a, 0x69, 0x74, 0x5f, 0x7a, 0x62, 0x6f, 0x7a, 0x32, 0x18, 0x15,
+	0x20, 0x01, 0x28, 0x01, 0x52, 0x0b, 0x70, 0x6f, 0x75, 0x7a, 0x69, 0x74, 0x5a, 0x62, 0x6f, 0x7a,
+	0x32, 0x12, 0x21, 0x0a, 0x0c, 0x70, 0x6f, 0x75, 0x7a, 0x69, 0x74, 0x5f, 0x7a, 0x62, 0x6f, 0x7a,
+	0x33, 0x18, 0x16, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0b, 0x70, 0x6f, 0x75, 0x7a, 0x69, 0x74, 0x5a,
+	0x62, 0x6f, 0x7a, 0x33, 0x12, 0x28, 0x0a, 0x10, 0x75, 0x72, 0x63, 0x65, 0x6e, 0x6f, 0x5f, 0x63,
+	0x65, 0x72, 0x70, 0x5f, 0x7a, 0x75, 0x63, 0x74, 0x18, 0x17, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0e,
+	0x75, 0x72, 0x63, 0x65, 0x6e, 0x6f, 0x43, 0x65, 0x72, 0x70, 0x5a, 0x75, 0x63, 0x74, 0x12, 0x1b,
+	0x0a, 0x09, 0x63, 0x65, 0x72, 0x70, 0x5f, 0x7a, 0x75, 0x63, 0x74, 0x18, 0x18, 0x20, 0x01, 0x28,
+	0x01, 0x52, 0x08, 0x63, 0x65, 0x72, 0x70, 0x5a, 0x75, 0x63, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x72,
+	0x65, 0x7a, 0x69, 0x6d, 0x18, 0x19, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x72, 0x65, 0x7a, 0x69,
+	0x6d, 0x42, 0x10, 0x0a, 0x0e, 0x5f, 0x70, 0x72, 0x76, 0x6e, 0x69, 0x5f, 0x7a, 0x61, 0x73, 0x6c,
+	0x61, 0x6e, 0x69, 0x22, 0x3e, 0x0a, 0x07, 0x57, 0x61, 0x72, 0x6e, 0x69, 0x6e, 0x67, 0x12, 0x1b,
+	0x0a, 0x09, 0x6b, 0x6f, 0x64, 0x5f, 0x76, 0x61, 0x72, 0x6f, 0x76, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x05, 0x52, 0x08, 0x6b, 0x6f, 0x64, 0x56, 0x61, 0x72, 0x6f, 0x76, 0x12, 0x16, 0x0a, 0x06, 0x7a,
+	0x70, 0x72, 0x61, 0x76, 0x61, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x7a, 0x70, 0x72,
+	0x61, 0x76, 0x61, 0x22, 0xee, 0x02, 0x0a, 0x10, 0x53, 0x65, 0x6e, 0x64, 0x53, 0x61, 0x6c, 0x65,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x17, 0x0a, 0x07, 0x63, 0x65, 0x72, 0x74,
+	0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x63, 0x65, 0x72, 0x74, 0x49,
+	0x64, 0x12, 0x37, 0x0a, 0x09, 0x64, 0x61, 0x74, 0x5f, 0x6f, 0x64, 0x6d, 0x69, 0x74, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70,
+	0x52, 0x08, 0x64, 0x61, 0x74, 0x4f, 0x64, 0x6d, 0x69, 0x74, 0x12, 0x1f, 0x0a, 0x0b, 0x63, 0x68,
+	0x79, 0x62, 0x5f, 0x7a, 0x70, 0x72, 0x61, 0x76, 0x61, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0a, 0x63, 0x68, 0x79, 0x62, 0x5a, 0x70, 0x72, 0x61, 0x76, 0x61, 0x12, 0x19, 0x0a, 0x08, 0x63,
+	0x68, 0x79, 0x62, 0x5f, 0x6b, 0x6f, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x07, 0x63,
+	0x68, 0x79, 0x62, 0x4b, 0x6f, 0x64, 0x12, 0x35, 0x0a, 0x08, 0x64, 0x61, 0x74, 0x5f, 0x70, 0x72,
+	0x69, 0x6a, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c,
+	0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73,
+	0x74, 0x61, 0x6d, 0x70, 0x52, 0x07, 0x64, 0x61, 0x74, 0x50, 0x72, 0x69, 0x6a, 0x12, 0x10, 0x0a,
+	0x03, 0x66, 0x69, 0x6b, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x66, 0x69, 0x6b, 0x12,
+	0x10, 0x0a, 0x03, 0x62, 0x6b, 0x70, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x62, 0x6b,
+	0x70, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x65, 0x73, 0x74, 0x18, 0x08, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x04, 0x74, 0x65, 0x73, 0x74, 0x12, 0x32, 0x0a, 0x08, 0x76, 0x61, 0x72, 0x6f, 0x76, 0x61, 0x6e,
+	0x69, 0x18, 0x09, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x65, 0x65, 0x74, 0x67, 0x61, 0x74,
+	0x65, 0x77, 0x61, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x57, 0x61, 0x72, 0x6e, 0x69, 0x6e, 0x67, 0x52,
+	0x08, 0x76, 0x61, 0x72, 0x6f, 0x76, 0x61, 0x6e, 0x69, 0x12, 0x29, 0x0a, 0x05, 0x74, 0x72, 0x7a,
+	0x62, 0x61, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x65, 0x65, 0x74, 0x67, 0x61,
+	0x74, 0x65, 0x77, 0x61, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x61, 0x6c, 0x65, 0x52, 0x05, 0x74,
+	0x72, 0x7a, 0x62, 0x61, 0x22, 0x53, 0x0a, 0x14, 0x43, 0x6f, 0x6d, 0x70, 0x75, 0x74, 0x65, 0x43,
+	0x6f, 0x64, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x17, 0x0a, 0x07,
+	0x63, 0x65, 0x72, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x63,
+	0x65, 0x72, 0x74, 0x49, 0x64, 0x12, 0x10, 0x0a, 0x03, 0x70, 0x6b, 0x70, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x03, 0x70, 0x6b, 0x70, 0x12, 0x10, 0x0a, 0x03, 0x62, 0x6b, 0x70, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x62, 0x6b, 0x70, 0x32, 0xa7, 0x01, 0x0a, 0x0b, 0x53, 0x61,
+	0x6c, 0x65, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x47, 0x0a, 0x08, 0x53, 0x65, 0x6e,
+	0x64, 0x53, 0x61, 0x6c, 0x65, 0x12, 0x1a, 0x2e, 0x65, 0x65, 0x74, 0x67, 0x61, 0x74, 0x65, 0x77,
+	0x61, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x61, 0x6c, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x1f, 0x2e, 0x65, 0x65, 0x74, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2e, 0x76,
+	0x31, 0x2e, 0x53, 0x65, 0x6e, 0x64, 0x53, 0x61, 0x6c, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x12, 0x4f, 0x0a, 0x0c, 0x43, 0x6f, 0x6d, 0x70, 0x75, 0x74, 0x65, 0x43, 0x6f, 0x64,
+	0x65, 0x73, 0x12, 0x1a, 0x2e, 0x65, 0x65, 0x74, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2e,
+	0x76, 0x31, 0x2e, 0x53, 0x61, 0x6c, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x23,
+	0x2e, 0x65, 0x65, 0x74, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x43,
+	0x6f, 0x6d, 0x70, 0x75, 0x74, 0x65, 0x43, 0x6f, 0x64, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x42, 0x3d, 0x5a, 0x3b, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f,
+	0x6d, 0x2f, 0x63, 0x68, 0x75, 0x74, 0x6f, 0x6d, 0x6d, 0x79, 0x2f, 0x65, 0x65, 0x74, 0x67, 0x61,
+	0x74, 0x65, 0x77, 0x61, 0x79, 0x2f, 0x70, 0x6b, 0x67, 0x2f, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72,
+	0x2f, 0x67, 0x72, 0x70, 0x63, 0x68, 0x61, 0x6e, 0x64, 0x6c, 0x65, 0x72, 0x2f, 0x70, 0x62, 0x3b,
+	0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_sale_proto_rawDescOnce sync.Once
+	file_sale_proto_rawDescData = file_sale_proto_rawDesc
+)
+
+func file_sale_proto_rawDescGZIP() []byte {
+	file_sale_proto_rawDescOnce.Do(func() {
+		file_sale_proto_rawDescData = protoimpl.X.CompressGZIP(file_sale_proto_rawDescData)
+	})
+	return file_sale_proto_rawDescData
+}
+
+var file_sale_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_sale_proto_goTypes = []interface{}{
+	(*SaleRequest)(nil),           // 0: eetgateway.v1.SaleRequest
+	(*Sale)(nil),                  // 1: eetgateway.v1.Sale
+	(*Warning)(nil),               // 2: eetgateway.v1.Warning
+	(*SendSaleResponse)(nil),      // 3: eetgateway.v1.SendSaleResponse
+	(*ComputeCodesResponse)(nil),  // 4: eetgateway.v1.ComputeCodesResponse
+	(*timestamppb.Timestamp)(nil), // 5: google.protobuf.Timestamp
+}
+var file_sale_proto_depIdxs = []int32{
+	1, // 0: eetgateway.v1.SaleRequest.sale:type_name -> eetgateway.v1.Sale
+	5, // 1: eetgateway.v1.Sale.dat_odesl:type_name -> google.protobuf.Timestamp
+	5, // 2: eetgateway.v1.Sale.dat_trzby:type_name -> google.protobuf.Timestamp
+	5, // 3: eetgateway.v1.SendSaleResponse.dat_odmit:type_name -> google.protobuf.Timestamp
+	5, // 4: eetgateway.v1.SendSaleResponse.dat_prij:type_name -> google.protobuf.Timestamp
+	2, // 5: eetgateway.v1.SendSaleResponse.varovani:type_name -> eetgateway.v1.Warning
+	1, // 6: eetgateway.v1.SendSaleResponse.trzba:type_name -> eetgateway.v1.Sale
+	0, // 7: eetgateway.v1.SaleService.SendSale:input_type -> eetgateway.v1.SaleRequest
+	0, // 8: eetgateway.v1.SaleService.ComputeCodes:input_type -> eetgateway.v1.SaleRequest
+	3, // 9: eetgateway.v1.SaleService.SendSale:output_type -> eetgateway.v1.SendSaleResponse
+	4, // 10: eetgateway.v1.SaleService.ComputeCodes:output_type -> eetgateway.v1.ComputeCodesResponse
+	9, // [9:11] is the sub-list for method output_type
+	7, // [7:9] is the sub-list for method input_type
+	7, // [7:7] is the sub-list for extension type_name
+	7, // [7:7] is the sub-list for extension extendee
+	0, // [0:7] is the sub-list for field type_name
+}
+
+func init() { file_sale_proto_init() }
+func file_sale_proto_init() {
+	if File_sale_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_sale_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SaleRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sale_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Sale); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sale_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Warning); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sale_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SendSaleResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sale_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ComputeCodesResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_sale_proto_msgTypes[0].OneofWrappers = []interface{}{
+		(*SaleRequest_CertPassword)(nil),
+		(*SaleRequest_SessionToken)(nil),
+	}
+	file_sale_proto_msgTypes[1].OneofWrappers = []interface{}{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_sale_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   5,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_sale_proto_goTypes,
+		DependencyIndexes: file_sale_proto_depIdxs,
+		MessageInfos:      file_sale_proto_msgTypes,
+	}.Build()
+	File_sale_proto = out.File
+	file_sale_proto_rawDesc = nil
+	file_sale_proto_goTypes = nil
+	file_sale_proto_depIdxs = nil
+}
@@ -0,0 +1,145 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             (unknown)
+// source: sale.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// SaleServiceClient is the client API for SaleService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type SaleServiceClient interface {
+	// SendSale sends the sale to the FSCR.
+	SendSale(ctx context.Context, in *SaleRequest, opts ...grpc.CallOption) (*SendSaleResponse, error)
+	// ComputeCodes computes the PKP and BKP security codes of the sale without sending it.
+	ComputeCodes(ctx context.Context, in *SaleRequest, opts ...grpc.CallOption) (*ComputeCodesResponse, error)
+}
+
+type saleServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSaleServiceClient(cc grpc.ClientConnInterface) SaleServiceClient {
+	return &saleServiceClient{cc}
+}
+
+func (c *saleServiceClient) SendSale(ctx context.Context, in *SaleRequest, opts ...grpc.CallOption) (*SendSaleResponse, error) {
+	out := new(SendSaleResponse)
+	err := c.cc.Invoke(ctx, "/eetgateway.v1.SaleService/SendSale", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *saleServiceClient) ComputeCodes(ctx context.Context, in *SaleRequest, opts ...grpc.CallOption) (*ComputeCodesResponse, error) {
+	out := new(ComputeCodesResponse)
+	err := c.cc.Invoke(ctx, "/eetgateway.v1.SaleService/ComputeCodes", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SaleServiceServer is the server API for SaleService service.
+// All implementations must embed UnimplementedSaleServiceServer
+// for forward compatibility
+type SaleServiceServer interface {
+	// SendSale sends the sale to the FSCR.
+	SendSale(context.Context, *SaleRequest) (*SendSaleResponse, error)
+	// ComputeCodes computes the PKP and BKP security codes of the sale without sending it.
+	ComputeCodes(context.Context, *SaleRequest) (*ComputeCodesResponse, error)
+	mustEmbedUnimplementedSaleServiceServer()
+}
+
+// UnimplementedSaleServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedSaleServiceServer struct {
+}
+
+func (UnimplementedSaleServiceServer) SendSale(context.Context, *SaleRequest) (*SendSaleResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SendSale not implemented")
+}
+func (UnimplementedSaleServiceServer) ComputeCodes(context.Context, *SaleRequest) (*ComputeCodesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ComputeCodes not implemented")
+}
+func (UnimplementedSaleServiceServer) mustEmbedUnimplementedSaleServiceServer() {}
+
+// UnsafeSaleServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SaleServiceServer will
+// result in compilation errors.
+type UnsafeSaleServiceServer interface {
+	mustEmbedUnimplementedSaleServiceServer()
+}
+
+func RegisterSaleServiceServer(s grpc.ServiceRegistrar, srv SaleServiceServer) {
+	s.RegisterService(&SaleService_ServiceDesc, srv)
+}
+
+func _SaleService_SendSale_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SaleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SaleServiceServer).SendSale(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/eetgateway.v1.SaleService/SendSale",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SaleServiceServer).SendSale(ctx, req.(*SaleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SaleService_ComputeCodes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SaleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SaleServiceServer).ComputeCodes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/eetgateway.v1.SaleService/ComputeCodes",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SaleServiceServer).ComputeCodes(ctx, req.(*SaleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// SaleService_ServiceDesc is the grpc.ServiceDesc for SaleService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var SaleService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "eetgateway.v1.SaleService",
+	HandlerType: (*SaleServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SendSale",
+			Handler:    _SaleService_SendSale_Handler,
+		},
+		{
+			MethodName: "ComputeCodes",
+			Handler:    _SaleService_ComputeCodes_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "sale.proto",
+}
@@ -0,0 +1,76 @@
+package grpchandler
+
+import (
+	"context"
+
+	"github.com/chutommy/eetgateway/pkg/eet"
+	"github.com/chutommy/eetgateway/pkg/server/grpchandler/pb"
+	"github.com/chutommy/eetgateway/pkg/server/httphandler"
+)
+
+// SendSale implements pb.SaleServiceServer.
+func (h *Handler) SendSale(ctx context.Context, r *pb.SaleRequest) (*pb.SendSaleResponse, error) {
+	req, err := validSaleReq(r)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = h.withClientAddr(ctx)
+	var odpoved *eet.OdpovedType
+	if r.GetSessionToken() != "" {
+		odpoved, err = h.gateway.SendSaleWithSession(ctx, req.CertID, r.GetSessionToken(), req.TrzbaType())
+	} else {
+		odpoved, err = h.gateway.SendSale(ctx, req.CertID, []byte(r.GetCertPassword()), req.TrzbaType())
+	}
+
+	if err != nil {
+		return nil, gatewayErr(err)
+	}
+
+	return sendSaleResponse(req, odpoved), nil
+}
+
+// ComputeCodes implements pb.SaleServiceServer.
+func (h *Handler) ComputeCodes(ctx context.Context, r *pb.SaleRequest) (*pb.ComputeCodesResponse, error) {
+	req, err := validSaleReq(r)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = h.withClientAddr(ctx)
+	var codes *eet.TrzbaKontrolniKodyType
+	if r.GetSessionToken() != "" {
+		codes, err = h.gateway.ComputeCodesWithSession(ctx, req.CertID, r.GetSessionToken(), req.TrzbaType())
+	} else {
+		codes, err = h.gateway.ComputeCodes(ctx, req.CertID, []byte(r.GetCertPassword()), req.TrzbaType())
+	}
+
+	if err != nil {
+		return nil, gatewayErr(err)
+	}
+
+	return computeCodesResponse(req.CertID, codes), nil
+}
+
+// validSaleReq returns the sale request with the default header values. The sale data
+// are validated the same way as by the HTTP handler.
+func validSaleReq(r *pb.SaleRequest) (*httphandler.SendSaleReq, error) {
+	credentials := r.GetCertPassword()
+	if credentials == "" {
+		credentials = r.GetSessionToken()
+	}
+
+	if err := requiredFields(map[string]string{
+		"cert_id":                     r.GetCertId(),
+		"cert_password|session_token": credentials,
+	}); err != nil {
+		return nil, err
+	}
+
+	req := saleReq(r.GetCertId(), r.GetSale())
+	if err := req.ValidateSale(); err != nil {
+		return nil, invalidArgument(err)
+	}
+
+	return req, nil
+}
@@ -0,0 +1,235 @@
+package grpchandler_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/chutommy/eetgateway/pkg/eet"
+	"github.com/chutommy/eetgateway/pkg/gateway"
+	"github.com/chutommy/eetgateway/pkg/server/grpchandler/pb"
+	"github.com/google/uuid"
+	"github.com/sethvargo/go-password/password"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/multierr"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func validSale() *pb.Sale {
+	return &pb.Sale{
+		DicPopl:   "CZ683555118",
+		IdProvoz:  11,
+		IdPokl:    "ABC",
+		PoradCis:  "123",
+		DatTrzby:  timestamppb.Now(),
+		CelkTrzba: 100,
+	}
+}
+
+func (suite *GRPCHandlerTestSuite) TestSendSale() {
+	ctx := context.Background()
+
+	suite.Run("missing credentials", func() {
+		_, err := suite.sales.SendSale(ctx, &pb.SaleRequest{
+			CertId: uuid.New().String(),
+			Sale:   validSale(),
+		})
+		suite.requireCode(codes.InvalidArgument, err)
+	})
+
+	suite.Run("invalid sale", func() {
+		sale := validSale()
+		sale.DicPopl = "invalid"
+		_, err := suite.sales.SendSale(ctx, &pb.SaleRequest{
+			CertId:      uuid.New().String(),
+			Credentials: &pb.SaleRequest_CertPassword{CertPassword: "secret"},
+			Sale:        sale,
+		})
+		suite.requireCode(codes.InvalidArgument, err)
+	})
+
+	suite.Run("missing sale", func() {
+		_, err := suite.sales.SendSale(ctx, &pb.SaleRequest{
+			CertId:      uuid.New().String(),
+			Credentials: &pb.SaleRequest_CertPassword{CertPassword: "secret"},
+		})
+		suite.requireCode(codes.InvalidArgument, err)
+	})
+
+	errTests := []struct {
+		name string
+		err  error
+		code codes.Code
+	}{
+		{
+			name: "unavailable keystore",
+			err:  gateway.ErrKeystoreUnavailable,
+			code: codes.Unavailable,
+		},
+		{
+			name: "locked certificate",
+			err:  gateway.ErrCertificateLocked,
+			code: codes.ResourceExhausted,
+		},
+		{
+			name: "revoked certificate",
+			err:  gateway.ErrCertificateRevoked,
+			code: codes.PermissionDenied,
+		},
+		{
+			name: "certificate not found",
+			err:  gateway.ErrCertificateNotFound,
+			code: codes.NotFound,
+		},
+		{
+			name: "invalid password",
+			err:  gateway.ErrInvalidCertificatePassword,
+			code: codes.Unauthenticated,
+		},
+		{
+			name: "untrusted FSCR signing certificate",
+			err:  multierr.Append(errors.New("x509: unknown authority"), gateway.ErrFSCRCertificateUntrusted),
+			code: codes.Internal,
+		},
+		{
+			name: "unexpected error",
+			err:  errors.New("unexpected"),
+			code: codes.Internal,
+		},
+	}
+
+	for _, tc := range errTests {
+		suite.Run(tc.name, func() {
+			certID := uuid.New().String()
+			certPassword := password.MustGenerate(64, 10, 10, false, false)
+
+			suite.gSvc.On("SendSale", mock.Anything, certID, []byte(certPassword), mock.Anything).
+				Return(nil, tc.err).Once()
+			_, err := suite.sales.SendSale(ctx, &pb.SaleRequest{
+				CertId:      certID,
+				Credentials: &pb.SaleRequest_CertPassword{CertPassword: certPassword},
+				Sale:        validSale(),
+			})
+			suite.requireCode(tc.code, err)
+		})
+	}
+
+	suite.Run("FSCR fault", func() {
+		certID := uuid.New().String()
+		fault := &eet.SOAPFault{
+			Code:   "soapenv:Client",
+			String: "Nespravny format zpravy",
+		}
+
+		suite.gSvc.On("SendSale", mock.Anything, certID, []byte("secret"), mock.Anything).
+			Return(nil, multierr.Append(fault, gateway.ErrFSCRFault)).Once()
+		_, err := suite.sales.SendSale(ctx, &pb.SaleRequest{
+			CertId:      certID,
+			Credentials: &pb.SaleRequest_CertPassword{CertPassword: "secret"},
+			Sale:        validSale(),
+		})
+		suite.requireCode(codes.Unknown, err)
+
+		details := status.Convert(err).Details()
+		suite.Require().Len(details, 1)
+		suite.Equal(fault.String, details[0].(*pb.FSCRFault).Faultstring)
+	})
+
+	suite.Run("ok", func() {
+		certID := uuid.New().String()
+		sale := validSale()
+
+		trzba := mock.MatchedBy(func(t *eet.TrzbaType) bool {
+			return t.Hlavicka.Prvnizaslani && t.Hlavicka.Uuidzpravy != "" &&
+				t.Data.Dicpopl == eet.CZDICType(sale.DicPopl) && t.Data.Celktrzba == 100 &&
+				time.Time(t.Data.Dattrzby).Equal(sale.DatTrzby.AsTime().Truncate(time.Second))
+		})
+		odpoved := &eet.OdpovedType{
+			Hlavicka: eet.OdpovedHlavickaType{
+				Datprij: eet.DateTime(time.Now()),
+				Bkp:     "03ec1d0e-6d9f77fb-1d798ccb-f4739666-a4069bc3",
+			},
+			Potvrzeni: eet.OdpovedPotvrzeniType{
+				Fik:  "b3a09b52-7c87-4014-a496-4c7a53cf9125-ff",
+				Test: true,
+			},
+		}
+
+		suite.gSvc.On("SendSale", mock.Anything, certID, []byte("secret"), trzba).
+			Return(odpoved, nil).Once()
+		resp, err := suite.sales.SendSale(ctx, &pb.SaleRequest{
+			CertId:      certID,
+			Credentials: &pb.SaleRequest_CertPassword{CertPassword: "secret"},
+			Sale:        sale,
+		})
+		suite.Require().NoError(err)
+		suite.Equal(certID, resp.CertId)
+		suite.Equal(string(odpoved.Potvrzeni.Fik), resp.Fik)
+		suite.Equal(string(odpoved.Hlavicka.Bkp), resp.Bkp)
+		suite.True(resp.Test)
+		suite.True(resp.Trzba.GetPrvniZaslani())
+		suite.NotEmpty(resp.Trzba.UuidZpravy)
+	})
+
+	suite.Run("rejected", func() {
+		certID := uuid.New().String()
+		odpoved := &eet.OdpovedType{
+			Hlavicka: eet.OdpovedHlavickaType{
+				Datodmit: eet.DateTime(time.Now()),
+			},
+			Chyba: eet.OdpovedChybaType{
+				Zprava: "Neplatny podpis SOAP zpravy",
+				Kod:    4,
+			},
+		}
+
+		suite.gSvc.On("SendSaleWithSession", mock.Anything, certID, "token", mock.Anything).
+			Return(odpoved, nil).Once()
+		resp, err := suite.sales.SendSale(ctx, &pb.SaleRequest{
+			CertId:      certID,
+			Credentials: &pb.SaleRequest_SessionToken{SessionToken: "token"},
+			Sale:        validSale(),
+		})
+		suite.Require().NoError(err)
+		suite.EqualValues(4, resp.ChybKod)
+		suite.NotNil(resp.DatOdmit)
+		suite.Nil(resp.Trzba)
+	})
+}
+
+func (suite *GRPCHandlerTestSuite) TestComputeCodes() {
+	ctx := context.Background()
+
+	suite.Run("invalid session token", func() {
+		certID := uuid.New().String()
+		suite.gSvc.On("ComputeCodesWithSession", mock.Anything, certID, "token", mock.Anything).
+			Return(nil, gateway.ErrInvalidSessionToken).Once()
+		_, err := suite.sales.ComputeCodes(ctx, &pb.SaleRequest{
+			CertId:      certID,
+			Credentials: &pb.SaleRequest_SessionToken{SessionToken: "token"},
+			Sale:        validSale(),
+		})
+		suite.requireCode(codes.Unauthenticated, err)
+	})
+
+	suite.Run("ok", func() {
+		certID := uuid.New().String()
+		codes := &eet.TrzbaKontrolniKodyType{
+			Pkp: eet.PkpElementType{PkpType: []byte("pkp")},
+			Bkp: eet.BkpElementType{BkpType: "03ec1d0e-6d9f77fb-1d798ccb-f4739666-a4069bc3"},
+		}
+
+		suite.gSvc.On("ComputeCodes", mock.Anything, certID, []byte("secret"), mock.Anything).
+			Return(codes, nil).Once()
+		resp, err := suite.sales.ComputeCodes(ctx, &pb.SaleRequest{
+			CertId:      certID,
+			Credentials: &pb.SaleRequest_CertPassword{CertPassword: "secret"},
+			Sale:        validSale(),
+		})
+		suite.Require().NoError(err)
+		suite.Equal([]byte("pkp"), resp.Pkp)
+		suite.Equal(string(codes.Bkp.BkpType), resp.Bkp)
+	})
+}
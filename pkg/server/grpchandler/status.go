@@ -0,0 +1,52 @@
+package grpchandler
+
+import (
+	"context"
+	"errors"
+
+	"github.com/chutommy/eetgateway/pkg/gateway"
+	"github.com/chutommy/eetgateway/pkg/server/grpchandler/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Ping implements pb.StatusServiceServer. The statuses of the FSCR and the keystore
+// are reported in the response.
+func (h *Handler) Ping(ctx context.Context, _ *pb.PingRequest) (*pb.PingResponse, error) {
+	err := h.gateway.Ping(ctx)
+	online := func(target error) string {
+		if errors.Is(err, target) {
+			return target.Error()
+		}
+
+		return "online"
+	}
+
+	eetRoots, dsigRoots := h.gateway.TrustedRoots()
+
+	return &pb.PingResponse{
+		EetGateway: "online", // is able to response
+		TaxAdmin:   online(gateway.ErrFSCRConnection),
+		Keystore:   online(gateway.ErrKeystoreUnavailable),
+		CaRoots:    append(caRoots("eet", eetRoots), caRoots("dsig", dsigRoots)...),
+	}, nil
+}
+
+// Ready implements pb.StatusServiceServer.
+func (h *Handler) Ready(ctx context.Context, _ *pb.ReadyRequest) (*pb.ReadyResponse, error) {
+	if err := h.gateway.Ready(ctx); err != nil {
+		return nil, &statusError{
+			status: status.New(codes.Unavailable, err.Error()),
+			cause:  err,
+		}
+	}
+
+	return &pb.ReadyResponse{Status: "ready"}, nil
+}
+
+// Status implements pb.StatusServiceServer.
+func (h *Handler) Status(context.Context, *pb.StatusRequest) (*pb.StatusResponse, error) {
+	return &pb.StatusResponse{
+		Dependencies: dependencies(h.gateway.Dependencies()),
+	}, nil
+}
@@ -0,0 +1,80 @@
+package grpchandler_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/chutommy/eetgateway/pkg/ca"
+	"github.com/chutommy/eetgateway/pkg/gateway"
+	"github.com/chutommy/eetgateway/pkg/server/grpchandler/pb"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+)
+
+func (suite *GRPCHandlerTestSuite) TestPing() {
+	dsigRoots, err := ca.DSigRoots()
+	suite.Require().NoError(err)
+	suite.gSvc.On("TrustedRoots").Return(nil, dsigRoots)
+
+	suite.Run("ok", func() {
+		suite.gSvc.On("Ping", mock.Anything).Return(nil).Once()
+		resp, err := suite.status.Ping(context.Background(), &pb.PingRequest{})
+		suite.Require().NoError(err)
+		suite.Equal("online", resp.TaxAdmin)
+		suite.Equal("online", resp.Keystore)
+		suite.Require().Len(resp.CaRoots, len(dsigRoots))
+		suite.Equal("dsig", resp.CaRoots[0].Purpose)
+		suite.Equal(dsigRoots[0].SerialNumber.String(), resp.CaRoots[0].SerialNumber)
+	})
+
+	suite.Run("fscr unavailable", func() {
+		suite.gSvc.On("Ping", mock.Anything).Return(gateway.ErrFSCRConnection).Once()
+		resp, err := suite.status.Ping(context.Background(), &pb.PingRequest{})
+		suite.Require().NoError(err)
+		suite.Equal(gateway.ErrFSCRConnection.Error(), resp.TaxAdmin)
+		suite.Equal("online", resp.Keystore)
+	})
+}
+
+func (suite *GRPCHandlerTestSuite) TestReady() {
+	suite.Run("ready", func() {
+		suite.gSvc.On("Ready", mock.Anything).Return(nil).Once()
+		resp, err := suite.status.Ready(context.Background(), &pb.ReadyRequest{})
+		suite.Require().NoError(err)
+		suite.Equal("ready", resp.Status)
+	})
+
+	suite.Run("trust store not loaded", func() {
+		suite.gSvc.On("Ready", mock.Anything).Return(gateway.ErrTrustStoreEmpty).Once()
+		_, err := suite.status.Ready(context.Background(), &pb.ReadyRequest{})
+		suite.requireCode(codes.Unavailable, err)
+		suite.Contains(err.Error(), gateway.ErrTrustStoreEmpty.Error())
+	})
+}
+
+func (suite *GRPCHandlerTestSuite) TestStatus() {
+	now := time.Now()
+	suite.gSvc.On("Dependencies").Return([]gateway.DependencyStatus{
+		{
+			Name:        gateway.FSCRDependency,
+			Latency:     20 * time.Millisecond,
+			CheckedAt:   now,
+			LastSuccess: now,
+		},
+		{
+			Name:      gateway.KeystoreDependency,
+			Err:       errors.New("connection refused"),
+			CheckedAt: now,
+		},
+	}).Once()
+
+	resp, err := suite.status.Status(context.Background(), &pb.StatusRequest{})
+	suite.Require().NoError(err)
+	suite.Require().Len(resp.Dependencies, 2)
+	suite.Equal("online", resp.Dependencies[0].Status)
+	suite.InDelta(20, resp.Dependencies[0].LatencyMs, 0.001)
+	suite.NotNil(resp.Dependencies[0].LastSuccess)
+	suite.Equal("connection refused", resp.Dependencies[1].Status)
+	suite.Nil(resp.Dependencies[1].LastSuccess)
+}
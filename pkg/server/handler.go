@@ -4,7 +4,9 @@ import (
 	"net/http"
 
 	"github.com/chutommy/eetgateway/pkg/gateway"
+	"github.com/chutommy/eetgateway/pkg/server/grpchandler"
 	"github.com/chutommy/eetgateway/pkg/server/httphandler"
+	"google.golang.org/grpc"
 )
 
 // Handler provides handling options for incoming requests.
@@ -12,7 +14,19 @@ type Handler interface {
 	HTTPHandler() http.Handler
 }
 
-// NewHTTPHandler returns an HTTP Handler implementation.
-func NewHTTPHandler(g gateway.Service) Handler {
-	return httphandler.NewHandler(g)
+// NewHTTPHandler returns an HTTP Handler implementation. The clients are told apart
+// according to the ClientPolicy.
+func NewHTTPHandler(g gateway.Service, clients httphandler.ClientPolicy) (Handler, error) {
+	return httphandler.NewHandler(g, clients)
+}
+
+// GRPCHandler provides handling options for incoming gRPC requests.
+type GRPCHandler interface {
+	GRPCServer(opts ...grpc.ServerOption) *grpc.Server
+}
+
+// NewGRPCHandler returns a GRPCHandler implementation. The failed password attempts are counted
+// per peer address if perClient is true.
+func NewGRPCHandler(g gateway.Service, perClient bool) GRPCHandler {
+	return grpchandler.NewHandler(g, perClient)
 }
@@ -11,19 +11,26 @@ func (h *Handler) storeCert(c *gin.Context) {
 	req := &StoreCertReq{}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		err = bindingErr(err)
-		c.JSON(http.StatusBadRequest, GatewayErrResp{err.Error()})
+		c.JSON(http.StatusBadRequest, GatewayErrResp{GatewayError: err.Error()})
 		_ = c.Error(err)
 		return
 	}
 
-	data, err := base64.StdEncoding.DecodeString(req.PKCS12Data)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, GatewayErrResp{err.Error()})
-		_ = c.Error(err)
-		return
+	var err error
+	if req.PEMCertificate != "" {
+		err = h.gateway.StoreCertPEM(c, req.CertID, []byte(req.CertPassword), []byte(req.PEMCertificate), []byte(req.PEMPrivateKey), certPolicy(req.Policy))
+	} else {
+		var data []byte
+		data, err = base64.StdEncoding.DecodeString(req.PKCS12Data)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, GatewayErrResp{GatewayError: err.Error()})
+			_ = c.Error(err)
+			return
+		}
+
+		err = h.gateway.StoreCert(c, req.CertID, []byte(req.CertPassword), data, req.PKCS12Password, certPolicy(req.Policy))
 	}
 
-	err = h.gateway.StoreCert(c, req.CertID, []byte(req.CertPassword), data, req.PKCS12Password)
 	if err != nil {
 		code, resp := gatewayErrResp(err)
 		c.JSON(code, resp)
@@ -43,7 +50,7 @@ func (h *Handler) listCertIDs(c *gin.Context) {
 
 	if err := c.ShouldBindQuery(&req); err != nil {
 		err = bindingErr(err)
-		c.JSON(http.StatusBadRequest, GatewayErrResp{err.Error()})
+		c.JSON(http.StatusBadRequest, GatewayErrResp{GatewayError: err.Error()})
 		_ = c.Error(err)
 		return
 	}
@@ -68,7 +75,7 @@ func (h *Handler) updateCertID(c *gin.Context) {
 	reqURI := &UpdateCertIDURIReq{}
 	if err := c.ShouldBindUri(&reqURI); err != nil {
 		err = bindingErr(err)
-		c.JSON(http.StatusBadRequest, GatewayErrResp{err.Error()})
+		c.JSON(http.StatusBadRequest, GatewayErrResp{GatewayError: err.Error()})
 		_ = c.Error(err)
 		return
 	}
@@ -76,7 +83,7 @@ func (h *Handler) updateCertID(c *gin.Context) {
 	reqJSON := &UpdateCertIDJSONReq{}
 	if err := c.ShouldBindJSON(&reqJSON); err != nil {
 		err = bindingErr(err)
-		c.JSON(http.StatusBadRequest, GatewayErrResp{err.Error()})
+		c.JSON(http.StatusBadRequest, GatewayErrResp{GatewayError: err.Error()})
 		_ = c.Error(err)
 		return
 	}
@@ -96,7 +103,7 @@ func (h *Handler) updateCertPassword(c *gin.Context) {
 	reqURI := &UpdateCertPasswordURIReq{}
 	if err := c.ShouldBindUri(&reqURI); err != nil {
 		err = bindingErr(err)
-		c.JSON(http.StatusBadRequest, GatewayErrResp{err.Error()})
+		c.JSON(http.StatusBadRequest, GatewayErrResp{GatewayError: err.Error()})
 		_ = c.Error(err)
 		return
 	}
@@ -104,12 +111,107 @@ func (h *Handler) updateCertPassword(c *gin.Context) {
 	reqJSON := &UpdateCertPasswordJSONReq{}
 	if err := c.ShouldBindJSON(&reqJSON); err != nil {
 		err = bindingErr(err)
-		c.JSON(http.StatusBadRequest, GatewayErrResp{err.Error()})
+		c.JSON(http.StatusBadRequest, GatewayErrResp{GatewayError: err.Error()})
+		_ = c.Error(err)
+		return
+	}
+
+	ctx := h.withClientAddr(c)
+	err := h.gateway.UpdateCertPassword(ctx, reqURI.CertID, []byte(reqJSON.CertPassword), []byte(reqJSON.NewPassword))
+	if err != nil {
+		code, resp := gatewayErrResp(err)
+		c.JSON(code, resp)
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, successCertResp(reqURI.CertID))
+}
+
+func (h *Handler) updateCertPolicy(c *gin.Context) {
+	reqURI := &UpdateCertPolicyURIReq{}
+	if err := c.ShouldBindUri(&reqURI); err != nil {
+		err = bindingErr(err)
+		c.JSON(http.StatusBadRequest, GatewayErrResp{GatewayError: err.Error()})
+		_ = c.Error(err)
+		return
+	}
+
+	reqJSON := &UpdateCertPolicyJSONReq{}
+	if err := c.ShouldBindJSON(&reqJSON); err != nil {
+		err = bindingErr(err)
+		c.JSON(http.StatusBadRequest, GatewayErrResp{GatewayError: err.Error()})
+		_ = c.Error(err)
+		return
+	}
+
+	ctx := h.withClientAddr(c)
+	err := h.gateway.UpdateCertPolicy(ctx, reqURI.CertID, []byte(reqJSON.CertPassword), certPolicy(reqJSON.Policy))
+	if err != nil {
+		code, resp := gatewayErrResp(err)
+		c.JSON(code, resp)
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, successCertResp(reqURI.CertID))
+}
+
+func (h *Handler) replaceCert(c *gin.Context) {
+	reqURI := &ReplaceCertURIReq{}
+	if err := c.ShouldBindUri(&reqURI); err != nil {
+		err = bindingErr(err)
+		c.JSON(http.StatusBadRequest, GatewayErrResp{GatewayError: err.Error()})
+		_ = c.Error(err)
+		return
+	}
+
+	reqJSON := &ReplaceCertJSONReq{}
+	if err := c.ShouldBindJSON(&reqJSON); err != nil {
+		err = bindingErr(err)
+		c.JSON(http.StatusBadRequest, GatewayErrResp{GatewayError: err.Error()})
 		_ = c.Error(err)
 		return
 	}
 
-	err := h.gateway.UpdateCertPassword(c, reqURI.CertID, []byte(reqJSON.CertPassword), []byte(reqJSON.NewPassword))
+	data, err := base64.StdEncoding.DecodeString(reqJSON.PKCS12Data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, GatewayErrResp{GatewayError: err.Error()})
+		_ = c.Error(err)
+		return
+	}
+
+	ctx := h.withClientAddr(c)
+	err = h.gateway.ReplaceCert(ctx, reqURI.CertID, []byte(reqJSON.CertPassword), data, reqJSON.PKCS12Password)
+	if err != nil {
+		code, resp := gatewayErrResp(err)
+		c.JSON(code, resp)
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, successCertResp(reqURI.CertID))
+}
+
+func (h *Handler) rollbackCert(c *gin.Context) {
+	reqURI := &RollbackCertURIReq{}
+	if err := c.ShouldBindUri(&reqURI); err != nil {
+		err = bindingErr(err)
+		c.JSON(http.StatusBadRequest, GatewayErrResp{GatewayError: err.Error()})
+		_ = c.Error(err)
+		return
+	}
+
+	reqJSON := &RollbackCertJSONReq{}
+	if err := c.ShouldBindJSON(&reqJSON); err != nil {
+		err = bindingErr(err)
+		c.JSON(http.StatusBadRequest, GatewayErrResp{GatewayError: err.Error()})
+		_ = c.Error(err)
+		return
+	}
+
+	ctx := h.withClientAddr(c)
+	err := h.gateway.RollbackCert(ctx, reqURI.CertID, []byte(reqJSON.CertPassword))
 	if err != nil {
 		code, resp := gatewayErrResp(err)
 		c.JSON(code, resp)
@@ -120,11 +222,43 @@ func (h *Handler) updateCertPassword(c *gin.Context) {
 	c.JSON(http.StatusOK, successCertResp(reqURI.CertID))
 }
 
+func (h *Handler) exportCert(c *gin.Context) {
+	reqURI := &ExportCertURIReq{}
+	if err := c.ShouldBindUri(&reqURI); err != nil {
+		err = bindingErr(err)
+		c.JSON(http.StatusBadRequest, GatewayErrResp{GatewayError: err.Error()})
+		_ = c.Error(err)
+		return
+	}
+
+	reqJSON := &ExportCertJSONReq{}
+	if err := c.ShouldBindJSON(&reqJSON); err != nil {
+		err = bindingErr(err)
+		c.JSON(http.StatusBadRequest, GatewayErrResp{GatewayError: err.Error()})
+		_ = c.Error(err)
+		return
+	}
+
+	ctx := h.withClientAddr(c)
+	data, err := h.gateway.ExportCert(ctx, reqURI.CertID, []byte(reqJSON.CertPassword), reqJSON.PKCS12Password)
+	if err != nil {
+		code, resp := gatewayErrResp(err)
+		c.JSON(code, resp)
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, &ExportCertResp{
+		CertID:     reqURI.CertID,
+		PKCS12Data: base64.StdEncoding.EncodeToString(data),
+	})
+}
+
 func (h *Handler) deleteCert(c *gin.Context) {
 	req := &DeleteCertReq{}
 	if err := c.ShouldBindUri(&req); err != nil {
 		err = bindingErr(err)
-		c.JSON(http.StatusBadRequest, GatewayErrResp{err.Error()})
+		c.JSON(http.StatusBadRequest, GatewayErrResp{GatewayError: err.Error()})
 		_ = c.Error(err)
 		return
 	}
@@ -2,6 +2,7 @@ package httphandler_test
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -9,6 +10,7 @@ import (
 	"net/url"
 
 	"github.com/chutommy/eetgateway/pkg/gateway"
+	"github.com/chutommy/eetgateway/pkg/keystore"
 	"github.com/chutommy/eetgateway/pkg/server/httphandler"
 	"github.com/google/uuid"
 	"github.com/sethvargo/go-password/password"
@@ -40,7 +42,7 @@ func (suite *HTTPHandlerTestSuite) TestStoreCert() {
 		body, err := json.Marshal(r)
 		suite.NoError(err)
 
-		suite.gSvc.On("StoreCert", mock.Anything, r.CertID, []byte(r.CertPassword), []byte("valid"), "eet").
+		suite.gSvc.On("StoreCert", mock.Anything, r.CertID, []byte(r.CertPassword), []byte("valid"), "eet", (*keystore.Policy)(nil)).
 			Return(gateway.ErrInvalidCertificatePassword).Once()
 		req := httptest.NewRequest(http.MethodPost, "/v1/certs", bytes.NewReader(body))
 		rw := httptest.NewRecorder()
@@ -65,7 +67,7 @@ func (suite *HTTPHandlerTestSuite) TestStoreCert() {
 		body, err := json.Marshal(r)
 		suite.NoError(err)
 
-		suite.gSvc.On("StoreCert", mock.Anything, r.CertID, []byte(r.CertPassword), []byte("valid"), "eet").
+		suite.gSvc.On("StoreCert", mock.Anything, r.CertID, []byte(r.CertPassword), []byte("valid"), "eet", (*keystore.Policy)(nil)).
 			Return(nil).Once()
 		req := httptest.NewRequest(http.MethodPost, "/v1/certs", bytes.NewReader(body))
 		rw := httptest.NewRecorder()
@@ -78,6 +80,138 @@ func (suite *HTTPHandlerTestSuite) TestStoreCert() {
 
 		suite.Equal(http.StatusOK, resp.StatusCode)
 	})
+
+	suite.Run("invalid policy", func() {
+		r := httphandler.StoreCertReq{
+			CertID:         uuid.New().String(),
+			CertPassword:   password.MustGenerate(64, 10, 10, false, false),
+			PKCS12Data:     "dmFsaWQ=", // = "valid"
+			PKCS12Password: "eet",
+			Policy: &httphandler.CertPolicyReq{
+				IDProvoz: []int{0},
+			},
+		}
+
+		body, err := json.Marshal(r)
+		suite.NoError(err)
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/certs", bytes.NewReader(body))
+		rw := httptest.NewRecorder()
+		suite.handler.ServeHTTP(rw, req)
+
+		resp := rw.Result()
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+
+		suite.Equal(http.StatusBadRequest, resp.StatusCode)
+	})
+
+	suite.Run("ok with policy", func() {
+		r := httphandler.StoreCertReq{
+			CertID:         uuid.New().String(),
+			CertPassword:   password.MustGenerate(64, 10, 10, false, false),
+			PKCS12Data:     "dmFsaWQ=", // = "valid"
+			PKCS12Password: "eet",
+			Policy: &httphandler.CertPolicyReq{
+				IDProvoz: []int{11},
+				IDPokl:   []string{"pokl-[0-9]+"},
+			},
+		}
+
+		body, err := json.Marshal(r)
+		suite.NoError(err)
+
+		policy := &keystore.Policy{
+			IDProvoz: []int{11},
+			IDPokl:   []string{"pokl-[0-9]+"},
+		}
+
+		suite.gSvc.On("StoreCert", mock.Anything, r.CertID, []byte(r.CertPassword), []byte("valid"), "eet", policy).
+			Return(nil).Once()
+		req := httptest.NewRequest(http.MethodPost, "/v1/certs", bytes.NewReader(body))
+		rw := httptest.NewRecorder()
+		suite.handler.ServeHTTP(rw, req)
+
+		resp := rw.Result()
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+
+		suite.Equal(http.StatusOK, resp.StatusCode)
+	})
+
+	suite.Run("ok pem", func() {
+		r := httphandler.StoreCertReq{
+			CertID:         uuid.New().String(),
+			CertPassword:   password.MustGenerate(64, 10, 10, false, false),
+			PEMCertificate: "certificate",
+			PEMPrivateKey:  "private key",
+		}
+
+		body, err := json.Marshal(r)
+		suite.NoError(err)
+
+		suite.gSvc.On("StoreCertPEM", mock.Anything, r.CertID, []byte(r.CertPassword), []byte("certificate"), []byte("private key"), (*keystore.Policy)(nil)).
+			Return(nil).Once()
+		req := httptest.NewRequest(http.MethodPost, "/v1/certs", bytes.NewReader(body))
+		rw := httptest.NewRecorder()
+		suite.handler.ServeHTTP(rw, req)
+
+		resp := rw.Result()
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+
+		suite.Equal(http.StatusOK, resp.StatusCode)
+	})
+
+	suite.Run("pem without private key", func() {
+		r := httphandler.StoreCertReq{
+			CertID:         uuid.New().String(),
+			CertPassword:   password.MustGenerate(64, 10, 10, false, false),
+			PEMCertificate: "certificate",
+		}
+
+		body, err := json.Marshal(r)
+		suite.NoError(err)
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/certs", bytes.NewReader(body))
+		rw := httptest.NewRecorder()
+		suite.handler.ServeHTTP(rw, req)
+
+		resp := rw.Result()
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+
+		suite.Equal(http.StatusBadRequest, resp.StatusCode)
+	})
+
+	suite.Run("both pkcs12 and pem", func() {
+		r := httphandler.StoreCertReq{
+			CertID:         uuid.New().String(),
+			CertPassword:   password.MustGenerate(64, 10, 10, false, false),
+			PKCS12Data:     "dmFsaWQ=", // = "valid"
+			PKCS12Password: "eet",
+			PEMCertificate: "certificate",
+			PEMPrivateKey:  "private key",
+		}
+
+		body, err := json.Marshal(r)
+		suite.NoError(err)
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/certs", bytes.NewReader(body))
+		rw := httptest.NewRecorder()
+		suite.handler.ServeHTTP(rw, req)
+
+		resp := rw.Result()
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+
+		suite.Equal(http.StatusBadRequest, resp.StatusCode)
+	})
 }
 
 func (suite *HTTPHandlerTestSuite) TestListCertIDs() {
@@ -206,6 +340,280 @@ func (suite *HTTPHandlerTestSuite) TestUpdateCertPassword() {
 	})
 }
 
+func (suite *HTTPHandlerTestSuite) TestUpdateCertPolicy() {
+	suite.Run("invalid uri", func() {
+		suite.HTTPStatusCode(suite.handler.ServeHTTP, http.MethodPut, "/v1/certs//policy", nil, http.StatusBadRequest)
+	})
+
+	suite.Run("invalid request body", func() {
+		suite.HTTPStatusCode(suite.handler.ServeHTTP, http.MethodPut, fmt.Sprintf("/v1/certs/%s/policy", uuid.New().String()), nil, http.StatusBadRequest)
+	})
+
+	suite.Run("missing password", func() {
+		body, err := json.Marshal(httphandler.UpdateCertPolicyJSONReq{
+			Policy: &httphandler.CertPolicyReq{
+				IDProvoz: []int{11},
+			},
+		})
+		suite.NoError(err)
+
+		req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/v1/certs/%s/policy", uuid.New().String()), bytes.NewReader(body))
+		rw := httptest.NewRecorder()
+		suite.handler.ServeHTTP(rw, req)
+
+		resp := rw.Result()
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+
+		suite.Equal(http.StatusBadRequest, resp.StatusCode)
+	})
+
+	suite.Run("invalid password", func() {
+		id := uuid.New().String()
+		r := httphandler.UpdateCertPolicyJSONReq{
+			CertPassword: password.MustGenerate(64, 10, 10, false, false),
+		}
+
+		body, err := json.Marshal(r)
+		suite.NoError(err)
+
+		suite.gSvc.On("UpdateCertPolicy", mock.Anything, id, []byte(r.CertPassword), (*keystore.Policy)(nil)).Return(gateway.ErrInvalidCertificatePassword).Once()
+		req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/v1/certs/%s/policy", id), bytes.NewReader(body))
+		rw := httptest.NewRecorder()
+		suite.handler.ServeHTTP(rw, req)
+
+		resp := rw.Result()
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+
+		suite.Equal(http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	suite.Run("invalid policy", func() {
+		id := uuid.New().String()
+		r := httphandler.UpdateCertPolicyJSONReq{
+			CertPassword: password.MustGenerate(64, 10, 10, false, false),
+			Policy: &httphandler.CertPolicyReq{
+				IDPokl: []string{"pokl-[0-9"},
+			},
+		}
+
+		body, err := json.Marshal(r)
+		suite.NoError(err)
+
+		suite.gSvc.On("UpdateCertPolicy", mock.Anything, id, []byte(r.CertPassword), &keystore.Policy{IDPokl: r.Policy.IDPokl}).Return(gateway.ErrInvalidCertificatePolicy).Once()
+		req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/v1/certs/%s/policy", id), bytes.NewReader(body))
+		rw := httptest.NewRecorder()
+		suite.handler.ServeHTTP(rw, req)
+
+		resp := rw.Result()
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+
+		suite.Equal(http.StatusBadRequest, resp.StatusCode)
+	})
+
+	suite.Run("ok", func() {
+		id := uuid.New().String()
+		r := httphandler.UpdateCertPolicyJSONReq{
+			CertPassword: password.MustGenerate(64, 10, 10, false, false),
+			Policy: &httphandler.CertPolicyReq{
+				IDProvoz:        []int{11, 12},
+				DICPoverujiciho: "CZ00000019",
+			},
+		}
+
+		body, err := json.Marshal(r)
+		suite.NoError(err)
+
+		policy := &keystore.Policy{
+			IDProvoz:        r.Policy.IDProvoz,
+			DICPoverujiciho: "CZ00000019",
+		}
+
+		suite.gSvc.On("UpdateCertPolicy", mock.Anything, id, []byte(r.CertPassword), policy).Return(nil).Once()
+		req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/v1/certs/%s/policy", id), bytes.NewReader(body))
+		rw := httptest.NewRecorder()
+		suite.handler.ServeHTTP(rw, req)
+
+		resp := rw.Result()
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+
+		suite.Equal(http.StatusOK, resp.StatusCode)
+	})
+}
+
+func (suite *HTTPHandlerTestSuite) TestReplaceCert() {
+	suite.Run("invalid request body", func() {
+		suite.HTTPStatusCode(suite.handler.ServeHTTP, http.MethodPut, fmt.Sprintf("/v1/certs/%s/certificate", uuid.New().String()), nil, http.StatusBadRequest)
+	})
+
+	suite.Run("invalid certificate", func() {
+		id := uuid.New().String()
+		r := httphandler.ReplaceCertJSONReq{
+			CertPassword:   password.MustGenerate(64, 10, 10, false, false),
+			PKCS12Data:     base64.StdEncoding.EncodeToString([]byte("p12 data")),
+			PKCS12Password: password.MustGenerate(64, 10, 10, false, false),
+		}
+
+		body, err := json.Marshal(r)
+		suite.NoError(err)
+
+		suite.gSvc.On("ReplaceCert", mock.Anything, id, []byte(r.CertPassword), []byte("p12 data"), r.PKCS12Password).
+			Return(gateway.ErrInvalidTaxpayersCertificate).Once()
+		req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/v1/certs/%s/certificate", id), bytes.NewReader(body))
+		rw := httptest.NewRecorder()
+		suite.handler.ServeHTTP(rw, req)
+
+		resp := rw.Result()
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+
+		suite.Equal(http.StatusBadRequest, resp.StatusCode)
+	})
+
+	suite.Run("ok", func() {
+		id := uuid.New().String()
+		r := httphandler.ReplaceCertJSONReq{
+			CertPassword:   password.MustGenerate(64, 10, 10, false, false),
+			PKCS12Data:     base64.StdEncoding.EncodeToString([]byte("p12 data")),
+			PKCS12Password: password.MustGenerate(64, 10, 10, false, false),
+		}
+
+		body, err := json.Marshal(r)
+		suite.NoError(err)
+
+		suite.gSvc.On("ReplaceCert", mock.Anything, id, []byte(r.CertPassword), []byte("p12 data"), r.PKCS12Password).
+			Return(nil).Once()
+		req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/v1/certs/%s/certificate", id), bytes.NewReader(body))
+		rw := httptest.NewRecorder()
+		suite.handler.ServeHTTP(rw, req)
+
+		resp := rw.Result()
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+
+		suite.Equal(http.StatusOK, resp.StatusCode)
+	})
+}
+
+func (suite *HTTPHandlerTestSuite) TestRollbackCert() {
+	suite.Run("invalid request body", func() {
+		suite.HTTPStatusCode(suite.handler.ServeHTTP, http.MethodPost, fmt.Sprintf("/v1/certs/%s/certificate/rollback", uuid.New().String()), nil, http.StatusBadRequest)
+	})
+
+	suite.Run("previous certificate not found", func() {
+		id := uuid.New().String()
+		r := httphandler.RollbackCertJSONReq{
+			CertPassword: password.MustGenerate(64, 10, 10, false, false),
+		}
+
+		body, err := json.Marshal(r)
+		suite.NoError(err)
+
+		suite.gSvc.On("RollbackCert", mock.Anything, id, []byte(r.CertPassword)).
+			Return(gateway.ErrPreviousCertificateNotFound).Once()
+		req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/v1/certs/%s/certificate/rollback", id), bytes.NewReader(body))
+		rw := httptest.NewRecorder()
+		suite.handler.ServeHTTP(rw, req)
+
+		resp := rw.Result()
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+
+		suite.Equal(http.StatusNotFound, resp.StatusCode)
+	})
+
+	suite.Run("ok", func() {
+		id := uuid.New().String()
+		r := httphandler.RollbackCertJSONReq{
+			CertPassword: password.MustGenerate(64, 10, 10, false, false),
+		}
+
+		body, err := json.Marshal(r)
+		suite.NoError(err)
+
+		suite.gSvc.On("RollbackCert", mock.Anything, id, []byte(r.CertPassword)).Return(nil).Once()
+		req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/v1/certs/%s/certificate/rollback", id), bytes.NewReader(body))
+		rw := httptest.NewRecorder()
+		suite.handler.ServeHTTP(rw, req)
+
+		resp := rw.Result()
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+
+		suite.Equal(http.StatusOK, resp.StatusCode)
+	})
+}
+
+func (suite *HTTPHandlerTestSuite) TestExportCert() {
+	suite.Run("invalid request body", func() {
+		suite.HTTPStatusCode(suite.handler.ServeHTTP, http.MethodPost, fmt.Sprintf("/v1/certs/%s/certificate/export", uuid.New().String()), nil, http.StatusBadRequest)
+	})
+
+	suite.Run("invalid certificate password", func() {
+		id := uuid.New().String()
+		r := httphandler.ExportCertJSONReq{
+			CertPassword:   password.MustGenerate(64, 10, 10, false, false),
+			PKCS12Password: "eet",
+		}
+
+		body, err := json.Marshal(r)
+		suite.NoError(err)
+
+		suite.gSvc.On("ExportCert", mock.Anything, id, []byte(r.CertPassword), "eet").
+			Return(nil, gateway.ErrInvalidCertificatePassword).Once()
+		req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/v1/certs/%s/certificate/export", id), bytes.NewReader(body))
+		rw := httptest.NewRecorder()
+		suite.handler.ServeHTTP(rw, req)
+
+		resp := rw.Result()
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+
+		suite.Equal(http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	suite.Run("ok", func() {
+		id := uuid.New().String()
+		r := httphandler.ExportCertJSONReq{
+			CertPassword:   password.MustGenerate(64, 10, 10, false, false),
+			PKCS12Password: "eet",
+		}
+
+		body, err := json.Marshal(r)
+		suite.NoError(err)
+
+		suite.gSvc.On("ExportCert", mock.Anything, id, []byte(r.CertPassword), "eet").
+			Return([]byte("valid"), nil).Once()
+		req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/v1/certs/%s/certificate/export", id), bytes.NewReader(body))
+		rw := httptest.NewRecorder()
+		suite.handler.ServeHTTP(rw, req)
+
+		resp := rw.Result()
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+
+		suite.Equal(http.StatusOK, resp.StatusCode)
+
+		var exported httphandler.ExportCertResp
+		suite.NoError(json.NewDecoder(resp.Body).Decode(&exported))
+		suite.Equal(id, exported.CertID)
+		suite.Equal(base64.StdEncoding.EncodeToString([]byte("valid")), exported.PKCS12Data)
+	})
+}
+
 func (suite *HTTPHandlerTestSuite) TestDeleteCert() {
 	suite.Run("unavailable keystore", func() {
 		id := uuid.New().String()
@@ -1,6 +1,7 @@
 package httphandler
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
@@ -13,16 +14,48 @@ import (
 // ErrUnexpected is returned if unexpected error is raised.
 var ErrUnexpected = errors.New("unexpected error")
 
+// ErrMissingCredentials is returned if a SOAP request comes without the certificate ID and password.
+var ErrMissingCredentials = errors.New("certificate ID and password required in the basic authorization")
+
+// ClientPolicy configures how the clients are told apart when counting their failed password attempts.
+type ClientPolicy struct {
+	// Enable counts the failed password attempts of each client besides the ones of the certificate.
+	// The clients behind the same proxy or NAT share the address and so the lockout unless
+	// the proxy is trusted.
+	Enable bool
+	// TrustedProxies are the IP addresses or CIDR ranges of the proxies trusted to set the client
+	// address in the Header. The address of the connection is used for the others.
+	TrustedProxies []string
+	// Header is the header with the client address set by the trusted proxies.
+	Header string
+}
+
 // Handler is HTTP requests handler.
 type Handler struct {
 	gateway gateway.Service
+	clients ClientPolicy
 }
 
-// NewHandler returns an implementation of Handler.
-func NewHandler(g gateway.Service) *Handler {
+// NewHandler returns an implementation of Handler. The clients are told apart according to the ClientPolicy.
+func NewHandler(g gateway.Service, clients ClientPolicy) (*Handler, error) {
+	if err := gin.New().SetTrustedProxies(clients.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("set trusted proxies: %w", err)
+	}
+
 	return &Handler{
 		gateway: g,
+		clients: clients,
+	}, nil
+}
+
+// withClientAddr returns a copy of the request context carrying the address of the client
+// if the failed password attempts are counted per client.
+func (h *Handler) withClientAddr(c *gin.Context) context.Context {
+	if !h.clients.Enable {
+		return c
 	}
+
+	return gateway.WithClientAddr(c, c.ClientIP())
 }
 
 // HTTPHandler implements server.Handler.
@@ -30,19 +63,35 @@ func (h *Handler) HTTPHandler() http.Handler {
 	gin.SetMode(gin.ReleaseMode)
 
 	r := gin.New()
+	// the client can't choose its address by headers unless it connects through a trusted proxy
+	r.ForwardedByClientIP = h.clients.Header != ""
+	r.RemoteIPHeaders = []string{h.clients.Header}
+	_ = r.SetTrustedProxies(h.clients.TrustedProxies)
 
 	setValidators()
 	r.Use(loggingMiddleware)
 	r.Use(recoverMiddleware)
 
+	// the probes don't depend on the FSCR, so the gateway stays in rotation during its outages
+	r.GET("/healthz", h.healthz)
+	r.GET("/readyz", h.readyz)
+
 	v1 := r.Group("/v1")
 	{
 		v1.GET("/ping", h.ping)
+		v1.GET("/status", h.status)
 		v1.POST("/sale", h.sendSale)
+		v1.POST("/sale/codes", h.computeCodes)
+		v1.POST("/soap", h.sendSOAP)
 		v1.POST("/certs", h.storeCert)
 		v1.GET("/certs", h.listCertIDs)
 		v1.PUT("/certs/:cert_id/id", h.updateCertID)
 		v1.PUT("/certs/:cert_id/password", h.updateCertPassword)
+		v1.PUT("/certs/:cert_id/policy", h.updateCertPolicy)
+		v1.PUT("/certs/:cert_id/certificate", h.replaceCert)
+		v1.POST("/certs/:cert_id/certificate/rollback", h.rollbackCert)
+		v1.POST("/certs/:cert_id/certificate/export", h.exportCert)
+		v1.POST("/certs/:cert_id/sessions", h.openSession)
 		v1.DELETE("/certs/:cert_id", h.deleteCert)
 	}
 
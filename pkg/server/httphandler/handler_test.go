@@ -20,7 +20,9 @@ type HTTPHandlerTestSuite struct {
 func (suite *HTTPHandlerTestSuite) SetupSuite() {
 	log.Logger = zerolog.Nop()
 	suite.gSvc = new(mocks.Service)
-	suite.handler = httphandler.NewHandler(suite.gSvc).HTTPHandler()
+	h, err := httphandler.NewHandler(suite.gSvc, httphandler.ClientPolicy{Enable: true})
+	suite.Require().NoError(err)
+	suite.handler = h.HTTPHandler()
 }
 
 func (suite *HTTPHandlerTestSuite) TearDownSuite() {
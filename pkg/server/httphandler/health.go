@@ -2,6 +2,7 @@ package httphandler
 
 import (
 	"errors"
+	"net/http"
 
 	"github.com/chutommy/eetgateway/pkg/gateway"
 	"github.com/gin-gonic/gin"
@@ -21,6 +22,25 @@ func (h *Handler) ping(c *gin.Context) {
 		_ = c.Error(gateway.ErrKeystoreUnavailable)
 	}
 
-	code, resp := pingEETResp(taxAdmin, keyStore)
+	eetRoots, dsigRoots := h.gateway.TrustedRoots()
+	code, resp := pingEETResp(taxAdmin, keyStore, eetRoots, dsigRoots)
 	c.JSON(code, resp)
 }
+
+func (h *Handler) healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, &ProbeResp{Status: "alive"})
+}
+
+func (h *Handler) readyz(c *gin.Context) {
+	err := h.gateway.Ready(c)
+	if err != nil {
+		_ = c.Error(err)
+	}
+
+	code, resp := readinessResp(err)
+	c.JSON(code, resp)
+}
+
+func (h *Handler) status(c *gin.Context) {
+	c.JSON(http.StatusOK, dependenciesResp(h.gateway.Dependencies()))
+}
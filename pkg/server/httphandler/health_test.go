@@ -2,17 +2,29 @@ package httphandler_test
 
 import (
 	"net/http"
+	"time"
 
+	"github.com/chutommy/eetgateway/pkg/ca"
 	"github.com/chutommy/eetgateway/pkg/gateway"
 	"github.com/stretchr/testify/mock"
 )
 
 func (suite *HTTPHandlerTestSuite) TestPing() {
+	dsigRoots, err := ca.DSigRoots()
+	suite.Require().NoError(err)
+	suite.gSvc.On("TrustedRoots").Return(nil, dsigRoots)
+
 	suite.Run("ok", func() {
 		suite.gSvc.On("Ping", mock.Anything).Return(nil).Once()
 		suite.HTTPStatusCode(suite.handler.ServeHTTP, http.MethodGet, "/v1/ping", nil, http.StatusOK)
 	})
 
+	suite.Run("trusted roots", func() {
+		suite.gSvc.On("Ping", mock.Anything).Return(nil).Twice()
+		suite.HTTPBodyContains(suite.handler.ServeHTTP, http.MethodGet, "/v1/ping", nil, dsigRoots[0].SerialNumber.String())
+		suite.HTTPBodyContains(suite.handler.ServeHTTP, http.MethodGet, "/v1/ping", nil, `"purpose":"dsig"`)
+	})
+
 	suite.Run("fscr unavailable", func() {
 		suite.gSvc.On("Ping", mock.Anything).Return(gateway.ErrFSCRConnection).Once()
 		suite.HTTPStatusCode(suite.handler.ServeHTTP, http.MethodGet, "/v1/ping", nil, http.StatusServiceUnavailable)
@@ -23,3 +35,50 @@ func (suite *HTTPHandlerTestSuite) TestPing() {
 		suite.HTTPStatusCode(suite.handler.ServeHTTP, http.MethodGet, "/v1/ping", nil, http.StatusServiceUnavailable)
 	})
 }
+
+func (suite *HTTPHandlerTestSuite) TestHealthz() {
+	suite.HTTPStatusCode(suite.handler.ServeHTTP, http.MethodGet, "/healthz", nil, http.StatusOK)
+	suite.HTTPBodyContains(suite.handler.ServeHTTP, http.MethodGet, "/healthz", nil, `"status":"alive"`)
+}
+
+func (suite *HTTPHandlerTestSuite) TestReadyz() {
+	suite.Run("ready", func() {
+		suite.gSvc.On("Ready", mock.Anything).Return(nil).Once()
+		suite.HTTPStatusCode(suite.handler.ServeHTTP, http.MethodGet, "/readyz", nil, http.StatusOK)
+	})
+
+	suite.Run("keystore unavailable", func() {
+		suite.gSvc.On("Ready", mock.Anything).Return(gateway.ErrKeystoreUnavailable).Once()
+		suite.HTTPStatusCode(suite.handler.ServeHTTP, http.MethodGet, "/readyz", nil, http.StatusServiceUnavailable)
+	})
+
+	suite.Run("trust store not loaded", func() {
+		suite.gSvc.On("Ready", mock.Anything).Return(gateway.ErrTrustStoreEmpty).Once()
+		suite.HTTPBodyContains(suite.handler.ServeHTTP, http.MethodGet, "/readyz", nil, gateway.ErrTrustStoreEmpty.Error())
+	})
+}
+
+func (suite *HTTPHandlerTestSuite) TestStatus() {
+	checkedAt := time.Date(2021, 9, 27, 10, 39, 3, 0, time.UTC)
+	suite.gSvc.On("Dependencies").Return([]gateway.DependencyStatus{
+		{
+			Name:      gateway.FSCRDependency,
+			Err:       gateway.ErrFSCRConnection,
+			Latency:   1500 * time.Millisecond,
+			CheckedAt: checkedAt,
+		},
+		{
+			Name:        gateway.KeystoreDependency,
+			Latency:     2 * time.Millisecond,
+			CheckedAt:   checkedAt,
+			LastSuccess: checkedAt,
+		},
+	}).Times(3)
+
+	// the status endpoint isn't a probe, the FSCR outage doesn't fail it
+	suite.HTTPStatusCode(suite.handler.ServeHTTP, http.MethodGet, "/v1/status", nil, http.StatusOK)
+	suite.HTTPBodyContains(suite.handler.ServeHTTP, http.MethodGet, "/v1/status", nil,
+		`{"name":"fscr","status":"bad FSCR connection","latency_ms":1500,"checked_at":"2021-09-27T10:39:03Z"}`)
+	suite.HTTPBodyContains(suite.handler.ServeHTTP, http.MethodGet, "/v1/status", nil,
+		`{"name":"keystore","status":"online","latency_ms":2,"checked_at":"2021-09-27T10:39:03Z","last_success":"2021-09-27T10:39:03Z"}`)
+}
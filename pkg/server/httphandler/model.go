@@ -1,21 +1,58 @@
 package httphandler
 
 import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/xml"
 	"errors"
+	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/chutommy/eetgateway/pkg/eet"
 	"github.com/chutommy/eetgateway/pkg/gateway"
+	"github.com/chutommy/eetgateway/pkg/keystore"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
 )
 
 // PingEETResp is a response structure for HTTP pings.
 type PingEETResp struct {
-	EETGatewayStatus string `json:"eet_gateway"`
-	TaxAdminStatus   string `json:"tax_admin"`
-	KeystoreStatus   string `json:"keystore"`
+	EETGatewayStatus string       `json:"eet_gateway"`
+	TaxAdminStatus   string       `json:"tax_admin"`
+	KeystoreStatus   string       `json:"keystore"`
+	CARoots          []CARootResp `json:"ca_roots"`
 }
 
-func pingEETResp(taxAdmin error, keyStore error) (int, *PingEETResp) {
+// CARootResp is a response structure describing a trusted CA certificate.
+type CARootResp struct {
+	Purpose      string    `json:"purpose"`
+	Subject      string    `json:"subject"`
+	SerialNumber string    `json:"serial_number"`
+	NotBefore    time.Time `json:"not_before"`
+	NotAfter     time.Time `json:"not_after"`
+	Expired      bool      `json:"expired"`
+}
+
+func caRootResps(purpose string, certs []*x509.Certificate) []CARootResp {
+	now := time.Now()
+	resps := make([]CARootResp, 0, len(certs))
+	for _, c := range certs {
+		resps = append(resps, CARootResp{
+			Purpose:      purpose,
+			Subject:      c.Subject.String(),
+			SerialNumber: c.SerialNumber.String(),
+			NotBefore:    c.NotBefore,
+			NotAfter:     c.NotAfter,
+			Expired:      now.After(c.NotAfter),
+		})
+	}
+
+	return resps
+}
+
+func pingEETResp(taxAdmin error, keyStore error, eetRoots, dsigRoots []*x509.Certificate) (int, *PingEETResp) {
 	online := func(err error) string {
 		if err != nil {
 			return err.Error()
@@ -33,13 +70,73 @@ func pingEETResp(taxAdmin error, keyStore error) (int, *PingEETResp) {
 		EETGatewayStatus: "online", // is able to response
 		TaxAdminStatus:   online(taxAdmin),
 		KeystoreStatus:   online(keyStore),
+		CARoots:          append(caRootResps("eet", eetRoots), caRootResps("dsig", dsigRoots)...),
+	}
+}
+
+// ProbeResp is a response structure for the liveness and readiness probes.
+type ProbeResp struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+func readinessResp(err error) (int, *ProbeResp) {
+	if err != nil {
+		return http.StatusServiceUnavailable, &ProbeResp{
+			Status: "not ready",
+			Error:  err.Error(),
+		}
+	}
+
+	return http.StatusOK, &ProbeResp{Status: "ready"}
+}
+
+// DependenciesResp is a response structure for the statuses of the dependencies.
+type DependenciesResp struct {
+	Dependencies []DependencyResp `json:"dependencies"`
+}
+
+// DependencyResp is a response structure describing the last check of a dependency.
+type DependencyResp struct {
+	Name        string     `json:"name"`
+	Status      string     `json:"status"`
+	LatencyMS   float64    `json:"latency_ms"`
+	CheckedAt   time.Time  `json:"checked_at"`
+	LastSuccess *time.Time `json:"last_success,omitempty"`
+}
+
+func dependenciesResp(statuses []gateway.DependencyStatus) *DependenciesResp {
+	resps := make([]DependencyResp, 0, len(statuses))
+	for _, s := range statuses {
+		resp := DependencyResp{
+			Name:      s.Name,
+			Status:    "online",
+			LatencyMS: float64(s.Latency) / float64(time.Millisecond),
+			CheckedAt: s.CheckedAt,
+		}
+
+		if s.Err != nil {
+			resp.Status = s.Err.Error()
+		}
+
+		if !s.LastSuccess.IsZero() {
+			lastSuccess := s.LastSuccess
+			resp.LastSuccess = &lastSuccess
+		}
+
+		resps = append(resps, resp)
+	}
+
+	return &DependenciesResp{
+		Dependencies: resps,
 	}
 }
 
 // SendSaleReq is a binding request structure for sales.
 type SendSaleReq struct {
 	CertID       string `json:"cert_id,omitempty" binding:"required"`
-	CertPassword string `json:"cert_password,omitempty" binding:"required"`
+	CertPassword string `json:"cert_password,omitempty" binding:"required_without=SessionToken,excluded_with=SessionToken"`
+	SessionToken string `json:"session_token,omitempty" binding:"required_without=CertPassword"`
 
 	UUIDZpravy      eet.UUIDType   `json:"uuid_zpravy" binding:"omitempty,uuid_zpravy"`
 	DatOdesl        *eet.DateTime  `json:"dat_odesl,omitempty" binding:""`
@@ -68,6 +165,37 @@ type SendSaleReq struct {
 	Rezim           eet.RezimType  `json:"rezim" binding:"omitempty,rezim"`
 }
 
+// NewSendSaleReq returns a sale request with the default header values: a random message UUID,
+// the current sending time and the first sending of the sale in the regular mode.
+func NewSendSaleReq() *SendSaleReq {
+	dateTime := eet.DateTime(time.Now())
+	dateTime.Normalize()
+
+	return &SendSaleReq{
+		UUIDZpravy:   eet.UUIDType(uuid.New().String()),
+		DatOdesl:     &dateTime,
+		PrvniZaslani: true,
+		Overeni:      false,
+		Rezim:        0,
+	}
+}
+
+// ValidateSale validates the sale data of the request the same way as the HTTP handler does.
+// The certificate fields of the request aren't validated.
+func (req *SendSaleReq) ValidateSale() error {
+	setValidators()
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		return bindingErr(v.StructExcept(req, "CertID", "CertPassword", "SessionToken"))
+	}
+
+	return nil
+}
+
+// TrzbaType returns the TrzbaType of the sale request.
+func (req *SendSaleReq) TrzbaType() *eet.TrzbaType {
+	return sendSaleRequest(req)
+}
+
 func sendSaleRequest(req *SendSaleReq) *eet.TrzbaType {
 	return &eet.TrzbaType{
 		Hlavicka: eet.TrzbaHlavickaType{
@@ -122,7 +250,7 @@ type SendSaleResp struct {
 
 func sendSaleResponse(req *SendSaleReq, odpoved *eet.OdpovedType) *SendSaleResp {
 	certID := req.CertID
-	req.CertID, req.CertPassword = "", ""
+	req.CertID, req.CertPassword, req.SessionToken = "", "", ""
 
 	if (odpoved.Hlavicka.Datodmit != eet.DateTime{}) {
 		return &SendSaleResp{
@@ -147,12 +275,94 @@ func sendSaleResponse(req *SendSaleReq, odpoved *eet.OdpovedType) *SendSaleResp
 	}
 }
 
-// StoreCertReq is a binding request structure for storing certificates.
+// ComputeCodesResp is a response structure to the security codes requests.
+type ComputeCodesResp struct {
+	CertID string `json:"cert_id"`
+	PKP    string `json:"pkp"`
+	BKP    string `json:"bkp"`
+}
+
+func computeCodesResponse(certID string, codes *eet.TrzbaKontrolniKodyType) *ComputeCodesResp {
+	return &ComputeCodesResp{
+		CertID: certID,
+		PKP:    base64.StdEncoding.EncodeToString(codes.Pkp.PkpType),
+		BKP:    string(codes.Bkp.BkpType),
+	}
+}
+
+// OpenSessionURIReq is a URI binding request structure for opening sessions.
+type OpenSessionURIReq struct {
+	CertID string `uri:"cert_id" binding:"required"`
+}
+
+// OpenSessionJSONReq is a JSON binding request structure for opening sessions.
+type OpenSessionJSONReq struct {
+	CertPassword string `json:"cert_password" binding:"required"`
+}
+
+// OpenSessionResp is a response structure to opened sessions.
+type OpenSessionResp struct {
+	CertID       string    `json:"cert_id"`
+	SessionToken string    `json:"session_token"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// StoreCertReq is a binding request structure for storing certificates. The certificate is given
+// either as a PKCS#12 file or as a PEM encoded certificate and private key.
 type StoreCertReq struct {
-	CertID         string `json:"cert_id" binding:"required"`
-	CertPassword   string `json:"cert_password" binding:"required"`
-	PKCS12Data     string `json:"pkcs12_data" binding:"required,base64"`
-	PKCS12Password string `json:"pkcs12_password" binding:"required"`
+	CertID         string         `json:"cert_id" binding:"required"`
+	CertPassword   string         `json:"cert_password" binding:"required"`
+	PKCS12Data     string         `json:"pkcs12_data,omitempty" binding:"required_without=PEMCertificate,excluded_with=PEMCertificate,omitempty,base64"`
+	PKCS12Password string         `json:"pkcs12_password,omitempty" binding:"required_with=PKCS12Data"`
+	PEMCertificate string         `json:"pem_certificate,omitempty" binding:"required_without=PKCS12Data"`
+	PEMPrivateKey  string         `json:"pem_private_key,omitempty" binding:"required_with=PEMCertificate,excluded_with=PKCS12Data"`
+	Policy         *CertPolicyReq `json:"policy,omitempty" binding:"omitempty"`
+}
+
+// CertPolicyReq is a binding request structure for usage policies of certificates.
+type CertPolicyReq struct {
+	IDProvoz        []int         `json:"id_provoz,omitempty" binding:"omitempty,dive,id_provoz"`
+	IDPokl          []string      `json:"id_pokl,omitempty" binding:"omitempty,dive,required"`
+	DICPoverujiciho eet.CZDICType `json:"dic_poverujiciho,omitempty" binding:"omitempty,dic"`
+	ValidFrom       *time.Time    `json:"valid_from,omitempty"`
+	ValidTo         *time.Time    `json:"valid_to,omitempty"`
+}
+
+// Validate validates the usage policy the same way as the HTTP handler does.
+func (req *CertPolicyReq) Validate() error {
+	setValidators()
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		return bindingErr(v.Struct(req))
+	}
+
+	return nil
+}
+
+// Policy returns the usage policy of the request, nil if the request is nil.
+func (req *CertPolicyReq) Policy() *keystore.Policy {
+	return certPolicy(req)
+}
+
+func certPolicy(req *CertPolicyReq) *keystore.Policy {
+	if req == nil {
+		return nil
+	}
+
+	p := &keystore.Policy{
+		IDProvoz:        req.IDProvoz,
+		IDPokl:          req.IDPokl,
+		DICPoverujiciho: string(req.DICPoverujiciho),
+	}
+
+	if req.ValidFrom != nil {
+		p.ValidFrom = *req.ValidFrom
+	}
+
+	if req.ValidTo != nil {
+		p.ValidTo = *req.ValidTo
+	}
+
+	return p
 }
 
 // ListCertIDsReq is a binding request structure for listing certificate IDs.
@@ -187,6 +397,57 @@ type UpdateCertPasswordJSONReq struct {
 	NewPassword  string `json:"new_password" binding:"required,necsfield=CertPassword"`
 }
 
+// UpdateCertPolicyURIReq is a URI binding request structure for policy updates.
+type UpdateCertPolicyURIReq struct {
+	CertID string `uri:"cert_id" binding:"required"`
+}
+
+// UpdateCertPolicyJSONReq is a JSON binding request structure for policy updates.
+// A missing policy removes all restrictions.
+type UpdateCertPolicyJSONReq struct {
+	CertPassword string         `json:"cert_password" binding:"required"`
+	Policy       *CertPolicyReq `json:"policy,omitempty" binding:"omitempty"`
+}
+
+// ReplaceCertURIReq is a URI binding request structure for certificate replacements.
+type ReplaceCertURIReq struct {
+	CertID string `uri:"cert_id" binding:"required"`
+}
+
+// ReplaceCertJSONReq is a JSON binding request structure for certificate replacements.
+type ReplaceCertJSONReq struct {
+	CertPassword   string `json:"cert_password" binding:"required"`
+	PKCS12Data     string `json:"pkcs12_data" binding:"required,base64"`
+	PKCS12Password string `json:"pkcs12_password" binding:"required"`
+}
+
+// RollbackCertURIReq is a URI binding request structure for certificate rollbacks.
+type RollbackCertURIReq struct {
+	CertID string `uri:"cert_id" binding:"required"`
+}
+
+// RollbackCertJSONReq is a JSON binding request structure for certificate rollbacks.
+type RollbackCertJSONReq struct {
+	CertPassword string `json:"cert_password" binding:"required"`
+}
+
+// ExportCertURIReq is a URI binding request structure for certificate exports.
+type ExportCertURIReq struct {
+	CertID string `uri:"cert_id" binding:"required"`
+}
+
+// ExportCertJSONReq is a JSON binding request structure for certificate exports.
+type ExportCertJSONReq struct {
+	CertPassword   string `json:"cert_password" binding:"required"`
+	PKCS12Password string `json:"pkcs12_password" binding:"required"`
+}
+
+// ExportCertResp is a response structure to exported certificates.
+type ExportCertResp struct {
+	CertID     string `json:"cert_id"`
+	PKCS12Data string `json:"pkcs12_data"`
+}
+
 // DeleteCertReq is a binding request structure for deleting certificates.
 type DeleteCertReq struct {
 	CertID string `uri:"cert_id" binding:"required"`
@@ -203,31 +464,135 @@ func successCertResp(id string) *SuccessCertResp {
 
 // GatewayErrResp represents an error response structure returned from the EET Gateway API (not from the FSCR).
 type GatewayErrResp struct {
-	GatewayError string `json:"gateway_error" example:"keystore service unavailable"`
+	GatewayError string         `json:"gateway_error" example:"keystore service unavailable"`
+	FSCRFault    *FSCRFaultResp `json:"fscr_fault,omitempty"`
 } //@name GatewayErrorResponse
 
+// FSCRFaultResp is a response structure of the SOAP faults returned by the FSCR.
+type FSCRFaultResp struct {
+	FaultCode   string `json:"faultcode" example:"soapenv:Client"`
+	FaultString string `json:"faultstring" example:"Nespravny format zpravy"`
+	Detail      string `json:"detail,omitempty"`
+} //@name FSCRFaultResponse
+
+// soapFault represents a SOAP 1.1 envelope with a Fault element.
+type soapFault struct {
+	XMLName     xml.Name         `xml:"soapenv:Envelope"`
+	Namespace   string           `xml:"xmlns:soapenv,attr"`
+	FaultCode   string           `xml:"soapenv:Body>soapenv:Fault>faultcode"`
+	FaultString string           `xml:"soapenv:Body>soapenv:Fault>faultstring"`
+	Detail      *soapFaultDetail `xml:"soapenv:Body>soapenv:Fault>detail,omitempty"`
+}
+
+// soapFaultDetail is the raw XML content of the detail element of a SOAP fault.
+type soapFaultDetail struct {
+	Content string `xml:",innerxml"`
+}
+
+// soapFaultEnvelope returns the error response as a SOAP fault envelope. The faults of the FSCR are
+// kept, the other errors are faults of the client or the server depending on the status code.
+func soapFaultEnvelope(code int, resp *GatewayErrResp) ([]byte, error) {
+	fault := soapFault{
+		Namespace:   "http://schemas.xmlsoap.org/soap/envelope/",
+		FaultCode:   "soapenv:Server",
+		FaultString: resp.GatewayError,
+	}
+
+	if code < http.StatusInternalServerError {
+		fault.FaultCode = "soapenv:Client"
+	}
+
+	if f := resp.FSCRFault; f != nil {
+		fault.FaultCode, fault.FaultString = f.FaultCode, f.FaultString
+		if f.Detail != "" {
+			fault.Detail = &soapFaultDetail{Content: f.Detail}
+		}
+	}
+
+	env, err := xml.Marshal(fault)
+	if err != nil {
+		return nil, fmt.Errorf("xml marshal soap fault: %w", err)
+	}
+
+	return append([]byte(xml.Header), env...), nil
+}
+
 func gatewayErrResp(err error) (int, *GatewayErrResp) {
 	c, e := http.StatusInternalServerError, ErrUnexpected
 
 	switch {
 	case errors.Is(err, gateway.ErrCertificateNotFound):
 		c, e = http.StatusNotFound, gateway.ErrCertificateNotFound
+	case errors.Is(err, gateway.ErrPreviousCertificateNotFound):
+		c, e = http.StatusNotFound, gateway.ErrPreviousCertificateNotFound
 	case errors.Is(err, gateway.ErrInvalidCertificatePassword):
 		c, e = http.StatusUnauthorized, gateway.ErrInvalidCertificatePassword
+	case errors.Is(err, gateway.ErrInvalidSessionToken):
+		c, e = http.StatusUnauthorized, gateway.ErrInvalidSessionToken
 	case errors.Is(err, gateway.ErrIDAlreadyExists):
 		c, e = http.StatusConflict, gateway.ErrIDAlreadyExists
+	case errors.Is(err, gateway.ErrCertificateLocked):
+		c, e = http.StatusTooManyRequests, gateway.ErrCertificateLocked
+	case errors.Is(err, gateway.ErrTooManyAttempts):
+		c, e = http.StatusTooManyRequests, gateway.ErrTooManyAttempts
+	case errors.Is(err, gateway.ErrCertificateRevoked):
+		c, e = http.StatusForbidden, gateway.ErrCertificateRevoked
+	case errors.Is(err, gateway.ErrRevocationStatusUnknown):
+		c, e = http.StatusServiceUnavailable, gateway.ErrRevocationStatusUnknown
+	case errors.Is(err, gateway.ErrCertificatePolicy):
+		c, e = http.StatusForbidden, gateway.ErrCertificatePolicy
+	case errors.Is(err, gateway.ErrInvalidCertificatePolicy):
+		c, e = http.StatusBadRequest, gateway.ErrInvalidCertificatePolicy
 	case errors.Is(err, gateway.ErrInvalidTaxpayersCertificate):
 		c, e = http.StatusBadRequest, gateway.ErrInvalidTaxpayersCertificate
+	case errors.Is(err, gateway.ErrInvalidSOAPRequest):
+		c, e = http.StatusBadRequest, gateway.ErrInvalidSOAPRequest
+	case errors.Is(err, gateway.ErrInvalidSecurityCodes):
+		c, e = http.StatusBadRequest, gateway.ErrInvalidSecurityCodes
 	case errors.Is(err, gateway.ErrFSCRConnection):
 		c, e = http.StatusServiceUnavailable, gateway.ErrFSCRConnection
 	case errors.Is(err, gateway.ErrKeystoreUnavailable):
 		c, e = http.StatusServiceUnavailable, gateway.ErrKeystoreUnavailable
+	case errors.Is(err, gateway.ErrSignerUnavailable):
+		c, e = http.StatusServiceUnavailable, gateway.ErrSignerUnavailable
 	case errors.Is(err, gateway.ErrRequestBuild):
 		c, e = http.StatusInternalServerError, gateway.ErrRequestBuild
 	case errors.Is(err, gateway.ErrFSCRResponseParse):
 		c, e = http.StatusInternalServerError, gateway.ErrFSCRResponseParse
+	case errors.Is(err, gateway.ErrFSCRFault):
+		var fault *eet.SOAPFault
+		if errors.As(err, &fault) {
+			return http.StatusBadGateway, &GatewayErrResp{
+				GatewayError: gateway.ErrFSCRFault.Error(),
+				FSCRFault: &FSCRFaultResp{
+					FaultCode:   fault.Code,
+					FaultString: fault.String,
+					Detail:      fault.Detail,
+				},
+			}
+		}
+
+		c, e = http.StatusBadGateway, gateway.ErrFSCRFault
+	case errors.Is(err, gateway.ErrFSCRResponseMismatch):
+		c, e = http.StatusInternalServerError, gateway.ErrFSCRResponseMismatch
+	case errors.Is(err, gateway.ErrFSCRResponseSignature):
+		c, e = http.StatusInternalServerError, gateway.ErrFSCRResponseSignature
+	case errors.Is(err, gateway.ErrFSCRSigningTime):
+		c, e = http.StatusInternalServerError, gateway.ErrFSCRSigningTime
+	case errors.Is(err, gateway.ErrFSCRCertificateUntrusted):
+		c, e = http.StatusInternalServerError, gateway.ErrFSCRCertificateUntrusted
+	case errors.Is(err, gateway.ErrFSCRCertificateKeyUsage):
+		c, e = http.StatusInternalServerError, gateway.ErrFSCRCertificateKeyUsage
+	case errors.Is(err, gateway.ErrFSCRCertificateExpired):
+		c, e = http.StatusInternalServerError, gateway.ErrFSCRCertificateExpired
+	case errors.Is(err, gateway.ErrFSCRCertificateRevoked):
+		c, e = http.StatusInternalServerError, gateway.ErrFSCRCertificateRevoked
+	case errors.Is(err, gateway.ErrFSCRRevocationStatusUnknown):
+		c, e = http.StatusServiceUnavailable, gateway.ErrFSCRRevocationStatusUnknown
 	case errors.Is(err, gateway.ErrFSCRResponseVerify):
 		c, e = http.StatusInternalServerError, gateway.ErrFSCRResponseVerify
+	case errors.Is(err, gateway.ErrCertificateExport):
+		c, e = http.StatusInternalServerError, gateway.ErrCertificateExport
 	case errors.Is(err, gateway.ErrCertificateParse):
 		c, e = http.StatusInternalServerError, gateway.ErrCertificateParse
 	case errors.Is(err, gateway.ErrKeystoreUnexpected):
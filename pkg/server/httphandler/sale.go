@@ -2,37 +2,51 @@ package httphandler
 
 import (
 	"net/http"
-	"time"
 
 	"github.com/chutommy/eetgateway/pkg/eet"
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 )
 
 func (h *Handler) sendSale(c *gin.Context) {
-	// default request
-	dateTime := eet.DateTime(time.Now())
-	dateTime.Normalize()
-	req := &SendSaleReq{
-		UUIDZpravy:   eet.UUIDType(uuid.New().String()),
-		DatOdesl:     &dateTime,
-		PrvniZaslani: true,
-		Overeni:      false,
-		Rezim:        0,
+	req, ok := bindSendSaleReq(c)
+	if !ok {
+		return
 	}
 
-	// bind to default
-	if err := c.ShouldBindJSON(&req); err != nil {
-		err = bindingErr(err)
-		c.JSON(http.StatusBadRequest, GatewayErrResp{err.Error()})
+	ctx := h.withClientAddr(c)
+	var odpoved *eet.OdpovedType
+	var err error
+	if req.SessionToken != "" {
+		odpoved, err = h.gateway.SendSaleWithSession(ctx, req.CertID, req.SessionToken, sendSaleRequest(req))
+	} else {
+		odpoved, err = h.gateway.SendSale(ctx, req.CertID, []byte(req.CertPassword), sendSaleRequest(req))
+	}
+
+	if err != nil {
+		code, resp := gatewayErrResp(err)
+		c.JSON(code, resp)
 		_ = c.Error(err)
 		return
 	}
 
-	req.DatOdesl.Normalize()
-	req.DatTrzby.Normalize()
+	c.JSON(http.StatusOK, sendSaleResponse(req, odpoved))
+}
+
+func (h *Handler) computeCodes(c *gin.Context) {
+	req, ok := bindSendSaleReq(c)
+	if !ok {
+		return
+	}
+
+	ctx := h.withClientAddr(c)
+	var codes *eet.TrzbaKontrolniKodyType
+	var err error
+	if req.SessionToken != "" {
+		codes, err = h.gateway.ComputeCodesWithSession(ctx, req.CertID, req.SessionToken, sendSaleRequest(req))
+	} else {
+		codes, err = h.gateway.ComputeCodes(ctx, req.CertID, []byte(req.CertPassword), sendSaleRequest(req))
+	}
 
-	odpoved, err := h.gateway.SendSale(c, req.CertID, []byte(req.CertPassword), sendSaleRequest(req))
 	if err != nil {
 		code, resp := gatewayErrResp(err)
 		c.JSON(code, resp)
@@ -40,5 +54,24 @@ func (h *Handler) sendSale(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, sendSaleResponse(req, odpoved))
+	c.JSON(http.StatusOK, computeCodesResponse(req.CertID, codes))
+}
+
+// bindSendSaleReq binds the sale request with the default header values. A bad request is
+// responded and false is returned if the binding fails.
+func bindSendSaleReq(c *gin.Context) (*SendSaleReq, bool) {
+	req := NewSendSaleReq()
+
+	// bind to default
+	if err := c.ShouldBindJSON(&req); err != nil {
+		err = bindingErr(err)
+		c.JSON(http.StatusBadRequest, GatewayErrResp{GatewayError: err.Error()})
+		_ = c.Error(err)
+		return nil, false
+	}
+
+	req.DatOdesl.Normalize()
+	req.DatTrzby.Normalize()
+
+	return req, true
 }
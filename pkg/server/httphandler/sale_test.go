@@ -2,6 +2,7 @@ package httphandler_test
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -13,6 +14,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/sethvargo/go-password/password"
 	"github.com/stretchr/testify/mock"
+	"go.uber.org/multierr"
 )
 
 func (suite *HTTPHandlerTestSuite) TestSendSale() {
@@ -55,6 +57,205 @@ func (suite *HTTPHandlerTestSuite) TestSendSale() {
 		suite.Equal(http.StatusServiceUnavailable, resp.StatusCode)
 	})
 
+	suite.Run("locked certificate", func() {
+		dat := eet.DateTime(time.Now())
+		dat.Normalize()
+		r := httphandler.SendSaleReq{
+			CertID:       uuid.New().String(),
+			CertPassword: password.MustGenerate(64, 10, 10, false, false),
+			DICPopl:      "CZ683555118",
+			IDProvoz:     11,
+			IDPokl:       "ABC",
+			PoradCis:     "123",
+			DatTrzby:     &dat,
+			CelkTrzba:    100,
+		}
+
+		b, err := json.Marshal(r)
+		suite.NoError(err)
+
+		// fix poorly marshalled eet.CastkaType fields
+		body := strings.Replace(string(b), "\"100.00\"", "100", 1)
+		body = strings.ReplaceAll(body, "\"0.00\"", "0")
+
+		suite.gSvc.On("SendSale", mock.Anything, r.CertID, []byte(r.CertPassword), mock.Anything).
+			Return(nil, gateway.ErrCertificateLocked).Once()
+		req := httptest.NewRequest(http.MethodPost, "/v1/sale", strings.NewReader(body))
+		rw := httptest.NewRecorder()
+		suite.handler.ServeHTTP(rw, req)
+
+		resp := rw.Result()
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+
+		suite.Equal(http.StatusTooManyRequests, resp.StatusCode)
+	})
+
+	suite.Run("revoked certificate", func() {
+		dat := eet.DateTime(time.Now())
+		dat.Normalize()
+		r := httphandler.SendSaleReq{
+			CertID:       uuid.New().String(),
+			CertPassword: password.MustGenerate(64, 10, 10, false, false),
+			DICPopl:      "CZ683555118",
+			IDProvoz:     11,
+			IDPokl:       "ABC",
+			PoradCis:     "123",
+			DatTrzby:     &dat,
+			CelkTrzba:    100,
+		}
+
+		b, err := json.Marshal(r)
+		suite.NoError(err)
+
+		// fix poorly marshalled eet.CastkaType fields
+		body := strings.Replace(string(b), "\"100.00\"", "100", 1)
+		body = strings.ReplaceAll(body, "\"0.00\"", "0")
+
+		suite.gSvc.On("SendSale", mock.Anything, r.CertID, []byte(r.CertPassword), mock.Anything).
+			Return(nil, gateway.ErrCertificateRevoked).Once()
+		req := httptest.NewRequest(http.MethodPost, "/v1/sale", strings.NewReader(body))
+		rw := httptest.NewRecorder()
+		suite.handler.ServeHTTP(rw, req)
+
+		resp := rw.Result()
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+
+		suite.Equal(http.StatusForbidden, resp.StatusCode)
+	})
+
+	suite.Run("untrusted FSCR signing certificate", func() {
+		dat := eet.DateTime(time.Now())
+		dat.Normalize()
+		r := httphandler.SendSaleReq{
+			CertID:       uuid.New().String(),
+			CertPassword: password.MustGenerate(64, 10, 10, false, false),
+			DICPopl:      "CZ683555118",
+			IDProvoz:     11,
+			IDPokl:       "ABC",
+			PoradCis:     "123",
+			DatTrzby:     &dat,
+			CelkTrzba:    100,
+		}
+
+		b, err := json.Marshal(r)
+		suite.NoError(err)
+
+		// fix poorly marshalled eet.CastkaType fields
+		body := strings.Replace(string(b), "\"100.00\"", "100", 1)
+		body = strings.ReplaceAll(body, "\"0.00\"", "0")
+
+		suite.gSvc.On("SendSale", mock.Anything, r.CertID, []byte(r.CertPassword), mock.Anything).
+			Return(nil, gateway.ErrFSCRCertificateUntrusted).Once()
+		req := httptest.NewRequest(http.MethodPost, "/v1/sale", strings.NewReader(body))
+		rw := httptest.NewRecorder()
+		suite.handler.ServeHTTP(rw, req)
+
+		resp := rw.Result()
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+
+		suite.Equal(http.StatusInternalServerError, resp.StatusCode)
+	})
+
+	suite.Run("FSCR fault", func() {
+		dat := eet.DateTime(time.Now())
+		dat.Normalize()
+		r := httphandler.SendSaleReq{
+			CertID:       uuid.New().String(),
+			CertPassword: password.MustGenerate(64, 10, 10, false, false),
+			DICPopl:      "CZ683555118",
+			IDProvoz:     11,
+			IDPokl:       "ABC",
+			PoradCis:     "123",
+			DatTrzby:     &dat,
+			CelkTrzba:    100,
+		}
+
+		b, err := json.Marshal(r)
+		suite.NoError(err)
+
+		// fix poorly marshalled eet.CastkaType fields
+		body := strings.Replace(string(b), "\"100.00\"", "100", 1)
+		body = strings.ReplaceAll(body, "\"0.00\"", "0")
+
+		suite.gSvc.On("SendSale", mock.Anything, r.CertID, []byte(r.CertPassword), mock.Anything).
+			Return(nil, multierr.Append(&eet.SOAPFault{
+				Code:   "soapenv:Client",
+				String: "Nespravny format zpravy",
+			}, gateway.ErrFSCRFault)).Once()
+		req := httptest.NewRequest(http.MethodPost, "/v1/sale", strings.NewReader(body))
+		rw := httptest.NewRecorder()
+		suite.handler.ServeHTTP(rw, req)
+
+		resp := rw.Result()
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+
+		suite.Equal(http.StatusBadGateway, resp.StatusCode)
+
+		var errResp httphandler.GatewayErrResp
+		suite.NoError(json.NewDecoder(resp.Body).Decode(&errResp))
+		suite.Equal(gateway.ErrFSCRFault.Error(), errResp.GatewayError)
+		suite.Require().NotNil(errResp.FSCRFault)
+		suite.Equal("soapenv:Client", errResp.FSCRFault.FaultCode)
+		suite.Equal("Nespravny format zpravy", errResp.FSCRFault.FaultString)
+	})
+
+	suite.Run("both password and session token", func() {
+		body := fmt.Sprintf(`{"cert_id":"%s","cert_password":"secret","session_token":"token","dic_popl":"CZ683555118","id_provoz":11,"id_pokl":"ABC","porad_cis":"123","dat_trzby":"2019-08-11T15:36:25+02:00","celk_trzba":100}`, uuid.New().String())
+		req := httptest.NewRequest(http.MethodPost, "/v1/sale", strings.NewReader(body))
+		rw := httptest.NewRecorder()
+		suite.handler.ServeHTTP(rw, req)
+
+		resp := rw.Result()
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+
+		suite.Equal(http.StatusBadRequest, resp.StatusCode)
+	})
+
+	suite.Run("invalid session token", func() {
+		dat := eet.DateTime(time.Now())
+		dat.Normalize()
+		r := httphandler.SendSaleReq{
+			CertID:       uuid.New().String(),
+			SessionToken: "token",
+			DICPopl:      "CZ683555118",
+			IDProvoz:     11,
+			IDPokl:       "ABC",
+			PoradCis:     "123",
+			DatTrzby:     &dat,
+			CelkTrzba:    100,
+		}
+
+		b, err := json.Marshal(r)
+		suite.NoError(err)
+
+		// fix poorly marshalled eet.CastkaType fields
+		body := strings.Replace(string(b), "\"100.00\"", "100", 1)
+		body = strings.ReplaceAll(body, "\"0.00\"", "0")
+
+		suite.gSvc.On("SendSaleWithSession", mock.Anything, r.CertID, r.SessionToken, mock.Anything).
+			Return(nil, gateway.ErrInvalidSessionToken).Once()
+		req := httptest.NewRequest(http.MethodPost, "/v1/sale", strings.NewReader(body))
+		rw := httptest.NewRecorder()
+		suite.handler.ServeHTTP(rw, req)
+
+		resp := rw.Result()
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+
+		suite.Equal(http.StatusUnauthorized, resp.StatusCode)
+	})
+
 	suite.Run("ok", func() {
 		dat := eet.DateTime(time.Now().Truncate(time.Second))
 		r := httphandler.SendSaleReq{
@@ -91,3 +292,73 @@ func (suite *HTTPHandlerTestSuite) TestSendSale() {
 		suite.Equal(http.StatusOK, resp.StatusCode)
 	})
 }
+
+func (suite *HTTPHandlerTestSuite) TestComputeCodes() {
+	suite.Run("invalid request", func() { // no request body
+		suite.HTTPStatusCode(suite.handler.ServeHTTP, http.MethodPost, "/v1/sale/codes", nil, 400)
+	})
+
+	suite.Run("policy violation", func() {
+		body := fmt.Sprintf(`{"cert_id":"%s","cert_password":"secret","dic_popl":"CZ683555118","id_provoz":11,"id_pokl":"ABC","porad_cis":"123","dat_trzby":"2019-08-11T15:36:25+02:00","celk_trzba":100}`, uuid.New().String())
+
+		suite.gSvc.On("ComputeCodes", mock.Anything, mock.Anything, []byte("secret"), mock.Anything).
+			Return(nil, gateway.ErrCertificatePolicy).Once()
+		req := httptest.NewRequest(http.MethodPost, "/v1/sale/codes", strings.NewReader(body))
+		rw := httptest.NewRecorder()
+		suite.handler.ServeHTTP(rw, req)
+
+		resp := rw.Result()
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+
+		suite.Equal(http.StatusForbidden, resp.StatusCode)
+	})
+
+	suite.Run("invalid session token", func() {
+		certID := uuid.New().String()
+		body := fmt.Sprintf(`{"cert_id":"%s","session_token":"token","dic_popl":"CZ683555118","id_provoz":11,"id_pokl":"ABC","porad_cis":"123","dat_trzby":"2019-08-11T15:36:25+02:00","celk_trzba":100}`, certID)
+
+		suite.gSvc.On("ComputeCodesWithSession", mock.Anything, certID, "token", mock.Anything).
+			Return(nil, gateway.ErrInvalidSessionToken).Once()
+		req := httptest.NewRequest(http.MethodPost, "/v1/sale/codes", strings.NewReader(body))
+		rw := httptest.NewRecorder()
+		suite.handler.ServeHTTP(rw, req)
+
+		resp := rw.Result()
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+
+		suite.Equal(http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	suite.Run("ok", func() {
+		certID := uuid.New().String()
+		body := fmt.Sprintf(`{"cert_id":"%s","cert_password":"secret","dic_popl":"CZ683555118","id_provoz":11,"id_pokl":"ABC","porad_cis":"123","dat_trzby":"2019-08-11T15:36:25+02:00","celk_trzba":100}`, certID)
+
+		codes := &eet.TrzbaKontrolniKodyType{
+			Pkp: eet.PkpElementType{PkpType: []byte{0x01, 0x02, 0x03}},
+			Bkp: eet.BkpElementType{BkpType: "aba7eb19-7ad8d753-60ed57b3-9ac9957e-c192030b"},
+		}
+		suite.gSvc.On("ComputeCodes", mock.Anything, certID, []byte("secret"), mock.MatchedBy(func(trzba *eet.TrzbaType) bool {
+			return trzba.Data.Idpokl == "ABC" && trzba.Data.Celktrzba == 100
+		})).Return(codes, nil).Once()
+		req := httptest.NewRequest(http.MethodPost, "/v1/sale/codes", strings.NewReader(body))
+		rw := httptest.NewRecorder()
+		suite.handler.ServeHTTP(rw, req)
+
+		resp := rw.Result()
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+
+		suite.Equal(http.StatusOK, resp.StatusCode)
+
+		var codesResp httphandler.ComputeCodesResp
+		suite.NoError(json.NewDecoder(resp.Body).Decode(&codesResp))
+		suite.Equal(certID, codesResp.CertID)
+		suite.Equal("AQID", codesResp.PKP)
+		suite.Equal("aba7eb19-7ad8d753-60ed57b3-9ac9957e-c192030b", codesResp.BKP)
+	})
+}
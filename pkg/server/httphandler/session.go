@@ -0,0 +1,40 @@
+package httphandler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) openSession(c *gin.Context) {
+	reqURI := &OpenSessionURIReq{}
+	if err := c.ShouldBindUri(&reqURI); err != nil {
+		err = bindingErr(err)
+		c.JSON(http.StatusBadRequest, GatewayErrResp{GatewayError: err.Error()})
+		_ = c.Error(err)
+		return
+	}
+
+	reqJSON := &OpenSessionJSONReq{}
+	if err := c.ShouldBindJSON(&reqJSON); err != nil {
+		err = bindingErr(err)
+		c.JSON(http.StatusBadRequest, GatewayErrResp{GatewayError: err.Error()})
+		_ = c.Error(err)
+		return
+	}
+
+	ctx := h.withClientAddr(c)
+	token, expiresAt, err := h.gateway.OpenSession(ctx, reqURI.CertID, []byte(reqJSON.CertPassword))
+	if err != nil {
+		code, resp := gatewayErrResp(err)
+		c.JSON(code, resp)
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, &OpenSessionResp{
+		CertID:       reqURI.CertID,
+		SessionToken: token,
+		ExpiresAt:    expiresAt,
+	})
+}
@@ -0,0 +1,186 @@
+package httphandler_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/chutommy/eetgateway/pkg/gateway"
+	mocks "github.com/chutommy/eetgateway/pkg/mocks/gateway"
+	"github.com/chutommy/eetgateway/pkg/server/httphandler"
+	"github.com/google/uuid"
+	"github.com/sethvargo/go-password/password"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func (suite *HTTPHandlerTestSuite) TestOpenSession() {
+	suite.Run("invalid request body", func() {
+		suite.HTTPStatusCode(suite.handler.ServeHTTP, http.MethodPost, fmt.Sprintf("/v1/certs/%s/sessions", uuid.New().String()), nil, http.StatusBadRequest)
+	})
+
+	suite.Run("invalid password", func() {
+		id := uuid.New().String()
+		r := httphandler.OpenSessionJSONReq{
+			CertPassword: password.MustGenerate(64, 10, 10, false, false),
+		}
+
+		body, err := json.Marshal(r)
+		suite.NoError(err)
+
+		suite.gSvc.On("OpenSession", mock.Anything, id, []byte(r.CertPassword)).
+			Return("", time.Time{}, gateway.ErrInvalidCertificatePassword).Once()
+		req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/v1/certs/%s/sessions", id), bytes.NewReader(body))
+		rw := httptest.NewRecorder()
+		suite.handler.ServeHTTP(rw, req)
+
+		resp := rw.Result()
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+
+		suite.Equal(http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	suite.Run("ok", func() {
+		id := uuid.New().String()
+		r := httphandler.OpenSessionJSONReq{
+			CertPassword: password.MustGenerate(64, 10, 10, false, false),
+		}
+
+		body, err := json.Marshal(r)
+		suite.NoError(err)
+
+		expiresAt := time.Now().Add(time.Minute).Truncate(time.Second)
+		suite.gSvc.On("OpenSession", mock.Anything, id, []byte(r.CertPassword)).
+			Return("token", expiresAt, nil).Once()
+		req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/v1/certs/%s/sessions", id), bytes.NewReader(body))
+		rw := httptest.NewRecorder()
+		suite.handler.ServeHTTP(rw, req)
+
+		resp := rw.Result()
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+
+		suite.Equal(http.StatusOK, resp.StatusCode)
+
+		var sessionResp httphandler.OpenSessionResp
+		suite.NoError(json.NewDecoder(resp.Body).Decode(&sessionResp))
+		suite.Equal("token", sessionResp.SessionToken)
+		suite.True(expiresAt.Equal(sessionResp.ExpiresAt))
+	})
+	suite.Run("forwarded client address ignored", func() {
+		id := uuid.New().String()
+		r := httphandler.OpenSessionJSONReq{
+			CertPassword: password.MustGenerate(64, 10, 10, false, false),
+		}
+
+		body, err := json.Marshal(r)
+		suite.NoError(err)
+
+		clientAddr := mock.MatchedBy(func(ctx context.Context) bool {
+			return gateway.ClientAddr(ctx) == "192.0.2.1"
+		})
+		suite.gSvc.On("OpenSession", clientAddr, id, []byte(r.CertPassword)).
+			Return("", time.Time{}, gateway.ErrInvalidCertificatePassword).Once()
+		req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/v1/certs/%s/sessions", id), bytes.NewReader(body))
+		req.RemoteAddr = "192.0.2.1:1234"
+		req.Header.Set("X-Forwarded-For", "198.51.100.7")
+		rw := httptest.NewRecorder()
+		suite.handler.ServeHTTP(rw, req)
+
+		resp := rw.Result()
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+
+		suite.Equal(http.StatusUnauthorized, resp.StatusCode)
+	})
+}
+
+func TestClientPolicy(t *testing.T) {
+	proxied := func(h http.Handler, id, pwd, forwardedFor string) int {
+		body, err := json.Marshal(httphandler.OpenSessionJSONReq{CertPassword: pwd})
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/v1/certs/%s/sessions", id), bytes.NewReader(body))
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, req)
+
+		return rw.Code
+	}
+
+	withClient := func(addr string) interface{} {
+		return mock.MatchedBy(func(ctx context.Context) bool {
+			return gateway.ClientAddr(ctx) == addr
+		})
+	}
+
+	t.Run("clients behind a trusted proxy", func(t *testing.T) {
+		gSvc := new(mocks.Service)
+		h, err := httphandler.NewHandler(gSvc, httphandler.ClientPolicy{
+			Enable:         true,
+			TrustedProxies: []string{"10.0.0.0/8"},
+			Header:         "X-Forwarded-For",
+		})
+		require.NoError(t, err)
+
+		id := uuid.New().String()
+		gSvc.On("OpenSession", withClient("192.0.2.1"), id, []byte("guess")).
+			Return("", time.Time{}, gateway.ErrCertificateLocked).Once()
+		gSvc.On("OpenSession", withClient("192.0.2.2"), id, []byte("secret")).
+			Return("token", time.Now().Add(time.Minute), nil).Once()
+
+		// the lockout of one client doesn't affect the other one behind the same proxy
+		require.Equal(t, http.StatusTooManyRequests, proxied(h.HTTPHandler(), id, "guess", "192.0.2.1"))
+		require.Equal(t, http.StatusOK, proxied(h.HTTPHandler(), id, "secret", "192.0.2.2"))
+
+		gSvc.AssertExpectations(t)
+	})
+
+	t.Run("untrusted proxy", func(t *testing.T) {
+		gSvc := new(mocks.Service)
+		h, err := httphandler.NewHandler(gSvc, httphandler.ClientPolicy{
+			Enable: true,
+			Header: "X-Forwarded-For",
+		})
+		require.NoError(t, err)
+
+		id := uuid.New().String()
+		gSvc.On("OpenSession", withClient("10.0.0.1"), id, []byte("secret")).
+			Return("token", time.Now().Add(time.Minute), nil).Once()
+
+		require.Equal(t, http.StatusOK, proxied(h.HTTPHandler(), id, "secret", "192.0.2.1"))
+
+		gSvc.AssertExpectations(t)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		gSvc := new(mocks.Service)
+		h, err := httphandler.NewHandler(gSvc, httphandler.ClientPolicy{})
+		require.NoError(t, err)
+
+		id := uuid.New().String()
+		gSvc.On("OpenSession", withClient(""), id, []byte("secret")).
+			Return("token", time.Now().Add(time.Minute), nil).Once()
+
+		require.Equal(t, http.StatusOK, proxied(h.HTTPHandler(), id, "secret", "192.0.2.1"))
+
+		gSvc.AssertExpectations(t)
+	})
+
+	t.Run("invalid trusted proxy", func(t *testing.T) {
+		_, err := httphandler.NewHandler(new(mocks.Service), httphandler.ClientPolicy{
+			TrustedProxies: []string{"proxy"},
+		})
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,56 @@
+package httphandler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// soapContentType is the content type of SOAP 1.1 messages.
+const soapContentType = "text/xml; charset=utf-8"
+
+// sendSOAP passes a Trzba element or a whole SOAP request envelope through the gateway to the FSCR
+// and responds with the verified SOAP response envelope. The certificate ID and password are given
+// by the basic authorization, so legacy POS software only needs to change the URL of the FSCR.
+func (h *Handler) sendSOAP(c *gin.Context) {
+	certID, certPassword, ok := c.Request.BasicAuth()
+	if !ok || certID == "" {
+		soapFaultResponse(c, http.StatusUnauthorized, &GatewayErrResp{GatewayError: ErrMissingCredentials.Error()})
+		_ = c.Error(ErrMissingCredentials)
+		return
+	}
+
+	req, err := c.GetRawData()
+	if err != nil {
+		soapFaultResponse(c, http.StatusBadRequest, &GatewayErrResp{GatewayError: err.Error()})
+		_ = c.Error(err)
+		return
+	}
+
+	ctx := h.withClientAddr(c)
+	resp, err := h.gateway.SendSOAP(ctx, certID, []byte(certPassword), req)
+	if err != nil {
+		code, resp := gatewayErrResp(err)
+		soapFaultResponse(c, code, resp)
+		_ = c.Error(err)
+		return
+	}
+
+	c.Data(http.StatusOK, soapContentType, resp)
+}
+
+// soapFaultResponse responds with the error as a SOAP fault. The faults of the FSCR are passed as they are.
+func soapFaultResponse(c *gin.Context, code int, resp *GatewayErrResp) {
+	if code == http.StatusUnauthorized {
+		c.Header("WWW-Authenticate", `Basic realm="EET Gateway"`)
+	}
+
+	env, err := soapFaultEnvelope(code, resp)
+	if err != nil {
+		c.Status(http.StatusInternalServerError)
+		_ = c.Error(err)
+		return
+	}
+
+	c.Data(code, soapContentType, env)
+}
@@ -0,0 +1,136 @@
+package httphandler_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/chutommy/eetgateway/pkg/eet"
+	"github.com/chutommy/eetgateway/pkg/gateway"
+	"github.com/google/uuid"
+	"github.com/sethvargo/go-password/password"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/multierr"
+)
+
+type soapFaultEnvelope struct {
+	Fault struct {
+		FaultCode   string `xml:"faultcode"`
+		FaultString string `xml:"faultstring"`
+		Detail      struct {
+			Content string `xml:",innerxml"`
+		} `xml:"detail"`
+	} `xml:"Body>Fault"`
+}
+
+func (suite *HTTPHandlerTestSuite) TestSendSOAP() {
+	trzba := []byte(`<Trzba xmlns="http://fs.mfcr.cz/eet/schema/v3"></Trzba>`)
+
+	sendSOAP := func(id, pwd string, withAuth bool) *http.Response {
+		req := httptest.NewRequest(http.MethodPost, "/v1/soap", bytes.NewReader(trzba))
+		req.Header.Set("Content-Type", "text/xml; charset=utf-8")
+		if withAuth {
+			req.SetBasicAuth(id, pwd)
+		}
+
+		rw := httptest.NewRecorder()
+		suite.handler.ServeHTTP(rw, req)
+
+		return rw.Result()
+	}
+
+	parseFault := func(resp *http.Response) *soapFaultEnvelope {
+		var env soapFaultEnvelope
+		suite.NoError(xml.NewDecoder(resp.Body).Decode(&env))
+		return &env
+	}
+
+	suite.Run("missing credentials", func() {
+		resp := sendSOAP("", "", false)
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+
+		suite.Equal(http.StatusUnauthorized, resp.StatusCode)
+		suite.NotEmpty(resp.Header.Get("WWW-Authenticate"))
+		suite.Equal("soapenv:Client", parseFault(resp).Fault.FaultCode)
+	})
+
+	suite.Run("invalid security codes", func() {
+		id := uuid.New().String()
+		pwd := password.MustGenerate(64, 10, 10, false, false)
+
+		suite.gSvc.On("SendSOAP", mock.Anything, id, []byte(pwd), trzba).
+			Return(nil, gateway.ErrInvalidSecurityCodes).Once()
+		resp := sendSOAP(id, pwd, true)
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+
+		suite.Equal(http.StatusBadRequest, resp.StatusCode)
+		env := parseFault(resp)
+		suite.Equal("soapenv:Client", env.Fault.FaultCode)
+		suite.Equal(gateway.ErrInvalidSecurityCodes.Error(), env.Fault.FaultString)
+	})
+
+	suite.Run("FSCR unavailable", func() {
+		id := uuid.New().String()
+		pwd := password.MustGenerate(64, 10, 10, false, false)
+
+		suite.gSvc.On("SendSOAP", mock.Anything, id, []byte(pwd), trzba).
+			Return(nil, gateway.ErrFSCRConnection).Once()
+		resp := sendSOAP(id, pwd, true)
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+
+		suite.Equal(http.StatusServiceUnavailable, resp.StatusCode)
+		suite.Equal("soapenv:Server", parseFault(resp).Fault.FaultCode)
+	})
+
+	suite.Run("FSCR fault", func() {
+		id := uuid.New().String()
+		pwd := password.MustGenerate(64, 10, 10, false, false)
+
+		fault := &eet.SOAPFault{
+			Code:   "soapenv:Client",
+			String: "Nespravny format zpravy",
+			Detail: "<chyba>cvc-complex-type.4</chyba>",
+		}
+		suite.gSvc.On("SendSOAP", mock.Anything, id, []byte(pwd), trzba).
+			Return(nil, multierr.Append(fault, gateway.ErrFSCRFault)).Once()
+		resp := sendSOAP(id, pwd, true)
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+
+		suite.Equal(http.StatusBadGateway, resp.StatusCode)
+		env := parseFault(resp)
+		suite.Equal(fault.Code, env.Fault.FaultCode)
+		suite.Equal(fault.String, env.Fault.FaultString)
+		suite.Equal(fault.Detail, env.Fault.Detail.Content)
+	})
+
+	suite.Run("ok", func() {
+		id := uuid.New().String()
+		pwd := password.MustGenerate(64, 10, 10, false, false)
+		odpoved := []byte(fmt.Sprintf(`<soapenv:Envelope xmlns:soapenv="http://schemas.xmlsoap.org/soap/envelope/"><soapenv:Body><eet:Odpoved xmlns:eet="http://fs.mfcr.cz/eet/schema/v3"><eet:Hlavicka uuid_zpravy="%s"/></eet:Odpoved></soapenv:Body></soapenv:Envelope>`, uuid.New()))
+
+		suite.gSvc.On("SendSOAP", mock.Anything, id, []byte(pwd), trzba).
+			Return(odpoved, nil).Once()
+		resp := sendSOAP(id, pwd, true)
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+
+		suite.Equal(http.StatusOK, resp.StatusCode)
+		suite.Contains(resp.Header.Get("Content-Type"), "text/xml")
+
+		body, err := ioutil.ReadAll(resp.Body)
+		suite.NoError(err)
+		suite.Equal(odpoved, body)
+	})
+}
@@ -2,7 +2,10 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
+	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -10,6 +13,7 @@ import (
 	"time"
 
 	"go.uber.org/multierr"
+	"google.golang.org/grpc"
 )
 
 // Service is a server of the EET Gateway.
@@ -61,3 +65,61 @@ func NewService(server *http.Server) Service {
 		server: server,
 	}
 }
+
+type grpcService struct {
+	server    *grpc.Server
+	addr      string
+	tlsConfig *tls.Config
+}
+
+// ListenAndServe runs the server and handles incoming requests the same way as the HTTP
+// server does. The connections are secured with the TLS config of the service if withTLS is true.
+// After the timeout exceeds the pending RPCs are canceled.
+func (s *grpcService) ListenAndServe(withTLS bool, timeout time.Duration) (err error) {
+	lis, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("listen on %s: %w", s.addr, err)
+	}
+
+	if withTLS {
+		lis = tls.NewListener(lis, s.tlsConfig)
+	}
+
+	stop := make(chan os.Signal, 1)
+
+	// non blocking server
+	go func() {
+		if e := s.server.Serve(lis); e != nil {
+			multierr.AppendInto(&err, e)
+			stop <- syscall.SIGABRT
+		}
+	}()
+
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM) // SIGKILL cannot be handled
+	<-stop                                               // block
+
+	stopped := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(timeout):
+		s.server.Stop()
+		multierr.AppendInto(&err, context.DeadlineExceeded)
+	}
+
+	return err
+}
+
+// NewGRPCService returns a Service implementation with the given gRPC server listening
+// on the address. The TLS config is used if the server is run with TLS.
+func NewGRPCService(server *grpc.Server, addr string, tlsConfig *tls.Config) Service {
+	return &grpcService{
+		server:    server,
+		addr:      addr,
+		tlsConfig: tlsConfig,
+	}
+}
@@ -1,125 +1,412 @@
 package wsse
 
 import (
+	"bufio"
+	"crypto"
+	"errors"
 	"fmt"
+	"io"
 	"sort"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/beevik/etree"
 )
 
+const (
+	// ExcC14NAlgorithm identifies the exclusive XML canonicalization without comments.
+	ExcC14NAlgorithm = "http://www.w3.org/2001/10/xml-exc-c14n#"
+	// ExcC14NWithCommentsAlgorithm identifies the exclusive XML canonicalization with comments.
+	ExcC14NWithCommentsAlgorithm = "http://www.w3.org/2001/10/xml-exc-c14n#WithComments"
+
+	// xmlNamespace is the namespace implicitly bound to the xml prefix.
+	xmlNamespace = "http://www.w3.org/XML/1998/namespace"
+	// defaultPrefix stands for the default namespace in the InclusiveNamespaces PrefixList.
+	defaultPrefix = "#default"
+)
+
 const (
 	emptyPrefix = ""
 	xmlnsPrefix = "xmlns"
+	xmlPrefix   = "xml"
 )
 
-func excC14NCanonicalize(elem *etree.Element) ([]byte, error) {
-	if err := transformExcC14n(elem); err != nil {
-		return nil, fmt.Errorf("transform the element (excC14N): %w", err)
+// canonicalizerBufSize is the size of the buffer between the canonicalizer
+// and the underlying writer (typically a hash).
+const canonicalizerBufSize = 4096
+
+// ErrUnsupportedAlgorithm is returned if the canonicalization algorithm is not supported.
+var ErrUnsupportedAlgorithm = errors.New("unsupported canonicalization algorithm")
+
+// ErrUndeclaredPrefix is returned if a utilized namespace prefix is not bound to any namespace.
+var ErrUndeclaredPrefix = errors.New("undeclared namespace prefix")
+
+// ExcC14N is the exclusive XML canonicalization (https://www.w3.org/TR/xml-exc-c14n/)
+// of an element and its descendants. Namespaces declared by the ancestors of the element
+// are in scope. Note that the canonical form can't be better than the parsed element:
+// the attribute value normalization and the line ending normalization are left to
+// the parser.
+type ExcC14N struct {
+	// WithComments keeps the comments in the canonical form.
+	WithComments bool
+	// InclusivePrefixes lists the prefixes of the InclusiveNamespaces PrefixList which are
+	// rendered as in the inclusive canonicalization. The default namespace is "#default".
+	InclusivePrefixes []string
+}
+
+// ParseExcC14N returns the canonicalization defined by the CanonicalizationMethod
+// or Transform element.
+func ParseExcC14N(method *etree.Element) (ExcC14N, error) {
+	var c ExcC14N
+
+	switch algorithm := method.SelectAttrValue("Algorithm", ""); algorithm {
+	case ExcC14NAlgorithm:
+	case ExcC14NWithCommentsAlgorithm:
+		c.WithComments = true
+	default:
+		return ExcC14N{}, fmt.Errorf("algorithm %q: %w", algorithm, ErrUnsupportedAlgorithm)
 	}
 
-	doc := etree.NewDocument()
-	doc.SetRoot(elem.Copy())
-	doc.WriteSettings = etree.WriteSettings{
-		CanonicalAttrVal: true,
-		CanonicalEndTags: true,
-		CanonicalText:    true,
+	if inclusive := method.FindElement("./InclusiveNamespaces"); inclusive != nil {
+		c.InclusivePrefixes = strings.Fields(inclusive.SelectAttrValue("PrefixList", ""))
 	}
 
-	canonical, err := doc.WriteToBytes()
-	if err != nil {
-		return nil, fmt.Errorf("write etree document to bytes: %w", err)
+	return c, nil
+}
+
+// Canonicalize writes the canonical form of the element into w. The element is neither
+// modified nor copied and the output is streamed directly.
+func (c ExcC14N) Canonicalize(w io.Writer, el *etree.Element) error {
+	return c.canonicalize(w, el, nil)
+}
+
+// Digest calculates the SHA-256 digest of the canonical form of the element.
+func (c ExcC14N) Digest(el *etree.Element) ([]byte, error) {
+	return c.digest(crypto.SHA256, el, nil)
+}
+
+// digest calculates the digest of the canonical form of the element without the excluded
+// descendant (the enveloped signature).
+func (c ExcC14N) digest(h crypto.Hash, el, exclude *etree.Element) ([]byte, error) {
+	hash := h.New()
+	if err := c.canonicalize(hash, el, exclude); err != nil {
+		return nil, fmt.Errorf("canonicalize the element (c14n): %w", err)
 	}
 
-	return canonical, nil
+	return hash.Sum(nil), nil
 }
 
-func transformExcC14n(el *etree.Element) error {
-	err := toExcC14n(nsContext{}, nsContext{}, el)
-	if err != nil {
-		return fmt.Errorf("c14n: %w", err)
+func (c ExcC14N) canonicalize(w io.Writer, el, exclude *etree.Element) error {
+	cw := &canonicalizer{
+		w:            bufio.NewWriterSize(w, canonicalizerBufSize),
+		withComments: c.WithComments,
+		exclude:      exclude,
+		scope:        ancestorNamespaces(el),
+	}
+
+	for _, prefix := range c.InclusivePrefixes {
+		if prefix == defaultPrefix {
+			prefix = emptyPrefix
+		}
+
+		cw.inclusive = append(cw.inclusive, prefix)
+	}
+
+	if err := cw.writeElement(el); err != nil {
+		return err
+	}
+
+	if err := cw.w.Flush(); err != nil {
+		return fmt.Errorf("flush canonicalized element: %w", err)
 	}
 
 	return nil
 }
 
-func toExcC14n(ctx, declared nsContext, el *etree.Element) error {
-	scope, err := ctx.subContext(el)
-	if err != nil {
-		return fmt.Errorf("create subcontext: %w", err)
+// nsBinding binds a namespace prefix to the namespace name.
+type nsBinding struct {
+	prefix string
+	ns     string
+}
+
+// ancestorNamespaces returns namespaces declared by the ancestors of the element.
+func ancestorNamespaces(el *etree.Element) []nsBinding {
+	var ancestors []*etree.Element
+	for p := el.Parent(); p != nil; p = p.Parent() {
+		ancestors = append(ancestors, p)
 	}
 
-	utilizedPrefixes := map[string]struct{}{
-		el.Space: {},
+	var scope []nsBinding
+	for i := len(ancestors) - 1; i >= 0; i-- {
+		for _, attr := range ancestors[i].Attr {
+			if prefix, ok := nsDeclaration(attr); ok {
+				scope = append(scope, nsBinding{prefix: prefix, ns: attr.Value})
+			}
+		}
 	}
 
-	var filteredAttrs []etree.Attr
+	return scope
+}
+
+// canonAttr is an attribute of the start tag with its resolved namespace.
+// Namespace declarations hold the declared prefix instead.
+type canonAttr struct {
+	etree.Attr
+	ns   string
+	decl bool
+}
+
+// canonicalizer keeps the namespace state of the element being written. Declarations
+// are kept in stacks, the innermost binding of a prefix is always the last one.
+type canonicalizer struct {
+	w            *bufio.Writer
+	withComments bool
+	inclusive    []string
+	exclude      *etree.Element
+
+	// scope holds namespaces declared by the element and its ancestors.
+	scope []nsBinding
+	// rendered holds namespaces already rendered by the output ancestors.
+	rendered []nsBinding
+	// attrs is a scratch space for attributes of the start tag being written.
+	attrs []canonAttr
+}
+
+func (c *canonicalizer) writeElement(el *etree.Element) error {
+	scopeLen, renderedLen, attrsLen := len(c.scope), len(c.rendered), len(c.attrs)
+
 	for _, attr := range el.Attr {
-		if attr.Space != xmlnsPrefix && (attr.Space != emptyPrefix || attr.Key != xmlnsPrefix) {
-			if attr.Space != emptyPrefix {
-				utilizedPrefixes[attr.Space] = struct{}{}
-			}
+		if prefix, ok := nsDeclaration(attr); ok {
+			c.scope = append(c.scope, nsBinding{prefix: prefix, ns: attr.Value})
+		}
+	}
+
+	for _, attr := range el.Attr {
+		if _, ok := nsDeclaration(attr); ok {
+			continue
+		}
 
-			filteredAttrs = append(filteredAttrs, attr)
+		ns, err := c.attrNamespace(attr)
+		if err != nil {
+			return fmt.Errorf("attribute %s of %s: %w", attr.FullKey(), el.FullTag(), err)
 		}
+
+		c.attrs = append(c.attrs, canonAttr{Attr: attr, ns: ns})
 	}
 
-	el.Attr = filteredAttrs
-	declared = declared.copy()
+	// render visibly utilized namespaces
+	nsStart := len(c.attrs)
+	if err := c.renderNamespace(el.Space, nsStart, true); err != nil {
+		return fmt.Errorf("element %s: %w", el.FullTag(), err)
+	}
 
-	for prefix := range utilizedPrefixes {
-		if declaredNamespace, ok := declared.prefixes[prefix]; ok {
-			value, ok := scope.prefixes[prefix]
-			if ok && declaredNamespace == value {
-				continue
+	for _, attr := range c.attrs[attrsLen:nsStart] {
+		if attr.Space != emptyPrefix {
+			if err := c.renderNamespace(attr.Space, nsStart, true); err != nil {
+				return fmt.Errorf("attribute %s of %s: %w", attr.FullKey(), el.FullTag(), err)
 			}
 		}
+	}
+
+	for _, prefix := range c.inclusive {
+		if err := c.renderNamespace(prefix, nsStart, false); err != nil {
+			return fmt.Errorf("element %s: %w", el.FullTag(), err)
+		}
+	}
 
-		ns := scope.prefixes[prefix]
-		el.Attr = append(el.Attr, declared.declare(prefix, ns))
+	attrs := c.attrs[attrsLen:]
+	sort.Sort(sortableAttrs(attrs))
+
+	c.w.WriteByte('<')
+	c.w.WriteString(el.FullTag())
+	for _, attr := range attrs {
+		c.w.WriteByte(' ')
+		c.w.WriteString(attr.FullKey())
+		c.w.WriteString(`="`)
+		c.writeEscaped(attr.Value, true)
+		c.w.WriteByte('"')
 	}
+	c.w.WriteByte('>')
 
-	sort.Sort(sortableAttrs(el.Attr))
+	c.attrs = c.attrs[:attrsLen]
 
-	for _, child := range el.ChildElements() {
-		err := toExcC14n(scope, declared, child)
-		if err != nil {
-			return fmt.Errorf("canonicalize %s to exc c14n: %w", child.FullTag(), err)
+	for _, token := range el.Child {
+		switch t := token.(type) {
+		case *etree.Element:
+			if t == c.exclude {
+				continue
+			}
+
+			if err := c.writeElement(t); err != nil {
+				return err
+			}
+		case *etree.CharData:
+			c.writeEscaped(t.Data, false)
+		case *etree.Comment:
+			if c.withComments {
+				c.w.WriteString("<!--")
+				c.w.WriteString(t.Data)
+				c.w.WriteString("-->")
+			}
+		case *etree.ProcInst:
+			c.w.WriteString("<?")
+			c.w.WriteString(t.Target)
+			if t.Inst != "" {
+				c.w.WriteByte(' ')
+				c.w.WriteString(t.Inst)
+			}
+			c.w.WriteString("?>")
 		}
 	}
 
+	c.w.WriteString("</")
+	c.w.WriteString(el.FullTag())
+	c.w.WriteByte('>')
+
+	c.scope = c.scope[:scopeLen]
+	c.rendered = c.rendered[:renderedLen]
+
 	return nil
 }
 
-type nsContext struct {
-	prefixes map[string]string
+// attrNamespace resolves the namespace of the attribute. Unqualified attributes
+// are in no namespace.
+func (c *canonicalizer) attrNamespace(attr etree.Attr) (string, error) {
+	switch attr.Space {
+	case emptyPrefix:
+		return "", nil
+	case xmlPrefix:
+		return xmlNamespace, nil
+	}
+
+	ns, ok := lookupNamespace(c.scope, attr.Space)
+	if !ok || ns == "" {
+		return "", fmt.Errorf("prefix %s: %w", attr.Space, ErrUndeclaredPrefix)
+	}
+
+	return ns, nil
 }
 
-func (ctx nsContext) subContext(el *etree.Element) (nsContext, error) {
-	nCtx := ctx.copy()
+// renderNamespace appends the declaration of the prefix to the attributes of the
+// current element unless it has been already rendered by an output ancestor or
+// by the element itself (declarations from the nsStart index). Utilized prefixes
+// must be declared, the others are rendered only if they are in scope.
+func (c *canonicalizer) renderNamespace(prefix string, nsStart int, utilized bool) error {
+	if prefix == xmlPrefix {
+		return nil
+	}
 
-	for _, attr := range el.Attr {
-		if attr.Space == xmlnsPrefix {
-			nCtx.declare(attr.Key, attr.Value)
-		} else if attr.Space == emptyPrefix && attr.Key == xmlnsPrefix {
-			nCtx.declare(emptyPrefix, attr.Value)
+	for _, attr := range c.attrs[nsStart:] {
+		if attr.ns == prefix {
+			return nil
 		}
 	}
 
-	return nCtx, nil
+	ns, inScope := lookupNamespace(c.scope, prefix)
+	if prefix != emptyPrefix && (!inScope || ns == "") {
+		if utilized {
+			return fmt.Errorf("prefix %s: %w", prefix, ErrUndeclaredPrefix)
+		}
+
+		return nil
+	}
+
+	rendered, _ := lookupNamespace(c.rendered, prefix)
+	if rendered == ns {
+		// an empty default namespace is undeclared only if a non-empty one was rendered
+		return nil
+	}
+
+	c.rendered = append(c.rendered, nsBinding{prefix: prefix, ns: ns})
+	c.attrs = append(c.attrs, canonAttr{Attr: nsAttr(prefix, ns), ns: prefix, decl: true})
+
+	return nil
 }
 
-func (ctx nsContext) copy() nsContext {
-	prefixes := make(map[string]string, len(ctx.prefixes)+4)
-	for k, v := range ctx.prefixes {
-		prefixes[k] = v
+// writeEscaped writes the text or the attribute value escaped according to the
+// canonical XML rules. Characters out of the XML character range are replaced.
+func (c *canonicalizer) writeEscaped(s string, attr bool) {
+	last := 0
+	for i := 0; i < len(s); {
+		r, width := utf8.DecodeRuneInString(s[i:])
+		i += width
+
+		var esc string
+		switch r {
+		case '&':
+			esc = "&amp;"
+		case '<':
+			esc = "&lt;"
+		case '>':
+			if attr {
+				continue
+			}
+			esc = "&gt;"
+		case '"':
+			if !attr {
+				continue
+			}
+			esc = "&quot;"
+		case '\t':
+			if !attr {
+				continue
+			}
+			esc = "&#x9;"
+		case '\n':
+			if !attr {
+				continue
+			}
+			esc = "&#xA;"
+		case '\r':
+			esc = "&#xD;"
+		default:
+			if !isXMLChar(r) || (r == utf8.RuneError && width == 1) {
+				esc = "\uFFFD"
+				break
+			}
+			continue
+		}
+
+		c.w.WriteString(s[last : i-width])
+		c.w.WriteString(esc)
+		last = i
+	}
+
+	c.w.WriteString(s[last:])
+}
+
+func isXMLChar(r rune) bool {
+	return r == 0x09 ||
+		r == 0x0A ||
+		r == 0x0D ||
+		r >= 0x20 && r <= 0xD7FF ||
+		r >= 0xE000 && r <= 0xFFFD ||
+		r >= 0x10000 && r <= 0x10FFFF
+}
+
+// nsDeclaration returns the declared prefix if the attribute is a namespace declaration.
+func nsDeclaration(attr etree.Attr) (string, bool) {
+	switch {
+	case attr.Space == xmlnsPrefix:
+		return attr.Key, true
+	case attr.Space == emptyPrefix && attr.Key == xmlnsPrefix:
+		return emptyPrefix, true
 	}
 
-	return nsContext{prefixes}
+	return "", false
 }
 
-func (ctx nsContext) declare(prefix, ns string) etree.Attr {
-	ctx.prefixes[prefix] = ns
+func lookupNamespace(bindings []nsBinding, prefix string) (string, bool) {
+	for i := len(bindings) - 1; i >= 0; i-- {
+		if bindings[i].prefix == prefix {
+			return bindings[i].ns, true
+		}
+	}
+
+	return "", false
+}
 
+func nsAttr(prefix, ns string) etree.Attr {
 	if prefix == emptyPrefix {
 		return etree.Attr{
 			Key:   xmlnsPrefix,
@@ -134,7 +421,9 @@ func (ctx nsContext) declare(prefix, ns string) etree.Attr {
 	}
 }
 
-type sortableAttrs []etree.Attr
+// sortableAttrs sorts namespace declarations by the prefix (the default one first)
+// followed by the attributes sorted by the namespace and the local name.
+type sortableAttrs []canonAttr
 
 func (a sortableAttrs) Len() int {
 	return len(a)
@@ -146,32 +435,11 @@ func (a sortableAttrs) Swap(i, j int) {
 
 func (a sortableAttrs) Less(i, j int) bool {
 	switch {
-	case a[j].Space == emptyPrefix && a[j].Key == xmlnsPrefix:
-		return false
-
-	case a[i].Space == emptyPrefix && a[i].Key == xmlnsPrefix:
-		return true
-
-	case a[i].Space == xmlnsPrefix:
-		if a[j].Space == xmlnsPrefix {
-			return a[i].Key < a[j].Key
-		}
-
-		return true
-
-	case a[j].Space == xmlnsPrefix:
-		return false
-
-	case a[i].Space == emptyPrefix:
-		if a[j].Space == emptyPrefix {
-			return a[i].Key < a[j].Key
-		}
-
-		return true
-
-	case a[j].Space == emptyPrefix:
-		return false
+	case a[i].decl != a[j].decl:
+		return a[i].decl
+	case a[i].ns != a[j].ns:
+		return a[i].ns < a[j].ns
 	}
 
-	return a[i].Space < a[j].Space
+	return a[i].Key < a[j].Key
 }
@@ -0,0 +1,479 @@
+package wsse
+
+import (
+	"bytes"
+	"crypto"
+	"io/ioutil"
+	"sort"
+	"testing"
+
+	"github.com/beevik/etree"
+	dsig "github.com/russellhaering/goxmldsig"
+	"github.com/stretchr/testify/require"
+)
+
+var sampleEnvelopes = []string{
+	"testdata/CZ00000019.v3.valid.v3.1.1.xml",
+	"testdata/CZ683555118.v3.valid.v3.1.1.xml",
+	"testdata/CZ1212121218.v3.valid.v3.1.1.xml",
+}
+
+// signedElements returns the body and the signed info of the sample envelope with the
+// namespaces defined outside their scope.
+func signedElements(t require.TestingT, xmlFile string) map[string]*etree.Element {
+	raw, err := ioutil.ReadFile(xmlFile)
+	require.NoError(t, err)
+
+	envelope := etree.NewDocument()
+	err = envelope.ReadFromBytes(raw)
+	require.NoError(t, err)
+
+	body := envelope.FindElement("./Envelope/Body").Copy()
+	body.CreateAttr("xmlns:u", "http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-utility-1.0.xsd")
+	body.CreateAttr("xmlns:s", "http://schemas.xmlsoap.org/soap/envelope/")
+
+	signedInfo := envelope.FindElement("./Envelope/Header/Security/Signature/SignedInfo").Copy()
+	signedInfo.CreateAttr("xmlns", "http://www.w3.org/2000/09/xmldsig#")
+
+	return map[string]*etree.Element{
+		"body":        body,
+		"signed info": signedInfo,
+	}
+}
+
+func serialize(el *etree.Element) ([]byte, error) {
+	doc := etree.NewDocument()
+	doc.SetRoot(el.Copy())
+	return doc.WriteToBytes()
+}
+
+func streamCanonicalize(t require.TestingT, el *etree.Element) []byte {
+	var buf bytes.Buffer
+	err := ExcC14N{}.Canonicalize(&buf, el)
+	require.NoError(t, err)
+
+	return buf.Bytes()
+}
+
+func TestExcC14N_CanonicalizeSamples(t *testing.T) {
+	for _, xmlFile := range sampleEnvelopes {
+		for name, el := range signedElements(t, xmlFile) {
+			t.Run(xmlFile+"/"+name, func(t *testing.T) {
+				raw, err := serialize(el)
+				require.NoError(t, err)
+
+				canonical := streamCanonicalize(t, el)
+
+				// the element stays untouched
+				after, err := serialize(el)
+				require.NoError(t, err)
+				require.Equal(t, raw, after)
+
+				expected, err := excC14NCanonicalize(el.Copy())
+				require.NoError(t, err)
+				require.Equal(t, string(expected), string(canonical))
+
+				expected, err = dsig.MakeC14N10ExclusiveCanonicalizerWithPrefixList("").Canonicalize(el.Copy())
+				require.NoError(t, err)
+				require.Equal(t, string(expected), string(canonical))
+			})
+		}
+	}
+}
+
+func TestExcC14N_Canonicalize(t *testing.T) {
+	// test vectors of the Canonical XML (https://www.w3.org/TR/xml-c14n/#Examples)
+	// and the Exclusive XML Canonicalization (https://www.w3.org/TR/xml-exc-c14n/#sec-Enveloping)
+	// reduced to the features supported by the etree parser (no DTD processing)
+	tests := []struct {
+		name   string
+		input  string
+		path   string
+		c14n   ExcC14N
+		output string
+	}{
+		{
+			name:   "3.1 comments and processing instructions",
+			input:  "3.1-input.xml",
+			output: "3.1-output.xml",
+		},
+		{
+			name:   "3.1 with comments",
+			input:  "3.1-input.xml",
+			c14n:   ExcC14N{WithComments: true},
+			output: "3.1-output-comments.xml",
+		},
+		{
+			name:   "3.2 whitespace in document content",
+			input:  "3.2-input.xml",
+			output: "3.2-output.xml",
+		},
+		{
+			name:   "3.3 start and end tags",
+			input:  "3.3-input.xml",
+			output: "3.3-output.xml",
+		},
+		{
+			name:   "3.4 character modifications and character references",
+			input:  "3.4-input.xml",
+			output: "3.4-output.xml",
+		},
+		{
+			name:   "3.6 UTF-8 encoding",
+			input:  "3.6-input.xml",
+			output: "3.6-output.xml",
+		},
+		{
+			name:   "2.2 exclusive canonicalization of the first document",
+			input:  "2.2-input-1.xml",
+			path:   "./elem2",
+			output: "2.2-output.xml",
+		},
+		{
+			name:   "2.2 exclusive canonicalization of the second document",
+			input:  "2.2-input-2.xml",
+			path:   "./elem2",
+			output: "2.2-output.xml",
+		},
+		{
+			name:   "inclusive namespaces",
+			input:  "2.2-input-1.xml",
+			path:   "./elem2",
+			c14n:   ExcC14N{InclusivePrefixes: []string{"n3"}},
+			output: "2.2-output-inclusive.xml",
+		},
+		{
+			name:   "inclusive default namespace",
+			input:  "default-input.xml",
+			path:   "./elem",
+			c14n:   ExcC14N{InclusivePrefixes: []string{"#default", "n1", "undeclared"}},
+			output: "default-output.xml",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			doc := etree.NewDocument()
+			err := doc.ReadFromFile("testdata/exc-c14n/" + tc.input)
+			require.NoError(t, err)
+
+			el := doc.Root()
+			if tc.path != "" {
+				el = el.FindElement(tc.path)
+				require.NotNil(t, el)
+			}
+
+			expected, err := ioutil.ReadFile("testdata/exc-c14n/" + tc.output)
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			err = tc.c14n.Canonicalize(&buf, el)
+			require.NoError(t, err)
+			require.Equal(t, string(expected), buf.String())
+		})
+	}
+}
+
+func TestExcC14N_CanonicalizeDocuments(t *testing.T) {
+	tests := []struct {
+		name      string
+		xml       string
+		canonical string
+	}{
+		{
+			name:      "empty element",
+			xml:       `<a:e xmlns:a="urn:a"/>`,
+			canonical: `<a:e xmlns:a="urn:a"></a:e>`,
+		},
+		{
+			name:      "unused namespaces",
+			xml:       `<a:e xmlns:a="urn:a" xmlns:b="urn:b"><a:c xmlns:c="urn:c">text</a:c></a:e>`,
+			canonical: `<a:e xmlns:a="urn:a"><a:c>text</a:c></a:e>`,
+		},
+		{
+			name:      "redeclared namespace",
+			xml:       `<a:e xmlns:a="urn:a"><a:c xmlns:a="urn:b"></a:c><a:c xmlns:a="urn:a"></a:c></a:e>`,
+			canonical: `<a:e xmlns:a="urn:a"><a:c xmlns:a="urn:b"></a:c><a:c></a:c></a:e>`,
+		},
+		{
+			name:      "namespace of attribute",
+			xml:       `<e b:id="1" xmlns="urn:a" xmlns:b="urn:b"><c b:id="2"></c></e>`,
+			canonical: `<e xmlns="urn:a" xmlns:b="urn:b" b:id="1"><c b:id="2"></c></e>`,
+		},
+		{
+			name:      "attribute order",
+			xml:       `<a:e z="1" b:y="2" a:x="3" xmlns:b="urn:b" xmlns:a="urn:a" w="4"/>`,
+			canonical: `<a:e xmlns:a="urn:a" xmlns:b="urn:b" w="4" z="1" a:x="3" b:y="2"></a:e>`,
+		},
+		{
+			name:      "undeclared default namespace",
+			xml:       `<e xmlns="urn:a"><c xmlns=""><d></d></c></e>`,
+			canonical: `<e xmlns="urn:a"><c xmlns=""><d></d></c></e>`,
+		},
+		{
+			name:      "no default namespace",
+			xml:       `<e><c></c></e>`,
+			canonical: `<e><c></c></e>`,
+		},
+		{
+			name:      "escaping",
+			xml:       "<e a=\"&quot;&lt;&gt;&amp;&#9;&#10;&#13;'\">\"&lt;&gt;&amp;&#13;'\t\n</e>",
+			canonical: "<e a=\"&quot;&lt;>&amp;&#x9;&#xA;&#xD;'\">\"&lt;&gt;&amp;&#xD;'\t\n</e>",
+		},
+		{
+			name:      "comments and processing instructions",
+			xml:       `<e><!-- comment --><?target inst?><![CDATA[<cdata>]]></e>`,
+			canonical: `<e><?target inst?>&lt;cdata&gt;</e>`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			doc := etree.NewDocument()
+			err := doc.ReadFromString(tc.xml)
+			require.NoError(t, err)
+
+			canonical := streamCanonicalize(t, doc.Root())
+			require.Equal(t, tc.canonical, string(canonical))
+		})
+	}
+}
+
+func TestExcC14N_CanonicalizeUndeclaredPrefix(t *testing.T) {
+	for _, xml := range []string{
+		`<a:e/>`,
+		`<e a:attr="value"/>`,
+		`<e xmlns:a="urn:a"><a:c xmlns:a=""/></e>`,
+	} {
+		doc := etree.NewDocument()
+		err := doc.ReadFromString(xml)
+		require.NoError(t, err)
+
+		err = ExcC14N{}.Canonicalize(ioutil.Discard, doc.Root())
+		require.ErrorIs(t, err, ErrUndeclaredPrefix)
+	}
+}
+
+func TestParseExcC14N(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		c14n   ExcC14N
+		err    error
+	}{
+		{
+			name:   "exclusive",
+			method: `<CanonicalizationMethod Algorithm="http://www.w3.org/2001/10/xml-exc-c14n#"/>`,
+			c14n:   ExcC14N{},
+		},
+		{
+			name:   "with comments",
+			method: `<Transform Algorithm="http://www.w3.org/2001/10/xml-exc-c14n#WithComments"/>`,
+			c14n:   ExcC14N{WithComments: true},
+		},
+		{
+			name: "inclusive namespaces",
+			method: `<Transform Algorithm="http://www.w3.org/2001/10/xml-exc-c14n#">
+				<ec:InclusiveNamespaces xmlns:ec="http://www.w3.org/2001/10/xml-exc-c14n#" PrefixList=" #default  soap eet "/>
+			</Transform>`,
+			c14n: ExcC14N{InclusivePrefixes: []string{"#default", "soap", "eet"}},
+		},
+		{
+			name:   "inclusive canonicalization",
+			method: `<CanonicalizationMethod Algorithm="http://www.w3.org/TR/2001/REC-xml-c14n-20010315"/>`,
+			err:    ErrUnsupportedAlgorithm,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			doc := etree.NewDocument()
+			err := doc.ReadFromString(tc.method)
+			require.NoError(t, err)
+
+			c14n, err := ParseExcC14N(doc.Root())
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.c14n, c14n)
+		})
+	}
+}
+
+func BenchmarkCanonicalization(b *testing.B) {
+	benchmarks := []struct {
+		name   string
+		digest func(b *testing.B, el *etree.Element)
+	}{
+		{
+			name: "streaming",
+			digest: func(b *testing.B, el *etree.Element) {
+				_, err := CalcDigest(el)
+				require.NoError(b, err)
+			},
+		},
+		{
+			name: "copy",
+			digest: func(b *testing.B, el *etree.Element) {
+				canonical, err := excC14NCanonicalize(el.Copy())
+				require.NoError(b, err)
+				crypto.SHA256.New().Write(canonical)
+			},
+		},
+		{
+			name: "goxmldsig",
+			digest: func(b *testing.B, el *etree.Element) {
+				canonical, err := dsig.MakeC14N10ExclusiveCanonicalizerWithPrefixList("").Canonicalize(el.Copy())
+				require.NoError(b, err)
+				crypto.SHA256.New().Write(canonical)
+			},
+		},
+	}
+
+	for _, xmlFile := range sampleEnvelopes {
+		body := signedElements(b, xmlFile)["body"]
+		for _, bm := range benchmarks {
+			b.Run(xmlFile+"/"+bm.name, func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					bm.digest(b, body)
+				}
+			})
+		}
+	}
+}
+
+// excC14NCanonicalize is the former implementation of the exclusive canonicalization which
+// transforms a copy of the element in place and serializes it via the etree document. It is
+// kept as a reference for the streaming canonicalizer.
+func excC14NCanonicalize(elem *etree.Element) ([]byte, error) {
+	if err := toExcC14n(nsContext{}, nsContext{}, elem); err != nil {
+		return nil, err
+	}
+
+	doc := etree.NewDocument()
+	doc.SetRoot(elem.Copy())
+	doc.WriteSettings = etree.WriteSettings{
+		CanonicalAttrVal: true,
+		CanonicalEndTags: true,
+		CanonicalText:    true,
+	}
+
+	return doc.WriteToBytes()
+}
+
+func toExcC14n(ctx, declared nsContext, el *etree.Element) error {
+	scope := ctx.subContext(el)
+
+	utilizedPrefixes := map[string]struct{}{
+		el.Space: {},
+	}
+
+	var filteredAttrs []etree.Attr
+	for _, attr := range el.Attr {
+		if _, ok := nsDeclaration(attr); !ok {
+			if attr.Space != emptyPrefix {
+				utilizedPrefixes[attr.Space] = struct{}{}
+			}
+
+			filteredAttrs = append(filteredAttrs, attr)
+		}
+	}
+
+	el.Attr = filteredAttrs
+	declared = declared.copy()
+
+	for prefix := range utilizedPrefixes {
+		if declaredNamespace, ok := declared.prefixes[prefix]; ok {
+			value, ok := scope.prefixes[prefix]
+			if ok && declaredNamespace == value {
+				continue
+			}
+		}
+
+		ns := scope.prefixes[prefix]
+		declared.prefixes[prefix] = ns
+		el.Attr = append(el.Attr, nsAttr(prefix, ns))
+	}
+
+	sort.Sort(referenceSortableAttrs(el.Attr))
+
+	for _, child := range el.ChildElements() {
+		if err := toExcC14n(scope, declared, child); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type nsContext struct {
+	prefixes map[string]string
+}
+
+func (ctx nsContext) subContext(el *etree.Element) nsContext {
+	nCtx := ctx.copy()
+	for _, attr := range el.Attr {
+		if prefix, ok := nsDeclaration(attr); ok {
+			nCtx.prefixes[prefix] = attr.Value
+		}
+	}
+
+	return nCtx
+}
+
+func (ctx nsContext) copy() nsContext {
+	prefixes := make(map[string]string, len(ctx.prefixes)+4)
+	for k, v := range ctx.prefixes {
+		prefixes[k] = v
+	}
+
+	return nsContext{prefixes}
+}
+
+// referenceSortableAttrs is the former attribute order of excC14NCanonicalize, namespace
+// declarations first followed by the attributes sorted by the prefix and the local name.
+type referenceSortableAttrs []etree.Attr
+
+func (a referenceSortableAttrs) Len() int {
+	return len(a)
+}
+
+func (a referenceSortableAttrs) Swap(i, j int) {
+	a[i], a[j] = a[j], a[i]
+}
+
+func (a referenceSortableAttrs) Less(i, j int) bool {
+	switch {
+	case a[j].Space == emptyPrefix && a[j].Key == xmlnsPrefix:
+		return false
+
+	case a[i].Space == emptyPrefix && a[i].Key == xmlnsPrefix:
+		return true
+
+	case a[i].Space == xmlnsPrefix:
+		if a[j].Space == xmlnsPrefix {
+			return a[i].Key < a[j].Key
+		}
+
+		return true
+
+	case a[j].Space == xmlnsPrefix:
+		return false
+
+	case a[i].Space == emptyPrefix:
+		if a[j].Space == emptyPrefix {
+			return a[i].Key < a[j].Key
+		}
+
+		return true
+
+	case a[j].Space == emptyPrefix:
+		return false
+	}
+
+	return a[i].Space < a[j].Space
+}
@@ -0,0 +1,249 @@
+package wsse
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/beevik/etree"
+)
+
+const (
+	// DSigNamespace is the namespace of the XML digital signature.
+	DSigNamespace = "http://www.w3.org/2000/09/xmldsig#"
+	// SecExtNamespace is the namespace of the WS-Security extensions.
+	SecExtNamespace = "http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-secext-1.0.xsd"
+	// UtilityNamespace is the namespace of the WS-Security utility attributes (wsu:Id).
+	UtilityNamespace = "http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-utility-1.0.xsd"
+
+	// RSASHA256Algorithm identifies the RSA PKCS #1 v1.5 signature with SHA-256.
+	RSASHA256Algorithm = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha256"
+	// RSASHA512Algorithm identifies the RSA PKCS #1 v1.5 signature with SHA-512.
+	RSASHA512Algorithm = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha512"
+	// SHA256Algorithm identifies the SHA-256 digest.
+	SHA256Algorithm = "http://www.w3.org/2001/04/xmlenc#sha256"
+	// SHA512Algorithm identifies the SHA-512 digest.
+	SHA512Algorithm = "http://www.w3.org/2001/04/xmlenc#sha512"
+	// EnvelopedSignatureAlgorithm identifies the enveloped signature transform.
+	EnvelopedSignatureAlgorithm = "http://www.w3.org/2000/09/xmldsig#enveloped-signature"
+
+	// X509TokenValueType is the value type of the binary security token with an X.509 v3 certificate.
+	X509TokenValueType = "http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-x509-token-profile-1.0#X509v3"
+)
+
+var signatureMethods = map[string]crypto.Hash{
+	RSASHA256Algorithm: crypto.SHA256,
+	RSASHA512Algorithm: crypto.SHA512,
+}
+
+var digestMethods = map[string]crypto.Hash{
+	SHA256Algorithm: crypto.SHA256,
+	SHA512Algorithm: crypto.SHA512,
+}
+
+// ErrMalformedSignature is returned if the signature misses a required element.
+var ErrMalformedSignature = errors.New("malformed XML signature")
+
+// ErrReferenceNotFound is returned if a reference URI can't be resolved to exactly one element.
+var ErrReferenceNotFound = errors.New("referenced element not found")
+
+// ErrSecurityToken is returned if the security token can't be resolved or parsed.
+var ErrSecurityToken = errors.New("invalid security token")
+
+// findElement returns the element in the path or ErrMalformedSignature.
+func findElement(root *etree.Element, path string) (*etree.Element, error) {
+	e := root.FindElement(path)
+	if e == nil {
+		return nil, fmt.Errorf("element in %s of %s element not found: %w", path, root.FullTag(), ErrMalformedSignature)
+	}
+
+	return e, nil
+}
+
+// documentRoot returns the topmost ancestor of the element.
+func documentRoot(el *etree.Element) *etree.Element {
+	for p := el.Parent(); p != nil && p.Tag != ""; p = el.Parent() {
+		el = p
+	}
+
+	return el
+}
+
+// resolveReference returns the only element of the document with the wsu:Id
+// of the same-document reference URI.
+func resolveReference(root *etree.Element, uri string) (*etree.Element, error) {
+	if !strings.HasPrefix(uri, "#") || len(uri) == 1 {
+		return nil, fmt.Errorf("unsupported URI %q: %w", uri, ErrReferenceNotFound)
+	}
+
+	id := uri[1:]
+
+	var found []*etree.Element
+	var find func(el *etree.Element)
+	find = func(el *etree.Element) {
+		if elementID(el) == id {
+			found = append(found, el)
+		}
+
+		for _, child := range el.ChildElements() {
+			find(child)
+		}
+	}
+	find(root)
+
+	if len(found) != 1 {
+		return nil, fmt.Errorf("%d elements with ID %q: %w", len(found), id, ErrReferenceNotFound)
+	}
+
+	return found[0], nil
+}
+
+// elementID returns the wsu:Id of the element.
+func elementID(el *etree.Element) string {
+	for _, attr := range el.Attr {
+		if attr.Key == "Id" && attr.Space != emptyPrefix && resolvePrefix(el, attr.Space) == UtilityNamespace {
+			return attr.Value
+		}
+	}
+
+	return ""
+}
+
+// resolvePrefix returns the namespace bound to the prefix in the scope of the element.
+func resolvePrefix(el *etree.Element, prefix string) string {
+	for ; el != nil; el = el.Parent() {
+		for _, attr := range el.Attr {
+			if p, ok := nsDeclaration(attr); ok && p == prefix {
+				return attr.Value
+			}
+		}
+	}
+
+	return ""
+}
+
+// referenceDigest resolves the referenced element and calculates its digest according to
+// the transforms and the digest method of the reference.
+func referenceDigest(root, signature, reference *etree.Element) (*etree.Element, []byte, error) {
+	el, err := resolveReference(root, reference.SelectAttrValue("URI", ""))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var c14n *ExcC14N
+	var exclude *etree.Element
+	for _, transform := range reference.FindElements("./Transforms/Transform") {
+		if transform.SelectAttrValue("Algorithm", "") == EnvelopedSignatureAlgorithm {
+			exclude = signature
+			continue
+		}
+
+		c, err := ParseExcC14N(transform)
+		if err != nil {
+			return nil, nil, fmt.Errorf("parse transform: %w", err)
+		}
+
+		c14n = &c
+	}
+
+	if c14n == nil {
+		return nil, nil, fmt.Errorf("missing canonicalization transform: %w", ErrUnsupportedAlgorithm)
+	}
+
+	h, err := algorithm(reference, "./DigestMethod", digestMethods)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	digest, err := c14n.digest(h, el, exclude)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return el, digest, nil
+}
+
+// signedInfoDigest calculates the digest of the signed info element according to
+// its canonicalization and signature methods.
+func signedInfoDigest(signedInfo *etree.Element) ([]byte, crypto.Hash, error) {
+	method, err := findElement(signedInfo, "./CanonicalizationMethod")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	c14n, err := ParseExcC14N(method)
+	if err != nil {
+		return nil, 0, fmt.Errorf("parse canonicalization method: %w", err)
+	}
+
+	h, err := algorithm(signedInfo, "./SignatureMethod", signatureMethods)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	digest, err := c14n.digest(h, signedInfo, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return digest, h, nil
+}
+
+// algorithm returns the hash function of the algorithm of the element in the path.
+func algorithm(root *etree.Element, path string, algorithms map[string]crypto.Hash) (crypto.Hash, error) {
+	method, err := findElement(root, path)
+	if err != nil {
+		return 0, err
+	}
+
+	alg := method.SelectAttrValue("Algorithm", "")
+	h, ok := algorithms[alg]
+	if !ok {
+		return 0, fmt.Errorf("%s %q: %w", method.Tag, alg, ErrUnsupportedAlgorithm)
+	}
+
+	return h, nil
+}
+
+// securityToken returns the binary security token referenced by the key info of the signature.
+func securityToken(root, signature *etree.Element) (*etree.Element, error) {
+	ref, err := findElement(signature, "./KeyInfo/SecurityTokenReference/Reference")
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := resolveReference(root, ref.SelectAttrValue("URI", ""))
+	if err != nil {
+		return nil, fmt.Errorf("resolve security token reference: %w", err)
+	}
+
+	if token.Tag != "BinarySecurityToken" || token.SelectAttrValue("ValueType", "") != X509TokenValueType {
+		return nil, fmt.Errorf("unexpected %s token of value type %q: %w",
+			token.Tag, token.SelectAttrValue("ValueType", ""), ErrSecurityToken)
+	}
+
+	return token, nil
+}
+
+// tokenCertificate parses the X.509 certificate of the binary security token.
+func tokenCertificate(token *etree.Element) (*x509.Certificate, error) {
+	raw, err := decodeBase64(token.Text())
+	if err != nil {
+		return nil, fmt.Errorf("decode binary security token: %v: %w", err, ErrSecurityToken)
+	}
+
+	cert, err := x509.ParseCertificate(raw)
+	if err != nil {
+		return nil, fmt.Errorf("parse x509 certificate: %v: %w", err, ErrSecurityToken)
+	}
+
+	return cert, nil
+}
+
+// decodeBase64 decodes the base64 content of an element ignoring whitespaces.
+func decodeBase64(s string) ([]byte, error) {
+	return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(s), ""))
+}
@@ -9,15 +9,20 @@ import (
 	"github.com/beevik/etree"
 )
 
-// CalcSignature calculates a signature value of the signedInfo element.
-func CalcSignature(pk *rsa.PrivateKey, signedInfo *etree.Element) ([]byte, error) {
+// CalcSignature calculates a signature value of the signedInfo element. The key must be
+// an RSA signer.
+func CalcSignature(key crypto.Signer, signedInfo *etree.Element) ([]byte, error) {
+	if _, ok := key.Public().(*rsa.PublicKey); !ok {
+		return nil, fmt.Errorf("unexpected public key type %T: %w", key.Public(), ErrUnsupportedKey)
+	}
+
 	signedInfo.CreateAttr("xmlns", "http://www.w3.org/2000/09/xmldsig#")
 	digest, err := CalcDigest(signedInfo.Copy())
 	if err != nil {
 		return nil, fmt.Errorf("calculate digest of signed info: %w", err)
 	}
 
-	rawSig, err := rsa.SignPKCS1v15(rand.Reader, pk, crypto.SHA256, digest)
+	rawSig, err := key.Sign(rand.Reader, digest, crypto.SHA256)
 	if err != nil {
 		return nil, fmt.Errorf("signing signedInfo digest: %w", err)
 	}
@@ -25,23 +30,11 @@ func CalcSignature(pk *rsa.PrivateKey, signedInfo *etree.Element) ([]byte, error
 	return rawSig, nil
 }
 
-// CalcDigest calculates a digest value of the given element.
+// CalcDigest calculates a digest value of the given element canonicalized by the
+// exclusive canonicalization without comments.
 func CalcDigest(e *etree.Element) ([]byte, error) {
-	// canonical, err := dsig.MakeC14N10ExclusiveCanonicalizerWithPrefixList("").Canonicalize(e.Copy())
-	// note: The line above is using the package dsig (https://github.com/russellhaering/goxmldsig) to canonicalize
-	//       the etree element. The custom excC14NCanonicalize function is used instead for the performance
-	//       boost (about 40% faster). Nevertheless, since the custom canonicalizer is heavily inspired by the one
-	//       derived from the dsig package, the link to the original code is left for debugging purposes.
-	canonical, err := excC14NCanonicalize(e.Copy())
-	if err != nil {
-		return nil, fmt.Errorf("canonicalize the element (c14n): %w", err)
-	}
-
-	hash := crypto.SHA256.New()
-	_, err = hash.Write(canonical)
-	if err != nil {
-		return nil, fmt.Errorf("hash canonicalized element: %w", err)
-	}
-
-	return hash.Sum(nil), nil
+	// note: The canonicalizer is heavily inspired by the one of the dsig package
+	//       (https://github.com/russellhaering/goxmldsig), which is kept in benchmarks
+	//       for comparison and debugging purposes.
+	return ExcC14N{}.Digest(e)
 }
@@ -0,0 +1,99 @@
+package wsse
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/beevik/etree"
+)
+
+// ErrUnsupportedKey is returned if the signing key isn't an RSA key.
+var ErrUnsupportedKey = errors.New("unsupported signing key")
+
+// Signer signs SOAP messages by the XML digital signature in the WS-Security header.
+type Signer interface {
+	Sign(signature *etree.Element) error
+}
+
+type rsaSigner struct {
+	cert *x509.Certificate
+	key  crypto.Signer
+}
+
+// Sign completes the Signature element template of a document. The binary security token
+// referenced by the KeyInfo is set to the signing certificate, the digest values of all
+// references in the SignedInfo are calculated according to their transforms and digest methods
+// and the SignedInfo is signed. References are resolved by the wsu:Id attribute.
+func (s *rsaSigner) Sign(signature *etree.Element) error {
+	if _, ok := s.key.Public().(*rsa.PublicKey); !ok {
+		return fmt.Errorf("unexpected public key type %T: %w", s.key.Public(), ErrUnsupportedKey)
+	}
+
+	root := documentRoot(signature)
+
+	token, err := securityToken(root, signature)
+	if err != nil {
+		return fmt.Errorf("find security token: %w", err)
+	}
+
+	token.SetText(base64.StdEncoding.EncodeToString(s.cert.Raw))
+
+	signedInfo, err := findElement(signature, "./SignedInfo")
+	if err != nil {
+		return err
+	}
+
+	references := signedInfo.SelectElements("Reference")
+	if len(references) == 0 {
+		return fmt.Errorf("no reference to sign: %w", ErrMalformedSignature)
+	}
+
+	for _, reference := range references {
+		_, digest, err := referenceDigest(root, signature, reference)
+		if err != nil {
+			return fmt.Errorf("calculate digest of reference %s: %w", reference.SelectAttrValue("URI", ""), err)
+		}
+
+		digestValue, err := findElement(reference, "./DigestValue")
+		if err != nil {
+			return err
+		}
+
+		digestValue.SetText(base64.StdEncoding.EncodeToString(digest))
+	}
+
+	digest, h, err := signedInfoDigest(signedInfo)
+	if err != nil {
+		return fmt.Errorf("calculate digest of signed info: %w", err)
+	}
+
+	// crypto.Hash options select the PKCS #1 v1.5 signature of RSA signers
+	rawSig, err := s.key.Sign(rand.Reader, digest, h)
+	if err != nil {
+		return fmt.Errorf("sign signed info digest: %w", err)
+	}
+
+	signatureValue, err := findElement(signature, "./SignatureValue")
+	if err != nil {
+		return err
+	}
+
+	signatureValue.SetText(base64.StdEncoding.EncodeToString(rawSig))
+
+	return nil
+}
+
+// NewSigner returns a Signer implementation signing with the given certificate and RSA key.
+// The key doesn't have to be held in memory, it may be backed by a PKCS #11 token or
+// a remote signing service.
+func NewSigner(cert *x509.Certificate, key crypto.Signer) Signer {
+	return &rsaSigner{
+		cert: cert,
+		key:  key,
+	}
+}
@@ -0,0 +1,284 @@
+package wsse_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/beevik/etree"
+	"github.com/chutommy/eetgateway/pkg/wsse"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/pkcs12"
+)
+
+var samples = []struct {
+	xmlFile string
+	pfxFile string
+}{
+	{
+		xmlFile: "testdata/CZ00000019.v3.valid.v3.1.1.xml",
+		pfxFile: "testdata/EET_CA1_Playground-CZ00000019.p12",
+	},
+	{
+		xmlFile: "testdata/CZ683555118.v3.valid.v3.1.1.xml",
+		pfxFile: "testdata/EET_CA1_Playground-CZ683555118.p12",
+	},
+	{
+		xmlFile: "testdata/CZ1212121218.v3.valid.v3.1.1.xml",
+		pfxFile: "testdata/EET_CA1_Playground-CZ1212121218.p12",
+	},
+}
+
+func readEnvelope(t require.TestingT, xmlFile string) *etree.Document {
+	raw, err := ioutil.ReadFile(xmlFile)
+	require.NoError(t, err)
+
+	envelope := etree.NewDocument()
+	err = envelope.ReadFromBytes(raw)
+	require.NoError(t, err)
+
+	return envelope
+}
+
+// readKeyPair returns the certificate of the private key bundled in the PFX file. Only the
+// certificate of the private key is parsed.
+func readKeyPair(t require.TestingT, pfxFile string) (*x509.Certificate, *rsa.PrivateKey) {
+	raw, err := ioutil.ReadFile(pfxFile)
+	require.NoError(t, err)
+
+	blocks, err := pkcs12.ToPEM(raw, "eet")
+	require.NoError(t, err)
+
+	var pk *rsa.PrivateKey
+	var certs [][]byte
+	for _, block := range blocks {
+		switch block.Type {
+		case "PRIVATE KEY":
+			pk, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+			require.NoError(t, err)
+		case "CERTIFICATE":
+			certs = append(certs, block.Bytes)
+		}
+	}
+
+	require.NotNil(t, pk)
+	for _, raw := range certs {
+		if cert, err := x509.ParseCertificate(raw); err == nil && pk.PublicKey.Equal(cert.PublicKey) {
+			return cert, pk
+		}
+	}
+
+	require.FailNow(t, "certificate of the private key not found")
+	return nil, nil
+}
+
+func generateKeyPair(t require.TestingT) (*x509.Certificate, *rsa.PrivateKey) {
+	pk, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "signer"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	raw, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &pk.PublicKey, pk)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(raw)
+	require.NoError(t, err)
+
+	return cert, pk
+}
+
+func TestSigner_Sign(t *testing.T) {
+	for _, tc := range samples {
+		t.Run(tc.xmlFile, func(t *testing.T) {
+			envelope := readEnvelope(t, tc.xmlFile)
+			signature := envelope.FindElement("./Envelope/Header/Security/Signature")
+
+			// clear the signed values of the template
+			values := map[string]string{}
+			for _, path := range []string{
+				"./Envelope/Header/Security/BinarySecurityToken",
+				"./Envelope/Header/Security/Signature/SignedInfo/Reference/DigestValue",
+				"./Envelope/Header/Security/Signature/SignatureValue",
+			} {
+				el := envelope.FindElement(path)
+				values[path] = el.Text()
+				el.SetText("")
+			}
+
+			cert, pk := readKeyPair(t, tc.pfxFile)
+			err := wsse.NewSigner(cert, pk).Sign(signature)
+			require.NoError(t, err)
+
+			// PKCS #1 v1.5 signatures are deterministic
+			for path, value := range values {
+				require.Equal(t, value, envelope.FindElement(path).Text(), path)
+			}
+		})
+	}
+}
+
+// signatureTemplate returns an envelope with the signature template of the given references.
+func signatureTemplate(references ...string) string {
+	var refs string
+	for _, ref := range references {
+		refs += ref
+	}
+
+	return `<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" xmlns:u="` + wsse.UtilityNamespace + `" u:Id="envelope">` +
+		`<s:Header><wsse:Security xmlns:wsse="` + wsse.SecExtNamespace + `">` +
+		`<u:Timestamp u:Id="timestamp"><u:Created>2021-09-27T10:39:03Z</u:Created></u:Timestamp>` +
+		`<wsse:BinarySecurityToken ValueType="` + wsse.X509TokenValueType + `" u:Id="token"></wsse:BinarySecurityToken>` +
+		`<ds:Signature xmlns:ds="` + wsse.DSigNamespace + `"><ds:SignedInfo>` +
+		`<ds:CanonicalizationMethod Algorithm="` + wsse.ExcC14NAlgorithm + `"/>` +
+		`<ds:SignatureMethod Algorithm="` + wsse.RSASHA512Algorithm + `"/>` + refs +
+		`</ds:SignedInfo><ds:SignatureValue/>` +
+		`<ds:KeyInfo><wsse:SecurityTokenReference><wsse:Reference URI="#token"/></wsse:SecurityTokenReference></ds:KeyInfo>` +
+		`</ds:Signature></wsse:Security></s:Header>` +
+		`<s:Body u:Id="body"><data xmlns="urn:data">signed content</data></s:Body></s:Envelope>`
+}
+
+func reference(uri string, transforms ...string) string {
+	ref := `<ds:Reference URI="` + uri + `"><ds:Transforms>`
+	for _, transform := range transforms {
+		ref += `<ds:Transform Algorithm="` + transform + `"/>`
+	}
+
+	return ref + `</ds:Transforms><ds:DigestMethod Algorithm="` + wsse.SHA256Algorithm + `"/><ds:DigestValue/></ds:Reference>`
+}
+
+func TestSigner_SignTemplate(t *testing.T) {
+	tests := []struct {
+		name       string
+		references []string
+		signed     []string
+		err        error
+	}{
+		{
+			name: "multiple references",
+			references: []string{
+				reference("#body", wsse.ExcC14NAlgorithm),
+				reference("#timestamp", wsse.ExcC14NWithCommentsAlgorithm),
+			},
+			signed: []string{"./Envelope/Body", "./Envelope/Header/Security/Timestamp"},
+		},
+		{
+			name: "enveloped signature",
+			references: []string{
+				reference("#envelope", wsse.EnvelopedSignatureAlgorithm, wsse.ExcC14NAlgorithm),
+			},
+			signed: []string{"./Envelope"},
+		},
+		{
+			name:       "unknown reference",
+			references: []string{reference("#unknown", wsse.ExcC14NAlgorithm)},
+			err:        wsse.ErrReferenceNotFound,
+		},
+		{
+			name:       "external reference",
+			references: []string{reference("http://example.com", wsse.ExcC14NAlgorithm)},
+			err:        wsse.ErrReferenceNotFound,
+		},
+		{
+			name:       "missing canonicalization",
+			references: []string{reference("#body", wsse.EnvelopedSignatureAlgorithm)},
+			err:        wsse.ErrUnsupportedAlgorithm,
+		},
+		{
+			name:       "no reference",
+			references: nil,
+			err:        wsse.ErrMalformedSignature,
+		},
+	}
+
+	cert, pk := generateKeyPair(t)
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			envelope := etree.NewDocument()
+			err := envelope.ReadFromString(signatureTemplate(tc.references...))
+			require.NoError(t, err)
+
+			signature := envelope.FindElement("./Envelope/Header/Security/Signature")
+			err = wsse.NewSigner(cert, pk).Sign(signature)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+
+			// verify the serialized message
+			raw, err := envelope.WriteToBytes()
+			require.NoError(t, err)
+			envelope = etree.NewDocument()
+			err = envelope.ReadFromBytes(raw)
+			require.NoError(t, err)
+
+			verified, err := wsse.NewVerifier().Verify(envelope.Root())
+			require.NoError(t, err)
+			require.True(t, cert.Equal(verified.Certificate))
+			require.Len(t, verified.References, len(tc.signed))
+			for _, path := range tc.signed {
+				require.True(t, verified.Signs(envelope.FindElement(path)), path)
+			}
+		})
+	}
+}
+
+// externalSigner is an in-process fake of a signer keeping the private key outside
+// of the caller's reach (e.g. a PKCS #11 token). Only its public key is exposed.
+type externalSigner struct {
+	key   crypto.Signer
+	calls int
+}
+
+func (s *externalSigner) Public() crypto.PublicKey {
+	return s.key.Public()
+}
+
+func (s *externalSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	s.calls++
+	return s.key.Sign(rand, digest, opts)
+}
+
+func TestSigner_SignExternalKey(t *testing.T) {
+	cert, pk := generateKeyPair(t)
+	signer := &externalSigner{key: pk}
+
+	envelope := etree.NewDocument()
+	err := envelope.ReadFromString(signatureTemplate(reference("#body", wsse.ExcC14NAlgorithm)))
+	require.NoError(t, err)
+
+	signature := envelope.FindElement("./Envelope/Header/Security/Signature")
+	err = wsse.NewSigner(cert, signer).Sign(signature)
+	require.NoError(t, err)
+	require.Equal(t, 1, signer.calls)
+
+	verified, err := wsse.NewVerifier().Verify(envelope.Root())
+	require.NoError(t, err)
+	require.True(t, verified.Signs(envelope.FindElement("./Envelope/Body")))
+
+	t.Run("non-RSA key", func(t *testing.T) {
+		ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+
+		signer := &externalSigner{key: ecKey}
+		err = wsse.NewSigner(cert, signer).Sign(signature)
+		require.ErrorIs(t, err, wsse.ErrUnsupportedKey)
+		require.Zero(t, signer.calls)
+	})
+}
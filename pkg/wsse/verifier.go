@@ -0,0 +1,160 @@
+package wsse
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"crypto/x509"
+	"errors"
+	"fmt"
+
+	"github.com/beevik/etree"
+	"go.uber.org/multierr"
+)
+
+// ErrNoSignature is returned if the message has no WS-Security header with a signature.
+var ErrNoSignature = errors.New("message not signed")
+
+// ErrDigestMismatch is returned if the digest of a referenced element differs from its digest value.
+var ErrDigestMismatch = errors.New("computed digest differs from the digest value")
+
+// ErrSignatureMismatch is returned if the signature value doesn't match the signed info.
+var ErrSignatureMismatch = errors.New("signature value doesn't match the signed info")
+
+// Signature is a verified XML digital signature.
+type Signature struct {
+	// Certificate is the signing certificate from the binary security token. It isn't verified.
+	Certificate *x509.Certificate
+	// References are the signed elements.
+	References []*etree.Element
+}
+
+// Signs returns true if the element is signed by the signature.
+func (s *Signature) Signs(el *etree.Element) bool {
+	for _, ref := range s.References {
+		if ref == el {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Verifier verifies XML digital signatures in WS-Security headers of SOAP messages.
+type Verifier interface {
+	Verify(envelope *etree.Element) (*Signature, error)
+}
+
+type verifier struct{}
+
+// Verify verifies the signature in the WS-Security header of the SOAP envelope. Digest values
+// of all references are checked before the signature value. The caller is responsible
+// for checking that the elements it processes are signed and that the certificate is trusted.
+func (v *verifier) Verify(envelope *etree.Element) (*Signature, error) {
+	root := documentRoot(envelope)
+
+	security := envelope.FindElement("./Header/Security")
+	if security == nil {
+		return nil, fmt.Errorf("missing security header: %w", ErrNoSignature)
+	}
+
+	signature := security.FindElement("./Signature")
+	if signature == nil {
+		return nil, fmt.Errorf("missing signature: %w", ErrNoSignature)
+	}
+
+	token, err := securityToken(root, signature)
+	if err != nil {
+		return nil, fmt.Errorf("find security token: %w", err)
+	}
+
+	cert, err := tokenCertificate(token)
+	if err != nil {
+		return nil, err
+	}
+
+	signedInfo, err := findElement(signature, "./SignedInfo")
+	if err != nil {
+		return nil, err
+	}
+
+	references := signedInfo.SelectElements("Reference")
+	if len(references) == 0 {
+		return nil, fmt.Errorf("no signed reference: %w", ErrMalformedSignature)
+	}
+
+	verified := &Signature{
+		Certificate: cert,
+		References:  make([]*etree.Element, 0, len(references)),
+	}
+
+	for _, reference := range references {
+		el, err := verifyReference(root, signature, reference)
+		if err != nil {
+			return nil, fmt.Errorf("verify reference %s: %w", reference.SelectAttrValue("URI", ""), err)
+		}
+
+		verified.References = append(verified.References, el)
+	}
+
+	if err = verifySignatureValue(cert, signature, signedInfo); err != nil {
+		return nil, err
+	}
+
+	return verified, nil
+}
+
+func verifyReference(root, signature, reference *etree.Element) (*etree.Element, error) {
+	el, digest, err := referenceDigest(root, signature, reference)
+	if err != nil {
+		return nil, err
+	}
+
+	digestValue, err := findElement(reference, "./DigestValue")
+	if err != nil {
+		return nil, err
+	}
+
+	expDigest, err := decodeBase64(digestValue.Text())
+	if err != nil {
+		return nil, fmt.Errorf("decode digest value: %v: %w", err, ErrMalformedSignature)
+	}
+
+	if !bytes.Equal(digest, expDigest) {
+		return nil, ErrDigestMismatch
+	}
+
+	return el, nil
+}
+
+func verifySignatureValue(cert *x509.Certificate, signature, signedInfo *etree.Element) error {
+	digest, h, err := signedInfoDigest(signedInfo)
+	if err != nil {
+		return fmt.Errorf("calculate digest of signed info: %w", err)
+	}
+
+	signatureValue, err := findElement(signature, "./SignatureValue")
+	if err != nil {
+		return err
+	}
+
+	sig, err := decodeBase64(signatureValue.Text())
+	if err != nil {
+		return fmt.Errorf("decode signature value: %v: %w", err, ErrMalformedSignature)
+	}
+
+	pub, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("unexpected public key type %T: %w", cert.PublicKey, ErrSecurityToken)
+	}
+
+	if err = rsa.VerifyPKCS1v15(pub, h, digest, sig); err != nil {
+		return multierr.Append(fmt.Errorf("verify PKCS1v15 signature: %w", err), ErrSignatureMismatch)
+	}
+
+	return nil
+}
+
+// NewVerifier returns a Verifier implementation.
+func NewVerifier() Verifier {
+	return &verifier{}
+}
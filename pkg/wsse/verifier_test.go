@@ -0,0 +1,116 @@
+package wsse_test
+
+import (
+	"crypto/rsa"
+	"testing"
+
+	"github.com/beevik/etree"
+	"github.com/chutommy/eetgateway/pkg/wsse"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifier_Verify(t *testing.T) {
+	for _, tc := range samples {
+		t.Run(tc.xmlFile, func(t *testing.T) {
+			envelope := readEnvelope(t, tc.xmlFile)
+
+			signature, err := wsse.NewVerifier().Verify(envelope.Root())
+			require.NoError(t, err)
+
+			cert, _ := readKeyPair(t, tc.pfxFile)
+			require.True(t, cert.Equal(signature.Certificate))
+			require.True(t, signature.Signs(envelope.FindElement("./Envelope/Body")))
+			require.False(t, signature.Signs(envelope.FindElement("./Envelope/Header")))
+		})
+	}
+}
+
+func TestVerifier_VerifyInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(envelope *etree.Document)
+		err    []error
+	}{
+		{
+			name: "modified body",
+			modify: func(envelope *etree.Document) {
+				envelope.FindElement("//Trzba/Data").CreateAttr("celk_trzba", "0.00")
+			},
+			err: []error{wsse.ErrDigestMismatch},
+		},
+		{
+			name: "modified signed info",
+			modify: func(envelope *etree.Document) {
+				envelope.FindElement("//SignedInfo").CreateComment("comment")
+				envelope.FindElement("//SignedInfo").CreateText(" ")
+			},
+			err: []error{wsse.ErrSignatureMismatch, rsa.ErrVerification},
+		},
+		{
+			name: "wrapped body",
+			modify: func(envelope *etree.Document) {
+				header := envelope.FindElement("./Envelope/Header")
+				header.AddChild(envelope.FindElement("./Envelope/Body").Copy())
+			},
+			err: []error{wsse.ErrReferenceNotFound},
+		},
+		{
+			name: "unsupported digest",
+			modify: func(envelope *etree.Document) {
+				envelope.FindElement("//DigestMethod").CreateAttr("Algorithm", "http://www.w3.org/2000/09/xmldsig#sha1")
+			},
+			err: []error{wsse.ErrUnsupportedAlgorithm},
+		},
+		{
+			name: "invalid security token",
+			modify: func(envelope *etree.Document) {
+				envelope.FindElement("//BinarySecurityToken").SetText("invalid")
+			},
+			err: []error{wsse.ErrSecurityToken},
+		},
+		{
+			name: "missing security token",
+			modify: func(envelope *etree.Document) {
+				token := envelope.FindElement("//BinarySecurityToken")
+				token.Parent().RemoveChild(token)
+			},
+			err: []error{wsse.ErrReferenceNotFound},
+		},
+		{
+			name: "missing signature value",
+			modify: func(envelope *etree.Document) {
+				value := envelope.FindElement("//SignatureValue")
+				value.Parent().RemoveChild(value)
+			},
+			err: []error{wsse.ErrMalformedSignature},
+		},
+		{
+			name: "missing signature",
+			modify: func(envelope *etree.Document) {
+				signature := envelope.FindElement("//Signature")
+				signature.Parent().RemoveChild(signature)
+			},
+			err: []error{wsse.ErrNoSignature},
+		},
+		{
+			name: "missing security header",
+			modify: func(envelope *etree.Document) {
+				header := envelope.FindElement("./Envelope/Header")
+				header.Parent().RemoveChild(header)
+			},
+			err: []error{wsse.ErrNoSignature},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			envelope := readEnvelope(t, samples[0].xmlFile)
+			tc.modify(envelope)
+
+			_, err := wsse.NewVerifier().Verify(envelope.Root())
+			for _, expErr := range tc.err {
+				require.ErrorIs(t, err, expErr)
+			}
+		})
+	}
+}